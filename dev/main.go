@@ -14,6 +14,8 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/golang-migrate/migrate/v4/source/github"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const eventStoreConnectionString = "esdb://127.0.0.1:2113?tls=false&keepAliveTimeout=10000&keepAliveInterval=10000"
@@ -28,23 +30,19 @@ func main() {
 	CreatePersistentSubscription("IntegrationTestGroup", []string{
 		"testEvent1",
 	})
-	CreatePersistentSubscription("menu.queries", []string{
-		"MenuCreated",
-		"MenuEnabled",
-		"MenuDisabled",
-		"MenuNameChanged",
-		"CategoryCreated",
-		"CategoryAddedToMenu",
-		"CategoryNameChanged",
-		"SubCategoryCreated",
-		"SubCategoryAddedToCategory",
-		"MenuItemCreated",
-		"MenuItemAddedToSubCategory",
-	})
+	// menu.queries subscribes after the checkpoint it saves in Postgres, its persistent subscription is not used anymore
+	DeletePersistentSubscription("menu.queries")
 	CreatePersistentSubscription("menu.commands", []string{
 		"CategoryCreated",
 		"SubCategoryCreated",
 		"MenuItemCreated",
+		"CategoryDeleted",
+		"SubCategoryDeleted",
+		"MenuItemDeleted",
+		"CategoryMoved",
+		"SubCategoryMoved",
+		"MenuItemMoved",
+		"MenuDuplicationStarted",
 	})
 	RunPostgresMigrations()
 }
@@ -66,28 +64,40 @@ func RunPostgresMigrations() {
 	panic(err)
 }
 
+// CreatePersistentSubscription creates the subscription to $all of the group, or replaces it when it already exists
+// so that a development environment created before gets the current filter: the update of a persistent subscription
+// cannot change its filter. Like a new one, the replaced subscription starts at the end of $all, which skips no event
+// since this runs before the services, and the events of menu.commands are written by menu.commands itself.
 func CreatePersistentSubscription(groupName string, events []string) {
 	settings, _ := esdb.ParseConnectionString(eventStoreConnectionString)
 	db, _ := esdb.NewClient(settings)
 
-	err := db.CreatePersistentSubscriptionAll(
+	options := esdb.PersistentAllSubscriptionOptions{
+		Filter: &esdb.SubscriptionFilter{
+			Type:     esdb.EventFilterType,
+			Prefixes: events,
+		},
+	}
+	err := db.CreatePersistentSubscriptionAll(context.Background(), groupName, options)
+	if err != nil && status.Code(errors.Unwrap(err)) == codes.AlreadyExists {
+		DeletePersistentSubscription(groupName)
+		err = db.CreatePersistentSubscriptionAll(context.Background(), groupName, options)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func DeletePersistentSubscription(groupName string) {
+	settings, _ := esdb.ParseConnectionString(eventStoreConnectionString)
+	db, _ := esdb.NewClient(settings)
+
+	err := db.DeletePersistentSubscriptionAll(
 		context.Background(),
 		groupName,
-		esdb.PersistentAllSubscriptionOptions{
-			Filter: &esdb.SubscriptionFilter{
-				Type:     esdb.EventFilterType,
-				Prefixes: events,
-			},
-		},
+		esdb.DeletePersistentSubscriptionOptions{},
 	)
-	if err != nil {
-		var badInputErr *esdb.PersistentSubscriptionError
-		if errors.As(err, &badInputErr) {
-			if badInputErr.Code == 6 {
-				return
-			}
-			panic(badInputErr)
-		}
+	if err != nil && status.Code(errors.Unwrap(err)) != codes.NotFound {
 		panic(err)
 	}
 }
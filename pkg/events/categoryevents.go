@@ -20,3 +20,27 @@ type SubCategoryAddedToCategory struct {
 	eventutils.EventInfo
 	SubCategoryID uuid.UUID
 }
+
+type SubCategoryRemovedFromCategory struct {
+	eventutils.EventInfo
+	SubCategoryID uuid.UUID
+}
+
+// CategoryDeleted detaches the subcategories of the category, that are not deleted with it
+type CategoryDeleted struct {
+	eventutils.EventInfo
+	ParentMenuID uuid.UUID
+}
+
+// SubCategoriesReordered has all the subcategories of the category in their new order
+type SubCategoriesReordered struct {
+	eventutils.EventInfo
+	SubCategoriesIDs []uuid.UUID
+}
+
+// CategoryMoved detaches the category from its old menu and attaches it to the new one
+type CategoryMoved struct {
+	eventutils.EventInfo
+	OldParentMenuID uuid.UUID
+	NewParentMenuID uuid.UUID
+}
@@ -0,0 +1,23 @@
+package events
+
+import (
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/gofrs/uuid"
+)
+
+// MenuDuplicationStarted starts copying the source menu, with all its descendants, into the new menu
+type MenuDuplicationStarted struct {
+	eventutils.EventInfo
+	SourceMenuID uuid.UUID
+	NewMenuID    uuid.UUID
+}
+
+type MenuDuplicationCompleted struct {
+	eventutils.EventInfo
+}
+
+// MenuDuplicationFailed leaves the new menu with the part of the source menu copied before the error
+type MenuDuplicationFailed struct {
+	eventutils.EventInfo
+	Error string
+}
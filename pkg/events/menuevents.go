@@ -1,6 +1,8 @@
 package events
 
 import (
+	"time"
+
 	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/gofrs/uuid"
 )
@@ -27,3 +29,78 @@ type CategoryAddedToMenu struct {
 	eventutils.EventInfo
 	CategoryID uuid.UUID
 }
+
+type CategoryRemovedFromMenu struct {
+	eventutils.EventInfo
+	CategoryID uuid.UUID
+}
+
+// MenuDeleted detaches the categories of the menu, that are not deleted with it
+type MenuDeleted struct {
+	eventutils.EventInfo
+}
+
+// CategoriesReordered has all the categories of the menu in their new order
+type CategoriesReordered struct {
+	eventutils.EventInfo
+	CategoriesIDs []uuid.UUID
+}
+
+// MenuPublished makes a new version of the menu the one guests see. It has the whole menu
+// as it was published, so that the version is not changed by the later edits of the draft
+type MenuPublished struct {
+	eventutils.EventInfo
+	Version    int
+	Name       string
+	Categories []PublishedCategory
+}
+
+// MenuVersionRestored makes an earlier version of the menu the one guests see again
+type MenuVersionRestored struct {
+	eventutils.EventInfo
+	Version int
+}
+
+type PublishedCategory struct {
+	ID            uuid.UUID
+	Name          string
+	SubCategories []PublishedSubCategory
+}
+
+type PublishedSubCategory struct {
+	ID        uuid.UUID
+	Name      string
+	MenuItems []PublishedMenuItem
+}
+
+type PublishedMenuItem struct {
+	ID                       uuid.UUID
+	Name                     string
+	Price                    Price
+	EstimatedPreparationTime time.Duration
+	Description              string
+	Allergens                []Allergen
+	DietaryTags              DietaryTags
+	Variants                 []PublishedVariant
+	ModifierGroups           []PublishedModifierGroup
+}
+
+type PublishedVariant struct {
+	ID    uuid.UUID
+	Name  string
+	Price Price
+}
+
+type PublishedModifierGroup struct {
+	ID            uuid.UUID
+	Name          string
+	MinSelections int
+	MaxSelections int
+	Modifiers     []PublishedModifier
+}
+
+type PublishedModifier struct {
+	ID         uuid.UUID
+	Name       string
+	PriceDelta Price
+}
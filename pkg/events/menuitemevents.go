@@ -22,3 +22,141 @@ type MenuItemEstimatedPreparationTimeChanged struct {
 	eventutils.EventInfo
 	NewEstimate time.Duration
 }
+
+// Price is an amount in the minor unit of an ISO 4217 currency, such as cents for EUR
+type Price struct {
+	Amount   int64
+	Currency string
+}
+
+type MenuItemPriceChanged struct {
+	eventutils.EventInfo
+	NewPrice Price
+}
+
+type MenuItemDescriptionChanged struct {
+	eventutils.EventInfo
+	NewDescription string
+}
+
+// Allergen is one of the 14 allergens that EU regulation 1169/2011 requires restaurants to declare
+type Allergen string
+
+const (
+	AllergenCelery      Allergen = "celery"
+	AllergenGluten      Allergen = "gluten"
+	AllergenCrustaceans Allergen = "crustaceans"
+	AllergenEggs        Allergen = "eggs"
+	AllergenFish        Allergen = "fish"
+	AllergenLupin       Allergen = "lupin"
+	AllergenMilk        Allergen = "milk"
+	AllergenMolluscs    Allergen = "molluscs"
+	AllergenMustard     Allergen = "mustard"
+	AllergenNuts        Allergen = "nuts"
+	AllergenPeanuts     Allergen = "peanuts"
+	AllergenSesame      Allergen = "sesame"
+	AllergenSoya        Allergen = "soya"
+	AllergenSulphites   Allergen = "sulphites"
+)
+
+var Allergens = []Allergen{
+	AllergenCelery,
+	AllergenGluten,
+	AllergenCrustaceans,
+	AllergenEggs,
+	AllergenFish,
+	AllergenLupin,
+	AllergenMilk,
+	AllergenMolluscs,
+	AllergenMustard,
+	AllergenNuts,
+	AllergenPeanuts,
+	AllergenSesame,
+	AllergenSoya,
+	AllergenSulphites,
+}
+
+func (allergen Allergen) IsValid() bool {
+	for _, knownAllergen := range Allergens {
+		if allergen == knownAllergen {
+			return true
+		}
+	}
+	return false
+}
+
+type MenuItemAllergensChanged struct {
+	eventutils.EventInfo
+	NewAllergens []Allergen
+}
+
+// DietaryTags tell guests which diets a menu item suits, SpicyLevel goes from 0, not spicy, to MaxSpicyLevel
+type DietaryTags struct {
+	Vegan      bool
+	Vegetarian bool
+	GlutenFree bool
+	Halal      bool
+	SpicyLevel int
+}
+
+const MaxSpicyLevel = 3
+
+type MenuItemDietaryTagsChanged struct {
+	eventutils.EventInfo
+	NewDietaryTags DietaryTags
+}
+
+type MenuItemVariantAdded struct {
+	eventutils.EventInfo
+	VariantID uuid.UUID
+	Name      string
+	Price     Price
+}
+
+type MenuItemVariantRemoved struct {
+	eventutils.EventInfo
+	VariantID uuid.UUID
+}
+
+// MenuItemModifierGroupAdded adds a group of modifiers of which a guest chooses
+// at least MinSelections and at most MaxSelections
+type MenuItemModifierGroupAdded struct {
+	eventutils.EventInfo
+	ModifierGroupID uuid.UUID
+	Name            string
+	MinSelections   int
+	MaxSelections   int
+}
+
+type MenuItemModifierGroupRemoved struct {
+	eventutils.EventInfo
+	ModifierGroupID uuid.UUID
+}
+
+// MenuItemModifierAdded adds a modifier to a group, PriceDelta is added to the price of the menu item
+// when the modifier is chosen and can be negative
+type MenuItemModifierAdded struct {
+	eventutils.EventInfo
+	ModifierGroupID uuid.UUID
+	ModifierID      uuid.UUID
+	Name            string
+	PriceDelta      Price
+}
+
+type MenuItemModifierRemoved struct {
+	eventutils.EventInfo
+	ModifierGroupID uuid.UUID
+	ModifierID      uuid.UUID
+}
+
+type MenuItemDeleted struct {
+	eventutils.EventInfo
+	ParentSubCategoryID uuid.UUID
+}
+
+// MenuItemMoved detaches the menu item from its old subcategory and attaches it to the new one
+type MenuItemMoved struct {
+	eventutils.EventInfo
+	OldParentSubCategoryID uuid.UUID
+	NewParentSubCategoryID uuid.UUID
+}
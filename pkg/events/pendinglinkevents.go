@@ -0,0 +1,26 @@
+package events
+
+import (
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/gofrs/uuid"
+)
+
+type PendingLinksCreated struct {
+	eventutils.EventInfo
+}
+
+type LinkParked struct {
+	eventutils.EventInfo
+	RequestEventID   uuid.UUID
+	RequestEventType string
+	ChildID          uuid.UUID
+	ParentID         uuid.UUID
+	PreviousParentID uuid.UUID
+	Attempts         int
+	LastError        string
+}
+
+type LinkResolved struct {
+	eventutils.EventInfo
+	RequestEventID uuid.UUID
+}
@@ -20,3 +20,27 @@ type MenuItemAddedToSubCategory struct {
 	eventutils.EventInfo
 	MenuItemID uuid.UUID
 }
+
+type MenuItemRemovedFromSubCategory struct {
+	eventutils.EventInfo
+	MenuItemID uuid.UUID
+}
+
+// SubCategoryDeleted detaches the menu items of the subcategory, that are not deleted with it
+type SubCategoryDeleted struct {
+	eventutils.EventInfo
+	ParentCategoryID uuid.UUID
+}
+
+// MenuItemsReordered has all the menu items of the subcategory in their new order
+type MenuItemsReordered struct {
+	eventutils.EventInfo
+	MenuItemsIDs []uuid.UUID
+}
+
+// SubCategoryMoved detaches the subcategory from its old category and attaches it to the new one
+type SubCategoryMoved struct {
+	eventutils.EventInfo
+	OldParentCategoryID uuid.UUID
+	NewParentCategoryID uuid.UUID
+}
@@ -0,0 +1,13 @@
+package events
+
+import "github.com/Resta-Inc/resta/pkg/eventutils"
+
+// Upcasters brings the events written with an old schema to their current shape,
+// every service that deserializes these events must use it.
+// No event has changed its schema yet, the upcaster of the next change is registered here.
+var Upcasters = newUpcasters()
+
+func newUpcasters() *eventutils.Upcasters {
+	upcasters := eventutils.NewUpcasters()
+	return upcasters
+}
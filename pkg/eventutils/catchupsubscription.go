@@ -0,0 +1,285 @@
+package eventutils
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/gofrs/uuid"
+)
+
+// CheckpointLoader returns the checkpoint a projection saved with its views, found is false when it has none
+type CheckpointLoader func(ctx context.Context) (position esdb.Position, found bool, err error)
+
+// allSubscriber opens a catch-up subscription to $all that delivers the events after the position
+type allSubscriber func(ctx context.Context, from esdb.AllPosition) (eventReceiver, error)
+
+type eventReceiver interface {
+	Recv() *esdb.SubscriptionEvent
+	Close() error
+}
+
+// IParkedEventStore keeps the events a catch-up subscription parked across its connections
+type IParkedEventStore interface {
+	ParkEvent(ctx context.Context, event *esdb.RecordedEvent) error
+	UnparkEvent(ctx context.Context, eventID uuid.UUID) error
+	LoadParkedEvents(ctx context.Context) ([]*esdb.RecordedEvent, error)
+}
+
+// CatchUpSubscription subscribes to $all after the checkpoint of a projection each time its EventHandler connects
+type CatchUpSubscription struct {
+	subscribe      allSubscriber
+	loadCheckpoint CheckpointLoader
+	parkedEvents   IParkedEventStore
+	mutex          sync.Mutex
+	connection     *catchUpConnection
+	retries        []*esdb.ResolvedEvent
+	parked         []*esdb.ResolvedEvent
+	replayed       map[uuid.UUID]bool
+	tracker        *CheckpointTracker
+	wake           chan struct{}
+}
+
+// catchUpConnection passes the events of one subscription to EventStoreDB to Recv, until it is closed
+type catchUpConnection struct {
+	receiver eventReceiver
+	events   chan *esdb.SubscriptionEvent
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func NewCatchUpSubscription(client *esdb.Client, eventTypes []string, loadCheckpoint CheckpointLoader, parkedEvents IParkedEventStore) *CatchUpSubscription {
+	filter := &esdb.SubscriptionFilter{
+		Type:  esdb.EventFilterType,
+		Regex: eventTypesRegex(eventTypes),
+	}
+	return newCatchUpSubscription(func(ctx context.Context, from esdb.AllPosition) (eventReceiver, error) {
+		subscription, err := client.SubscribeToAll(ctx, esdb.SubscribeToAllOptions{
+			From:   from,
+			Filter: filter,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return subscription, nil
+	}, loadCheckpoint, parkedEvents)
+}
+
+func newCatchUpSubscription(subscribe allSubscriber, loadCheckpoint CheckpointLoader, parkedEvents IParkedEventStore) *CatchUpSubscription {
+	return &CatchUpSubscription{
+		subscribe:      subscribe,
+		loadCheckpoint: loadCheckpoint,
+		parkedEvents:   parkedEvents,
+		replayed:       map[uuid.UUID]bool{},
+		tracker:        NewCheckpointTracker(),
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// Connect subscribes after the checkpoint, or from the start of $all, and loads the parked events again
+func (subscription *CatchUpSubscription) Connect(ctx context.Context) (ISubscription, error) {
+	checkpoint, found, err := subscription.loadCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parkedEvents, err := subscription.parkedEvents.LoadParkedEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parked := []*esdb.ResolvedEvent{}
+	for _, parkedEvent := range parkedEvents {
+		parked = append(parked, &esdb.ResolvedEvent{Event: parkedEvent})
+	}
+	var from esdb.AllPosition = esdb.Start{}
+	if found {
+		from = checkpoint
+	}
+	receiver, err := subscription.subscribe(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	connection := &catchUpConnection{
+		receiver: receiver,
+		events:   make(chan *esdb.SubscriptionEvent),
+		closed:   make(chan struct{}),
+	}
+	go connection.pump()
+
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	subscription.connection = connection
+	subscription.retries = nil
+	subscription.parked = parked
+	subscription.replayed = map[uuid.UUID]bool{}
+	subscription.tracker = NewCheckpointTracker()
+	return subscription, nil
+}
+
+func (subscription *CatchUpSubscription) Recv() *esdb.SubscriptionEvent {
+	connection, tracker := subscription.current()
+	for {
+		if retry := subscription.nextRetry(); retry != nil {
+			return &esdb.SubscriptionEvent{EventAppeared: retry}
+		}
+		select {
+		case event := <-connection.events:
+			if event.EventAppeared != nil {
+				tracker.Received(event.EventAppeared.OriginalEvent().Position)
+			}
+			return event
+		case <-subscription.wake:
+		case <-connection.closed:
+			return &esdb.SubscriptionEvent{
+				SubscriptionDropped: &esdb.SubscriptionDropped{},
+			}
+		}
+	}
+}
+
+// Ack removes from the IParkedEventStore the parked events that were replayed
+func (subscription *CatchUpSubscription) Ack(messages ...*esdb.ResolvedEvent) error {
+	_, tracker := subscription.current()
+	for _, message := range messages {
+		tracker.Done(message.OriginalEvent().Position)
+		if !subscription.takeReplayed(message.OriginalEvent().EventID) {
+			continue
+		}
+		err := subscription.parkedEvents.UnparkEvent(context.Background(), message.OriginalEvent().EventID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Nack keeps a parked event holding back the checkpoint when the IParkedEventStore fails to keep it
+func (subscription *CatchUpSubscription) Nack(reason string, action esdb.Nack_Action, messages ...*esdb.ResolvedEvent) error {
+	if action != esdb.Nack_Park {
+		subscription.mutex.Lock()
+		defer subscription.mutex.Unlock()
+		if action == esdb.Nack_Retry {
+			subscription.retries = append(subscription.retries, messages...)
+			subscription.wakeUp()
+		}
+		return nil
+	}
+
+	var parkErr error
+	for _, message := range messages {
+		err := subscription.parkedEvents.ParkEvent(context.Background(), message.OriginalEvent())
+		subscription.mutex.Lock()
+		subscription.parked = append(subscription.parked, message)
+		delete(subscription.replayed, message.OriginalEvent().EventID)
+		tracker := subscription.tracker
+		subscription.mutex.Unlock()
+		if err != nil {
+			parkErr = err
+			continue
+		}
+		tracker.Parked(message.OriginalEvent().Position)
+	}
+	return parkErr
+}
+
+// Checkpoint returns the position below which the handlers are done with every event of the current connection
+func (subscription *CatchUpSubscription) Checkpoint() (esdb.Position, bool) {
+	_, tracker := subscription.current()
+	return tracker.Checkpoint()
+}
+
+func (subscription *CatchUpSubscription) GetParkedEvents() ([]Event, error) {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	events := []Event{}
+	for _, parkedEvent := range subscription.parked {
+		events = append(events, DeserializeRecordedEvent(parkedEvent.Event))
+	}
+	return events, nil
+}
+
+func (subscription *CatchUpSubscription) ReplayParkedEvents() error {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	for _, parkedEvent := range subscription.parked {
+		subscription.replayed[parkedEvent.OriginalEvent().EventID] = true
+	}
+	subscription.retries = append(subscription.retries, subscription.parked...)
+	subscription.parked = nil
+	subscription.wakeUp()
+	return nil
+}
+
+func (subscription *CatchUpSubscription) Close() error {
+	connection, _ := subscription.current()
+	if connection == nil {
+		return nil
+	}
+	connection.once.Do(func() {
+		close(connection.closed)
+		connection.receiver.Close()
+	})
+	return nil
+}
+
+func (subscription *CatchUpSubscription) current() (*catchUpConnection, *CheckpointTracker) {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	return subscription.connection, subscription.tracker
+}
+
+// takeReplayed tells whether the event is a parked event being replayed, and forgets it
+func (subscription *CatchUpSubscription) takeReplayed(eventID uuid.UUID) bool {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	replayed := subscription.replayed[eventID]
+	delete(subscription.replayed, eventID)
+	return replayed
+}
+
+func (subscription *CatchUpSubscription) nextRetry() *esdb.ResolvedEvent {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	if len(subscription.retries) == 0 {
+		return nil
+	}
+	retry := subscription.retries[0]
+	subscription.retries = subscription.retries[1:]
+	return retry
+}
+
+// wakeUp makes a Recv waiting for a new event look at the retries again, the mutex must be held
+func (subscription *CatchUpSubscription) wakeUp() {
+	select {
+	case subscription.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (connection *catchUpConnection) pump() {
+	for {
+		event := connection.receiver.Recv()
+		select {
+		case connection.events <- event:
+		case <-connection.closed:
+			return
+		}
+		if event.SubscriptionDropped != nil {
+			return
+		}
+	}
+}
+
+// eventTypesRegex returns the filter of EventStoreDB that matches exactly the given event types
+func eventTypesRegex(eventTypes []string) string {
+	quoted := []string{}
+	for _, eventType := range eventTypes {
+		quoted = append(quoted, regexp.QuoteMeta(eventType))
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
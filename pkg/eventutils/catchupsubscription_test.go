@@ -0,0 +1,223 @@
+package eventutils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReceiver delivers the events sent to it, and drops once closed
+type fakeReceiver struct {
+	events chan *esdb.SubscriptionEvent
+	closed chan struct{}
+}
+
+func newFakeReceiver(events ...*esdb.RecordedEvent) *fakeReceiver {
+	receiver := &fakeReceiver{
+		events: make(chan *esdb.SubscriptionEvent, len(events)),
+		closed: make(chan struct{}),
+	}
+	for _, event := range events {
+		receiver.events <- &esdb.SubscriptionEvent{EventAppeared: &esdb.ResolvedEvent{Event: event}}
+	}
+	return receiver
+}
+
+func (receiver *fakeReceiver) Recv() *esdb.SubscriptionEvent {
+	select {
+	case event := <-receiver.events:
+		return event
+	case <-receiver.closed:
+		return &esdb.SubscriptionEvent{SubscriptionDropped: &esdb.SubscriptionDropped{}}
+	}
+}
+
+func (receiver *fakeReceiver) Close() error {
+	close(receiver.closed)
+	return nil
+}
+
+// fakeParkedEventStore keeps the parked events in memory, or fails to park them with parkErr
+type fakeParkedEventStore struct {
+	events  []*esdb.RecordedEvent
+	parkErr error
+}
+
+func newFakeParkedEventStore(events ...*esdb.RecordedEvent) *fakeParkedEventStore {
+	return &fakeParkedEventStore{events: events}
+}
+
+func (store *fakeParkedEventStore) ParkEvent(ctx context.Context, event *esdb.RecordedEvent) error {
+	if store.parkErr != nil {
+		return store.parkErr
+	}
+	store.events = append(store.events, event)
+	return nil
+}
+
+func (store *fakeParkedEventStore) UnparkEvent(ctx context.Context, eventID uuid.UUID) error {
+	for i, event := range store.events {
+		if event.EventID == eventID {
+			store.events = append(store.events[:i], store.events[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (store *fakeParkedEventStore) LoadParkedEvents(ctx context.Context) ([]*esdb.RecordedEvent, error) {
+	return append([]*esdb.RecordedEvent{}, store.events...), nil
+}
+
+func newTestRecordedEvent(t *testing.T, position esdb.Position) *esdb.RecordedEvent {
+	event := serializeTestEvents(t, NewTestEntity())[0]
+	return &esdb.RecordedEvent{
+		EventID:   event.ID,
+		EventType: event.Name,
+		Data:      event.Data,
+		Position:  position,
+	}
+}
+
+func TestCatchUpSubscription_SubscribesAfterCheckpoint(t *testing.T) {
+	testCases := []struct {
+		name         string
+		checkpoint   esdb.Position
+		found        bool
+		expectedFrom esdb.AllPosition
+	}{
+		{"WhenCheckpointIsFound", esdb.Position{Commit: 42, Prepare: 42}, true, esdb.Position{Commit: 42, Prepare: 42}},
+		{"WhenCheckpointIsNotFound", esdb.Position{}, false, esdb.Start{}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			var from esdb.AllPosition
+			subscription := newCatchUpSubscription(func(ctx context.Context, position esdb.AllPosition) (eventReceiver, error) {
+				from = position
+				return newFakeReceiver(), nil
+			}, func(ctx context.Context) (esdb.Position, bool, error) {
+				return testCase.checkpoint, testCase.found, nil
+			}, newFakeParkedEventStore())
+
+			// Act
+			_, err := subscription.Connect(context.Background())
+
+			// Assert
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedFrom, from)
+			subscription.Close()
+		})
+	}
+}
+
+func TestCatchUpSubscription_ParkedEventDoesNotHoldCheckpoint(t *testing.T) {
+	// Arrange
+	first, second, third := esdb.Position{Commit: 10, Prepare: 10}, esdb.Position{Commit: 20, Prepare: 20}, esdb.Position{Commit: 30, Prepare: 30}
+	receiver := newFakeReceiver(newTestRecordedEvent(t, first), newTestRecordedEvent(t, second), newTestRecordedEvent(t, third))
+	parkedEventStore := newFakeParkedEventStore()
+	subscription := newCatchUpSubscription(func(ctx context.Context, from esdb.AllPosition) (eventReceiver, error) {
+		return receiver, nil
+	}, func(ctx context.Context) (esdb.Position, bool, error) {
+		return esdb.Position{}, false, nil
+	}, parkedEventStore)
+	_, err := subscription.Connect(context.Background())
+	require.NoError(t, err)
+	defer subscription.Close()
+
+	// Act
+	firstEvent, secondEvent, thirdEvent := subscription.Recv(), subscription.Recv(), subscription.Recv()
+	subscription.Ack(thirdEvent.EventAppeared)
+	subscription.Nack("test error", esdb.Nack_Park, secondEvent.EventAppeared)
+	subscription.Ack(firstEvent.EventAppeared)
+	checkpointWhileParked, _ := subscription.Checkpoint()
+	parkedEvents, _ := subscription.GetParkedEvents()
+	storedWhileParked, _ := parkedEventStore.LoadParkedEvents(context.Background())
+	subscription.ReplayParkedEvents()
+	replayedEvent := subscription.Recv()
+	subscription.Ack(replayedEvent.EventAppeared)
+	checkpointAfterReplay, _ := subscription.Checkpoint()
+
+	// Assert
+	require.Equal(t, third, checkpointWhileParked)
+	require.Len(t, parkedEvents, 1)
+	require.Len(t, storedWhileParked, 1)
+	require.Empty(t, parkedEventStore.events)
+	require.Equal(t, second, replayedEvent.EventAppeared.Event.Position)
+	require.Equal(t, third, checkpointAfterReplay)
+}
+
+func TestCatchUpSubscription_WhenParkedEventCannotBeStored(t *testing.T) {
+	// Arrange
+	first, second := esdb.Position{Commit: 10, Prepare: 10}, esdb.Position{Commit: 20, Prepare: 20}
+	receiver := newFakeReceiver(newTestRecordedEvent(t, first), newTestRecordedEvent(t, second))
+	unavailable := errors.New("postgres unavailable")
+	subscription := newCatchUpSubscription(func(ctx context.Context, from esdb.AllPosition) (eventReceiver, error) {
+		return receiver, nil
+	}, func(ctx context.Context) (esdb.Position, bool, error) {
+		return esdb.Position{}, false, nil
+	}, &fakeParkedEventStore{parkErr: unavailable})
+	_, err := subscription.Connect(context.Background())
+	require.NoError(t, err)
+	defer subscription.Close()
+
+	// Act
+	firstEvent, secondEvent := subscription.Recv(), subscription.Recv()
+	nackErr := subscription.Nack("test error", esdb.Nack_Park, firstEvent.EventAppeared)
+	subscription.Ack(secondEvent.EventAppeared)
+	_, found := subscription.Checkpoint()
+	parkedEvents, _ := subscription.GetParkedEvents()
+
+	// Assert
+	require.ErrorIs(t, nackErr, unavailable)
+	require.False(t, found)
+	require.Len(t, parkedEvents, 1)
+}
+
+func TestCatchUpSubscription_LoadsParkedEventsOnConnect(t *testing.T) {
+	// Arrange
+	parkedEvent := newTestRecordedEvent(t, esdb.Position{Commit: 10, Prepare: 10})
+	parkedEventStore := newFakeParkedEventStore(parkedEvent)
+	subscription := newCatchUpSubscription(func(ctx context.Context, from esdb.AllPosition) (eventReceiver, error) {
+		return newFakeReceiver(), nil
+	}, func(ctx context.Context) (esdb.Position, bool, error) {
+		return esdb.Position{Commit: 20, Prepare: 20}, true, nil
+	}, parkedEventStore)
+
+	// Act
+	_, err := subscription.Connect(context.Background())
+	require.NoError(t, err)
+	defer subscription.Close()
+	parkedEvents, _ := subscription.GetParkedEvents()
+	subscription.ReplayParkedEvents()
+	replayedEvent := subscription.Recv()
+	subscription.Ack(replayedEvent.EventAppeared)
+
+	// Assert
+	require.Len(t, parkedEvents, 1)
+	require.Equal(t, parkedEvent.EventID, parkedEvents[0].ID)
+	require.Equal(t, parkedEvent.EventID, replayedEvent.EventAppeared.Event.EventID)
+	require.Empty(t, parkedEventStore.events)
+}
+
+func TestCatchUpSubscription_WhenClosed(t *testing.T) {
+	// Arrange
+	subscription := newCatchUpSubscription(func(ctx context.Context, from esdb.AllPosition) (eventReceiver, error) {
+		return newFakeReceiver(), nil
+	}, func(ctx context.Context) (esdb.Position, bool, error) {
+		return esdb.Position{}, false, nil
+	}, newFakeParkedEventStore())
+	_, err := subscription.Connect(context.Background())
+	require.NoError(t, err)
+
+	// Act
+	subscription.Close()
+	event := subscription.Recv()
+
+	// Assert
+	require.NotNil(t, event.SubscriptionDropped)
+}
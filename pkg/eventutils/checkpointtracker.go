@@ -0,0 +1,68 @@
+package eventutils
+
+import (
+	"sync"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+// ICheckpoints gives the position in $all below which the handlers of a subscription are done with every event
+type ICheckpoints interface {
+	Checkpoint() (esdb.Position, bool)
+}
+
+// CheckpointTracker gives the position below which the handlers are done with, or parked, every delivered event
+type CheckpointTracker struct {
+	mutex      sync.Mutex
+	order      []esdb.Position
+	pending    map[esdb.Position]bool
+	checkpoint esdb.Position
+	found      bool
+}
+
+func NewCheckpointTracker() *CheckpointTracker {
+	return &CheckpointTracker{
+		pending: map[esdb.Position]bool{},
+	}
+}
+
+// Received records that the event at the position was delivered, an event delivered again is recorded once
+func (tracker *CheckpointTracker) Received(position esdb.Position) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if _, found := tracker.pending[position]; found {
+		return
+	}
+	tracker.pending[position] = false
+	tracker.order = append(tracker.order, position)
+}
+
+// Done records that the handlers are done with the event at the position
+func (tracker *CheckpointTracker) Done(position esdb.Position) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if _, found := tracker.pending[position]; !found {
+		return
+	}
+	tracker.pending[position] = true
+	for len(tracker.order) > 0 && tracker.pending[tracker.order[0]] {
+		tracker.checkpoint = tracker.order[0]
+		tracker.found = true
+		delete(tracker.pending, tracker.order[0])
+		tracker.order = tracker.order[1:]
+	}
+}
+
+// Parked records that the event at the position was parked, the subscription keeps it so it does not hold back the checkpoint
+func (tracker *CheckpointTracker) Parked(position esdb.Position) {
+	tracker.Done(position)
+}
+
+// Checkpoint returns false while the handlers are not done with the first event delivered
+func (tracker *CheckpointTracker) Checkpoint() (esdb.Position, bool) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	return tracker.checkpoint, tracker.found
+}
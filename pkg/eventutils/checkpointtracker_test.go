@@ -0,0 +1,65 @@
+package eventutils
+
+import (
+	"testing"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointTracker_WhenEventsAreDoneOutOfOrder(t *testing.T) {
+	// Arrange
+	first, second, third := esdb.Position{Commit: 10, Prepare: 10}, esdb.Position{Commit: 20, Prepare: 20}, esdb.Position{Commit: 30, Prepare: 30}
+	tracker := NewCheckpointTracker()
+	tracker.Received(first)
+	tracker.Received(second)
+	tracker.Received(third)
+
+	// Act
+	tracker.Done(third)
+	_, foundBeforeFirst := tracker.Checkpoint()
+	tracker.Done(first)
+	afterFirst, _ := tracker.Checkpoint()
+	tracker.Done(second)
+	afterSecond, _ := tracker.Checkpoint()
+
+	// Assert
+	require.False(t, foundBeforeFirst)
+	require.Equal(t, first, afterFirst)
+	require.Equal(t, third, afterSecond)
+}
+
+func TestCheckpointTracker_WhenEventIsReceivedAgain(t *testing.T) {
+	// Arrange
+	first, second := esdb.Position{Commit: 10, Prepare: 10}, esdb.Position{Commit: 20, Prepare: 20}
+	tracker := NewCheckpointTracker()
+	tracker.Received(first)
+	tracker.Received(second)
+
+	// Act
+	tracker.Received(first)
+	tracker.Done(first)
+	tracker.Done(second)
+
+	// Assert
+	checkpoint, found := tracker.Checkpoint()
+	require.True(t, found)
+	require.Equal(t, second, checkpoint)
+}
+
+func TestCheckpointTracker_WhenEventIsParked(t *testing.T) {
+	// Arrange
+	first, second := esdb.Position{Commit: 10, Prepare: 10}, esdb.Position{Commit: 20, Prepare: 20}
+	tracker := NewCheckpointTracker()
+	tracker.Received(first)
+	tracker.Received(second)
+
+	// Act
+	tracker.Parked(first)
+	tracker.Done(second)
+
+	// Assert
+	checkpoint, found := tracker.Checkpoint()
+	require.True(t, found)
+	require.Equal(t, second, checkpoint)
+}
@@ -1,7 +1,9 @@
 package eventutils
 
 import (
+	"context"
 	"errors"
+	"log"
 
 	"github.com/Resta-Inc/resta/pkg/utils"
 	"github.com/gofrs/uuid"
@@ -9,11 +11,15 @@ import (
 
 type IEntityRepository interface {
 	GetEntity(entity IReconstructible, id uuid.UUID) (IReconstructible, error)
-	SaveEntity(entity IReconstructible) error
+	SaveEntity(ctx context.Context, entity IReconstructible) error
 }
 
 type EntityRepository struct {
 	EventStore IEventStore
+	// SnapshotStore is optional, without it the entities are always rebuilt from their whole stream
+	SnapshotStore ISnapshotStore
+	// SnapshotFrequency is the number of events after which a new snapshot of an ISnapshottable entity is taken
+	SnapshotFrequency uint64
 }
 
 func NewEntityRepository(eventStore IEventStore) IEntityRepository {
@@ -22,32 +28,116 @@ func NewEntityRepository(eventStore IEventStore) IEntityRepository {
 	}
 }
 
+func NewEntityRepositoryWithSnapshots(eventStore IEventStore, snapshotStore ISnapshotStore, snapshotFrequency uint64) IEntityRepository {
+	return &EntityRepository{
+		EventStore:        eventStore,
+		SnapshotStore:     snapshotStore,
+		SnapshotFrequency: snapshotFrequency,
+	}
+}
+
 func (repo EntityRepository) GetEntity(entity IReconstructible, id uuid.UUID) (IReconstructible, error) {
 	streamName := getStreamNameWithID(entity, id)
-	returnedEvents, err := repo.EventStore.GetAllEventsByStreamName(streamName)
+	fromRevision := repo.loadSnapshot(entity, streamName)
+	err := repo.EventStore.ReadEventsByStreamName(streamName, fromRevision, func(event Event) error {
+		return ApplyRecordedEvent(entity, event)
+	})
 	if errors.Is(err, ErrResourceNotFound) {
 		return nil, ErrEntityNotFound
 	}
-	ReconstructFromEvents(entity, returnedEvents)
+	if err != nil {
+		return nil, err
+	}
+	if deletable, ok := entity.(IDeletable); ok && deletable.WasDeleted() {
+		return nil, ErrEntityNotFound
+	}
 	return entity, nil
 }
 
-func (repo EntityRepository) SaveEntity(entity IReconstructible) error {
-	var err error
+// SaveEntity saves the new events of the entity, with the metadata carried by ctx
+func (repo EntityRepository) SaveEntity(ctx context.Context, entity IReconstructible) error {
+	events, err := serializeEvents(entity, MetadataFromContext(ctx))
+	if err != nil {
+		return err
+	}
 	if entity.IsNew() {
-		_, err = repo.EventStore.SaveEventsToNewStream(getStreamName(entity), serializeEvents(entity.GetEvents()))
+		_, err = repo.EventStore.SaveEventsToNewStream(getStreamName(entity), events)
 	} else {
-		_, err = repo.EventStore.SaveEventsToExistingStream(getStreamName(entity), serializeEvents(entity.GetEvents()))
+		_, err = repo.EventStore.SaveEventsToExistingStream(getStreamName(entity), entity.GetRevision(), events)
+	}
+	if err != nil {
+		return err
+	}
+	repo.saveSnapshotIfDue(entity)
+	return nil
+}
+
+// loadSnapshot restores the latest usable snapshot of the entity and returns the revision to read the stream from
+func (repo EntityRepository) loadSnapshot(entity IReconstructible, streamName string) uint64 {
+	snapshottable, ok := entity.(ISnapshottable)
+	if !ok || repo.SnapshotStore == nil {
+		return 0
+	}
+	snapshot, err := repo.SnapshotStore.GetLatestSnapshot(streamName)
+	if err != nil || snapshot.Version != snapshottable.GetSnapshotVersion() {
+		return 0
+	}
+	err = restoreSnapshot(snapshottable, snapshot)
+	if err != nil {
+		return 0
+	}
+	return snapshot.Revision + 1
+}
+
+// saveSnapshotIfDue snapshots the entity every SnapshotFrequency events, a failed snapshot does not fail the save
+func (repo EntityRepository) saveSnapshotIfDue(entity IReconstructible) {
+	snapshottable, ok := entity.(ISnapshottable)
+	if !ok || repo.SnapshotStore == nil || repo.SnapshotFrequency == 0 {
+		return
+	}
+	var previousCount uint64
+	if !entity.IsNew() {
+		previousCount = entity.GetRevision() + 1
+	}
+	count := previousCount + uint64(len(entity.GetEvents()))
+	if count/repo.SnapshotFrequency == previousCount/repo.SnapshotFrequency {
+		return
+	}
+	streamName := getStreamName(entity)
+	snapshot, err := takeSnapshot(snapshottable, count-1)
+	if err == nil {
+		err = repo.SnapshotStore.SaveSnapshot(streamName, snapshot)
+	}
+	if err != nil {
+		log.Printf("could not save the snapshot of %s: %v", streamName, err)
+	}
+}
+
+// RetryOnConcurrencyConflict runs fn again on ErrConcurrencyConflict, fn must load the entities it changes
+func RetryOnConcurrencyConflict(maxAttempts int, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		err = fn()
+		if !errors.Is(err, ErrConcurrencyConflict) {
+			return err
+		}
 	}
 	return err
 }
 
-func serializeEvents(events []IEvent) []Event {
+func serializeEvents(entity IReconstructible, metadata EventMetadata) ([]Event, error) {
 	serializedEvents := []Event{}
-	for _, event := range events {
-		serializedEvents = append(serializedEvents, SerializedEvent(event))
+	for _, event := range entity.GetEvents() {
+		serializedEvent, err := entity.SerializeEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		schemaVersion := serializedEvent.Metadata.SchemaVersion
+		serializedEvent.Metadata = metadata
+		serializedEvent.Metadata.SchemaVersion = schemaVersion
+		serializedEvents = append(serializedEvents, serializedEvent)
 	}
-	return serializedEvents
+	return serializedEvents, nil
 }
 
 func getStreamName(entity IReconstructible) string {
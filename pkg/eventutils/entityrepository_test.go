@@ -1,6 +1,8 @@
 package eventutils
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -9,16 +11,21 @@ import (
 func TestGetEntity(t *testing.T) {
 	// Arrange
 	entity := NewTestEntity()
+	entity.ChangeName("NewName")
 	mockEventStore := new(MockEventStore)
 
-	serializedEvents := serializeEvents(entity.GetEvents())
+	serializedEvents := serializeTestEvents(t, entity)
+	for i := range serializedEvents {
+		serializedEvents[i].Revision = uint64(i)
+	}
 
 	// resetting the entity like it was saved, it will allow to compare with the retrieved one
 	entity.New = false
 	entity.Events = nil
+	entity.Revision = 1
 
 	mockEventStore.
-		On("GetAllEventsByStreamName", getStreamName(entity)).
+		On("ReadEventsByStreamName", getStreamName(entity), uint64(0)).
 		Return(serializedEvents, nil)
 
 	repo := NewEntityRepository(mockEventStore)
@@ -32,19 +39,44 @@ func TestGetEntity(t *testing.T) {
 	require.Equal(t, entity, foundEntity)
 }
 
+func TestGetEntity_WhenEntityWasDeleted(t *testing.T) {
+	// Arrange
+	entity := NewTestEntity()
+	entity.Delete()
+	mockEventStore := new(MockEventStore)
+
+	serializedEvents := serializeTestEvents(t, entity)
+	for i := range serializedEvents {
+		serializedEvents[i].Revision = uint64(i)
+	}
+
+	mockEventStore.
+		On("ReadEventsByStreamName", getStreamName(entity), uint64(0)).
+		Return(serializedEvents, nil)
+
+	repo := NewEntityRepository(mockEventStore)
+
+	//Act
+	foundEntity, err := repo.GetEntity(&TestEntity{}, entity.GetID())
+
+	//Assert
+	require.ErrorIs(t, err, ErrEntityNotFound)
+	require.Nil(t, foundEntity)
+}
+
 func TestSaveNewEntity(t *testing.T) {
 	// Arrange
 	entity := NewTestEntity()
 	mockEventStore := new(MockEventStore)
 
 	mockEventStore.
-		On("SaveEventsToNewStream", getStreamName(entity), serializeEvents(entity.Events)).
+		On("SaveEventsToNewStream", getStreamName(entity), serializeTestEvents(t, entity)).
 		Return(nil, nil)
 
 	repo := NewEntityRepository(mockEventStore)
 
 	//Act
-	repo.SaveEntity(entity)
+	repo.SaveEntity(context.Background(), entity)
 
 	//Assert
 	mockEventStore.AssertExpectations(t)
@@ -55,18 +87,98 @@ func TestSaveExistingEntity(t *testing.T) {
 	entity := NewTestEntity()
 	entity.Events = []IEvent{}
 	entity.New = false
+	entity.Revision = 3
 	entity.ChangeName("NewName")
 	mockEventStore := new(MockEventStore)
 
 	mockEventStore.
-		On("SaveEventsToExistingStream", getStreamName(entity), serializeEvents(entity.Events)).
+		On("SaveEventsToExistingStream", getStreamName(entity), uint64(3), serializeTestEvents(t, entity)).
 		Return(nil, nil)
 
 	repo := NewEntityRepository(mockEventStore)
 
 	//Act
-	repo.SaveEntity(entity)
+	repo.SaveEntity(context.Background(), entity)
 
 	//Assert
 	mockEventStore.AssertExpectations(t)
 }
+
+func TestSaveEntity_WhenModifiedConcurrently(t *testing.T) {
+	// Arrange
+	repo := NewEntityRepository(NewInMemoryEventStore())
+	entity := NewTestEntity()
+	err := repo.SaveEntity(context.Background(), entity)
+	require.NoError(t, err)
+
+	firstCopy, _ := repo.GetEntity(&TestEntity{}, entity.GetID())
+	secondCopy, _ := repo.GetEntity(&TestEntity{}, entity.GetID())
+	firstCopy.(*TestEntity).ChangeName("FirstName")
+	secondCopy.(*TestEntity).ChangeName("SecondName")
+	err = repo.SaveEntity(context.Background(), firstCopy)
+	require.NoError(t, err)
+
+	// Act
+	err = repo.SaveEntity(context.Background(), secondCopy)
+
+	// Assert
+	require.ErrorIs(t, err, ErrConcurrencyConflict)
+}
+
+func TestRetryOnConcurrencyConflict(t *testing.T) {
+	// Arrange
+	attempts := 0
+	fn := func() error {
+		attempts++
+		if attempts < 3 {
+			return ErrConcurrencyConflict
+		}
+		return nil
+	}
+
+	// Act
+	err := RetryOnConcurrencyConflict(5, fn)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+}
+
+func TestRetryOnConcurrencyConflict_WhenAttemptsAreExhausted(t *testing.T) {
+	// Arrange
+	attempts := 0
+	fn := func() error {
+		attempts++
+		return ErrConcurrencyConflict
+	}
+
+	// Act
+	err := RetryOnConcurrencyConflict(3, fn)
+
+	// Assert
+	require.ErrorIs(t, err, ErrConcurrencyConflict)
+	require.Equal(t, 3, attempts)
+}
+
+func TestRetryOnConcurrencyConflict_WhenOtherErrorOccurs(t *testing.T) {
+	// Arrange
+	otherErr := errors.New("other error")
+	attempts := 0
+	fn := func() error {
+		attempts++
+		return otherErr
+	}
+
+	// Act
+	err := RetryOnConcurrencyConflict(3, fn)
+
+	// Assert
+	require.ErrorIs(t, err, otherErr)
+	require.Equal(t, 1, attempts)
+}
+
+func serializeTestEvents(t *testing.T, entity IReconstructible) []Event {
+	events, err := serializeEvents(entity, EventMetadata{})
+	require.NoError(t, err)
+	return events
+}
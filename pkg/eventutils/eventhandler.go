@@ -2,50 +2,343 @@ package eventutils
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
 
 	"github.com/EventStore/EventStore-Client-Go/esdb"
 )
 
+type ISubscription interface {
+	Recv() *esdb.SubscriptionEvent
+	Ack(messages ...*esdb.ResolvedEvent) error
+	Nack(reason string, action esdb.Nack_Action, messages ...*esdb.ResolvedEvent) error
+	Close() error
+}
+
+// DefaultEventRetryPolicy is used by the handlers registered without a retry policy
+var DefaultEventRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+}
+
+// DefaultReconnectPolicy is the wait before connecting again to a subscription that dropped
+var DefaultReconnectPolicy = RetryPolicy{
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     30 * time.Second,
+}
+
+// DefaultPartitionQueueSize is the number of events that can wait for each worker
+const DefaultPartitionQueueSize = 100
+
+// SubscriptionConnector opens a new connection to the subscription of an EventHandler
+type SubscriptionConnector func(ctx context.Context) (ISubscription, error)
+
+// EventHandlerFunc handles an event, the context is done when the EventHandler is stopping
+type EventHandlerFunc func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error
+
+type eventHandlerRegistration struct {
+	handle      EventHandlerFunc
+	retryPolicy RetryPolicy
+}
+
+// EventHandler passes the events of a subscription to the handlers of their type and parks the ones that keep failing
 type EventHandler struct {
-	subscription *esdb.PersistentSubscription
-	handlers     map[string]func(rawEvent *esdb.SubscriptionEvent) error
+	connect            SubscriptionConnector
+	reconnectPolicy    RetryPolicy
+	reconnectable      bool
+	concurrency        int
+	partitionQueueSize int
+	parkedEvents       IParkedEvents
+	handlers           map[string]eventHandlerRegistration
+	fallback           *eventHandlerRegistration
+	cancel             context.CancelFunc
+	stopped            chan struct{}
+}
+
+func NewEventHandler(client *esdb.Client, groupName string) *EventHandler {
+	handler := NewEventHandlerFromConnector(func(ctx context.Context) (ISubscription, error) {
+		return client.ConnectToPersistentSubscriptionToAll(
+			ctx,
+			groupName,
+			esdb.ConnectToPersistentSubscriptionOptions{},
+		)
+	})
+	handler.parkedEvents = NewEsdbParkedEvents(client, groupName)
+	return handler
+}
+
+// NewEventHandlerFromSubscription returns an EventHandler that stops when the subscription drops
+func NewEventHandlerFromSubscription(subscription ISubscription) *EventHandler {
+	handler := NewEventHandlerFromConnector(func(ctx context.Context) (ISubscription, error) {
+		return subscription, nil
+	})
+	handler.reconnectable = false
+	handler.parkedEvents, _ = subscription.(IParkedEvents)
+	return handler
+}
+
+// NewCatchUpEventHandler returns an EventHandler that subscribes again after the checkpoint when the subscription drops
+func NewCatchUpEventHandler(subscription *CatchUpSubscription) *EventHandler {
+	handler := NewEventHandlerFromConnector(subscription.Connect)
+	handler.parkedEvents = subscription
+	return handler
 }
 
-func NewEventHandler(client *esdb.Client, groupName string) EventHandler {
-	sub, err := client.ConnectToPersistentSubscriptionToAll(
-		context.Background(),
-		groupName,
-		esdb.ConnectToPersistentSubscriptionOptions{},
-	)
-	if err != nil {
-		panic(err)
+func NewEventHandlerFromConnector(connect SubscriptionConnector) *EventHandler {
+	return &EventHandler{
+		connect:            connect,
+		reconnectPolicy:    DefaultReconnectPolicy,
+		reconnectable:      true,
+		concurrency:        1,
+		partitionQueueSize: DefaultPartitionQueueSize,
+		handlers:           make(map[string]eventHandlerRegistration),
 	}
-	return EventHandler{
-		subscription: sub,
-		handlers:     make(map[string]func(rawEvent *esdb.SubscriptionEvent) error),
+}
+
+func (handler *EventHandler) WithReconnectPolicy(reconnectPolicy RetryPolicy) *EventHandler {
+	handler.reconnectPolicy = reconnectPolicy
+	return handler
+}
+
+// WithConcurrency handles the events with a pool of workers, the events of an entity are handled in order by the same one
+func (handler *EventHandler) WithConcurrency(workers int, partitionQueueSize int) *EventHandler {
+	if workers < 1 {
+		workers = 1
+	}
+	if partitionQueueSize < 1 {
+		partitionQueueSize = DefaultPartitionQueueSize
+	}
+	handler.concurrency = workers
+	handler.partitionQueueSize = partitionQueueSize
+	return handler
+}
+
+func (handler *EventHandler) HandleEvent(eventName string, fn EventHandlerFunc) *EventHandler {
+	return handler.HandleEventWithRetryPolicy(eventName, DefaultEventRetryPolicy, fn)
+}
+
+func (handler *EventHandler) HandleEventWithRetryPolicy(eventName string, retryPolicy RetryPolicy, fn EventHandlerFunc) *EventHandler {
+	handler.handlers[eventName] = eventHandlerRegistration{
+		handle:      fn,
+		retryPolicy: retryPolicy,
 	}
+	return handler
 }
 
-func (handler EventHandler) HandleEvent(eventName string, fn func(rawEvent *esdb.SubscriptionEvent) error) EventHandler {
-	handler.handlers[eventName] = fn
+// HandleUnknownEvents registers the handler of the events no other handler was registered for
+func (handler *EventHandler) HandleUnknownEvents(retryPolicy RetryPolicy, fn EventHandlerFunc) *EventHandler {
+	handler.fallback = &eventHandlerRegistration{
+		handle:      fn,
+		retryPolicy: retryPolicy,
+	}
 	return handler
 }
 
-func (handler EventHandler) Start() {
+func (handler *EventHandler) GetParkedEvents() ([]Event, error) {
+	if handler.parkedEvents == nil {
+		return nil, ErrParkingNotSupported
+	}
+	return handler.parkedEvents.GetParkedEvents()
+}
+
+// ReplayParkedEvents sends the parked events to the subscription again
+func (handler *EventHandler) ReplayParkedEvents() error {
+	if handler.parkedEvents == nil {
+		return ErrParkingNotSupported
+	}
+	return handler.parkedEvents.ReplayParkedEvents()
+}
+
+// Start handles the events of the subscription in the background until the context is done or Stop is called
+func (handler *EventHandler) Start(ctx context.Context) {
+	ctx, handler.cancel = context.WithCancel(ctx)
+	handler.stopped = make(chan struct{})
+	go func() {
+		defer close(handler.stopped)
+		handler.run(ctx)
+	}()
+}
+
+// Stop closes the subscription, cancels the context of the event being handled, if any, and waits for it to be done
+func (handler *EventHandler) Stop() {
+	if handler.cancel == nil {
+		return
+	}
+	handler.cancel()
+	<-handler.stopped
+}
+
+// Wait blocks until the handler is stopped or its subscription dropped and cannot be connected again
+func (handler *EventHandler) Wait() {
+	if handler.stopped == nil {
+		return
+	}
+	<-handler.stopped
+}
+
+func (handler *EventHandler) run(ctx context.Context) {
+	failures := 0
+	for {
+		subscription, err := handler.connect(ctx)
+		if err == nil {
+			failures = 0
+			err = handler.consume(ctx, subscription)
+			if ctx.Err() != nil || !handler.reconnectable {
+				return
+			}
+			log.Printf("the subscription dropped, connecting again: %v", err)
+		} else {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("could not connect to the subscription: %v", err)
+		}
+		failures++
+		if handler.reconnectPolicy.WaitContext(ctx, failures) != nil {
+			return
+		}
+	}
+}
+
+// consume handles the events until the subscription drops, the subscription is closed when the context is done
+func (handler *EventHandler) consume(ctx context.Context, subscription ISubscription) error {
+	var closing sync.WaitGroup
+	closing.Add(1)
+	consumed := make(chan struct{})
 	go func() {
-		for {
-			event := handler.subscription.Recv()
-			if event.EventAppeared != nil {
-				err := handler.handlers[event.EventAppeared.Event.EventType](event)
-				if err != nil {
-					handler.subscription.Nack(err.Error(), esdb.Nack_Retry)
-				} else {
-					handler.subscription.Ack(event.EventAppeared)
-				}
+		defer closing.Done()
+		select {
+		case <-ctx.Done():
+		case <-consumed:
+		}
+		subscription.Close()
+	}()
+	defer closing.Wait()
+	defer close(consumed)
+
+	if handler.concurrency > 1 {
+		return handler.consumeConcurrently(ctx, subscription)
+	}
+	for {
+		event := subscription.Recv()
+		if event.EventAppeared != nil {
+			handler.handle(ctx, subscription, event)
+		}
+		if event.SubscriptionDropped != nil {
+			return event.SubscriptionDropped.Error
+		}
+	}
+}
+
+// consumeConcurrently queues every event to the worker of its partition and drains the queues when the subscription drops
+func (handler *EventHandler) consumeConcurrently(ctx context.Context, subscription ISubscription) error {
+	var workers sync.WaitGroup
+	acker := &syncSubscription{ISubscription: subscription}
+	partitions := make([]chan *esdb.SubscriptionEvent, handler.concurrency)
+	for i := range partitions {
+		partitions[i] = make(chan *esdb.SubscriptionEvent, handler.partitionQueueSize)
+		workers.Add(1)
+		go func(partition chan *esdb.SubscriptionEvent) {
+			defer workers.Done()
+			for event := range partition {
+				handler.handleQueued(ctx, acker, event)
 			}
-			if event.SubscriptionDropped != nil {
-				break
+		}(partitions[i])
+	}
+	defer workers.Wait()
+
+	for {
+		event := subscription.Recv()
+		if event.EventAppeared != nil {
+			partitions[getPartition(event.EventAppeared, len(partitions))] <- event
+		}
+		if event.SubscriptionDropped != nil {
+			for _, partition := range partitions {
+				close(partition)
 			}
+			return event.SubscriptionDropped.Error
+		}
+	}
+}
+
+// handleQueued nacks the queued event for a retry when the handler is stopping
+func (handler *EventHandler) handleQueued(ctx context.Context, subscription ISubscription, event *esdb.SubscriptionEvent) {
+	if ctx.Err() != nil {
+		subscription.Nack("the event handler is stopping", esdb.Nack_Retry, event.EventAppeared)
+		return
+	}
+	handler.handle(ctx, subscription, event)
+}
+
+// getPartition returns the partition of the entity of the event, or of its stream when it has no entity ID
+func getPartition(event *esdb.ResolvedEvent, partitions int) int {
+	var eventInfo EventInfo
+	key := event.OriginalEvent().StreamID
+	if json.Unmarshal(event.Event.Data, &eventInfo) == nil && !eventInfo.EntityID.IsNil() {
+		key = eventInfo.EntityID.String()
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return int(hash.Sum32() % uint32(partitions))
+}
+
+func (handler *EventHandler) handle(ctx context.Context, subscription ISubscription, event *esdb.SubscriptionEvent) {
+	eventType := event.EventAppeared.Event.EventType
+	registration, found := handler.handlers[eventType]
+	if !found && handler.fallback == nil {
+		subscription.Nack(fmt.Sprintf("there is no handler for %s", eventType), esdb.Nack_Park, event.EventAppeared)
+		return
+	}
+	if !found {
+		registration = *handler.fallback
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := registration.tryHandle(ctx, event)
+		if err == nil {
+			subscription.Ack(event.EventAppeared)
+			return
+		}
+		if attempt >= registration.retryPolicy.MaxAttempts {
+			subscription.Nack(err.Error(), esdb.Nack_Park, event.EventAppeared)
+			return
+		}
+		if registration.retryPolicy.WaitContext(ctx, attempt) != nil {
+			subscription.Nack("the event handler is stopping", esdb.Nack_Retry, event.EventAppeared)
+			return
+		}
+	}
+}
+
+// syncSubscription serializes the acks and nacks of the workers, a persistent subscription cannot take them concurrently
+type syncSubscription struct {
+	ISubscription
+	mutex sync.Mutex
+}
+
+func (subscription *syncSubscription) Ack(messages ...*esdb.ResolvedEvent) error {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	return subscription.ISubscription.Ack(messages...)
+}
+
+func (subscription *syncSubscription) Nack(reason string, action esdb.Nack_Action, messages ...*esdb.ResolvedEvent) error {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	return subscription.ISubscription.Nack(reason, action, messages...)
+}
+
+// tryHandle turns the panics of the handler into errors, so a poison event cannot stop the subscription
+func (registration eventHandlerRegistration) tryHandle(ctx context.Context, event *esdb.SubscriptionEvent) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("handler panicked: %v", recovered)
 		}
 	}()
+	return registration.handle(ctx, event)
 }
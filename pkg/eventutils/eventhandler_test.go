@@ -23,7 +23,7 @@ func TestHandleEvent(t *testing.T) {
 
 	var dataReceived string
 
-	var testHandler = func(rawEvent *esdb.SubscriptionEvent) error {
+	var testHandler = func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 		var event testEvent1
 		json.Unmarshal(rawEvent.EventAppeared.Event.Data, &event)
 		dataReceived = event.Data
@@ -35,7 +35,7 @@ func TestHandleEvent(t *testing.T) {
 	}
 
 	//Act
-	eventHandler.HandleEvent("testEvent1", testHandler).Start()
+	eventHandler.HandleEvent("testEvent1", testHandler).Start(context.Background())
 	sendTestEvent(db, "testStream", data)
 	time.Sleep(1 * time.Second) // sleep to make sure the event is received
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strings"
 
 	"github.com/EventStore/EventStore-Client-Go/esdb"
 )
@@ -19,17 +20,28 @@ func NewEsdbClient(connectionString string) (*esdb.Client, error) {
 
 type IEventStore interface {
 	SaveEventsToNewStream(streamName string, events []Event) (*esdb.WriteResult, error)
-	SaveEventsToExistingStream(streamName string, events []Event) (*esdb.WriteResult, error)
+	SaveEventsToExistingStream(streamName string, expectedRevision uint64, events []Event) (*esdb.WriteResult, error)
 	GetAllEventsByStreamName(streamName string) ([]Event, error)
+	ReadEventsByStreamName(streamName string, fromRevision uint64, handle func(event Event) error) error
 }
 
+// IAllEventsReader reads the events of every stream, in the order they were written
+type IAllEventsReader interface {
+	ReadAllEvents(fromPosition esdb.Position, handle func(event *esdb.RecordedEvent) error) error
+}
+
+const DefaultReadBatchSize uint64 = 200
+
 type EventStore struct {
 	db *esdb.Client
+	// ReadBatchSize is the number of events requested to EventStoreDB for every page of a stream
+	ReadBatchSize uint64
 }
 
 func NewEventStore(client *esdb.Client) (*EventStore, error) {
 	eventStore := &EventStore{
-		db: client,
+		db:            client,
+		ReadBatchSize: DefaultReadBatchSize,
 	}
 	return eventStore, nil
 }
@@ -40,53 +52,153 @@ func (eventStore EventStore) SaveEventsToNewStream(streamName string, events []E
 		ExpectedRevision: esdb.NoStream{},
 	}
 	writeResult, err := eventStore.db.AppendToStream(context.Background(), streamName, options, batch...)
+	if errors.Is(err, esdb.ErrWrongExpectedStreamRevision) {
+		return nil, ErrConcurrencyConflict
+	}
 	return writeResult, err
 }
 
-func (eventStore EventStore) SaveEventsToExistingStream(streamName string, events []Event) (*esdb.WriteResult, error) {
+func (eventStore EventStore) SaveEventsToExistingStream(streamName string, expectedRevision uint64, events []Event) (*esdb.WriteResult, error) {
 	batch := prepareEventsBatch(events)
 	options := esdb.AppendToStreamOptions{
-		ExpectedRevision: esdb.StreamExists{},
+		ExpectedRevision: esdb.Revision(expectedRevision),
 	}
 	writeResult, err := eventStore.db.AppendToStream(context.Background(), streamName, options, batch...)
+	if errors.Is(err, esdb.ErrWrongExpectedStreamRevision) {
+		return nil, ErrConcurrencyConflict
+	}
 	return writeResult, err
 }
 
 func (eventStore EventStore) GetAllEventsByStreamName(streamName string) ([]Event, error) {
+	events := []Event{}
+	err := eventStore.ReadEventsByStreamName(streamName, 0, func(event Event) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ReadEventsByStreamName passes the events of the stream from the revision to handle, reading ReadBatchSize events at a time
+func (eventStore EventStore) ReadEventsByStreamName(streamName string, fromRevision uint64, handle func(event Event) error) error {
+	batchSize := eventStore.ReadBatchSize
+	if batchSize == 0 {
+		batchSize = DefaultReadBatchSize
+	}
+	nextRevision := fromRevision
+	for {
+		readCount, err := eventStore.readPage(streamName, nextRevision, batchSize, handle)
+		if err != nil {
+			return err
+		}
+		if readCount < batchSize {
+			return nil
+		}
+		nextRevision += readCount
+	}
+}
 
-	options := esdb.ReadStreamOptions{}
-	stream, err := eventStore.db.ReadStream(context.Background(), streamName, options, 200)
+func (eventStore EventStore) readPage(streamName string, fromRevision, count uint64, handle func(event Event) error) (uint64, error) {
+	options := esdb.ReadStreamOptions{
+		From: esdb.Revision(fromRevision),
+	}
+	stream, err := eventStore.db.ReadStream(context.Background(), streamName, options, count)
 	if errors.Is(err, esdb.ErrStreamNotFound) {
-		return nil, ErrResourceNotFound
+		return 0, ErrResourceNotFound
+	}
+	if errors.Is(err, io.EOF) {
+		// the stream exists but has no events after fromRevision
+		return 0, nil
 	}
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 	defer stream.Close()
-	events := []Event{}
+	var readCount uint64
 	for {
 		eventData, err := stream.Recv()
 		if errors.Is(err, io.EOF) {
 			break
 		}
 		if err != nil {
-			return nil, err
+			return readCount, err
 		}
-		event := Event{
-			ID:   eventData.Event.EventID,
-			Name: eventData.Event.EventType,
-			Data: eventData.Event.Data,
+		readCount++
+		err = handle(DeserializeRecordedEvent(eventData.Event))
+		if err != nil {
+			return readCount, err
+		}
+	}
+	return readCount, nil
+}
+
+// ReadAllEvents passes the events of $all from the position to handle, skipping the system events
+func (eventStore EventStore) ReadAllEvents(fromPosition esdb.Position, handle func(event *esdb.RecordedEvent) error) error {
+	batchSize := eventStore.ReadBatchSize
+	if batchSize == 0 {
+		batchSize = DefaultReadBatchSize
+	}
+	from := fromPosition
+	skipFirst := false
+	for {
+		readCount, lastPosition, err := eventStore.readAllPage(from, batchSize, skipFirst, handle)
+		if err != nil {
+			return err
+		}
+		if readCount < batchSize {
+			return nil
+		}
+		// the next page starts from the last event read, which must not be handled twice
+		from = lastPosition
+		skipFirst = true
+	}
+}
+
+func (eventStore EventStore) readAllPage(from esdb.Position, count uint64, skipFirst bool, handle func(event *esdb.RecordedEvent) error) (uint64, esdb.Position, error) {
+	options := esdb.ReadAllOptions{
+		From: from,
+	}
+	stream, err := eventStore.db.ReadAll(context.Background(), options, count)
+	if errors.Is(err, io.EOF) {
+		return 0, from, nil
+	}
+	if err != nil {
+		return 0, from, err
+	}
+	defer stream.Close()
+	var readCount uint64
+	lastPosition := from
+	for {
+		resolvedEvent, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return readCount, lastPosition, nil
+		}
+		if err != nil {
+			return readCount, lastPosition, err
+		}
+		readCount++
+		event := resolvedEvent.Event
+		lastPosition = event.Position
+		if (skipFirst && readCount == 1) || strings.HasPrefix(event.EventType, "$") {
+			continue
+		}
+		err = handle(event)
+		if err != nil {
+			return readCount, lastPosition, err
 		}
-		events = append(events, event)
 	}
-	return events, nil
 }
 
 func DeserializeRecordedEvent(recordedEvent *esdb.RecordedEvent) Event {
 	return Event{
-		ID:   recordedEvent.EventID,
-		Name: recordedEvent.EventType,
-		Data: recordedEvent.Data,
+		ID:       recordedEvent.EventID,
+		Name:     recordedEvent.EventType,
+		Data:     recordedEvent.Data,
+		Revision: recordedEvent.EventNumber,
+		Metadata: deserializeMetadata(recordedEvent.UserMetadata),
 	}
 }
 
@@ -98,6 +210,7 @@ func prepareEventsBatch(events []Event) []esdb.EventData {
 			ContentType: esdb.JsonContentType,
 			EventType:   event.Name,
 			Data:        event.Data,
+			Metadata:    serializeMetadata(event.Metadata),
 		}
 		batch = append(batch, eventData)
 	}
@@ -107,5 +220,6 @@ func prepareEventsBatch(events []Event) []esdb.EventData {
 // Errors
 
 var (
-	ErrResourceNotFound = errors.New("resource was not found")
+	ErrResourceNotFound    = errors.New("resource was not found")
+	ErrConcurrencyConflict = errors.New("stream was modified concurrently")
 )
@@ -36,7 +36,7 @@ func TestSaveEventsAsNewStream(t *testing.T) {
 	require.NoError(t, err)
 
 	// Assert
-	require.Equal(t, returnedEvents, events)
+	require.Equal(t, returnedEvents, withRevisions(events))
 }
 
 func TestSaveEventsToExistentStream(t *testing.T) {
@@ -52,13 +52,103 @@ func TestSaveEventsToExistentStream(t *testing.T) {
 	_, _ = eventStore.SaveEventsToNewStream(streamName, events)
 
 	// Act
-	_, err = eventStore.SaveEventsToExistingStream(streamName, newEvents)
+	_, err = eventStore.SaveEventsToExistingStream(streamName, 4, newEvents)
 	require.NoError(t, err)
 
 	// Assert
 	returnedEvents, err := eventStore.GetAllEventsByStreamName(streamName)
 	require.NoError(t, err)
-	require.Equal(t, returnedEvents, append(events, newEvents...))
+	require.Equal(t, returnedEvents, withRevisions(append(events, newEvents...)))
+}
+
+func TestGetAllEventsByStreamName_WhenStreamIsLongerThanOnePage(t *testing.T) {
+	// Arrange
+	settings, _ := esdb.ParseConnectionString(eventStoreConnectionString)
+	db, _ := esdb.NewClient(settings)
+	eventStore, _ := NewEventStore(db)
+	eventStore.ReadBatchSize = 10
+
+	events := getRandomEvents(25)
+	streamName := "TestStream-" + utils.GenerateNewUUID().String()
+	_, err := eventStore.SaveEventsToNewStream(streamName, events)
+	require.NoError(t, err)
+
+	// Act
+	returnedEvents, err := eventStore.GetAllEventsByStreamName(streamName)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, withRevisions(events), returnedEvents)
+}
+
+func TestReadEventsByStreamName_FromRevision(t *testing.T) {
+	// Arrange
+	settings, _ := esdb.ParseConnectionString(eventStoreConnectionString)
+	db, _ := esdb.NewClient(settings)
+	eventStore, _ := NewEventStore(db)
+	eventStore.ReadBatchSize = 10
+
+	events := getRandomEvents(20)
+	streamName := "TestStream-" + utils.GenerateNewUUID().String()
+	_, _ = eventStore.SaveEventsToNewStream(streamName, events)
+	returnedEvents := []Event{}
+
+	// Act
+	err := eventStore.ReadEventsByStreamName(streamName, 5, func(event Event) error {
+		returnedEvents = append(returnedEvents, event)
+		return nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, withRevisions(events)[5:], returnedEvents)
+}
+
+func TestSaveEventsToExistentStream_WhenRevisionIsOutdated(t *testing.T) {
+	// Arrange
+	settings, _ := esdb.ParseConnectionString(eventStoreConnectionString)
+	db, _ := esdb.NewClient(settings)
+	eventStore, _ := NewEventStore(db)
+
+	streamName := "TestStream-" + utils.GenerateNewUUID().String()
+	_, _ = eventStore.SaveEventsToNewStream(streamName, getRandomEvents(2))
+
+	// Act
+	_, err := eventStore.SaveEventsToExistingStream(streamName, 0, getRandomEvents(1))
+
+	// Assert
+	require.ErrorIs(t, err, ErrConcurrencyConflict)
+}
+
+// withRevisions returns a copy of the events with the revisions they get when saved to a new stream
+func TestGetLatestSnapshot(t *testing.T) {
+	// Arrange
+	settings, _ := esdb.ParseConnectionString(eventStoreConnectionString)
+	db, _ := esdb.NewClient(settings)
+	eventStore, err := NewEventStore(db)
+	require.NoError(t, err)
+
+	streamName := "TestStream-" + utils.GenerateNewUUID().String()
+	firstSnapshot := Snapshot{EntityID: utils.GenerateNewUUID(), Version: 1, Revision: 9, State: []byte(`{"Name":"First"}`)}
+	secondSnapshot := Snapshot{EntityID: firstSnapshot.EntityID, Version: 1, Revision: 19, State: []byte(`{"Name":"Second"}`)}
+	require.NoError(t, eventStore.SaveSnapshot(streamName, firstSnapshot))
+	require.NoError(t, eventStore.SaveSnapshot(streamName, secondSnapshot))
+
+	// Act
+	snapshot, err := eventStore.GetLatestSnapshot(streamName)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, secondSnapshot, snapshot)
+}
+
+func withRevisions(events []Event) []Event {
+	revisioned := []Event{}
+	for i, event := range events {
+		event.Revision = uint64(i)
+		revisioned = append(revisioned, event)
+	}
+	return revisioned
 }
 
 func getRandomEvents(n int) []Event {
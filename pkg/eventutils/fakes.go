@@ -1,8 +1,6 @@
 package eventutils
 
 import (
-	"encoding/json"
-
 	"github.com/Resta-Inc/resta/pkg/utils"
 )
 
@@ -38,6 +36,23 @@ func (testEntity *TestEntity) ChangeName(name string) {
 	AddEvent(newEvent, testEntity)
 }
 
+func (testEntity *TestEntity) Delete() {
+	newEvent := TestEntityDeleted{
+		EventInfo: NewEventInfo(testEntity.ID),
+	}
+	AddEvent(newEvent, testEntity)
+}
+
+const testEntitySnapshotVersion = 1
+
+func (testEntity *TestEntity) GetSnapshotState() interface{} {
+	return &testEntity.State
+}
+
+func (testEntity TestEntity) GetSnapshotVersion() int {
+	return testEntitySnapshotVersion
+}
+
 type TestEntityCreated struct {
 	EventInfo
 	Name string
@@ -48,14 +63,30 @@ type TestEntityNameChanged struct {
 	NewName string
 }
 
-func (testEntity *TestEntity) ApplyEvent(event IEvent) {
-	eventType := utils.GetType(event)
-	switch eventType {
-	case "TestEntityCreated":
-		testEntity.applyTestEntityCreated(event.(TestEntityCreated))
-	case "TestEntityNameChanged":
-		testEntity.applyTestEntityNameChanged(event.(TestEntityNameChanged))
-	}
+type TestEntityDeleted struct {
+	EventInfo
+}
+
+var testEntityEvents = newTestEntityEvents()
+
+func newTestEntityEvents() *EventRegistry[*TestEntity] {
+	registry := NewEventRegistry[*TestEntity]()
+	RegisterEvent(registry, (*TestEntity).applyTestEntityCreated)
+	RegisterEvent(registry, (*TestEntity).applyTestEntityNameChanged)
+	RegisterEvent(registry, (*TestEntity).applyTestEntityDeleted)
+	return registry
+}
+
+func (testEntity TestEntity) SerializeEvent(event IEvent) (Event, error) {
+	return testEntityEvents.Serialize(event)
+}
+
+func (testEntity TestEntity) DeserializeEvent(event Event) (IEvent, error) {
+	return testEntityEvents.Deserialize(event)
+}
+
+func (testEntity *TestEntity) ApplyEvent(event IEvent) error {
+	return testEntityEvents.Apply(testEntity, event)
 }
 
 func (testEntity *TestEntity) applyTestEntityCreated(event TestEntityCreated) {
@@ -63,21 +94,10 @@ func (testEntity *TestEntity) applyTestEntityCreated(event TestEntityCreated) {
 	testEntity.ID = event.EntityID
 }
 
-func (testEntity TestEntity) applyTestEntityNameChanged(event TestEntityNameChanged) {
+func (testEntity *TestEntity) applyTestEntityNameChanged(event TestEntityNameChanged) {
 	testEntity.State.Name = event.NewName
 }
 
-func (testEntity TestEntity) DeserializeEvent(event Event) IEvent {
-	switch event.Name {
-	case "TestEntityCreated":
-		var e TestEntityCreated
-		json.Unmarshal(event.Data, &e)
-		return e
-	case "TestEntityNameChanged":
-		var e TestEntityNameChanged
-		json.Unmarshal(event.Data, &e)
-		return e
-	default:
-		return nil
-	}
+func (testEntity *TestEntity) applyTestEntityDeleted(event TestEntityDeleted) {
+	testEntity.SetDeleted()
 }
@@ -0,0 +1,254 @@
+package eventutils
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/utils"
+)
+
+// InMemoryEventStore is an IEventStore in memory, with the expected revision rules of EventStore
+type InMemoryEventStore struct {
+	mutex         sync.Mutex
+	streams       map[string][]Event
+	all           []*esdb.RecordedEvent
+	snapshots     map[string]Snapshot
+	subscriptions []*InMemorySubscription
+}
+
+func NewInMemoryEventStore() *InMemoryEventStore {
+	return &InMemoryEventStore{
+		streams:   make(map[string][]Event),
+		snapshots: make(map[string]Snapshot),
+	}
+}
+
+func (store *InMemoryEventStore) SaveEventsToNewStream(streamName string, events []Event) (*esdb.WriteResult, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, exists := store.streams[streamName]; exists {
+		return nil, ErrConcurrencyConflict
+	}
+	return store.appendToStream(streamName, events), nil
+}
+
+func (store *InMemoryEventStore) SaveEventsToExistingStream(streamName string, expectedRevision uint64, events []Event) (*esdb.WriteResult, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	stream, exists := store.streams[streamName]
+	if !exists || uint64(len(stream)-1) != expectedRevision {
+		return nil, ErrConcurrencyConflict
+	}
+	return store.appendToStream(streamName, events), nil
+}
+
+func (store *InMemoryEventStore) GetAllEventsByStreamName(streamName string) ([]Event, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	stream, exists := store.streams[streamName]
+	if !exists {
+		return nil, ErrResourceNotFound
+	}
+	events := make([]Event, len(stream))
+	copy(events, stream)
+	return events, nil
+}
+
+func (store *InMemoryEventStore) ReadEventsByStreamName(streamName string, fromRevision uint64, handle func(event Event) error) error {
+	store.mutex.Lock()
+	stream, exists := store.streams[streamName]
+	store.mutex.Unlock()
+
+	if !exists {
+		return ErrResourceNotFound
+	}
+	for revision := fromRevision; revision < uint64(len(stream)); revision++ {
+		err := handle(stream[revision])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadAllEvents passes the events of every stream, starting from the one at the given position, to handle one at a time
+func (store *InMemoryEventStore) ReadAllEvents(fromPosition esdb.Position, handle func(event *esdb.RecordedEvent) error) error {
+	store.mutex.Lock()
+	all := store.all
+	store.mutex.Unlock()
+
+	for position := fromPosition.Commit; position < uint64(len(all)); position++ {
+		err := handle(all[position])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SubscribeToAll delivers every event, past and future, whose type starts with one of the prefixes, if any
+func (store *InMemoryEventStore) SubscribeToAll(eventTypePrefixes ...string) *InMemorySubscription {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	subscription := newInMemorySubscription(eventTypePrefixes)
+	for _, recordedEvent := range store.all {
+		subscription.push(recordedEvent)
+	}
+	store.subscriptions = append(store.subscriptions, subscription)
+	return subscription
+}
+
+func (store *InMemoryEventStore) appendToStream(streamName string, events []Event) *esdb.WriteResult {
+	stream := store.streams[streamName]
+	for _, event := range events {
+		event.Revision = uint64(len(stream))
+		if event.Metadata.SchemaVersion == 0 {
+			event.Metadata.SchemaVersion = InitialSchemaVersion
+		}
+		recordedEvent := &esdb.RecordedEvent{
+			EventID:     event.ID,
+			EventType:   event.Name,
+			ContentType: "application/json",
+			StreamID:    streamName,
+			EventNumber: event.Revision,
+			Position: esdb.Position{
+				Commit:  uint64(len(store.all)),
+				Prepare: uint64(len(store.all)),
+			},
+			CreatedDate:  utils.Time.Now(),
+			Data:         event.Data,
+			UserMetadata: serializeMetadata(event.Metadata),
+		}
+		stream = append(stream, event)
+		store.all = append(store.all, recordedEvent)
+		for _, subscription := range store.subscriptions {
+			subscription.push(recordedEvent)
+		}
+	}
+	store.streams[streamName] = stream
+
+	lastPosition := uint64(len(store.all) - 1)
+	return &esdb.WriteResult{
+		CommitPosition:      lastPosition,
+		PreparePosition:     lastPosition,
+		NextExpectedVersion: uint64(len(stream) - 1),
+	}
+}
+
+// InMemorySubscription delivers the events in the order they were appended and keeps the parked ones until replayed
+type InMemorySubscription struct {
+	mutex             sync.Mutex
+	available         *sync.Cond
+	eventTypePrefixes []string
+	queue             []*esdb.ResolvedEvent
+	parked            []*esdb.ResolvedEvent
+	closed            bool
+}
+
+func newInMemorySubscription(eventTypePrefixes []string) *InMemorySubscription {
+	subscription := &InMemorySubscription{
+		eventTypePrefixes: eventTypePrefixes,
+	}
+	subscription.available = sync.NewCond(&subscription.mutex)
+	return subscription
+}
+
+func (subscription *InMemorySubscription) Recv() *esdb.SubscriptionEvent {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	for len(subscription.queue) == 0 && !subscription.closed {
+		subscription.available.Wait()
+	}
+	if subscription.closed {
+		return &esdb.SubscriptionEvent{
+			SubscriptionDropped: &esdb.SubscriptionDropped{},
+		}
+	}
+	event := subscription.queue[0]
+	subscription.queue = subscription.queue[1:]
+	return &esdb.SubscriptionEvent{
+		EventAppeared: event,
+	}
+}
+
+func (subscription *InMemorySubscription) Ack(messages ...*esdb.ResolvedEvent) error {
+	return nil
+}
+
+func (subscription *InMemorySubscription) Nack(reason string, action esdb.Nack_Action, messages ...*esdb.ResolvedEvent) error {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	switch action {
+	case esdb.Nack_Retry:
+		subscription.queue = append(subscription.queue, messages...)
+		subscription.available.Signal()
+	case esdb.Nack_Park:
+		subscription.parked = append(subscription.parked, messages...)
+	case esdb.Nack_Stop:
+		subscription.closed = true
+		subscription.available.Broadcast()
+	}
+	return nil
+}
+
+func (subscription *InMemorySubscription) GetParkedEvents() ([]Event, error) {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	events := []Event{}
+	for _, parkedEvent := range subscription.parked {
+		events = append(events, DeserializeRecordedEvent(parkedEvent.Event))
+	}
+	return events, nil
+}
+
+func (subscription *InMemorySubscription) ReplayParkedEvents() error {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	subscription.queue = append(subscription.queue, subscription.parked...)
+	subscription.parked = nil
+	subscription.available.Broadcast()
+	return nil
+}
+
+func (subscription *InMemorySubscription) Close() error {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	subscription.closed = true
+	subscription.available.Broadcast()
+	return nil
+}
+
+func (subscription *InMemorySubscription) push(recordedEvent *esdb.RecordedEvent) {
+	if !subscription.accepts(recordedEvent.EventType) {
+		return
+	}
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	subscription.queue = append(subscription.queue, &esdb.ResolvedEvent{
+		Event: recordedEvent,
+	})
+	subscription.available.Signal()
+}
+
+func (subscription *InMemorySubscription) accepts(eventType string) bool {
+	if len(subscription.eventTypePrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range subscription.eventTypePrefixes {
+		if strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,594 @@
+package eventutils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemorySaveEventsAsNewStream(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	events := getRandomEvents(5)
+	streamName := "TestStream-" + utils.GenerateNewUUID().String()
+
+	// Act
+	writeResult, err := eventStore.SaveEventsToNewStream(streamName, events)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), writeResult.NextExpectedVersion)
+	returnedEvents, err := eventStore.GetAllEventsByStreamName(streamName)
+	require.NoError(t, err)
+	require.Equal(t, withRevisions(events), returnedEvents)
+}
+
+func TestInMemorySaveEventsAsNewStream_WhenStreamExists(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	streamName := "TestStream-" + utils.GenerateNewUUID().String()
+	_, _ = eventStore.SaveEventsToNewStream(streamName, getRandomEvents(1))
+
+	// Act
+	_, err := eventStore.SaveEventsToNewStream(streamName, getRandomEvents(1))
+
+	// Assert
+	require.ErrorIs(t, err, ErrConcurrencyConflict)
+}
+
+func TestInMemorySaveEventsToExistentStream(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	events := getRandomEvents(5)
+	newEvents := getRandomEvents(5)
+	streamName := "TestStream-" + utils.GenerateNewUUID().String()
+	_, _ = eventStore.SaveEventsToNewStream(streamName, events)
+
+	// Act
+	_, err := eventStore.SaveEventsToExistingStream(streamName, 4, newEvents)
+
+	// Assert
+	require.NoError(t, err)
+	returnedEvents, err := eventStore.GetAllEventsByStreamName(streamName)
+	require.NoError(t, err)
+	require.Equal(t, withRevisions(append(events, newEvents...)), returnedEvents)
+}
+
+func TestInMemorySaveEventsToExistentStream_WhenRevisionIsOutdated(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	streamName := "TestStream-" + utils.GenerateNewUUID().String()
+	_, _ = eventStore.SaveEventsToNewStream(streamName, getRandomEvents(2))
+
+	// Act
+	_, err := eventStore.SaveEventsToExistingStream(streamName, 0, getRandomEvents(1))
+
+	// Assert
+	require.ErrorIs(t, err, ErrConcurrencyConflict)
+}
+
+func TestInMemorySaveEventsToExistentStream_WhenStreamDoesNotExist(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	streamName := "TestStream-" + utils.GenerateNewUUID().String()
+
+	// Act
+	_, err := eventStore.SaveEventsToExistingStream(streamName, 0, getRandomEvents(1))
+
+	// Assert
+	require.ErrorIs(t, err, ErrConcurrencyConflict)
+}
+
+func TestInMemoryGetAllEventsByStreamName_WhenStreamDoesNotExist(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+
+	// Act
+	_, err := eventStore.GetAllEventsByStreamName("TestStream-" + utils.GenerateNewUUID().String())
+
+	// Assert
+	require.ErrorIs(t, err, ErrResourceNotFound)
+}
+
+func TestInMemoryReadEventsByStreamName_FromRevision(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	events := getRandomEvents(5)
+	streamName := "TestStream-" + utils.GenerateNewUUID().String()
+	_, _ = eventStore.SaveEventsToNewStream(streamName, events)
+	returnedEvents := []Event{}
+
+	// Act
+	err := eventStore.ReadEventsByStreamName(streamName, 3, func(event Event) error {
+		returnedEvents = append(returnedEvents, event)
+		return nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, withRevisions(events)[3:], returnedEvents)
+}
+
+func TestInMemoryReadEventsByStreamName_WhenHandlerFails(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	streamName := "TestStream-" + utils.GenerateNewUUID().String()
+	_, _ = eventStore.SaveEventsToNewStream(streamName, getRandomEvents(5))
+	handled := 0
+
+	// Act
+	err := eventStore.ReadEventsByStreamName(streamName, 0, func(event Event) error {
+		handled++
+		return ErrConcurrencyConflict
+	})
+
+	// Assert
+	require.ErrorIs(t, err, ErrConcurrencyConflict)
+	require.Equal(t, 1, handled)
+}
+
+func TestInMemorySubscribeToAll(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	entity := NewTestEntity()
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+
+	// Act
+	subscription := eventStore.SubscribeToAll("TestEntityNameChanged")
+	entity.Events = nil
+	entity.ChangeName("NewName")
+	_, _ = eventStore.SaveEventsToExistingStream(getStreamName(entity), 0, serializeTestEvents(t, entity))
+
+	// Assert
+	received := subscription.Recv()
+	require.NotNil(t, received.EventAppeared)
+	require.Equal(t, "TestEntityNameChanged", received.EventAppeared.Event.EventType)
+	require.Equal(t, getStreamName(entity), received.EventAppeared.Event.StreamID)
+	require.Equal(t, uint64(1), received.EventAppeared.Event.EventNumber)
+}
+
+func TestHandleEventWithInMemorySubscription(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	eventHandler := NewEventHandlerFromSubscription(eventStore.SubscribeToAll("TestEntityCreated"))
+	received := make(chan TestEntityCreated, 1)
+	attempts := 0
+
+	var testHandler = func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+		attempts++
+		if attempts == 1 {
+			return ErrResourceNotFound
+		}
+		var event TestEntityCreated
+		json.Unmarshal(rawEvent.EventAppeared.Event.Data, &event)
+		received <- event
+		return nil
+	}
+
+	entity := NewTestEntity()
+
+	// Act
+	eventHandler.HandleEventWithRetryPolicy("TestEntityCreated", RetryPolicy{MaxAttempts: 3}, testHandler).Start(context.Background())
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+
+	// Assert
+	select {
+	case event := <-received:
+		require.Equal(t, entity.GetID(), event.GetEntityID())
+		require.Equal(t, 2, attempts)
+	case <-time.After(time.Second):
+		require.Fail(t, "the event was not received")
+	}
+}
+
+func TestHandleEventWithInMemorySubscription_ParksEventAfterMaxAttempts(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	eventHandler := NewEventHandlerFromSubscription(eventStore.SubscribeToAll("TestEntityCreated"))
+	attempts := 0
+
+	var testHandler = func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+		attempts++
+		return ErrResourceNotFound
+	}
+
+	entity := NewTestEntity()
+
+	// Act
+	eventHandler.HandleEventWithRetryPolicy("TestEntityCreated", RetryPolicy{MaxAttempts: 3}, testHandler).Start(context.Background())
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+
+	// Assert
+	parkedEvents := waitForParkedEvents(t, eventHandler, 1)
+	require.Equal(t, "TestEntityCreated", parkedEvents[0].Name)
+	require.Equal(t, 3, attempts)
+}
+
+func TestHandleEventWithInMemorySubscription_ParksEventThatPanics(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	eventHandler := NewEventHandlerFromSubscription(eventStore.SubscribeToAll("TestEntityCreated"))
+
+	var testHandler = func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+		panic("poison event")
+	}
+
+	entity := NewTestEntity()
+
+	// Act
+	eventHandler.HandleEventWithRetryPolicy("TestEntityCreated", RetryPolicy{MaxAttempts: 2}, testHandler).Start(context.Background())
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+
+	// Assert
+	parkedEvents := waitForParkedEvents(t, eventHandler, 1)
+	require.Equal(t, "TestEntityCreated", parkedEvents[0].Name)
+}
+
+func TestHandleEventWithInMemorySubscription_ParksUnknownEvent(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	eventHandler := NewEventHandlerFromSubscription(eventStore.SubscribeToAll())
+	entity := NewTestEntity()
+
+	// Act
+	eventHandler.Start(context.Background())
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+
+	// Assert
+	parkedEvents := waitForParkedEvents(t, eventHandler, 1)
+	require.Equal(t, "TestEntityCreated", parkedEvents[0].Name)
+}
+
+func TestHandleEventWithInMemorySubscription_UnknownEventGoesToFallback(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	eventHandler := NewEventHandlerFromSubscription(eventStore.SubscribeToAll())
+	received := make(chan string, 1)
+
+	var fallback = func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+		received <- rawEvent.EventAppeared.Event.EventType
+		return nil
+	}
+
+	entity := NewTestEntity()
+
+	// Act
+	eventHandler.HandleUnknownEvents(RetryPolicy{MaxAttempts: 1}, fallback).Start(context.Background())
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+
+	// Assert
+	select {
+	case eventType := <-received:
+		require.Equal(t, "TestEntityCreated", eventType)
+	case <-time.After(time.Second):
+		require.Fail(t, "the event was not received")
+	}
+	parkedEvents, err := eventHandler.GetParkedEvents()
+	require.NoError(t, err)
+	require.Empty(t, parkedEvents)
+}
+
+func TestHandleEventWithInMemorySubscription_ReplayParkedEvents(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	eventHandler := NewEventHandlerFromSubscription(eventStore.SubscribeToAll("TestEntityCreated"))
+	received := make(chan TestEntityCreated, 1)
+	poisoned := true
+
+	var testHandler = func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+		if poisoned {
+			return ErrResourceNotFound
+		}
+		var event TestEntityCreated
+		json.Unmarshal(rawEvent.EventAppeared.Event.Data, &event)
+		received <- event
+		return nil
+	}
+
+	entity := NewTestEntity()
+	eventHandler.HandleEventWithRetryPolicy("TestEntityCreated", RetryPolicy{MaxAttempts: 1}, testHandler).Start(context.Background())
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+	waitForParkedEvents(t, eventHandler, 1)
+	poisoned = false
+
+	// Act
+	err := eventHandler.ReplayParkedEvents()
+
+	// Assert
+	require.NoError(t, err)
+	select {
+	case event := <-received:
+		require.Equal(t, entity.GetID(), event.GetEntityID())
+	case <-time.After(time.Second):
+		require.Fail(t, "the event was not replayed")
+	}
+	parkedEvents, err := eventHandler.GetParkedEvents()
+	require.NoError(t, err)
+	require.Empty(t, parkedEvents)
+}
+
+func TestHandleEventWithInMemorySubscription_ReconnectsWhenSubscriptionDrops(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	connections := make(chan *InMemorySubscription, 2)
+	eventHandler := NewEventHandlerFromConnector(func(ctx context.Context) (ISubscription, error) {
+		subscription := eventStore.SubscribeToAll("TestEntityCreated")
+		connections <- subscription
+		return subscription, nil
+	}).WithReconnectPolicy(RetryPolicy{})
+	received := make(chan TestEntityCreated, 1)
+
+	var testHandler = func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+		var event TestEntityCreated
+		json.Unmarshal(rawEvent.EventAppeared.Event.Data, &event)
+		received <- event
+		return nil
+	}
+
+	entity := NewTestEntity()
+	eventHandler.HandleEvent("TestEntityCreated", testHandler).Start(context.Background())
+	defer eventHandler.Stop()
+	firstSubscription := <-connections
+
+	// Act
+	firstSubscription.Nack("dropped", esdb.Nack_Stop)
+	<-connections
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+
+	// Assert
+	select {
+	case event := <-received:
+		require.Equal(t, entity.GetID(), event.GetEntityID())
+	case <-time.After(time.Second):
+		require.Fail(t, "the event was not received after reconnecting")
+	}
+}
+
+func TestHandleEventWithInMemorySubscription_RetriesConnection(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	subscription := eventStore.SubscribeToAll("TestEntityCreated")
+	connectionAttempts := 0
+	eventHandler := NewEventHandlerFromConnector(func(ctx context.Context) (ISubscription, error) {
+		connectionAttempts++
+		if connectionAttempts < 3 {
+			return nil, ErrResourceNotFound
+		}
+		return subscription, nil
+	}).WithReconnectPolicy(RetryPolicy{})
+	received := make(chan TestEntityCreated, 1)
+
+	var testHandler = func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+		var event TestEntityCreated
+		json.Unmarshal(rawEvent.EventAppeared.Event.Data, &event)
+		received <- event
+		return nil
+	}
+
+	entity := NewTestEntity()
+
+	// Act
+	eventHandler.HandleEvent("TestEntityCreated", testHandler).Start(context.Background())
+	defer eventHandler.Stop()
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+
+	// Assert
+	select {
+	case event := <-received:
+		require.Equal(t, entity.GetID(), event.GetEntityID())
+		require.Equal(t, 3, connectionAttempts)
+	case <-time.After(time.Second):
+		require.Fail(t, "the event was not received")
+	}
+}
+
+func TestStopEventHandler_WaitsForEventBeingHandled(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	subscription := eventStore.SubscribeToAll("TestEntityCreated")
+	eventHandler := NewEventHandlerFromSubscription(subscription)
+	handling := make(chan struct{})
+	release := make(chan struct{})
+	handled := false
+
+	var testHandler = func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+		close(handling)
+		<-release
+		handled = true
+		return nil
+	}
+
+	entity := NewTestEntity()
+	eventHandler.HandleEvent("TestEntityCreated", testHandler).Start(context.Background())
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+	<-handling
+
+	// Act
+	stopped := make(chan struct{})
+	go func() {
+		eventHandler.Stop()
+		close(stopped)
+	}()
+
+	// Assert
+	select {
+	case <-stopped:
+		require.Fail(t, "the handler stopped before the event was handled")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-stopped:
+		require.True(t, handled)
+	case <-time.After(time.Second):
+		require.Fail(t, "the handler did not stop")
+	}
+	require.True(t, subscription.Recv().SubscriptionDropped != nil)
+}
+
+func TestStartEventHandler_StopsWhenContextIsDone(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	subscription := eventStore.SubscribeToAll()
+	eventHandler := NewEventHandlerFromSubscription(subscription)
+	ctx, cancel := context.WithCancel(context.Background())
+	eventHandler.Start(ctx)
+
+	// Act
+	cancel()
+
+	// Assert
+	select {
+	case <-eventHandler.stopped:
+	case <-time.After(time.Second):
+		require.Fail(t, "the handler did not stop")
+	}
+	require.True(t, subscription.Recv().SubscriptionDropped != nil)
+}
+
+func TestHandleEventConcurrently_KeepsOrderOfEachEntity(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	eventHandler := NewEventHandlerFromSubscription(eventStore.SubscribeToAll("TestEntity")).WithConcurrency(4, 2)
+	var mutex sync.Mutex
+	names := make(map[uuid.UUID][]string)
+	handled := make(chan struct{}, 100)
+
+	var testHandler = func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+		var event TestEntityNameChanged
+		json.Unmarshal(rawEvent.EventAppeared.Event.Data, &event)
+		mutex.Lock()
+		names[event.GetEntityID()] = append(names[event.GetEntityID()], event.NewName)
+		mutex.Unlock()
+		handled <- struct{}{}
+		return nil
+	}
+
+	entities := []*TestEntity{}
+	for i := 0; i < 10; i++ {
+		entity := NewTestEntity()
+		for j := 0; j < 5; j++ {
+			entity.ChangeName(fmt.Sprint(j))
+		}
+		entities = append(entities, entity)
+	}
+
+	// Act
+	eventHandler.
+		HandleEvent("TestEntityCreated", func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error { return nil }).
+		HandleEvent("TestEntityNameChanged", testHandler).
+		Start(context.Background())
+	defer eventHandler.Stop()
+	for _, entity := range entities {
+		_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+	}
+
+	// Assert
+	for i := 0; i < 50; i++ {
+		select {
+		case <-handled:
+		case <-time.After(time.Second):
+			require.Fail(t, "the events were not handled")
+		}
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, entity := range entities {
+		require.Equal(t, []string{"0", "1", "2", "3", "4"}, names[entity.GetID()])
+	}
+}
+
+func TestHandleEventConcurrently_HandlesEntitiesInParallel(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	eventHandler := NewEventHandlerFromSubscription(eventStore.SubscribeToAll("TestEntityCreated")).WithConcurrency(2, 1)
+	blockedEntity := NewTestEntity()
+	otherEntity := NewTestEntity()
+	for getTestEntityPartition(otherEntity, 2) == getTestEntityPartition(blockedEntity, 2) {
+		otherEntity = NewTestEntity()
+	}
+	otherHandled := make(chan struct{})
+
+	var testHandler = func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+		var event TestEntityCreated
+		json.Unmarshal(rawEvent.EventAppeared.Event.Data, &event)
+		if event.GetEntityID() == blockedEntity.GetID() {
+			<-otherHandled
+			return nil
+		}
+		close(otherHandled)
+		return nil
+	}
+
+	// Act
+	eventHandler.HandleEvent("TestEntityCreated", testHandler).Start(context.Background())
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(blockedEntity), serializeTestEvents(t, blockedEntity))
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(otherEntity), serializeTestEvents(t, otherEntity))
+
+	// Assert
+	select {
+	case <-otherHandled:
+	case <-time.After(time.Second):
+		require.Fail(t, "the event of the other entity waited for the blocked one")
+	}
+	eventHandler.Stop()
+}
+
+func getTestEntityPartition(entity *TestEntity, partitions int) int {
+	event := &esdb.ResolvedEvent{
+		Event: &esdb.RecordedEvent{
+			StreamID: getStreamName(entity),
+			Data:     []byte(fmt.Sprintf(`{"EntityID":"%s"}`, entity.GetID())),
+		},
+	}
+	return getPartition(event, partitions)
+}
+
+func TestGetParkedEvents_WhenSubscriptionDoesNotSupportParking(t *testing.T) {
+	// Arrange
+	eventHandler := NewEventHandlerFromSubscription(&esdb.PersistentSubscription{})
+
+	// Act
+	_, err := eventHandler.GetParkedEvents()
+
+	// Assert
+	require.ErrorIs(t, err, ErrParkingNotSupported)
+}
+
+func waitForParkedEvents(t *testing.T, eventHandler *EventHandler, count int) []Event {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		parkedEvents, err := eventHandler.GetParkedEvents()
+		require.NoError(t, err)
+		if len(parkedEvents) >= count {
+			return parkedEvents
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Fail(t, "the events were not parked")
+	return nil
+}
+
+func TestEntityRepositoryWithInMemoryEventStore(t *testing.T) {
+	// Arrange
+	repo := NewEntityRepository(NewInMemoryEventStore())
+	entity := NewTestEntity()
+	err := repo.SaveEntity(context.Background(), entity)
+	require.NoError(t, err)
+
+	// Act
+	foundEntity, err := repo.GetEntity(&TestEntity{}, entity.GetID())
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, entity.GetID(), foundEntity.GetID())
+	require.Equal(t, entity.State, foundEntity.(*TestEntity).State)
+}
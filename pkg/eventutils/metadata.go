@@ -0,0 +1,61 @@
+package eventutils
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gofrs/uuid"
+)
+
+// InitialSchemaVersion is the schema version of the events written before their schema was versioned
+const InitialSchemaVersion = 1
+
+// EventMetadata traces the request and the event that caused an event, with the names EventStoreDB expects
+type EventMetadata struct {
+	CorrelationID uuid.UUID `json:"$correlationId"`
+	CausationID   uuid.UUID `json:"$causationId"`
+	UserID        string    `json:"userId,omitempty"`
+	TenantID      string    `json:"tenantId,omitempty"`
+	SchemaVersion int       `json:"schemaVersion"`
+}
+
+type metadataContextKey struct{}
+
+// ContextWithMetadata returns a copy of ctx carrying the metadata of the events saved with it
+func ContextWithMetadata(ctx context.Context, metadata EventMetadata) context.Context {
+	return context.WithValue(ctx, metadataContextKey{}, metadata)
+}
+
+func MetadataFromContext(ctx context.Context) EventMetadata {
+	metadata, _ := ctx.Value(metadataContextKey{}).(EventMetadata)
+	return metadata
+}
+
+// CausedBy returns the metadata of the events written in reaction to event
+func CausedBy(event Event) EventMetadata {
+	correlationID := event.Metadata.CorrelationID
+	if correlationID == uuid.Nil {
+		correlationID = event.ID
+	}
+	return EventMetadata{
+		CorrelationID: correlationID,
+		CausationID:   event.ID,
+		UserID:        event.Metadata.UserID,
+		TenantID:      event.Metadata.TenantID,
+	}
+}
+
+func serializeMetadata(metadata EventMetadata) []byte {
+	bytes, _ := json.Marshal(metadata)
+	return bytes
+}
+
+// deserializeMetadata never fails, an event without metadata is at the initial schema version
+func deserializeMetadata(data []byte) EventMetadata {
+	var metadata EventMetadata
+	_ = json.Unmarshal(data, &metadata)
+	if metadata.SchemaVersion == 0 {
+		metadata.SchemaVersion = InitialSchemaVersion
+	}
+	return metadata
+}
@@ -0,0 +1,105 @@
+package eventutils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveEntity_WithMetadataFromContext(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	repo := NewEntityRepository(eventStore)
+	entity := NewTestEntity()
+	metadata := EventMetadata{
+		CorrelationID: utils.GenerateNewUUID(),
+		CausationID:   utils.GenerateNewUUID(),
+		UserID:        "user",
+		TenantID:      "tenant",
+	}
+	ctx := ContextWithMetadata(context.Background(), metadata)
+
+	// Act
+	err := repo.SaveEntity(ctx, entity)
+
+	// Assert
+	require.NoError(t, err)
+	events, err := eventStore.GetAllEventsByStreamName(getStreamName(entity))
+	require.NoError(t, err)
+	metadata.SchemaVersion = InitialSchemaVersion
+	require.Equal(t, metadata, events[0].Metadata)
+}
+
+func TestCausedBy(t *testing.T) {
+	// Arrange
+	event := Event{
+		ID: utils.GenerateNewUUID(),
+		Metadata: EventMetadata{
+			CorrelationID: utils.GenerateNewUUID(),
+			CausationID:   utils.GenerateNewUUID(),
+			UserID:        "user",
+			TenantID:      "tenant",
+			SchemaVersion: 2,
+		},
+	}
+
+	// Act
+	metadata := CausedBy(event)
+
+	// Assert
+	require.Equal(t, EventMetadata{
+		CorrelationID: event.Metadata.CorrelationID,
+		CausationID:   event.ID,
+		UserID:        "user",
+		TenantID:      "tenant",
+	}, metadata)
+}
+
+func TestCausedBy_WhenEventHasNoCorrelation(t *testing.T) {
+	// Arrange
+	event := Event{
+		ID: utils.GenerateNewUUID(),
+	}
+
+	// Act
+	metadata := CausedBy(event)
+
+	// Assert
+	require.Equal(t, event.ID, metadata.CorrelationID)
+	require.Equal(t, event.ID, metadata.CausationID)
+}
+
+func TestDeserializeRecordedEvent_WithMetadata(t *testing.T) {
+	// Arrange
+	correlationID := utils.GenerateNewUUID()
+	recordedEvent := &esdb.RecordedEvent{
+		EventID:      utils.GenerateNewUUID(),
+		EventType:    "TestEntityCreated",
+		UserMetadata: []byte(`{"$correlationId": "` + correlationID.String() + `", "userId": "user", "schemaVersion": 2}`),
+	}
+
+	// Act
+	event := DeserializeRecordedEvent(recordedEvent)
+
+	// Assert
+	require.Equal(t, correlationID, event.Metadata.CorrelationID)
+	require.Equal(t, "user", event.Metadata.UserID)
+	require.Equal(t, 2, event.Metadata.SchemaVersion)
+}
+
+func TestDeserializeRecordedEvent_WithoutMetadata(t *testing.T) {
+	// Arrange
+	recordedEvent := &esdb.RecordedEvent{
+		EventID:   utils.GenerateNewUUID(),
+		EventType: "TestEntityCreated",
+	}
+
+	// Act
+	event := DeserializeRecordedEvent(recordedEvent)
+
+	// Assert
+	require.Equal(t, EventMetadata{SchemaVersion: InitialSchemaVersion}, event.Metadata)
+}
@@ -1,6 +1,8 @@
 package eventutils
 
 import (
+	"context"
+
 	"github.com/EventStore/EventStore-Client-Go/esdb"
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/mock"
@@ -15,8 +17,8 @@ func (m MockEventStore) SaveEventsToNewStream(streamName string, events []Event)
 	writeResult, _ := args.Get(0).(*esdb.WriteResult)
 	return writeResult, args.Error(1)
 }
-func (m MockEventStore) SaveEventsToExistingStream(streamName string, events []Event) (*esdb.WriteResult, error) {
-	args := m.Called(streamName, events)
+func (m MockEventStore) SaveEventsToExistingStream(streamName string, expectedRevision uint64, events []Event) (*esdb.WriteResult, error) {
+	args := m.Called(streamName, expectedRevision, events)
 	writeResult, _ := args.Get(0).(*esdb.WriteResult)
 	return writeResult, args.Error(1)
 }
@@ -27,6 +29,18 @@ func (m MockEventStore) GetAllEventsByStreamName(streamName string) ([]Event, er
 	return returnedEvents, args.Error(1)
 }
 
+func (m MockEventStore) ReadEventsByStreamName(streamName string, fromRevision uint64, handle func(event Event) error) error {
+	args := m.Called(streamName, fromRevision)
+	returnedEvents, _ := args.Get(0).([]Event)
+	for _, event := range returnedEvents {
+		err := handle(event)
+		if err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
 type MockEntityRepository struct {
 	mock.Mock
 }
@@ -37,7 +51,7 @@ func (m MockEntityRepository) GetEntity(entity IReconstructible, id uuid.UUID) (
 	return returnedEntity, args.Error(1)
 }
 
-func (m MockEntityRepository) SaveEntity(entity IReconstructible) error {
+func (m MockEntityRepository) SaveEntity(ctx context.Context, entity IReconstructible) error {
 	args := m.Called(entity)
 	return args.Error(0)
 }
@@ -0,0 +1,116 @@
+package eventutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+// IParkedEvents gives access to the events a subscription group parked
+type IParkedEvents interface {
+	GetParkedEvents() ([]Event, error)
+	ReplayParkedEvents() error
+}
+
+const parkedEventsPageSize uint64 = 200
+
+// EsdbParkedEvents reads the parked events of a persistent subscription to $all and replays them with the HTTP API
+type EsdbParkedEvents struct {
+	client     *esdb.Client
+	groupName  string
+	httpClient *http.Client
+}
+
+func NewEsdbParkedEvents(client *esdb.Client, groupName string) *EsdbParkedEvents {
+	return &EsdbParkedEvents{
+		client:     client,
+		groupName:  groupName,
+		httpClient: http.DefaultClient,
+	}
+}
+
+func (parked EsdbParkedEvents) GetParkedEvents() ([]Event, error) {
+	events := []Event{}
+	var from esdb.StreamPosition = esdb.Start{}
+	for {
+		options := esdb.ReadStreamOptions{
+			From:           from,
+			ResolveLinkTos: true,
+		}
+		stream, err := parked.client.ReadStream(context.Background(), parked.getStreamName(), options, parkedEventsPageSize)
+		if errors.Is(err, esdb.ErrStreamNotFound) || errors.Is(err, io.EOF) {
+			return events, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		readCount, lastRevision, err := readParkedPage(stream, &events)
+		stream.Close()
+		if err != nil {
+			return nil, err
+		}
+		if readCount < parkedEventsPageSize {
+			return events, nil
+		}
+		from = esdb.Revision(lastRevision + 1)
+	}
+}
+
+func readParkedPage(stream *esdb.ReadStream, events *[]Event) (uint64, uint64, error) {
+	var readCount, lastRevision uint64
+	for {
+		resolvedEvent, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return readCount, lastRevision, nil
+		}
+		if err != nil {
+			return readCount, lastRevision, err
+		}
+		readCount++
+		lastRevision = resolvedEvent.OriginalEvent().EventNumber
+		// the parked event is a link to the original one, which can have been deleted in the meantime
+		if resolvedEvent.Event != nil {
+			*events = append(*events, DeserializeRecordedEvent(resolvedEvent.Event))
+		}
+	}
+}
+
+func (parked EsdbParkedEvents) ReplayParkedEvents() error {
+	config := parked.client.Config
+	scheme := "https"
+	if config.DisableTLS {
+		scheme = "http"
+	}
+	replayURL := fmt.Sprintf("%s://%s/subscriptions/%s/%s/replayParked", scheme, config.Address, url.PathEscape("$all"), url.PathEscape(parked.groupName))
+	request, err := http.NewRequest(http.MethodPost, replayURL, nil)
+	if err != nil {
+		return err
+	}
+	if config.Username != "" {
+		request.SetBasicAuth(config.Username, config.Password)
+	}
+	response, err := parked.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("could not replay the parked events of %s: %s", parked.groupName, response.Status)
+	}
+	return nil
+}
+
+func (parked EsdbParkedEvents) getStreamName() string {
+	return "$persistentsubscription-$all::" + parked.groupName + "-parked"
+}
+
+// Errors
+
+var (
+	ErrParkingNotSupported = errors.New("the subscription does not support parked events")
+)
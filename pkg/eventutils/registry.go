@@ -0,0 +1,86 @@
+package eventutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Resta-Inc/resta/pkg/utils"
+)
+
+// EventRegistry holds how the event types of an entity of type T are named, deserialized and applied
+type EventRegistry[T any] struct {
+	deserializers map[string]func(data []byte) (IEvent, error)
+	appliers      map[string]func(entity T, event IEvent)
+	upcasters     *Upcasters
+}
+
+func NewEventRegistry[T any]() *EventRegistry[T] {
+	return &EventRegistry[T]{
+		deserializers: make(map[string]func(data []byte) (IEvent, error)),
+		appliers:      make(map[string]func(entity T, event IEvent)),
+	}
+}
+
+// RegisterEvent registers the event type E, named after its Go type, with the function that applies it
+func RegisterEvent[E IEvent, T any](registry *EventRegistry[T], apply func(entity T, event E)) {
+	var zero E
+	eventName := utils.GetType(zero)
+	registry.deserializers[eventName] = func(data []byte) (IEvent, error) {
+		var event E
+		err := json.Unmarshal(data, &event)
+		return event, err
+	}
+	registry.appliers[eventName] = func(entity T, event IEvent) {
+		apply(entity, event.(E))
+	}
+}
+
+// UseUpcasters makes the registry write the current schema version and upcast the older events it reads
+func (registry *EventRegistry[T]) UseUpcasters(upcasters *Upcasters) {
+	registry.upcasters = upcasters
+}
+
+func (registry *EventRegistry[T]) Serialize(event IEvent) (Event, error) {
+	if _, ok := registry.appliers[utils.GetType(event)]; !ok {
+		return Event{}, unknownEventTypeError(utils.GetType(event))
+	}
+	serializedEvent := SerializedEvent(event)
+	serializedEvent.Metadata.SchemaVersion = registry.upcasters.CurrentVersion(serializedEvent.Name)
+	return serializedEvent, nil
+}
+
+func (registry *EventRegistry[T]) Deserialize(event Event) (IEvent, error) {
+	deserialize, ok := registry.deserializers[event.Name]
+	if !ok {
+		return nil, unknownEventTypeError(event.Name)
+	}
+	event, err := registry.upcasters.Upcast(event)
+	if err != nil {
+		return nil, err
+	}
+	deserializedEvent, err := deserialize(event.Data)
+	if err != nil {
+		return nil, fmt.Errorf("could not deserialize %s %s: %w", event.Name, event.ID, err)
+	}
+	return deserializedEvent, nil
+}
+
+func (registry *EventRegistry[T]) Apply(entity T, event IEvent) error {
+	apply, ok := registry.appliers[utils.GetType(event)]
+	if !ok {
+		return unknownEventTypeError(utils.GetType(event))
+	}
+	apply(entity, event)
+	return nil
+}
+
+func unknownEventTypeError(eventName string) error {
+	return fmt.Errorf("%w: %s", ErrUnknownEventType, eventName)
+}
+
+// Errors
+
+var (
+	ErrUnknownEventType = errors.New("event type is not registered")
+)
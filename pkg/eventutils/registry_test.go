@@ -0,0 +1,103 @@
+package eventutils
+
+import (
+	"testing"
+
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+type UnregisteredEvent struct {
+	EventInfo
+}
+
+func TestRegistrySerializeAndDeserialize(t *testing.T) {
+	// Arrange
+	eventInfo := NewEventInfo(utils.GenerateNewUUID())
+	// a time encoded in UTC is decoded in UTC, whatever the local time zone is
+	eventInfo.CreatedAt = eventInfo.CreatedAt.UTC()
+	event := TestEntityNameChanged{
+		EventInfo: eventInfo,
+		NewName:   "NewName",
+	}
+
+	// Act
+	serialized, err := testEntityEvents.Serialize(event)
+	require.NoError(t, err)
+	deserialized, err := testEntityEvents.Deserialize(serialized)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "TestEntityNameChanged", serialized.Name)
+	require.Equal(t, event, deserialized)
+}
+
+func TestRegistrySerialize_WhenEventTypeIsUnknown(t *testing.T) {
+	// Act
+	_, err := testEntityEvents.Serialize(UnregisteredEvent{})
+
+	// Assert
+	require.ErrorIs(t, err, ErrUnknownEventType)
+}
+
+func TestRegistryDeserialize_WhenEventTypeIsUnknown(t *testing.T) {
+	// Arrange
+	event := SerializedEvent(UnregisteredEvent{
+		EventInfo: NewEventInfo(utils.GenerateNewUUID()),
+	})
+
+	// Act
+	deserialized, err := testEntityEvents.Deserialize(event)
+
+	// Assert
+	require.ErrorIs(t, err, ErrUnknownEventType)
+	require.Nil(t, deserialized)
+}
+
+func TestRegistryApply_WhenEventTypeIsUnknown(t *testing.T) {
+	// Act
+	err := testEntityEvents.Apply(&TestEntity{}, UnregisteredEvent{})
+
+	// Assert
+	require.ErrorIs(t, err, ErrUnknownEventType)
+}
+
+func TestAddEvent_WhenEventTypeIsUnknown(t *testing.T) {
+	// Arrange
+	entity := NewTestEntity()
+
+	// Act & Assert
+	require.Panics(t, func() {
+		AddEvent(UnregisteredEvent{EventInfo: NewEventInfo(entity.GetID())}, entity)
+	})
+}
+
+func TestGetEntity_WhenStreamHasUnknownEventType(t *testing.T) {
+	// Arrange
+	entity := NewTestEntity()
+	storedEvents := append(serializeTestEvents(t, entity), SerializedEvent(UnregisteredEvent{
+		EventInfo: NewEventInfo(entity.GetID()),
+	}))
+	mockEventStore := new(MockEventStore)
+	mockEventStore.
+		On("ReadEventsByStreamName", getStreamName(entity), uint64(0)).
+		Return(storedEvents, nil)
+	repo := NewEntityRepository(mockEventStore)
+
+	// Act
+	_, err := repo.GetEntity(&TestEntity{}, entity.GetID())
+
+	// Assert
+	require.ErrorIs(t, err, ErrUnknownEventType)
+}
+
+func TestReconstructFromEvents_WhenEventTypeIsUnknown(t *testing.T) {
+	// Arrange
+	events := []Event{SerializedEvent(UnregisteredEvent{})}
+
+	// Act
+	err := ReconstructFromEvents(&TestEntity{}, events)
+
+	// Assert
+	require.ErrorIs(t, err, ErrUnknownEventType)
+}
@@ -0,0 +1,143 @@
+package eventutils
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+// ReplaySubscription delivers the events already written to $all from a position and drops after the last one
+type ReplaySubscription struct {
+	mutex   sync.Mutex
+	events  chan *esdb.ResolvedEvent
+	retries []*esdb.ResolvedEvent
+	parked  []*esdb.ResolvedEvent
+	tracker *CheckpointTracker
+	closed  chan struct{}
+	once    sync.Once
+	readErr error
+}
+
+func NewReplaySubscription(reader IAllEventsReader, fromPosition esdb.Position) *ReplaySubscription {
+	subscription := &ReplaySubscription{
+		events:  make(chan *esdb.ResolvedEvent),
+		tracker: NewCheckpointTracker(),
+		closed:  make(chan struct{}),
+	}
+	go subscription.read(reader, fromPosition)
+	return subscription
+}
+
+func (subscription *ReplaySubscription) read(reader IAllEventsReader, fromPosition esdb.Position) {
+	err := reader.ReadAllEvents(fromPosition, func(event *esdb.RecordedEvent) error {
+		select {
+		case subscription.events <- &esdb.ResolvedEvent{Event: event}:
+			return nil
+		case <-subscription.closed:
+			return errReplayClosed
+		}
+	})
+	if err != nil && !errors.Is(err, errReplayClosed) {
+		subscription.mutex.Lock()
+		subscription.readErr = err
+		subscription.mutex.Unlock()
+	}
+	close(subscription.events)
+}
+
+func (subscription *ReplaySubscription) Recv() *esdb.SubscriptionEvent {
+	if retry := subscription.nextRetry(); retry != nil {
+		return &esdb.SubscriptionEvent{EventAppeared: retry}
+	}
+	select {
+	case event, ok := <-subscription.events:
+		if ok {
+			subscription.tracker.Received(event.OriginalEvent().Position)
+			return &esdb.SubscriptionEvent{EventAppeared: event}
+		}
+	case <-subscription.closed:
+	}
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	return &esdb.SubscriptionEvent{
+		SubscriptionDropped: &esdb.SubscriptionDropped{Error: subscription.readErr},
+	}
+}
+
+// Err returns the error that stopped the replay before its end, if any
+func (subscription *ReplaySubscription) Err() error {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+	return subscription.readErr
+}
+
+func (subscription *ReplaySubscription) Ack(messages ...*esdb.ResolvedEvent) error {
+	for _, message := range messages {
+		subscription.tracker.Done(message.OriginalEvent().Position)
+	}
+	return nil
+}
+
+func (subscription *ReplaySubscription) Checkpoint() (esdb.Position, bool) {
+	return subscription.tracker.Checkpoint()
+}
+
+func (subscription *ReplaySubscription) Nack(reason string, action esdb.Nack_Action, messages ...*esdb.ResolvedEvent) error {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	switch action {
+	case esdb.Nack_Retry:
+		subscription.retries = append(subscription.retries, messages...)
+	case esdb.Nack_Park:
+		subscription.parked = append(subscription.parked, messages...)
+	}
+	return nil
+}
+
+func (subscription *ReplaySubscription) GetParkedEvents() ([]Event, error) {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	events := []Event{}
+	for _, parkedEvent := range subscription.parked {
+		events = append(events, DeserializeRecordedEvent(parkedEvent.Event))
+	}
+	return events, nil
+}
+
+// ReplayParkedEvents delivers the parked events again, if the replay is not over yet
+func (subscription *ReplaySubscription) ReplayParkedEvents() error {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	subscription.retries = append(subscription.retries, subscription.parked...)
+	subscription.parked = nil
+	return nil
+}
+
+func (subscription *ReplaySubscription) Close() error {
+	subscription.once.Do(func() {
+		close(subscription.closed)
+	})
+	return nil
+}
+
+func (subscription *ReplaySubscription) nextRetry() *esdb.ResolvedEvent {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	if len(subscription.retries) == 0 {
+		return nil
+	}
+	retry := subscription.retries[0]
+	subscription.retries = subscription.retries[1:]
+	return retry
+}
+
+// Errors
+
+var (
+	errReplayClosed = errors.New("the replay was closed")
+)
@@ -0,0 +1,104 @@
+package eventutils
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaySubscription(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	entities := []*TestEntity{NewTestEntity(), NewTestEntity(), NewTestEntity()}
+	for _, entity := range entities {
+		entity.ChangeName("NewName")
+		_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+	}
+	var mutex sync.Mutex
+	handled := make(map[uuid.UUID][]string)
+
+	var testHandler = func(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+		var event TestEntityCreated
+		json.Unmarshal(rawEvent.EventAppeared.Event.Data, &event)
+		mutex.Lock()
+		defer mutex.Unlock()
+		handled[event.GetEntityID()] = append(handled[event.GetEntityID()], rawEvent.EventAppeared.Event.EventType)
+		return nil
+	}
+
+	subscription := NewReplaySubscription(eventStore, esdb.Position{})
+	eventHandler := NewEventHandlerFromSubscription(subscription).
+		WithConcurrency(2, 1).
+		HandleEvent("TestEntityCreated", testHandler).
+		HandleEvent("TestEntityNameChanged", testHandler)
+
+	// Act
+	eventHandler.Start(context.Background())
+	waitForEventHandler(t, eventHandler)
+
+	// Assert
+	for _, entity := range entities {
+		require.Equal(t, []string{"TestEntityCreated", "TestEntityNameChanged"}, handled[entity.GetID()])
+	}
+	checkpoint, found := subscription.Checkpoint()
+	require.True(t, found)
+	require.Equal(t, uint64(5), checkpoint.Commit)
+}
+
+func TestReplaySubscription_FromPosition(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	entity := NewTestEntity()
+	entity.ChangeName("NewName")
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+	subscription := NewReplaySubscription(eventStore, esdb.Position{Commit: 1, Prepare: 1})
+
+	// Act
+	first := subscription.Recv()
+	second := subscription.Recv()
+
+	// Assert
+	require.Equal(t, "TestEntityNameChanged", first.EventAppeared.Event.EventType)
+	require.NotNil(t, second.SubscriptionDropped)
+	require.NoError(t, second.SubscriptionDropped.Error)
+}
+
+func TestReplaySubscription_KeepsParkedEvents(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	entity := NewTestEntity()
+	_, _ = eventStore.SaveEventsToNewStream(getStreamName(entity), serializeTestEvents(t, entity))
+	subscription := NewReplaySubscription(eventStore, esdb.Position{})
+	eventHandler := NewEventHandlerFromSubscription(subscription)
+
+	// Act
+	eventHandler.Start(context.Background())
+	waitForEventHandler(t, eventHandler)
+
+	// Assert
+	parkedEvents, err := eventHandler.GetParkedEvents()
+	require.NoError(t, err)
+	require.Len(t, parkedEvents, 1)
+	require.Equal(t, "TestEntityCreated", parkedEvents[0].Name)
+	_, found := subscription.Checkpoint()
+	require.False(t, found, "the parked event holds back the checkpoint")
+}
+
+func waitForEventHandler(t *testing.T, eventHandler *EventHandler) {
+	done := make(chan struct{})
+	go func() {
+		eventHandler.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "the event handler did not stop")
+	}
+}
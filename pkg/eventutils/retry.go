@@ -0,0 +1,49 @@
+package eventutils
+
+import (
+	"context"
+	"time"
+
+	"github.com/Resta-Inc/resta/pkg/utils"
+)
+
+// RetryPolicy tells how many times an operation is attempted and how long to wait between the attempts
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Backoff doubles at every failed attempt, starting from 1, from InitialBackoff up to MaxBackoff
+func (policy RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := policy.InitialBackoff
+	for i := 1; i < attempt && backoff < policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+		return policy.MaxBackoff
+	}
+	return backoff
+}
+
+// Wait sleeps for the backoff of the given failed attempt
+func (policy RetryPolicy) Wait(attempt int) {
+	backoff := policy.Backoff(attempt)
+	if backoff > 0 {
+		utils.Time.Sleep(backoff)
+	}
+}
+
+// WaitContext is like Wait, but it stops waiting and returns the error of the context when the context is done
+func (policy RetryPolicy) WaitContext(ctx context.Context, attempt int) error {
+	backoff := policy.Backoff(attempt)
+	if backoff <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-utils.Time.After(backoff):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,50 @@
+package eventutils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	// Arrange
+	policy := RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+
+	// Act
+	backoffs := []time.Duration{}
+	for attempt := 1; attempt <= 6; attempt++ {
+		backoffs = append(backoffs, policy.Backoff(attempt))
+	}
+
+	// Assert
+	require.Equal(t, []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}, backoffs)
+}
+
+func TestRetryPolicyWaitContext_WhenContextIsDone(t *testing.T) {
+	// Arrange
+	policy := RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: time.Hour,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	err := policy.WaitContext(ctx, 1)
+
+	// Assert
+	require.ErrorIs(t, err, context.Canceled)
+}
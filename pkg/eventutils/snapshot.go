@@ -0,0 +1,144 @@
+package eventutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
+)
+
+// ISnapshottable is implemented by the entities that can be loaded from a snapshot
+type ISnapshottable interface {
+	IReconstructible
+	SetID(id uuid.UUID)
+	// GetSnapshotState returns a pointer to the state that is serialized into the snapshot
+	GetSnapshotState() interface{}
+	// GetSnapshotVersion must be increased when the state changes shape, older snapshots are ignored
+	GetSnapshotVersion() int
+}
+
+type Snapshot struct {
+	EntityID uuid.UUID
+	Version  int
+	// Revision is the revision of the last event applied to the state
+	Revision uint64
+	State    json.RawMessage
+	// IsDeleted is kept out of the state, as it belongs to every entity
+	IsDeleted bool `json:",omitempty"`
+}
+
+type ISnapshotStore interface {
+	SaveSnapshot(streamName string, snapshot Snapshot) error
+	GetLatestSnapshot(streamName string) (Snapshot, error)
+}
+
+const snapshotEventName = "Snapshot"
+
+func takeSnapshot(entity ISnapshottable, revision uint64) (Snapshot, error) {
+	state, err := json.Marshal(entity.GetSnapshotState())
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshot := Snapshot{
+		EntityID: entity.GetID(),
+		Version:  entity.GetSnapshotVersion(),
+		Revision: revision,
+		State:    state,
+	}
+	if deletable, ok := entity.(IDeletable); ok {
+		snapshot.IsDeleted = deletable.WasDeleted()
+	}
+	return snapshot, nil
+}
+
+func restoreSnapshot(entity ISnapshottable, snapshot Snapshot) error {
+	err := json.Unmarshal(snapshot.State, entity.GetSnapshotState())
+	if err != nil {
+		return err
+	}
+	entity.SetID(snapshot.EntityID)
+	entity.SetRevision(snapshot.Revision)
+	if deletable, ok := entity.(IDeletable); ok && snapshot.IsDeleted {
+		deletable.SetDeleted()
+	}
+	return nil
+}
+
+func getSnapshotStreamName(streamName string) string {
+	return streamName + "-snapshot"
+}
+
+// snapshotsToKeep is the max count of the snapshot streams, the older snapshots are scavenged
+const snapshotsToKeep uint64 = 1
+
+func (eventStore EventStore) SaveSnapshot(streamName string, snapshot Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	snapshotStreamName := getSnapshotStreamName(streamName)
+	eventData := esdb.EventData{
+		EventID:     utils.GenerateNewUUID(),
+		ContentType: esdb.JsonContentType,
+		EventType:   snapshotEventName,
+		Data:        data,
+	}
+	writeResult, err := eventStore.db.AppendToStream(context.Background(), snapshotStreamName, esdb.AppendToStreamOptions{ExpectedRevision: esdb.Any{}}, eventData)
+	if err != nil {
+		return err
+	}
+	if writeResult.NextExpectedVersion == 0 {
+		metadata := esdb.StreamMetadata{}
+		metadata.SetMaxCount(snapshotsToKeep)
+		_, err = eventStore.db.SetStreamMetadata(context.Background(), snapshotStreamName, esdb.AppendToStreamOptions{ExpectedRevision: esdb.Any{}}, metadata)
+	}
+	return err
+}
+
+func (eventStore EventStore) GetLatestSnapshot(streamName string) (Snapshot, error) {
+	options := esdb.ReadStreamOptions{
+		Direction: esdb.Backwards,
+		From:      esdb.End{},
+	}
+	stream, err := eventStore.db.ReadStream(context.Background(), getSnapshotStreamName(streamName), options, 1)
+	if errors.Is(err, esdb.ErrStreamNotFound) || errors.Is(err, io.EOF) {
+		return Snapshot{}, ErrResourceNotFound
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer stream.Close()
+	eventData, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return Snapshot{}, ErrResourceNotFound
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var snapshot Snapshot
+	err = json.Unmarshal(eventData.Event.Data, &snapshot)
+	return snapshot, err
+}
+
+func (store *InMemoryEventStore) SaveSnapshot(streamName string, snapshot Snapshot) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.snapshots[getSnapshotStreamName(streamName)] = snapshot
+	return nil
+}
+
+func (store *InMemoryEventStore) GetLatestSnapshot(streamName string) (Snapshot, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	snapshot, exists := store.snapshots[getSnapshotStreamName(streamName)]
+	if !exists {
+		return Snapshot{}, ErrResourceNotFound
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,117 @@
+package eventutils
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveEntity_TakesSnapshotEveryNEvents(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	repo := NewEntityRepositoryWithSnapshots(eventStore, eventStore, 3)
+	entity := NewTestEntity()
+	err := repo.SaveEntity(context.Background(), entity)
+	require.NoError(t, err)
+	_, err = eventStore.GetLatestSnapshot(getStreamName(entity))
+	require.ErrorIs(t, err, ErrResourceNotFound)
+
+	foundEntity, err := repo.GetEntity(&TestEntity{}, entity.GetID())
+	require.NoError(t, err)
+	foundEntity.(*TestEntity).ChangeName("FirstName")
+	foundEntity.(*TestEntity).ChangeName("SecondName")
+
+	// Act
+	err = repo.SaveEntity(context.Background(), foundEntity)
+
+	// Assert
+	require.NoError(t, err)
+	snapshot, err := eventStore.GetLatestSnapshot(getStreamName(entity))
+	require.NoError(t, err)
+	require.Equal(t, entity.GetID(), snapshot.EntityID)
+	require.Equal(t, testEntitySnapshotVersion, snapshot.Version)
+	require.Equal(t, uint64(2), snapshot.Revision)
+	require.JSONEq(t, `{"Name": "SecondName"}`, string(snapshot.State))
+}
+
+func TestGetEntity_FromSnapshot(t *testing.T) {
+	// Arrange
+	entity := NewTestEntity()
+	snapshotStore := NewInMemoryEventStore()
+	_ = snapshotStore.SaveSnapshot(getStreamName(entity), Snapshot{
+		EntityID: entity.GetID(),
+		Version:  testEntitySnapshotVersion,
+		Revision: 9,
+		State:    json.RawMessage(`{"Name": "NameInSnapshot"}`),
+	})
+
+	entity.Events = nil
+	entity.ChangeName("NewName")
+	tail := serializeTestEvents(t, entity)
+	tail[0].Revision = 10
+
+	mockEventStore := new(MockEventStore)
+	mockEventStore.
+		On("ReadEventsByStreamName", getStreamName(entity), uint64(10)).
+		Return(tail, nil)
+
+	repo := NewEntityRepositoryWithSnapshots(mockEventStore, snapshotStore, 10)
+
+	// Act
+	foundEntity, err := repo.GetEntity(&TestEntity{}, entity.GetID())
+
+	// Assert
+	mockEventStore.AssertExpectations(t)
+	require.NoError(t, err)
+	require.Equal(t, entity.GetID(), foundEntity.GetID())
+	require.Equal(t, "NewName", foundEntity.(*TestEntity).State.Name)
+	require.Equal(t, uint64(10), foundEntity.GetRevision())
+}
+
+func TestGetEntity_WhenSnapshotIsOfDeletedEntity(t *testing.T) {
+	// Arrange
+	eventStore := NewInMemoryEventStore()
+	repo := NewEntityRepositoryWithSnapshots(eventStore, eventStore, 2)
+	entity := NewTestEntity()
+	entity.Delete()
+	err := repo.SaveEntity(context.Background(), entity)
+	require.NoError(t, err)
+	snapshot, err := eventStore.GetLatestSnapshot(getStreamName(entity))
+	require.NoError(t, err)
+	require.True(t, snapshot.IsDeleted)
+
+	// Act
+	_, err = repo.GetEntity(&TestEntity{}, entity.GetID())
+
+	// Assert
+	require.ErrorIs(t, err, ErrEntityNotFound)
+}
+
+func TestGetEntity_WhenSnapshotVersionIsOutdated(t *testing.T) {
+	// Arrange
+	entity := NewTestEntity()
+	snapshotStore := NewInMemoryEventStore()
+	_ = snapshotStore.SaveSnapshot(getStreamName(entity), Snapshot{
+		EntityID: entity.GetID(),
+		Version:  testEntitySnapshotVersion - 1,
+		Revision: 0,
+		State:    json.RawMessage(`{"OldName": "NameInSnapshot"}`),
+	})
+
+	mockEventStore := new(MockEventStore)
+	mockEventStore.
+		On("ReadEventsByStreamName", getStreamName(entity), uint64(0)).
+		Return(serializeTestEvents(t, entity), nil)
+
+	repo := NewEntityRepositoryWithSnapshots(mockEventStore, snapshotStore, 10)
+
+	// Act
+	foundEntity, err := repo.GetEntity(&TestEntity{}, entity.GetID())
+
+	// Assert
+	mockEventStore.AssertExpectations(t)
+	require.NoError(t, err)
+	require.Equal(t, entity.State, foundEntity.(*TestEntity).State)
+}
@@ -0,0 +1,82 @@
+package eventutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Upcaster transforms the data of an event from a schema version to the next one
+type Upcaster func(data []byte) ([]byte, error)
+
+// Upcasters holds, for every event type, the upcasters from each old schema version to the next one
+type Upcasters struct {
+	chains map[string][]Upcaster
+}
+
+func NewUpcasters() *Upcasters {
+	return &Upcasters{
+		chains: make(map[string][]Upcaster),
+	}
+}
+
+// Register adds the upcaster from fromVersion to fromVersion+1, in version order
+func (upcasters *Upcasters) Register(eventName string, fromVersion int, upcast Upcaster) {
+	if fromVersion != upcasters.CurrentVersion(eventName) {
+		panic(fmt.Sprintf("the upcaster of %s from version %d is not the next one of the chain", eventName, fromVersion))
+	}
+	upcasters.chains[eventName] = append(upcasters.chains[eventName], upcast)
+}
+
+// CurrentVersion is the schema version the event type is written with
+func (upcasters *Upcasters) CurrentVersion(eventName string) int {
+	if upcasters == nil {
+		return InitialSchemaVersion
+	}
+	return InitialSchemaVersion + len(upcasters.chains[eventName])
+}
+
+// Upcast returns the event with its data in the shape of the current schema version
+func (upcasters *Upcasters) Upcast(event Event) (Event, error) {
+	version := event.Metadata.SchemaVersion
+	if version == 0 {
+		version = InitialSchemaVersion
+	}
+	currentVersion := upcasters.CurrentVersion(event.Name)
+	if version > currentVersion {
+		return Event{}, fmt.Errorf("%w: %s %s has version %d, the latest known is %d", ErrUnsupportedSchemaVersion, event.Name, event.ID, version, currentVersion)
+	}
+	data := event.Data
+	for ; version < currentVersion; version++ {
+		var err error
+		data, err = upcasters.chains[event.Name][version-InitialSchemaVersion](data)
+		if err != nil {
+			return Event{}, fmt.Errorf("could not upcast %s %s from version %d: %w", event.Name, event.ID, version, err)
+		}
+	}
+	event.Data = data
+	event.Metadata.SchemaVersion = currentVersion
+	return event, nil
+}
+
+// RenameField returns an upcaster that moves the value of a top level field of the data to a new name
+func RenameField(oldName, newName string) Upcaster {
+	return func(data []byte) ([]byte, error) {
+		fields := map[string]json.RawMessage{}
+		err := json.Unmarshal(data, &fields)
+		if err != nil {
+			return nil, err
+		}
+		if value, exists := fields[oldName]; exists {
+			fields[newName] = value
+			delete(fields, oldName)
+		}
+		return json.Marshal(fields)
+	}
+}
+
+// Errors
+
+var (
+	ErrUnsupportedSchemaVersion = errors.New("event was written with a newer schema version")
+)
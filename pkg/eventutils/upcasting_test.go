@@ -0,0 +1,140 @@
+package eventutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestEntityEstimateChanged had a NewEstimate field until its schema version 2 renamed it
+type TestEntityEstimateChanged struct {
+	EventInfo
+	NewEstimatedPreparationTime time.Duration
+}
+
+func applyTestEntityEstimateChanged(entity *TestEntity, event TestEntityEstimateChanged) {}
+
+func newTestEstimateEvents() *EventRegistry[*TestEntity] {
+	upcasters := NewUpcasters()
+	upcasters.Register("TestEntityEstimateChanged", 1, RenameField("NewEstimate", "NewEstimatedPreparationTime"))
+	registry := NewEventRegistry[*TestEntity]()
+	registry.UseUpcasters(upcasters)
+	RegisterEvent(registry, applyTestEntityEstimateChanged)
+	return registry
+}
+
+func TestUpcast(t *testing.T) {
+	// Arrange
+	upcasters := NewUpcasters()
+	upcasters.Register("TestEntityNameChanged", 1, RenameField("Name", "Title"))
+	upcasters.Register("TestEntityNameChanged", 2, RenameField("Title", "NewName"))
+	event := Event{
+		Name:     "TestEntityNameChanged",
+		Data:     []byte(`{"Name": "OldName"}`),
+		Metadata: EventMetadata{SchemaVersion: 1},
+	}
+
+	// Act
+	upcastedEvent, err := upcasters.Upcast(event)
+
+	// Assert
+	require.NoError(t, err)
+	require.JSONEq(t, `{"NewName": "OldName"}`, string(upcastedEvent.Data))
+	require.Equal(t, 3, upcastedEvent.Metadata.SchemaVersion)
+}
+
+func TestUpcast_FromIntermediateVersion(t *testing.T) {
+	// Arrange
+	upcasters := NewUpcasters()
+	upcasters.Register("TestEntityNameChanged", 1, RenameField("Name", "Title"))
+	upcasters.Register("TestEntityNameChanged", 2, RenameField("Title", "NewName"))
+	event := Event{
+		Name:     "TestEntityNameChanged",
+		Data:     []byte(`{"Title": "OldName"}`),
+		Metadata: EventMetadata{SchemaVersion: 2},
+	}
+
+	// Act
+	upcastedEvent, err := upcasters.Upcast(event)
+
+	// Assert
+	require.NoError(t, err)
+	require.JSONEq(t, `{"NewName": "OldName"}`, string(upcastedEvent.Data))
+}
+
+func TestUpcast_WhenVersionIsNewerThanKnown(t *testing.T) {
+	// Arrange
+	upcasters := NewUpcasters()
+	event := Event{
+		Name:     "TestEntityNameChanged",
+		Data:     []byte(`{}`),
+		Metadata: EventMetadata{SchemaVersion: 2},
+	}
+
+	// Act
+	_, err := upcasters.Upcast(event)
+
+	// Assert
+	require.ErrorIs(t, err, ErrUnsupportedSchemaVersion)
+}
+
+func TestRegisterUpcaster_WhenVersionIsNotTheNextOne(t *testing.T) {
+	// Arrange
+	upcasters := NewUpcasters()
+
+	// Act & Assert
+	require.Panics(t, func() {
+		upcasters.Register("TestEntityNameChanged", 2, RenameField("Name", "NewName"))
+	})
+}
+
+func TestRegistryDeserialize_UpcastsOldEvents(t *testing.T) {
+	// Arrange
+	upcasters := NewUpcasters()
+	upcasters.Register("TestEntityNameChanged", 1, RenameField("Name", "NewName"))
+	registry := NewEventRegistry[*TestEntity]()
+	RegisterEvent(registry, (*TestEntity).applyTestEntityNameChanged)
+	registry.UseUpcasters(upcasters)
+	event := Event{
+		Name: "TestEntityNameChanged",
+		Data: []byte(`{"Name": "OldName"}`),
+	}
+
+	// Act
+	deserialized, err := registry.Deserialize(event)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "OldName", deserialized.(TestEntityNameChanged).NewName)
+}
+
+func TestRegistryDeserialize_WithFieldRenamedBySchemaVersion2(t *testing.T) {
+	// Arrange
+	event := Event{
+		Name:     "TestEntityEstimateChanged",
+		Data:     []byte(`{"NewEstimate": 900000000000}`),
+		Metadata: EventMetadata{SchemaVersion: 1},
+	}
+
+	// Act
+	deserialized, err := newTestEstimateEvents().Deserialize(event)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, 15*time.Minute, deserialized.(TestEntityEstimateChanged).NewEstimatedPreparationTime)
+}
+
+func TestRegistrySerialize_WithCurrentSchemaVersion(t *testing.T) {
+	// Arrange
+	event := TestEntityEstimateChanged{
+		NewEstimatedPreparationTime: 10 * time.Minute,
+	}
+
+	// Act
+	serialized, err := newTestEstimateEvents().Serialize(event)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, 2, serialized.Metadata.SchemaVersion)
+}
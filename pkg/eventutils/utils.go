@@ -12,6 +12,9 @@ type Event struct {
 	ID   uuid.UUID
 	Name string
 	Data []byte
+	// Revision is the position of the event in its stream, it is set when the event is read from the store
+	Revision uint64
+	Metadata EventMetadata
 }
 
 type EventInfo struct {
@@ -46,6 +49,8 @@ type Entity struct {
 	Events    []IEvent
 	IsDeleted bool
 	New       bool
+	// Revision is the stream revision the entity was loaded at, used as expected revision when saving
+	Revision uint64
 }
 
 func (e Entity) GetEvents() []IEvent {
@@ -56,6 +61,10 @@ func (e Entity) GetID() uuid.UUID {
 	return e.ID
 }
 
+func (e *Entity) SetID(id uuid.UUID) {
+	e.ID = id
+}
+
 func (e *Entity) SetNew() {
 	e.New = true
 }
@@ -64,18 +73,43 @@ func (e Entity) IsNew() bool {
 	return e.New
 }
 
+func (e Entity) GetRevision() uint64 {
+	return e.Revision
+}
+
+func (e *Entity) SetRevision(revision uint64) {
+	e.Revision = revision
+}
+
 func (e *Entity) AppendEvent(event IEvent) {
 	e.Events = append(e.Events, event)
 }
 
+func (e Entity) WasDeleted() bool {
+	return e.IsDeleted
+}
+
+func (e *Entity) SetDeleted() {
+	e.IsDeleted = true
+}
+
 type IReconstructible interface {
 	GetID() uuid.UUID
 	GetEvents() []IEvent
-	DeserializeEvent(event Event) IEvent
-	ApplyEvent(event IEvent)
+	SerializeEvent(event IEvent) (Event, error)
+	DeserializeEvent(event Event) (IEvent, error)
+	ApplyEvent(event IEvent) error
 	AppendEvent(event IEvent)
 	IsNew() bool
 	SetNew()
+	GetRevision() uint64
+	SetRevision(revision uint64)
+}
+
+// IDeletable is implemented by the entities that can be deleted, GetEntity does not find them once deleted
+type IDeletable interface {
+	WasDeleted() bool
+	SetDeleted()
 }
 
 type IEvent interface {
@@ -84,11 +118,28 @@ type IEvent interface {
 	GetTimeStamp() time.Time
 }
 
-func ReconstructFromEvents(entity IReconstructible, events []Event) {
+func ReconstructFromEvents(entity IReconstructible, events []Event) error {
 	for _, event := range events {
-		deserializedEvent := entity.DeserializeEvent(event)
-		entity.ApplyEvent(deserializedEvent)
+		err := ApplyRecordedEvent(entity, event)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyRecordedEvent applies an event read from the store while the stream is being read
+func ApplyRecordedEvent(entity IReconstructible, event Event) error {
+	deserializedEvent, err := entity.DeserializeEvent(event)
+	if err != nil {
+		return err
 	}
+	err = entity.ApplyEvent(deserializedEvent)
+	if err != nil {
+		return err
+	}
+	entity.SetRevision(event.Revision)
+	return nil
 }
 
 func SerializedEvent(eventObj IEvent) Event {
@@ -97,11 +148,18 @@ func SerializedEvent(eventObj IEvent) Event {
 		ID:   eventObj.GetEventID(),
 		Name: utils.GetType(eventObj),
 		Data: bytes,
+		Metadata: EventMetadata{
+			SchemaVersion: InitialSchemaVersion,
+		},
 	}
 	return event
 }
 
+// AddEvent applies the new event and appends it to the events to save, it panics on an unregistered event type
 func AddEvent(event IEvent, obj IReconstructible) {
-	obj.ApplyEvent(event)
+	err := obj.ApplyEvent(event)
+	if err != nil {
+		panic(err)
+	}
 	obj.AppendEvent(event)
 }
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofrs/uuid"
+)
+
+// AdminApi is served on its own listener, see ADMIN_LISTEN_ADDRESS, so that it is not exposed with the public API
+type AdminApi struct {
+	linkProcessManager *LinkProcessManager
+	parkedEvents       eventutils.IParkedEvents
+}
+
+func SetupAdminApi(app *fiber.App, linkProcessManager *LinkProcessManager, parkedEvents eventutils.IParkedEvents) {
+	api := AdminApi{
+		linkProcessManager: linkProcessManager,
+		parkedEvents:       parkedEvents,
+	}
+	api.setupRoutes(app)
+}
+
+func (api AdminApi) setupRoutes(app *fiber.App) {
+	app.Get("/admin/links", api.GetStuckLinks)
+	app.Post("/admin/links/:id/retry", api.RetryParkedLink)
+
+	app.Get("/admin/parked-events", api.GetParkedEvents)
+	app.Post("/admin/parked-events/replay", api.ReplayParkedEvents)
+}
+
+func (api AdminApi) GetStuckLinks(c *fiber.Ctx) error {
+	parkedLinks, err := api.linkProcessManager.GetParkedLinks()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to find the parked links, please try again later.")
+	}
+	return c.JSON(fiber.Map{
+		"inProgress": api.linkProcessManager.GetInProgressLinks(),
+		"parked":     parkedLinks,
+	})
+}
+
+func (api AdminApi) RetryParkedLink(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request event id")
+	}
+	err := api.linkProcessManager.RetryParkedLink(c.UserContext(), id)
+	if errors.Is(err, ErrLinkNotParked) {
+		return fiber.NewError(fiber.StatusNotFound, "Parked link not found")
+	}
+	if err != nil {
+		log.Printf("could not retry the parked link %s: %v", id, err)
+		return fiber.NewError(fiber.StatusInternalServerError, "The link failed again, its error is in the log of the service.")
+	}
+	c.SendStatus(fiber.StatusOK)
+	return nil
+}
+
+type ParkedEventView struct {
+	ID       uuid.UUID                `json:"id"`
+	Type     string                   `json:"type"`
+	Data     json.RawMessage          `json:"data"`
+	Metadata eventutils.EventMetadata `json:"metadata"`
+}
+
+func (api AdminApi) GetParkedEvents(c *fiber.Ctx) error {
+	parkedEvents, err := api.parkedEvents.GetParkedEvents()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to find the parked events, please try again later.")
+	}
+	views := []ParkedEventView{}
+	for _, event := range parkedEvents {
+		views = append(views, ParkedEventView{
+			ID:       event.ID,
+			Type:     event.Name,
+			Data:     event.Data,
+			Metadata: event.Metadata,
+		})
+	}
+	return c.JSON(views)
+}
+
+func (api AdminApi) ReplayParkedEvents(c *fiber.Ctx) error {
+	err := api.parkedEvents.ReplayParkedEvents()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to replay the parked events, please try again later.")
+	}
+	c.SendStatus(fiber.StatusAccepted)
+	return nil
+}
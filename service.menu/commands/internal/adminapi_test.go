@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/menu/commands/internal/entities"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStuckLinks(t *testing.T) {
+	// Arrange
+	entityRepository := eventutils.NewEntityRepository(eventutils.NewInMemoryEventStore())
+	processManager := NewLinkProcessManager(entityRepository, testLinkRetryPolicy)
+	// the subcategory does not exist, so the link is parked
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	err := entityRepository.SaveEntity(context.Background(), menuItem)
+	require.NoError(t, err)
+	link := Link{
+		RequestEventID:   utils.GenerateNewUUID(),
+		RequestEventType: "MenuItemCreated",
+		ChildID:          menuItem.ID,
+		ParentID:         menuItem.GetParentSubCategoryID(),
+	}
+	err = processManager.Link(context.Background(), link)
+	require.NoError(t, err)
+
+	app := fiber.New()
+	SetupAdminApi(app, processManager, newTestParkedEvents())
+
+	request, err := http.NewRequest(http.MethodGet, "/admin/links", nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	var response struct {
+		InProgress []LinkStatus           `json:"inProgress"`
+		Parked     []entities.PendingLink `json:"parked"`
+	}
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+	require.Empty(t, response.InProgress)
+	require.Len(t, response.Parked, 1)
+	require.Equal(t, link.RequestEventID, response.Parked[0].RequestEventID)
+	require.Equal(t, link.ChildID, response.Parked[0].ChildID)
+	require.Equal(t, testLinkRetryPolicy.MaxAttempts, response.Parked[0].Attempts)
+}
+
+func TestRetryParkedLinkApi_WhenLinkIsNotParked(t *testing.T) {
+	// Arrange
+	entityRepository := eventutils.NewEntityRepository(eventutils.NewInMemoryEventStore())
+	app := fiber.New()
+	SetupAdminApi(app, NewLinkProcessManager(entityRepository, testLinkRetryPolicy), newTestParkedEvents())
+
+	url := fmt.Sprintf("/admin/links/%s/retry", utils.GenerateNewUUID())
+	request, err := http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestRetryParkedLinkApi_WhenLinkFailsAgain(t *testing.T) {
+	// Arrange
+	entityRepository := eventutils.NewEntityRepository(eventutils.NewInMemoryEventStore())
+	processManager := NewLinkProcessManager(entityRepository, testLinkRetryPolicy)
+	category := entities.NewCategory(utils.GenerateNewUUID())
+	err := entityRepository.SaveEntity(context.Background(), category)
+	require.NoError(t, err)
+	link := Link{
+		RequestEventID:   utils.GenerateNewUUID(),
+		RequestEventType: "CategoryCreated",
+		ChildID:          category.ID,
+		ParentID:         category.GetParentMenuID(),
+	}
+	// the menu does not exist, so the link is parked and fails again when retried
+	err = processManager.Link(context.Background(), link)
+	require.NoError(t, err)
+
+	app := fiber.New()
+	SetupAdminApi(app, processManager, newTestParkedEvents())
+
+	url := fmt.Sprintf("/admin/links/%s/retry", link.RequestEventID)
+	request, err := http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	require.NotContains(t, string(body), eventutils.ErrEntityNotFound.Error())
+}
+
+func TestGetParkedEvents(t *testing.T) {
+	// Arrange
+	eventStore := eventutils.NewInMemoryEventStore()
+	subscription := eventStore.SubscribeToAll()
+	event := eventutils.Event{
+		ID:   utils.GenerateNewUUID(),
+		Name: "MenuCreated",
+		Data: []byte(`{"Name":"TestMenu"}`),
+	}
+	_, err := eventStore.SaveEventsToNewStream("Menu-"+utils.GenerateNewUUID().String(), []eventutils.Event{event})
+	require.NoError(t, err)
+	received := subscription.Recv()
+	err = subscription.Nack("test", esdb.Nack_Park, received.EventAppeared)
+	require.NoError(t, err)
+
+	entityRepository := eventutils.NewEntityRepository(eventStore)
+	app := fiber.New()
+	SetupAdminApi(app, NewLinkProcessManager(entityRepository, testLinkRetryPolicy), subscription)
+
+	request, err := http.NewRequest(http.MethodGet, "/admin/parked-events", nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	var parkedEvents []ParkedEventView
+	err = json.Unmarshal(body, &parkedEvents)
+	require.NoError(t, err)
+	require.Len(t, parkedEvents, 1)
+	require.Equal(t, event.ID, parkedEvents[0].ID)
+	require.Equal(t, event.Name, parkedEvents[0].Type)
+}
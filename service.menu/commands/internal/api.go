@@ -1,12 +1,15 @@
 package internal
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"mime/multipart"
 	"path/filepath"
+	"time"
 
 	"github.com/Resta-Inc/resta/menu/commands/internal/entities"
+	"github.com/Resta-Inc/resta/pkg/events"
 	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/Resta-Inc/resta/pkg/utils"
 	"github.com/gofiber/fiber/v2"
@@ -14,38 +17,94 @@ import (
 )
 
 type Api struct {
-	repository   eventutils.IEntityRepository
-	resourcePath string
+	repository     eventutils.IEntityRepository
+	resourcePath   string
+	menuDuplicator MenuDuplicator
 }
 
 func SetupApi(app *fiber.App, repo eventutils.IEntityRepository, resourcePath string) {
 	api := Api{
-		repository:   repo,
-		resourcePath: resourcePath,
+		repository:     repo,
+		resourcePath:   resourcePath,
+		menuDuplicator: NewMenuDuplicator(repo, resourcePath),
 	}
 	api.setupRoutes(app)
 }
 
 func (api Api) setupRoutes(app *fiber.App) {
+	app.Use(setEventMetadata)
+
 	app.Post("/menus", api.CreateNewMenu)
 	app.Post("/menus/:id/enable", api.EnableMenu)
 	app.Post("/menus/:id/disable", api.DisableMenu)
 	app.Post("/menus/:id/change-name", api.ChangeMenuName)
+	app.Post("/menus/:id/reorder-categories", api.ReorderCategories)
+	app.Delete("/menus/:id", api.DeleteMenu)
+	app.Post("/menus/:id/duplicate", api.DuplicateMenu)
+	app.Post("/menus/:id/publish", api.PublishMenu)
+	app.Post("/menus/:id/restore-version", api.RestoreMenuVersion)
+	app.Get("/menu-duplications/:id", api.GetMenuDuplication)
 
 	app.Post("/categories", api.CreateNewCategory)
 	app.Post("/categories/:id/change-name", api.ChangeCategoryName)
 	app.Post("/categories/:id/upload-image", api.UploadCategoryImage)
+	app.Post("/categories/:id/reorder-subcategories", api.ReorderSubCategories)
+	app.Post("/categories/:id/move", api.MoveCategory)
+	app.Delete("/categories/:id", api.DeleteCategory)
 
 	app.Post("/subcategories", api.CreateNewSubCategory)
 	app.Post("/subcategories/:id/upload-image", api.UploadSubCategoryImage)
+	app.Post("/subcategories/:id/reorder-menuitems", api.ReorderMenuItems)
+	app.Post("/subcategories/:id/move", api.MoveSubCategory)
+	app.Delete("/subcategories/:id", api.DeleteSubCategory)
 
 	app.Post("/menuitems", api.CreateNewMenuItem)
 	app.Post("/menuitems/:id/change-name", api.ChangeMenuItemName)
+	app.Delete("/menuitems/:id", api.DeleteMenuItem)
+	app.Post("/menuitems/:id/move", api.MoveMenuItem)
+	app.Post("/menuitems/:id/change-price", api.ChangeMenuItemPrice)
+	app.Post("/menuitems/:id/change-estimated-preparation-time", api.ChangeMenuItemEstimatedPreparationTime)
+	app.Post("/menuitems/:id/change-description", api.ChangeMenuItemDescription)
+	app.Delete("/menuitems/:id/description", api.ClearMenuItemDescription)
+	app.Post("/menuitems/:id/change-allergens", api.ChangeMenuItemAllergens)
+	app.Delete("/menuitems/:id/allergens", api.ClearMenuItemAllergens)
+	app.Post("/menuitems/:id/change-dietary-tags", api.ChangeMenuItemDietaryTags)
+	app.Delete("/menuitems/:id/dietary-tags", api.ClearMenuItemDietaryTags)
+	app.Post("/menuitems/:id/variants", api.AddMenuItemVariant)
+	app.Delete("/menuitems/:id/variants/:variantID", api.RemoveMenuItemVariant)
+	app.Post("/menuitems/:id/modifier-groups", api.AddMenuItemModifierGroup)
+	app.Delete("/menuitems/:id/modifier-groups/:modifierGroupID", api.RemoveMenuItemModifierGroup)
+	app.Post("/menuitems/:id/modifier-groups/:modifierGroupID/modifiers", api.AddMenuItemModifier)
+	app.Delete("/menuitems/:id/modifier-groups/:modifierGroupID/modifiers/:modifierID", api.RemoveMenuItemModifier)
+}
+
+const (
+	correlationIDHeader = "X-Correlation-ID"
+	userIDHeader        = "X-User-ID"
+	tenantIDHeader      = "X-Tenant-ID"
+)
+
+// setEventMetadata puts in the request context the metadata of the events saved while handling it.
+// The request is the cause of those events, so its correlation ID is used as causation ID too.
+func setEventMetadata(c *fiber.Ctx) error {
+	correlationID := uuid.FromStringOrNil(c.Get(correlationIDHeader))
+	if correlationID == uuid.Nil {
+		correlationID = utils.GenerateNewUUID()
+	}
+	metadata := eventutils.EventMetadata{
+		CorrelationID: correlationID,
+		CausationID:   correlationID,
+		UserID:        c.Get(userIDHeader),
+		TenantID:      c.Get(tenantIDHeader),
+	}
+	c.Set(correlationIDHeader, correlationID.String())
+	c.SetUserContext(eventutils.ContextWithMetadata(c.UserContext(), metadata))
+	return c.Next()
 }
 
 func (api Api) CreateNewMenu(c *fiber.Ctx) error {
 	menu := entities.NewMenu()
-	err := api.repository.SaveEntity(menu)
+	err := api.repository.SaveEntity(c.UserContext(), menu)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when saving the new menu. Please try again later")
 	}
@@ -63,9 +122,9 @@ func (api Api) EnableMenu(c *fiber.Ctx) error {
 		return err
 	}
 	menu.(*entities.Menu).Enable()
-	err = api.repository.SaveEntity(menu)
+	err = saveChanges(c.UserContext(), api.repository, menu)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when saving the changes. Please try again later")
+		return err
 	}
 	c.SendStatus(fiber.StatusOK)
 	return nil
@@ -83,9 +142,9 @@ func (api Api) DisableMenu(c *fiber.Ctx) error {
 	}
 
 	menu.(*entities.Menu).Disable()
-	err = api.repository.SaveEntity(menu)
+	err = saveChanges(c.UserContext(), api.repository, menu)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when saving the changes. Please try again later")
+		return err
 	}
 	c.SendStatus(fiber.StatusOK)
 	return nil
@@ -112,14 +171,196 @@ func (api Api) ChangeMenuName(c *fiber.Ctx) error {
 	}
 
 	menu.(*entities.Menu).ChangeName(reqBody.NewName)
-	err = api.repository.SaveEntity(menu)
+	err = saveChanges(c.UserContext(), api.repository, menu)
+	if err != nil {
+		return err
+	}
+	c.SendStatus(fiber.StatusOK)
+	return nil
+}
+
+type ReorderCategoriesRequest struct {
+	CategoriesIDs []string `json:"categoriesIDs"`
+}
+
+// ReorderCategories puts the categories of the menu in the order of the request, which must have all of them
+func (api Api) ReorderCategories(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menu id")
+	}
+
+	reqBody := new(ReorderCategoriesRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	ids, ok := parseIDs(reqBody.CategoriesIDs)
+	if !ok {
+		return fiber.NewError(fiber.StatusBadRequest, "categoriesIDs is not valid")
+	}
+
+	menu, err := checkIfEntityExists(api.repository, &entities.Menu{}, id)
+	if menu == nil {
+		return err
+	}
+
+	err = menu.(*entities.Menu).ReorderCategories(ids)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	err = saveChanges(c.UserContext(), api.repository, menu)
+	if err != nil {
+		return err
+	}
+	c.SendStatus(fiber.StatusOK)
+	return nil
+}
+
+// DeleteMenu deletes the menu, its categories are not deleted but can only be moved to another menu or deleted
+func (api Api) DeleteMenu(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menu id")
+	}
+
+	menu, err := checkIfEntityExists(api.repository, &entities.Menu{}, id)
+	if menu == nil {
+		return err
+	}
+
+	menu.(*entities.Menu).Delete()
+	err = saveChanges(c.UserContext(), api.repository, menu)
+	if err != nil {
+		return err
+	}
+	c.SendStatus(fiber.StatusOK)
+	return nil
+}
+
+type PublishMenuResponse struct {
+	Version int `json:"version"`
+}
+
+// PublishMenu makes the current draft of the menu, with all its descendants, a new version that guests see,
+// and responds with the number of the version
+func (api Api) PublishMenu(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menu id")
+	}
+
+	menu, err := checkIfEntityExists(api.repository, &entities.Menu{}, id)
+	if menu == nil {
+		return err
+	}
+	categories, err := readPublishedCategories(api.repository, menu.(*entities.Menu))
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when saving the changes. Please try again later")
+		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to read the menu to publish, please try again later.")
+	}
+
+	version := menu.(*entities.Menu).Publish(categories)
+	err = saveChanges(c.UserContext(), api.repository, menu)
+	if err != nil {
+		return err
+	}
+	return c.JSON(PublishMenuResponse{Version: version})
+}
+
+type RestoreMenuVersionRequest struct {
+	Version int `json:"version"`
+}
+
+// RestoreMenuVersion makes an earlier published version of the menu the one guests see again
+func (api Api) RestoreMenuVersion(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menu id")
+	}
+
+	reqBody := new(RestoreMenuVersionRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+
+	menu, err := checkIfEntityExists(api.repository, &entities.Menu{}, id)
+	if menu == nil {
+		return err
+	}
+
+	err = menu.(*entities.Menu).RestoreVersion(reqBody.Version)
+	if errors.Is(err, entities.ErrVersionNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	err = saveChanges(c.UserContext(), api.repository, menu)
+	if err != nil {
+		return err
 	}
 	c.SendStatus(fiber.StatusOK)
 	return nil
 }
 
+type MenuDuplicationView struct {
+	ID           uuid.UUID                      `json:"id"`
+	SourceMenuID uuid.UUID                      `json:"sourceMenuID"`
+	NewMenuID    uuid.UUID                      `json:"newMenuID"`
+	Status       entities.MenuDuplicationStatus `json:"status"`
+	Error        string                         `json:"error,omitempty"`
+	StartedAt    time.Time                      `json:"startedAt"`
+	FinishedAt   *time.Time                     `json:"finishedAt,omitempty"`
+}
+
+func newMenuDuplicationView(duplication *entities.MenuDuplication) MenuDuplicationView {
+	view := MenuDuplicationView{
+		ID:           duplication.ID,
+		SourceMenuID: duplication.State.SourceMenuID,
+		NewMenuID:    duplication.State.NewMenuID,
+		Status:       duplication.State.Status,
+		Error:        duplication.State.Error,
+		StartedAt:    duplication.State.StartedAt,
+	}
+	if !duplication.State.FinishedAt.IsZero() {
+		view.FinishedAt = &duplication.State.FinishedAt
+	}
+	return view
+}
+
+// DuplicateMenu starts copying the menu into a new one and responds with the duplication,
+// whose status is then read from the Location of the response
+func (api Api) DuplicateMenu(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menu id")
+	}
+
+	menu, err := checkIfEntityExists(api.repository, &entities.Menu{}, id)
+	if menu == nil {
+		return err
+	}
+
+	duplication, err := api.menuDuplicator.Start(c.UserContext(), menu.(*entities.Menu))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when starting the duplication of the menu. Please try again later")
+	}
+	c.Location(fmt.Sprintf("/menu-duplications/%s", duplication.ID))
+	return c.Status(fiber.StatusAccepted).JSON(newMenuDuplicationView(duplication))
+}
+
+func (api Api) GetMenuDuplication(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menu duplication id")
+	}
+
+	duplication, err := checkIfEntityExists(api.repository, &entities.MenuDuplication{}, id)
+	if duplication == nil {
+		return err
+	}
+	return c.JSON(newMenuDuplicationView(duplication.(*entities.MenuDuplication)))
+}
+
 type CreateNewCategoryRequest struct {
 	MenuID string `json:"menuID"`
 }
@@ -138,14 +379,10 @@ func (api Api) CreateNewCategory(c *fiber.Ctx) error {
 	if menu == nil {
 		return err
 	}
-
-	if menu.(*entities.Menu).IsDeleted {
-		return fiber.NewError(fiber.StatusNotFound, "Menu not found")
-	}
 	category := entities.NewCategory(menuID)
-	err = api.repository.SaveEntity(category)
+	err = saveChanges(c.UserContext(), api.repository, category)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when saving the changes. Please try again later")
+		return err
 	}
 	c.SendStatus(fiber.StatusCreated)
 	return nil
@@ -170,11 +407,14 @@ func (api Api) ChangeCategoryName(c *fiber.Ctx) error {
 	if category == nil {
 		return err
 	}
+	if err = checkIfParentExists(api.repository, category); err != nil {
+		return err
+	}
 
 	category.(*entities.Category).ChangeName(reqBody.NewName)
-	err = api.repository.SaveEntity(category)
+	err = saveChanges(c.UserContext(), api.repository, category)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when saving the changes. Please try again later")
+		return err
 	}
 	c.SendStatus(fiber.StatusOK)
 	return nil
@@ -190,6 +430,9 @@ func (api Api) UploadCategoryImage(c *fiber.Ctx) error {
 	if entity == nil {
 		return err
 	}
+	if err = checkIfParentExists(api.repository, entity); err != nil {
+		return err
+	}
 
 	file, err := c.FormFile("image")
 
@@ -208,6 +451,110 @@ func (api Api) UploadCategoryImage(c *fiber.Ctx) error {
 	return nil
 }
 
+type ReorderSubCategoriesRequest struct {
+	SubCategoriesIDs []string `json:"subCategoriesIDs"`
+}
+
+// ReorderSubCategories puts the subcategories of the category in the order of the request, which must have all of them
+func (api Api) ReorderSubCategories(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid category id")
+	}
+
+	reqBody := new(ReorderSubCategoriesRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	ids, ok := parseIDs(reqBody.SubCategoriesIDs)
+	if !ok {
+		return fiber.NewError(fiber.StatusBadRequest, "subCategoriesIDs is not valid")
+	}
+
+	category, err := checkIfEntityExists(api.repository, &entities.Category{}, id)
+	if category == nil {
+		return err
+	}
+	if err = checkIfParentExists(api.repository, category); err != nil {
+		return err
+	}
+
+	err = category.(*entities.Category).ReorderSubCategories(ids)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	err = saveChanges(c.UserContext(), api.repository, category)
+	if err != nil {
+		return err
+	}
+	c.SendStatus(fiber.StatusOK)
+	return nil
+}
+
+type MoveCategoryRequest struct {
+	MenuID string `json:"menuID"`
+}
+
+// MoveCategory moves the category to the menu of the request, the category is removed
+// from its current menu and added to the new one in the background
+func (api Api) MoveCategory(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid category id")
+	}
+
+	reqBody := new(MoveCategoryRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	menuID := uuid.FromStringOrNil(reqBody.MenuID)
+	if menuID == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "menuID is not valid")
+	}
+
+	category, err := checkIfEntityExists(api.repository, &entities.Category{}, id)
+	if category == nil {
+		return err
+	}
+	menu, err := checkIfEntityExists(api.repository, &entities.Menu{}, menuID)
+	if menu == nil {
+		return err
+	}
+
+	err = category.(*entities.Category).MoveToMenu(menuID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	err = saveChanges(c.UserContext(), api.repository, category)
+	if err != nil {
+		return err
+	}
+	c.SendStatus(fiber.StatusOK)
+	return nil
+}
+
+// DeleteCategory deletes the category, which is removed from its menu in the background.
+// Its subcategories are not deleted but can only be moved to another category or deleted
+func (api Api) DeleteCategory(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid category id")
+	}
+
+	category, err := checkIfEntityExists(api.repository, &entities.Category{}, id)
+	if category == nil {
+		return err
+	}
+
+	category.(*entities.Category).Delete()
+	err = saveChanges(c.UserContext(), api.repository, category)
+	if err != nil {
+		return err
+	}
+	c.SendStatus(fiber.StatusOK)
+	return nil
+}
+
 type CreateNewSubCategoryRequest struct {
 	CategoryID string `json:"categoryID"`
 }
@@ -225,13 +572,13 @@ func (api Api) CreateNewSubCategory(c *fiber.Ctx) error {
 	if category == nil {
 		return err
 	}
-	if category.(*entities.Category).IsDeleted {
-		return fiber.NewError(fiber.StatusNotFound, "Category not found")
+	if err = checkIfParentExists(api.repository, category); err != nil {
+		return err
 	}
 	subcategory := entities.NewSubCategory(categoryID)
-	err = api.repository.SaveEntity(subcategory)
+	err = saveChanges(c.UserContext(), api.repository, subcategory)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when saving the changes. Please try again later")
+		return err
 	}
 	c.SendStatus(fiber.StatusCreated)
 	return nil
@@ -254,13 +601,13 @@ func (api Api) CreateNewMenuItem(c *fiber.Ctx) error {
 	if subCategory == nil {
 		return err
 	}
-	if subCategory.(*entities.SubCategory).IsDeleted {
-		return fiber.NewError(fiber.StatusNotFound, "SubCategory not found")
+	if err = checkIfParentExists(api.repository, subCategory); err != nil {
+		return err
 	}
 	menuItem := entities.NewMenuItem(subCategoryID)
-	err = api.repository.SaveEntity(menuItem)
+	err = saveChanges(c.UserContext(), api.repository, menuItem)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when saving the changes. Please try again later")
+		return err
 	}
 	c.SendStatus(fiber.StatusCreated)
 	return nil
@@ -276,6 +623,9 @@ func (api Api) UploadSubCategoryImage(c *fiber.Ctx) error {
 	if subCategory == nil {
 		return err
 	}
+	if err = checkIfParentExists(api.repository, subCategory); err != nil {
+		return err
+	}
 
 	file, err := c.FormFile("image")
 
@@ -294,6 +644,110 @@ func (api Api) UploadSubCategoryImage(c *fiber.Ctx) error {
 	return nil
 }
 
+type ReorderMenuItemsRequest struct {
+	MenuItemsIDs []string `json:"menuItemsIDs"`
+}
+
+// ReorderMenuItems puts the menu items of the subcategory in the order of the request, which must have all of them
+func (api Api) ReorderMenuItems(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid subcategory id")
+	}
+
+	reqBody := new(ReorderMenuItemsRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	ids, ok := parseIDs(reqBody.MenuItemsIDs)
+	if !ok {
+		return fiber.NewError(fiber.StatusBadRequest, "menuItemsIDs is not valid")
+	}
+
+	subCategory, err := checkIfEntityExists(api.repository, &entities.SubCategory{}, id)
+	if subCategory == nil {
+		return err
+	}
+	if err = checkIfParentExists(api.repository, subCategory); err != nil {
+		return err
+	}
+
+	err = subCategory.(*entities.SubCategory).ReorderMenuItems(ids)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	err = saveChanges(c.UserContext(), api.repository, subCategory)
+	if err != nil {
+		return err
+	}
+	c.SendStatus(fiber.StatusOK)
+	return nil
+}
+
+type MoveSubCategoryRequest struct {
+	CategoryID string `json:"categoryID"`
+}
+
+// MoveSubCategory moves the subcategory to the category of the request, the subcategory is removed
+// from its current category and added to the new one in the background
+func (api Api) MoveSubCategory(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid subcategory id")
+	}
+
+	reqBody := new(MoveSubCategoryRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	categoryID := uuid.FromStringOrNil(reqBody.CategoryID)
+	if categoryID == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "categoryID is not valid")
+	}
+
+	subCategory, err := checkIfEntityExists(api.repository, &entities.SubCategory{}, id)
+	if subCategory == nil {
+		return err
+	}
+	category, err := checkIfEntityExists(api.repository, &entities.Category{}, categoryID)
+	if category == nil {
+		return err
+	}
+
+	err = subCategory.(*entities.SubCategory).MoveToCategory(categoryID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	err = saveChanges(c.UserContext(), api.repository, subCategory)
+	if err != nil {
+		return err
+	}
+	c.SendStatus(fiber.StatusOK)
+	return nil
+}
+
+// DeleteSubCategory deletes the subcategory, which is removed from its category in the background.
+// Its menu items are not deleted but can only be moved to another subcategory or deleted
+func (api Api) DeleteSubCategory(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid subcategory id")
+	}
+
+	subCategory, err := checkIfEntityExists(api.repository, &entities.SubCategory{}, id)
+	if subCategory == nil {
+		return err
+	}
+
+	subCategory.(*entities.SubCategory).Delete()
+	err = saveChanges(c.UserContext(), api.repository, subCategory)
+	if err != nil {
+		return err
+	}
+	c.SendStatus(fiber.StatusOK)
+	return nil
+}
+
 func saveFile(id uuid.UUID, c *fiber.Ctx, file *multipart.FileHeader, resourcePath string) error {
 	imageName := fmt.Sprintf("%s.jpg", id)
 	path := filepath.Join(resourcePath, imageName)
@@ -320,16 +774,323 @@ func (api Api) ChangeMenuItemName(c *fiber.Ctx) error {
 	if menuItem == nil {
 		return err
 	}
+	if err = checkIfParentExists(api.repository, menuItem); err != nil {
+		return err
+	}
 
 	menuItem.(*entities.MenuItem).ChangeName(reqBody.NewName)
-	err = api.repository.SaveEntity(menuItem)
+	err = saveChanges(c.UserContext(), api.repository, menuItem)
+	if err != nil {
+		return err
+	}
+	c.SendStatus(fiber.StatusOK)
+	return nil
+}
+
+// DeleteMenuItem deletes the menu item, which is removed from its subcategory in the background
+func (api Api) DeleteMenuItem(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menuitem id")
+	}
+
+	menuItem, err := checkIfEntityExists(api.repository, &entities.MenuItem{}, id)
+	if menuItem == nil {
+		return err
+	}
+
+	menuItem.(*entities.MenuItem).Delete()
+	err = saveChanges(c.UserContext(), api.repository, menuItem)
+	if err != nil {
+		return err
+	}
+	c.SendStatus(fiber.StatusOK)
+	return nil
+}
+
+type MoveMenuItemRequest struct {
+	SubCategoryID string `json:"subCategoryID"`
+}
+
+// MoveMenuItem moves the menu item to the subcategory of the request, the menu item is removed
+// from its current subcategory and added to the new one in the background
+func (api Api) MoveMenuItem(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menuitem id")
+	}
+
+	reqBody := new(MoveMenuItemRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	subCategoryID := uuid.FromStringOrNil(reqBody.SubCategoryID)
+	if subCategoryID == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "subCategoryID is not valid")
+	}
+
+	menuItem, err := checkIfEntityExists(api.repository, &entities.MenuItem{}, id)
+	if menuItem == nil {
+		return err
+	}
+	subCategory, err := checkIfEntityExists(api.repository, &entities.SubCategory{}, subCategoryID)
+	if subCategory == nil {
+		return err
+	}
+
+	err = menuItem.(*entities.MenuItem).MoveToSubCategory(subCategoryID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when saving the changes. Please try again later")
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	err = saveChanges(c.UserContext(), api.repository, menuItem)
+	if err != nil {
+		return err
 	}
 	c.SendStatus(fiber.StatusOK)
 	return nil
 }
 
+type ChangeMenuItemPriceRequest struct {
+	Amount   *int64 `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (api Api) ChangeMenuItemPrice(c *fiber.Ctx) error {
+	reqBody := new(ChangeMenuItemPriceRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	if reqBody.Amount == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "amount is required")
+	}
+	return api.changeMenuItem(c, fiber.StatusOK, func(menuItem *entities.MenuItem) error {
+		return menuItem.ChangePrice(*reqBody.Amount, reqBody.Currency)
+	})
+}
+
+// ChangeMenuItemEstimatedPreparationTimeRequest has the new time as a duration such as "15m" or "1h30m"
+type ChangeMenuItemEstimatedPreparationTimeRequest struct {
+	EstimatedPreparationTime string `json:"estimatedPreparationTime"`
+}
+
+func (api Api) ChangeMenuItemEstimatedPreparationTime(c *fiber.Ctx) error {
+	reqBody := new(ChangeMenuItemEstimatedPreparationTimeRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	estimatedPreparationTime, err := time.ParseDuration(reqBody.EstimatedPreparationTime)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid estimatedPreparationTime, expected a duration such as 15m")
+	}
+	return api.changeMenuItem(c, fiber.StatusOK, func(menuItem *entities.MenuItem) error {
+		return menuItem.ChangeEstimatedPreparationTime(estimatedPreparationTime)
+	})
+}
+
+type ChangeMenuItemDescriptionRequest struct {
+	Description string `json:"description"`
+}
+
+func (api Api) ChangeMenuItemDescription(c *fiber.Ctx) error {
+	reqBody := new(ChangeMenuItemDescriptionRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	return api.changeMenuItem(c, fiber.StatusOK, func(menuItem *entities.MenuItem) error {
+		return menuItem.ChangeDescription(reqBody.Description)
+	})
+}
+
+func (api Api) ClearMenuItemDescription(c *fiber.Ctx) error {
+	return api.changeMenuItem(c, fiber.StatusOK, func(menuItem *entities.MenuItem) error {
+		return menuItem.ChangeDescription("")
+	})
+}
+
+type ChangeMenuItemAllergensRequest struct {
+	Allergens []events.Allergen `json:"allergens"`
+}
+
+func (api Api) ChangeMenuItemAllergens(c *fiber.Ctx) error {
+	reqBody := new(ChangeMenuItemAllergensRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	return api.changeMenuItem(c, fiber.StatusOK, func(menuItem *entities.MenuItem) error {
+		return menuItem.ChangeAllergens(reqBody.Allergens)
+	})
+}
+
+func (api Api) ClearMenuItemAllergens(c *fiber.Ctx) error {
+	return api.changeMenuItem(c, fiber.StatusOK, func(menuItem *entities.MenuItem) error {
+		return menuItem.ChangeAllergens(nil)
+	})
+}
+
+type ChangeMenuItemDietaryTagsRequest struct {
+	Vegan      bool `json:"vegan"`
+	Vegetarian bool `json:"vegetarian"`
+	GlutenFree bool `json:"glutenFree"`
+	Halal      bool `json:"halal"`
+	SpicyLevel int  `json:"spicyLevel"`
+}
+
+func (api Api) ChangeMenuItemDietaryTags(c *fiber.Ctx) error {
+	reqBody := new(ChangeMenuItemDietaryTagsRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	return api.changeMenuItem(c, fiber.StatusOK, func(menuItem *entities.MenuItem) error {
+		return menuItem.ChangeDietaryTags(events.DietaryTags(*reqBody))
+	})
+}
+
+func (api Api) ClearMenuItemDietaryTags(c *fiber.Ctx) error {
+	return api.changeMenuItem(c, fiber.StatusOK, func(menuItem *entities.MenuItem) error {
+		return menuItem.ChangeDietaryTags(events.DietaryTags{})
+	})
+}
+
+type AddMenuItemVariantRequest struct {
+	Name     string `json:"name"`
+	Amount   *int64 `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (api Api) AddMenuItemVariant(c *fiber.Ctx) error {
+	reqBody := new(AddMenuItemVariantRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	if reqBody.Amount == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "amount is required")
+	}
+	return api.changeMenuItem(c, fiber.StatusCreated, func(menuItem *entities.MenuItem) error {
+		_, err := menuItem.AddVariant(reqBody.Name, *reqBody.Amount, reqBody.Currency)
+		return err
+	})
+}
+
+func (api Api) RemoveMenuItemVariant(c *fiber.Ctx) error {
+	variantID := uuid.FromStringOrNil(c.Params("variantID"))
+	if variantID == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid variant id")
+	}
+	return api.changeMenuItem(c, fiber.StatusOK, func(menuItem *entities.MenuItem) error {
+		return menuItem.RemoveVariant(variantID)
+	})
+}
+
+type AddMenuItemModifierGroupRequest struct {
+	Name          string `json:"name"`
+	MinSelections int    `json:"minSelections"`
+	MaxSelections int    `json:"maxSelections"`
+}
+
+func (api Api) AddMenuItemModifierGroup(c *fiber.Ctx) error {
+	reqBody := new(AddMenuItemModifierGroupRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	return api.changeMenuItem(c, fiber.StatusCreated, func(menuItem *entities.MenuItem) error {
+		_, err := menuItem.AddModifierGroup(reqBody.Name, reqBody.MinSelections, reqBody.MaxSelections)
+		return err
+	})
+}
+
+func (api Api) RemoveMenuItemModifierGroup(c *fiber.Ctx) error {
+	modifierGroupID := uuid.FromStringOrNil(c.Params("modifierGroupID"))
+	if modifierGroupID == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid modifier group id")
+	}
+	return api.changeMenuItem(c, fiber.StatusOK, func(menuItem *entities.MenuItem) error {
+		return menuItem.RemoveModifierGroup(modifierGroupID)
+	})
+}
+
+// AddMenuItemModifierRequest has the price delta of the modifier, which can be negative
+type AddMenuItemModifierRequest struct {
+	Name     string `json:"name"`
+	Amount   *int64 `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (api Api) AddMenuItemModifier(c *fiber.Ctx) error {
+	modifierGroupID := uuid.FromStringOrNil(c.Params("modifierGroupID"))
+	if modifierGroupID == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid modifier group id")
+	}
+	reqBody := new(AddMenuItemModifierRequest)
+	if err := c.BodyParser(reqBody); err != nil {
+		return err
+	}
+	if reqBody.Amount == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "amount is required")
+	}
+	return api.changeMenuItem(c, fiber.StatusCreated, func(menuItem *entities.MenuItem) error {
+		_, err := menuItem.AddModifier(modifierGroupID, reqBody.Name, *reqBody.Amount, reqBody.Currency)
+		return err
+	})
+}
+
+func (api Api) RemoveMenuItemModifier(c *fiber.Ctx) error {
+	modifierGroupID := uuid.FromStringOrNil(c.Params("modifierGroupID"))
+	modifierID := uuid.FromStringOrNil(c.Params("modifierID"))
+	if modifierGroupID == uuid.Nil || modifierID == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid modifier group or modifier id")
+	}
+	return api.changeMenuItem(c, fiber.StatusOK, func(menuItem *entities.MenuItem) error {
+		return menuItem.RemoveModifier(modifierGroupID, modifierID)
+	})
+}
+
+// changeMenuItem applies the change to the menu item of the id parameter, saves it and responds with
+// the status, an error returned by the change is a bad request, or a not found for a missing part of the menu item.
+// The menu items of a deleted subcategory are not changed
+func (api Api) changeMenuItem(c *fiber.Ctx, status int, change func(menuItem *entities.MenuItem) error) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menuitem id")
+	}
+
+	menuItem, err := checkIfEntityExists(api.repository, &entities.MenuItem{}, id)
+	if menuItem == nil {
+		return err
+	}
+	if err = checkIfParentExists(api.repository, menuItem); err != nil {
+		return err
+	}
+
+	err = change(menuItem.(*entities.MenuItem))
+	if errors.Is(err, entities.ErrVariantNotFound) ||
+		errors.Is(err, entities.ErrModifierGroupNotFound) ||
+		errors.Is(err, entities.ErrModifierNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	err = saveChanges(c.UserContext(), api.repository, menuItem)
+	if err != nil {
+		return err
+	}
+	c.SendStatus(status)
+	return nil
+}
+
+// parseIDs parses the IDs of a request, ok is false if one of them is not valid
+func parseIDs(rawIDs []string) (ids []uuid.UUID, ok bool) {
+	ids = make([]uuid.UUID, 0, len(rawIDs))
+	for _, rawID := range rawIDs {
+		id := uuid.FromStringOrNil(rawID)
+		if id == uuid.Nil {
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
 func checkIfEntityExists(repo eventutils.IEntityRepository, entity eventutils.IReconstructible, id uuid.UUID) (eventutils.IReconstructible, error) {
 	foundEntity, err := repo.GetEntity(entity, id)
 	if err != nil {
@@ -341,3 +1102,37 @@ func checkIfEntityExists(repo eventutils.IEntityRepository, entity eventutils.IR
 	}
 	return foundEntity, nil
 }
+
+// checkIfParentExists rejects the commands on a child whose parent was deleted, which can only be moved or deleted
+func checkIfParentExists(repo eventutils.IEntityRepository, child eventutils.IReconstructible) error {
+	var parent eventutils.IReconstructible
+	var parentID uuid.UUID
+	switch child := child.(type) {
+	case *entities.Category:
+		parent, parentID = &entities.Menu{}, child.GetParentMenuID()
+	case *entities.SubCategory:
+		parent, parentID = &entities.Category{}, child.GetParentCategoryID()
+	case *entities.MenuItem:
+		parent, parentID = &entities.SubCategory{}, child.GetParentSubCategoryID()
+	default:
+		return nil
+	}
+	_, err := checkIfEntityExists(repo, parent, parentID)
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusNotFound {
+		return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("The %s of the %s was deleted, the %s can only be moved or deleted.", utils.GetType(parent), utils.GetType(child), utils.GetType(child)))
+	}
+	return err
+}
+
+func saveChanges(ctx context.Context, repo eventutils.IEntityRepository, entity eventutils.IReconstructible) error {
+	err := repo.SaveEntity(ctx, entity)
+	if err != nil {
+		if errors.Is(err, eventutils.ErrConcurrencyConflict) {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("The %s was modified by another request, please reload it and try again.", utils.GetType(entity)))
+		} else {
+			return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when saving the changes. Please try again later")
+		}
+	}
+	return nil
+}
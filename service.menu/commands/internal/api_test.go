@@ -2,6 +2,8 @@ package internal
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -10,13 +12,17 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Resta-Inc/resta/menu/commands/internal/entities"
+	"github.com/Resta-Inc/resta/pkg/events"
 	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/Resta-Inc/resta/pkg/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
 )
 
 func TestCreateNewMenu(t *testing.T) {
@@ -136,7 +142,7 @@ func TestChangeMenuName(t *testing.T) {
 	mockEntityRepository.AssertExpectations(t)
 }
 
-func TestNewCategory(t *testing.T) {
+func TestChangeMenuName_WhenMenuWasModifiedConcurrently(t *testing.T) {
 	// Arrange
 	menu := entities.NewMenu()
 	mockEntityRepository := new(eventutils.MockEntityRepository)
@@ -145,14 +151,14 @@ func TestNewCategory(t *testing.T) {
 		Return(menu, nil)
 
 	mockEntityRepository.
-		On("SaveEntity", mock.AnythingOfType("*entities.Category")).
-		Return(nil)
+		On("SaveEntity", mock.AnythingOfType("*entities.Menu")).
+		Return(eventutils.ErrConcurrencyConflict)
 
 	app := fiber.New()
 	SetupApi(app, mockEntityRepository, "")
 
-	jsonBody := fmt.Sprintf(`{"menuID": "%s"}`, menu.ID)
-	url := "/categories"
+	jsonBody := `{"newName": "NewMenuName"}`
+	url := fmt.Sprintf("/menus/%s/change-name", menu.ID)
 	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
 	request.Header.Add("content-type", "application/json")
 	require.NoError(t, err)
@@ -161,24 +167,25 @@ func TestNewCategory(t *testing.T) {
 	resp, _ := app.Test(request)
 
 	// Assert
-	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.Equal(t, fiber.StatusConflict, resp.StatusCode)
 	mockEntityRepository.AssertExpectations(t)
 }
 
-func TestChangeCategoryName(t *testing.T) {
+func TestReorderCategories(t *testing.T) {
 	// Arrange
+	categoryID1, categoryID2 := utils.GenerateNewUUID(), utils.GenerateNewUUID()
 	menu := entities.NewMenu()
-	category := entities.NewCategory(menu.ID)
-	newName := "NewCategoryName"
+	menu.AddCategory(categoryID1)
+	menu.AddCategory(categoryID2)
 	mockEntityRepository := new(eventutils.MockEntityRepository)
 	mockEntityRepository.
-		On("GetEntity", &entities.Category{}, category.ID).
-		Return(category, nil)
+		On("GetEntity", &entities.Menu{}, menu.ID).
+		Return(menu, nil)
 
 	mockEntityRepository.
 		On("SaveEntity", mock.MatchedBy(
-			func(category *entities.Category) bool {
-				return category.GetName() == newName
+			func(menu *entities.Menu) bool {
+				return slices.Equal(menu.GetCategoriesIDs(), []uuid.UUID{categoryID2, categoryID1})
 			},
 		)).
 		Return(nil)
@@ -186,8 +193,8 @@ func TestChangeCategoryName(t *testing.T) {
 	app := fiber.New()
 	SetupApi(app, mockEntityRepository, "")
 
-	jsonBody := fmt.Sprintf(`{"newName": "%s"}`, newName)
-	url := fmt.Sprintf("/categories/%s/change-name", category.ID)
+	jsonBody := fmt.Sprintf(`{"categoriesIDs": ["%s", "%s"]}`, categoryID2, categoryID1)
+	url := fmt.Sprintf("/menus/%s/reorder-categories", menu.ID)
 	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
 	request.Header.Add("content-type", "application/json")
 	require.NoError(t, err)
@@ -200,66 +207,60 @@ func TestChangeCategoryName(t *testing.T) {
 	mockEntityRepository.AssertExpectations(t)
 }
 
-func TestUploadCategoryPicture(t *testing.T) {
+func TestReorderCategories_WhenNotAPermutation(t *testing.T) {
 	// Arrange
-	err := os.MkdirAll("./resources/images/categories", 0755)
-	defer os.RemoveAll("./resources")
-	require.NoError(t, err)
+	categoryID1, categoryID2 := utils.GenerateNewUUID(), utils.GenerateNewUUID()
 	menu := entities.NewMenu()
-	category := entities.NewCategory(menu.ID)
+	menu.AddCategory(categoryID1)
+	menu.AddCategory(categoryID2)
 	mockEntityRepository := new(eventutils.MockEntityRepository)
 	mockEntityRepository.
-		On("GetEntity", &entities.Category{}, category.ID).
-		Return(category, nil)
+		On("GetEntity", &entities.Menu{}, menu.ID).
+		Return(menu, nil)
 
 	app := fiber.New()
-	SetupApi(app, mockEntityRepository, "./resources")
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	fw, err := writer.CreateFormFile("image", "test.jpg")
-	require.NoError(t, err)
-	file, err := os.Open("test.jpg")
-	require.NoError(t, err)
-	_, err = io.Copy(fw, file)
-	require.NoError(t, err)
-	writer.Close()
+	SetupApi(app, mockEntityRepository, "")
 
-	url := fmt.Sprintf("/categories/%s/upload-image", category.ID)
-	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body.Bytes()))
-	request.Header.Set("Content-Type", writer.FormDataContentType())
+	jsonBody := fmt.Sprintf(`{"categoriesIDs": ["%s"]}`, categoryID2)
+	url := fmt.Sprintf("/menus/%s/reorder-categories", menu.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
 	require.NoError(t, err)
 
 	// Act
-	resp, err := app.Test(request)
+	resp, _ := app.Test(request)
 
 	// Assert
-	require.NoError(t, err)
-	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
 	mockEntityRepository.AssertExpectations(t)
-	imageName := fmt.Sprintf("%s.jpg", category.ID)
-	path := filepath.Join("./resources", "images/categories", imageName)
-	_, err = os.Stat(path)
-	require.NoError(t, err)
+	mockEntityRepository.AssertNotCalled(t, "SaveEntity", mock.Anything)
 }
 
-func TestNewSubCategory(t *testing.T) {
+func TestMoveCategory(t *testing.T) {
 	// Arrange
 	category := entities.NewCategory(utils.GenerateNewUUID())
+	newMenu := entities.NewMenu()
 	mockEntityRepository := new(eventutils.MockEntityRepository)
 	mockEntityRepository.
 		On("GetEntity", &entities.Category{}, category.ID).
 		Return(category, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, newMenu.ID).
+		Return(newMenu, nil)
 
 	mockEntityRepository.
-		On("SaveEntity", mock.AnythingOfType("*entities.SubCategory")).
+		On("SaveEntity", mock.MatchedBy(
+			func(category *entities.Category) bool {
+				return category.GetParentMenuID() == newMenu.ID
+			},
+		)).
 		Return(nil)
 
 	app := fiber.New()
 	SetupApi(app, mockEntityRepository, "")
 
-	jsonBody := fmt.Sprintf(`{"categoryID": "%s"}`, category.ID)
-	url := "/subcategories"
+	jsonBody := fmt.Sprintf(`{"menuID": "%s"}`, newMenu.ID)
+	url := fmt.Sprintf("/categories/%s/move", category.ID)
 	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
 	request.Header.Add("content-type", "application/json")
 	require.NoError(t, err)
@@ -268,27 +269,56 @@ func TestNewSubCategory(t *testing.T) {
 	resp, _ := app.Test(request)
 
 	// Assert
-	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
 	mockEntityRepository.AssertExpectations(t)
 }
 
-func TestNewMenuItem(t *testing.T) {
+func TestMoveCategory_WhenMenuIsNotFound(t *testing.T) {
+	// Arrange
+	category := entities.NewCategory(utils.GenerateNewUUID())
+	newMenuID := utils.GenerateNewUUID()
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Category{}, category.ID).
+		Return(category, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, newMenuID).
+		Return(nil, eventutils.ErrEntityNotFound)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := fmt.Sprintf(`{"menuID": "%s"}`, newMenuID)
+	url := fmt.Sprintf("/categories/%s/move", category.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	mockEntityRepository.AssertNotCalled(t, "SaveEntity", mock.Anything)
+}
+
+func TestMoveMenuItem_WhenSubCategoryIsTheCurrentOne(t *testing.T) {
 	// Arrange
 	subCategory := entities.NewSubCategory(utils.GenerateNewUUID())
+	menuItem := entities.NewMenuItem(subCategory.ID)
 	mockEntityRepository := new(eventutils.MockEntityRepository)
 	mockEntityRepository.
 		On("GetEntity", &entities.SubCategory{}, subCategory.ID).
 		Return(subCategory, nil)
-
 	mockEntityRepository.
-		On("SaveEntity", mock.AnythingOfType("*entities.MenuItem")).
-		Return(nil)
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(menuItem, nil)
 
 	app := fiber.New()
 	SetupApi(app, mockEntityRepository, "")
 
 	jsonBody := fmt.Sprintf(`{"subCategoryID": "%s"}`, subCategory.ID)
-	url := "/menuitems"
+	url := fmt.Sprintf("/menuitems/%s/move", menuItem.ID)
 	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
 	request.Header.Add("content-type", "application/json")
 	require.NoError(t, err)
@@ -297,64 +327,186 @@ func TestNewMenuItem(t *testing.T) {
 	resp, _ := app.Test(request)
 
 	// Assert
-	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
 	mockEntityRepository.AssertExpectations(t)
+	mockEntityRepository.AssertNotCalled(t, "SaveEntity", mock.Anything)
 }
 
-func TestUploadSubCategoryPicture(t *testing.T) {
+func TestPublishMenu(t *testing.T) {
 	// Arrange
-	err := os.MkdirAll("./resources/images/subcategories", 0755)
-	defer os.RemoveAll("./resources")
-	require.NoError(t, err)
-	subcategory := entities.NewSubCategory(utils.GenerateNewUUID())
-	mockEntityRepository := new(eventutils.MockEntityRepository)
-	mockEntityRepository.
-		On("GetEntity", &entities.SubCategory{}, subcategory.ID).
-		Return(subcategory, nil)
+	eventStore := eventutils.NewInMemoryEventStore()
+	entityRepository := eventutils.NewEntityRepository(eventStore)
+
+	menu := entities.NewMenu()
+	category := entities.NewCategory(menu.ID)
+	subCategory := entities.NewSubCategory(category.ID)
+	menuItem := entities.NewMenuItem(subCategory.ID)
+	require.NoError(t, menuItem.ChangePrice(850, "EUR"))
+	menu.AddCategory(category.ID)
+	category.AddSubCategory(subCategory.ID)
+	subCategory.AddMenuItem(menuItem.ID)
+	for _, entity := range []eventutils.IReconstructible{menu, category, subCategory, menuItem} {
+		require.NoError(t, entityRepository.SaveEntity(context.Background(), entity))
+	}
 
 	app := fiber.New()
-	SetupApi(app, mockEntityRepository, "./resources")
+	SetupApi(app, entityRepository, "")
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	fw, err := writer.CreateFormFile("image", "test.jpg")
+	url := fmt.Sprintf("/menus/%s/publish", menu.ID)
+	request, err := http.NewRequest(http.MethodPost, url, nil)
 	require.NoError(t, err)
-	file, err := os.Open("test.jpg")
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var body PublishMenuResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, 1, body.Version)
+
+	foundMenu, err := entityRepository.GetEntity(&entities.Menu{}, menu.ID)
 	require.NoError(t, err)
-	_, err = io.Copy(fw, file)
+	require.Equal(t, 1, foundMenu.(*entities.Menu).GetPublishedVersion())
+
+	recordedEvent := eventStore.SubscribeToAll("MenuPublished").Recv().EventAppeared.Event
+	publishedEvent, err := foundMenu.DeserializeEvent(eventutils.DeserializeRecordedEvent(recordedEvent))
 	require.NoError(t, err)
-	writer.Close()
+	categories := publishedEvent.(events.MenuPublished).Categories
+	require.Len(t, categories, 1)
+	require.Equal(t, category.ID, categories[0].ID)
+	require.Equal(t, subCategory.ID, categories[0].SubCategories[0].ID)
+	require.Equal(t, menuItem.ID, categories[0].SubCategories[0].MenuItems[0].ID)
+	require.Equal(t, events.Price{Amount: 850, Currency: "EUR"}, categories[0].SubCategories[0].MenuItems[0].Price)
+}
 
-	url := fmt.Sprintf("/subcategories/%s/upload-image", subcategory.ID)
-	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body.Bytes()))
-	request.Header.Set("Content-Type", writer.FormDataContentType())
+func TestRestoreMenuVersion_WhenVersionIsNotFound(t *testing.T) {
+	// Arrange
+	menu := entities.NewMenu()
+	menu.Publish(nil)
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, menu.ID).
+		Return(menu, nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := `{"version": 2}`
+	url := fmt.Sprintf("/menus/%s/restore-version", menu.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
 	require.NoError(t, err)
 
 	// Act
-	resp, err := app.Test(request)
+	resp, _ := app.Test(request)
 
 	// Assert
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	mockEntityRepository.AssertNotCalled(t, "SaveEntity", mock.Anything)
+}
+
+func TestDeleteMenu(t *testing.T) {
+	// Arrange
+	menu := entities.NewMenu()
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, menu.ID).
+		Return(menu, nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.MatchedBy(
+			func(menu *entities.Menu) bool {
+				return menu.IsDeleted
+			},
+		)).
+		Return(nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	url := fmt.Sprintf("/menus/%s", menu.ID)
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
 	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
 	require.Equal(t, fiber.StatusOK, resp.StatusCode)
 	mockEntityRepository.AssertExpectations(t)
-	imageName := fmt.Sprintf("%s.jpg", subcategory.ID)
-	path := filepath.Join("./resources", "images/subcategories", imageName)
-	_, err = os.Stat(path)
+}
+
+func TestDeleteMenu_WhenMenuWasDeleted(t *testing.T) {
+	// Arrange
+	menuID := utils.GenerateNewUUID()
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, menuID).
+		Return(nil, eventutils.ErrEntityNotFound)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	url := fmt.Sprintf("/menus/%s", menuID)
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
 	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+	mockEntityRepository.AssertNotCalled(t, "SaveEntity", mock.Anything)
 }
 
-func TestChangeMenuItemName(t *testing.T) {
+func TestNewCategory(t *testing.T) {
 	// Arrange
-	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	menu := entities.NewMenu()
 	mockEntityRepository := new(eventutils.MockEntityRepository)
 	mockEntityRepository.
-		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
-		Return(menuItem, nil)
+		On("GetEntity", &entities.Menu{}, menu.ID).
+		Return(menu, nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.AnythingOfType("*entities.Category")).
+		Return(nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := fmt.Sprintf(`{"menuID": "%s"}`, menu.ID)
+	url := "/categories"
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestChangeCategoryName(t *testing.T) {
+	// Arrange
+	menu := entities.NewMenu()
+	category := entities.NewCategory(menu.ID)
+	newName := "NewCategoryName"
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Category{}, category.ID).
+		Return(category, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, category.GetParentMenuID()).
+		Return(menu, nil)
 
 	mockEntityRepository.
 		On("SaveEntity", mock.MatchedBy(
-			func(menu *entities.MenuItem) bool {
-				return menu.GetName() == "NewMenuItemName"
+			func(category *entities.Category) bool {
+				return category.GetName() == newName
 			},
 		)).
 		Return(nil)
@@ -362,8 +514,8 @@ func TestChangeMenuItemName(t *testing.T) {
 	app := fiber.New()
 	SetupApi(app, mockEntityRepository, "")
 
-	jsonBody := `{"newName": "NewMenuItemName"}`
-	url := fmt.Sprintf("/menuitems/%s/change-name", menuItem.ID)
+	jsonBody := fmt.Sprintf(`{"newName": "%s"}`, newName)
+	url := fmt.Sprintf("/categories/%s/change-name", category.ID)
 	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
 	request.Header.Add("content-type", "application/json")
 	require.NoError(t, err)
@@ -376,19 +528,24 @@ func TestChangeMenuItemName(t *testing.T) {
 	mockEntityRepository.AssertExpectations(t)
 }
 
-func TestChangeMenuItemName_WhenItemNotFound(t *testing.T) {
+func TestChangeCategoryName_WhenMenuWasDeleted(t *testing.T) {
 	// Arrange
-	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	category := entities.NewCategory(utils.GenerateNewUUID())
+
+	// SaveEntity is not expected, the mock fails the test if it is called
 	mockEntityRepository := new(eventutils.MockEntityRepository)
 	mockEntityRepository.
-		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		On("GetEntity", &entities.Category{}, category.ID).
+		Return(category, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, category.GetParentMenuID()).
 		Return(nil, eventutils.ErrEntityNotFound)
 
 	app := fiber.New()
 	SetupApi(app, mockEntityRepository, "")
 
-	jsonBody := `{"newName": "NewMenuItemName"}`
-	url := fmt.Sprintf("/menuitems/%s/change-name", menuItem.ID)
+	jsonBody := `{"newName": "NewCategoryName"}`
+	url := fmt.Sprintf("/categories/%s/change-name", category.ID)
 	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
 	request.Header.Add("content-type", "application/json")
 	require.NoError(t, err)
@@ -397,6 +554,654 @@ func TestChangeMenuItemName_WhenItemNotFound(t *testing.T) {
 	resp, _ := app.Test(request)
 
 	// Assert
-	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	require.Equal(t, fiber.StatusConflict, resp.StatusCode)
 	mockEntityRepository.AssertExpectations(t)
 }
+
+func TestUploadCategoryPicture(t *testing.T) {
+	// Arrange
+	err := os.MkdirAll("./resources/images/categories", 0755)
+	defer os.RemoveAll("./resources")
+	require.NoError(t, err)
+	menu := entities.NewMenu()
+	category := entities.NewCategory(menu.ID)
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Category{}, category.ID).
+		Return(category, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, category.GetParentMenuID()).
+		Return(entities.NewMenu(), nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "./resources")
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	fw, err := writer.CreateFormFile("image", "test.jpg")
+	require.NoError(t, err)
+	file, err := os.Open("test.jpg")
+	require.NoError(t, err)
+	_, err = io.Copy(fw, file)
+	require.NoError(t, err)
+	writer.Close()
+
+	url := fmt.Sprintf("/categories/%s/upload-image", category.ID)
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body.Bytes()))
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	require.NoError(t, err)
+
+	// Act
+	resp, err := app.Test(request)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+	imageName := fmt.Sprintf("%s.jpg", category.ID)
+	path := filepath.Join("./resources", "images/categories", imageName)
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+}
+
+func TestNewSubCategory(t *testing.T) {
+	// Arrange
+	category := entities.NewCategory(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Category{}, category.ID).
+		Return(category, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, category.GetParentMenuID()).
+		Return(entities.NewMenu(), nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.AnythingOfType("*entities.SubCategory")).
+		Return(nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := fmt.Sprintf(`{"categoryID": "%s"}`, category.ID)
+	url := "/subcategories"
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestNewMenuItem(t *testing.T) {
+	// Arrange
+	subCategory := entities.NewSubCategory(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, subCategory.ID).
+		Return(subCategory, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.Category{}, subCategory.GetParentCategoryID()).
+		Return(entities.NewCategory(utils.GenerateNewUUID()), nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.AnythingOfType("*entities.MenuItem")).
+		Return(nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := fmt.Sprintf(`{"subCategoryID": "%s"}`, subCategory.ID)
+	url := "/menuitems"
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestUploadSubCategoryPicture(t *testing.T) {
+	// Arrange
+	err := os.MkdirAll("./resources/images/subcategories", 0755)
+	defer os.RemoveAll("./resources")
+	require.NoError(t, err)
+	subcategory := entities.NewSubCategory(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, subcategory.ID).
+		Return(subcategory, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.Category{}, subcategory.GetParentCategoryID()).
+		Return(entities.NewCategory(utils.GenerateNewUUID()), nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "./resources")
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	fw, err := writer.CreateFormFile("image", "test.jpg")
+	require.NoError(t, err)
+	file, err := os.Open("test.jpg")
+	require.NoError(t, err)
+	_, err = io.Copy(fw, file)
+	require.NoError(t, err)
+	writer.Close()
+
+	url := fmt.Sprintf("/subcategories/%s/upload-image", subcategory.ID)
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body.Bytes()))
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	require.NoError(t, err)
+
+	// Act
+	resp, err := app.Test(request)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+	imageName := fmt.Sprintf("%s.jpg", subcategory.ID)
+	path := filepath.Join("./resources", "images/subcategories", imageName)
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+}
+
+func TestChangeMenuItemName(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(menuItem, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, menuItem.GetParentSubCategoryID()).
+		Return(entities.NewSubCategory(utils.GenerateNewUUID()), nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.MatchedBy(
+			func(menu *entities.MenuItem) bool {
+				return menu.GetName() == "NewMenuItemName"
+			},
+		)).
+		Return(nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := `{"newName": "NewMenuItemName"}`
+	url := fmt.Sprintf("/menuitems/%s/change-name", menuItem.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestChangeMenuItemName_WhenItemNotFound(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(nil, eventutils.ErrEntityNotFound)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := `{"newName": "NewMenuItemName"}`
+	url := fmt.Sprintf("/menuitems/%s/change-name", menuItem.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestDeleteMenuItem(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(menuItem, nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.MatchedBy(
+			func(menuItem *entities.MenuItem) bool {
+				return menuItem.IsDeleted
+			},
+		)).
+		Return(nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	url := fmt.Sprintf("/menuitems/%s", menuItem.ID)
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestChangeMenuItemPrice(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(menuItem, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, menuItem.GetParentSubCategoryID()).
+		Return(entities.NewSubCategory(utils.GenerateNewUUID()), nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.MatchedBy(
+			func(menuItem *entities.MenuItem) bool {
+				return menuItem.GetPrice() == events.Price{Amount: 1250, Currency: "EUR"}
+			},
+		)).
+		Return(nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := `{"amount": 1250, "currency": "EUR"}`
+	url := fmt.Sprintf("/menuitems/%s/change-price", menuItem.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestChangeMenuItemPrice_WhenPriceIsInvalid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		jsonBody string
+	}{
+		{"missing amount", `{"currency": "EUR"}`},
+		{"negative amount", `{"amount": -1, "currency": "EUR"}`},
+		{"unknown currency", `{"amount": 1250, "currency": "EURO"}`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			// SaveEntity is not expected, the mock fails the test if it is called
+			menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+			mockEntityRepository := new(eventutils.MockEntityRepository)
+			mockEntityRepository.
+				On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+				Return(menuItem, nil)
+			mockEntityRepository.
+				On("GetEntity", &entities.SubCategory{}, menuItem.GetParentSubCategoryID()).
+				Return(entities.NewSubCategory(utils.GenerateNewUUID()), nil)
+
+			app := fiber.New()
+			SetupApi(app, mockEntityRepository, "")
+
+			url := fmt.Sprintf("/menuitems/%s/change-price", menuItem.ID)
+			request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(testCase.jsonBody))
+			request.Header.Add("content-type", "application/json")
+			require.NoError(t, err)
+
+			// Act
+			resp, _ := app.Test(request)
+
+			// Assert
+			require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func TestChangeMenuItemEstimatedPreparationTime(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(menuItem, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, menuItem.GetParentSubCategoryID()).
+		Return(entities.NewSubCategory(utils.GenerateNewUUID()), nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.MatchedBy(
+			func(menuItem *entities.MenuItem) bool {
+				return menuItem.GetEstimatedPreparationtime() == 90*time.Minute
+			},
+		)).
+		Return(nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := `{"estimatedPreparationTime": "1h30m"}`
+	url := fmt.Sprintf("/menuitems/%s/change-estimated-preparation-time", menuItem.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestChangeMenuItemEstimatedPreparationTime_WhenTimeIsInvalid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		jsonBody string
+	}{
+		{"missing time", `{}`},
+		{"not a duration", `{"estimatedPreparationTime": "15"}`},
+		{"too long", `{"estimatedPreparationTime": "48h"}`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+			mockEntityRepository := new(eventutils.MockEntityRepository)
+			mockEntityRepository.
+				On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+				Return(menuItem, nil)
+			mockEntityRepository.
+				On("GetEntity", &entities.SubCategory{}, menuItem.GetParentSubCategoryID()).
+				Return(entities.NewSubCategory(utils.GenerateNewUUID()), nil)
+
+			app := fiber.New()
+			SetupApi(app, mockEntityRepository, "")
+
+			url := fmt.Sprintf("/menuitems/%s/change-estimated-preparation-time", menuItem.ID)
+			request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(testCase.jsonBody))
+			request.Header.Add("content-type", "application/json")
+			require.NoError(t, err)
+
+			// Act
+			resp, _ := app.Test(request)
+
+			// Assert
+			require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func TestChangeMenuItemAllergens(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(menuItem, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, menuItem.GetParentSubCategoryID()).
+		Return(entities.NewSubCategory(utils.GenerateNewUUID()), nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.MatchedBy(
+			func(menuItem *entities.MenuItem) bool {
+				return slices.Equal(menuItem.GetAllergens(), []events.Allergen{events.AllergenGluten, events.AllergenNuts})
+			},
+		)).
+		Return(nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := `{"allergens": ["nuts", "gluten"]}`
+	url := fmt.Sprintf("/menuitems/%s/change-allergens", menuItem.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestChangeMenuItemAllergens_WhenAllergenIsUnknown(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(menuItem, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, menuItem.GetParentSubCategoryID()).
+		Return(entities.NewSubCategory(utils.GenerateNewUUID()), nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := `{"allergens": ["pineapple"]}`
+	url := fmt.Sprintf("/menuitems/%s/change-allergens", menuItem.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestChangeMenuItemDietaryTags(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(menuItem, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, menuItem.GetParentSubCategoryID()).
+		Return(entities.NewSubCategory(utils.GenerateNewUUID()), nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.MatchedBy(
+			func(menuItem *entities.MenuItem) bool {
+				return menuItem.GetDietaryTags() == events.DietaryTags{Vegetarian: true, GlutenFree: true, SpicyLevel: 1}
+			},
+		)).
+		Return(nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := `{"vegetarian": true, "glutenFree": true, "spicyLevel": 1}`
+	url := fmt.Sprintf("/menuitems/%s/change-dietary-tags", menuItem.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestClearMenuItemDescription(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	menuItem.ChangeDescription("TestDescription")
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(menuItem, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, menuItem.GetParentSubCategoryID()).
+		Return(entities.NewSubCategory(utils.GenerateNewUUID()), nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.MatchedBy(
+			func(menuItem *entities.MenuItem) bool {
+				return menuItem.GetDescription() == ""
+			},
+		)).
+		Return(nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	url := fmt.Sprintf("/menuitems/%s/description", menuItem.ID)
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestAddMenuItemVariant(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(menuItem, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, menuItem.GetParentSubCategoryID()).
+		Return(entities.NewSubCategory(utils.GenerateNewUUID()), nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.MatchedBy(
+			func(menuItem *entities.MenuItem) bool {
+				variants := menuItem.GetVariants()
+				return len(variants) == 1 &&
+					variants[0].Name == "Large" &&
+					variants[0].Price == events.Price{Amount: 1450, Currency: "EUR"}
+			},
+		)).
+		Return(nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := `{"name": "Large", "amount": 1450, "currency": "EUR"}`
+	url := fmt.Sprintf("/menuitems/%s/variants", menuItem.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestAddMenuItemModifier_WhenAmountIsMissing(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	modifierGroupID, err := menuItem.AddModifierGroup("Toppings", 0, 3)
+	require.NoError(t, err)
+
+	// GetEntity and SaveEntity are not expected, the mock fails the test if they are called
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := `{"name": "Extra cheese", "currency": "EUR"}`
+	url := fmt.Sprintf("/menuitems/%s/modifier-groups/%s/modifiers", menuItem.ID, modifierGroupID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestRemoveMenuItemModifier_WhenModifierIsNotFound(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	modifierGroupID, err := menuItem.AddModifierGroup("Toppings", 0, 3)
+	require.NoError(t, err)
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(menuItem, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, menuItem.GetParentSubCategoryID()).
+		Return(entities.NewSubCategory(utils.GenerateNewUUID()), nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	url := fmt.Sprintf("/menuitems/%s/modifier-groups/%s/modifiers/%s", menuItem.ID, modifierGroupID, utils.GenerateNewUUID())
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestAddMenuItemModifierGroup_WhenSelectionsAreInvalid(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItem.ID).
+		Return(menuItem, nil)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, menuItem.GetParentSubCategoryID()).
+		Return(entities.NewSubCategory(utils.GenerateNewUUID()), nil)
+
+	app := fiber.New()
+	SetupApi(app, mockEntityRepository, "")
+
+	jsonBody := `{"name": "Toppings", "minSelections": 4, "maxSelections": 3}`
+	url := fmt.Sprintf("/menuitems/%s/modifier-groups", menuItem.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
@@ -1,12 +1,21 @@
 package internal
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	EventStoreConnectionString string `mapstructure:"EVENT_STORE_CONNECTION_STRING"`
-	ResourcePath               string `mapstructure:"RESOURCE_PATH"`
+	EventStoreConnectionString string        `mapstructure:"EVENT_STORE_CONNECTION_STRING"`
+	UseInMemoryEventStore      bool          `mapstructure:"USE_IN_MEMORY_EVENT_STORE"`
+	EventStoreReadBatchSize    uint64        `mapstructure:"EVENT_STORE_READ_BATCH_SIZE"`
+	SnapshotFrequency          uint64        `mapstructure:"SNAPSHOT_FREQUENCY"`
+	LinkMaxAttempts            int           `mapstructure:"LINK_MAX_ATTEMPTS"`
+	LinkInitialBackoff         time.Duration `mapstructure:"LINK_INITIAL_BACKOFF"`
+	LinkMaxBackoff             time.Duration `mapstructure:"LINK_MAX_BACKOFF"`
+	ResourcePath               string        `mapstructure:"RESOURCE_PATH"`
+	AdminListenAddress         string        `mapstructure:"ADMIN_LISTEN_ADDRESS"`
 }
 
 func LoadConfig(path string) (config Config) {
@@ -0,0 +1,292 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Resta-Inc/resta/menu/commands/internal/entities"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCategoryEndToEnd(t *testing.T) {
+	// Arrange
+	eventStore := eventutils.NewInMemoryEventStore()
+	entityRepository := eventutils.NewEntityRepository(eventStore)
+
+	eventHandler := eventutils.NewEventHandlerFromSubscription(
+		eventStore.SubscribeToAll("CategoryCreated", "SubCategoryCreated", "MenuItemCreated"),
+	)
+	menuEventHandler := NewMenuEventHandler(NewLinkProcessManager(entityRepository, testLinkRetryPolicy))
+	eventHandler.HandleEvent("CategoryCreated", menuEventHandler.HandleCategoryCreated)
+	eventHandler.HandleEvent("SubCategoryCreated", menuEventHandler.HandleSubCategoryCreated)
+	eventHandler.HandleEvent("MenuItemCreated", menuEventHandler.HandleMenuItemCreated)
+	eventHandler.Start(context.Background())
+	defer eventHandler.Stop()
+
+	app := fiber.New()
+	SetupApi(app, entityRepository, "")
+
+	menu := entities.NewMenu()
+	err := entityRepository.SaveEntity(context.Background(), menu)
+	require.NoError(t, err)
+
+	jsonBody := fmt.Sprintf(`{"menuID": "%s"}`, menu.ID)
+	request, err := http.NewRequest(http.MethodPost, "/categories", strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.Eventually(t, func() bool {
+		foundMenu, err := entityRepository.GetEntity(&entities.Menu{}, menu.ID)
+		return err == nil && len(foundMenu.(*entities.Menu).GetCategoriesIDs()) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCreateCategoryEndToEnd_PropagatesEventMetadata(t *testing.T) {
+	// Arrange
+	eventStore := eventutils.NewInMemoryEventStore()
+	entityRepository := eventutils.NewEntityRepository(eventStore)
+
+	eventHandler := eventutils.NewEventHandlerFromSubscription(eventStore.SubscribeToAll("CategoryCreated"))
+	menuEventHandler := NewMenuEventHandler(NewLinkProcessManager(entityRepository, testLinkRetryPolicy))
+	eventHandler.HandleEvent("CategoryCreated", menuEventHandler.HandleCategoryCreated)
+	eventHandler.Start(context.Background())
+	defer eventHandler.Stop()
+
+	app := fiber.New()
+	SetupApi(app, entityRepository, "")
+
+	menu := entities.NewMenu()
+	err := entityRepository.SaveEntity(context.Background(), menu)
+	require.NoError(t, err)
+
+	correlationID := utils.GenerateNewUUID()
+	jsonBody := fmt.Sprintf(`{"menuID": "%s"}`, menu.ID)
+	request, err := http.NewRequest(http.MethodPost, "/categories", strings.NewReader(jsonBody))
+	require.NoError(t, err)
+	request.Header.Add("content-type", "application/json")
+	request.Header.Add("X-Correlation-ID", correlationID.String())
+	request.Header.Add("X-User-ID", "user")
+	request.Header.Add("X-Tenant-ID", "tenant")
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.Equal(t, correlationID.String(), resp.Header.Get("X-Correlation-ID"))
+
+	var menuEvents []eventutils.Event
+	require.Eventually(t, func() bool {
+		menuEvents, err = eventStore.GetAllEventsByStreamName("Menu_" + menu.ID.String())
+		return err == nil && len(menuEvents) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	categoryAddedToMenu := menuEvents[1]
+	require.Equal(t, "CategoryAddedToMenu", categoryAddedToMenu.Name)
+	require.Equal(t, correlationID, categoryAddedToMenu.Metadata.CorrelationID)
+	require.Equal(t, "user", categoryAddedToMenu.Metadata.UserID)
+	require.Equal(t, "tenant", categoryAddedToMenu.Metadata.TenantID)
+
+	categoryCreated := eventutils.DeserializeRecordedEvent(eventStore.SubscribeToAll("CategoryCreated").Recv().EventAppeared.Event)
+	require.Equal(t, correlationID, categoryCreated.Metadata.CorrelationID)
+	require.Equal(t, categoryCreated.ID, categoryAddedToMenu.Metadata.CausationID)
+}
+
+func TestDeleteCategoryEndToEnd(t *testing.T) {
+	// Arrange
+	eventStore := eventutils.NewInMemoryEventStore()
+	entityRepository := eventutils.NewEntityRepository(eventStore)
+
+	eventHandler := eventutils.NewEventHandlerFromSubscription(eventStore.SubscribeToAll("CategoryDeleted"))
+	menuEventHandler := NewMenuEventHandler(NewLinkProcessManager(entityRepository, testLinkRetryPolicy))
+	eventHandler.HandleEvent("CategoryDeleted", menuEventHandler.HandleCategoryDeleted)
+	eventHandler.Start(context.Background())
+	defer eventHandler.Stop()
+
+	app := fiber.New()
+	SetupApi(app, entityRepository, "")
+
+	menu := entities.NewMenu()
+	category := entities.NewCategory(menu.ID)
+	menu.AddCategory(category.ID)
+	err := entityRepository.SaveEntity(context.Background(), menu)
+	require.NoError(t, err)
+	err = entityRepository.SaveEntity(context.Background(), category)
+	require.NoError(t, err)
+
+	url := fmt.Sprintf("/categories/%s", category.ID)
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Eventually(t, func() bool {
+		foundMenu, err := entityRepository.GetEntity(&entities.Menu{}, menu.ID)
+		return err == nil && len(foundMenu.(*entities.Menu).GetCategoriesIDs()) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	jsonBody := `{"newName": "NewCategoryName"}`
+	url = fmt.Sprintf("/categories/%s/change-name", category.ID)
+	request, err = http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+	resp, _ = app.Test(request)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestMoveCategoryEndToEnd(t *testing.T) {
+	// Arrange
+	eventStore := eventutils.NewInMemoryEventStore()
+	entityRepository := eventutils.NewEntityRepository(eventStore)
+
+	eventHandler := eventutils.NewEventHandlerFromSubscription(eventStore.SubscribeToAll("CategoryMoved"))
+	menuEventHandler := NewMenuEventHandler(NewLinkProcessManager(entityRepository, testLinkRetryPolicy))
+	eventHandler.HandleEvent("CategoryMoved", menuEventHandler.HandleCategoryMoved)
+	eventHandler.Start(context.Background())
+	defer eventHandler.Stop()
+
+	app := fiber.New()
+	SetupApi(app, entityRepository, "")
+
+	oldMenu, newMenu := entities.NewMenu(), entities.NewMenu()
+	category, otherCategory := entities.NewCategory(oldMenu.ID), entities.NewCategory(newMenu.ID)
+	oldMenu.AddCategory(category.ID)
+	newMenu.AddCategory(otherCategory.ID)
+	for _, entity := range []eventutils.IReconstructible{oldMenu, newMenu, category, otherCategory} {
+		err := entityRepository.SaveEntity(context.Background(), entity)
+		require.NoError(t, err)
+	}
+
+	jsonBody := fmt.Sprintf(`{"menuID": "%s"}`, newMenu.ID)
+	url := fmt.Sprintf("/categories/%s/move", category.ID)
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(jsonBody))
+	request.Header.Add("content-type", "application/json")
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Eventually(t, func() bool {
+		foundMenu, err := entityRepository.GetEntity(&entities.Menu{}, newMenu.ID)
+		return err == nil && len(foundMenu.(*entities.Menu).GetCategoriesIDs()) == 2
+	}, time.Second, 10*time.Millisecond)
+	foundMenu, err := entityRepository.GetEntity(&entities.Menu{}, newMenu.ID)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{otherCategory.ID, category.ID}, foundMenu.(*entities.Menu).GetCategoriesIDs())
+	foundMenu, err = entityRepository.GetEntity(&entities.Menu{}, oldMenu.ID)
+	require.NoError(t, err)
+	require.Empty(t, foundMenu.(*entities.Menu).GetCategoriesIDs())
+}
+
+func TestDuplicateMenuEndToEnd(t *testing.T) {
+	// Arrange
+	eventStore := eventutils.NewInMemoryEventStore()
+	entityRepository := eventutils.NewEntityRepository(eventStore)
+
+	resourcePath := t.TempDir()
+	eventHandler := eventutils.NewEventHandlerFromSubscription(
+		eventStore.SubscribeToAll("CategoryCreated", "SubCategoryCreated", "MenuItemCreated", "MenuDuplicationStarted"),
+	)
+	menuEventHandler := NewMenuEventHandler(NewLinkProcessManager(entityRepository, testLinkRetryPolicy))
+	eventHandler.HandleEvent("CategoryCreated", menuEventHandler.HandleCategoryCreated)
+	eventHandler.HandleEvent("SubCategoryCreated", menuEventHandler.HandleSubCategoryCreated)
+	eventHandler.HandleEvent("MenuItemCreated", menuEventHandler.HandleMenuItemCreated)
+	eventHandler.HandleEvent("MenuDuplicationStarted", NewMenuDuplicator(entityRepository, resourcePath).HandleMenuDuplicationStarted)
+	eventHandler.Start(context.Background())
+	defer eventHandler.Stop()
+
+	app := fiber.New()
+	SetupApi(app, entityRepository, resourcePath)
+
+	menu := entities.NewMenu()
+	starters, mains := entities.NewCategory(menu.ID), entities.NewCategory(menu.ID)
+	starters.ChangeName("Starters")
+	mains.ChangeName("Mains")
+	pizzas := entities.NewSubCategory(mains.ID)
+	margherita := entities.NewMenuItem(pizzas.ID)
+	require.NoError(t, margherita.ChangePrice(850, "EUR"))
+	for _, entity := range []eventutils.IReconstructible{menu, starters, mains, pizzas, margherita} {
+		err := entityRepository.SaveEntity(context.Background(), entity)
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool {
+		foundSubCategory, err := entityRepository.GetEntity(&entities.SubCategory{}, pizzas.ID)
+		return err == nil && len(foundSubCategory.(*entities.SubCategory).GetMenuItemsIDs()) == 1
+	}, time.Second, 10*time.Millisecond)
+	foundMenu, err := entityRepository.GetEntity(&entities.Menu{}, menu.ID)
+	require.NoError(t, err)
+	require.NoError(t, foundMenu.(*entities.Menu).ReorderCategories([]uuid.UUID{mains.ID, starters.ID}))
+	require.NoError(t, entityRepository.SaveEntity(context.Background(), foundMenu))
+	imagesPath := filepath.Join(resourcePath, "images/categories")
+	require.NoError(t, os.MkdirAll(imagesPath, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(imagesPath, fmt.Sprintf("%s.jpg", mains.ID)), []byte("image"), 0o644))
+
+	url := fmt.Sprintf("/menus/%s/duplicate", menu.ID)
+	request, err := http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusAccepted, resp.StatusCode)
+	var duplication MenuDuplicationView
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&duplication))
+	require.Equal(t, fmt.Sprintf("/menu-duplications/%s", duplication.ID), resp.Header.Get("Location"))
+
+	require.Eventually(t, func() bool {
+		request, _ := http.NewRequest(http.MethodGet, resp.Header.Get("Location"), nil)
+		statusResp, err := app.Test(request)
+		if err != nil || statusResp.StatusCode != fiber.StatusOK {
+			return false
+		}
+		var status MenuDuplicationView
+		err = json.NewDecoder(statusResp.Body).Decode(&status)
+		return err == nil && status.Status == entities.MenuDuplicationCompleted
+	}, time.Second, 10*time.Millisecond)
+
+	var newMenu *entities.Menu
+	require.Eventually(t, func() bool {
+		foundMenu, err := entityRepository.GetEntity(&entities.Menu{}, duplication.NewMenuID)
+		if err != nil {
+			return false
+		}
+		newMenu = foundMenu.(*entities.Menu)
+		return len(newMenu.GetCategoriesIDs()) == 2
+	}, time.Second, 10*time.Millisecond)
+	newMains, err := entityRepository.GetEntity(&entities.Category{}, newMenu.GetCategoriesIDs()[0])
+	require.NoError(t, err)
+	require.Equal(t, "Mains", newMains.(*entities.Category).GetName())
+	require.NotEqual(t, mains.ID, newMains.GetID())
+	require.FileExists(t, filepath.Join(imagesPath, fmt.Sprintf("%s.jpg", newMains.GetID())))
+
+	require.Eventually(t, func() bool {
+		foundCategory, err := entityRepository.GetEntity(&entities.Category{}, newMains.GetID())
+		if err != nil || len(foundCategory.(*entities.Category).GetSubCategoriesIDs()) != 1 {
+			return false
+		}
+		foundSubCategory, err := entityRepository.GetEntity(&entities.SubCategory{}, foundCategory.(*entities.Category).GetSubCategoriesIDs()[0])
+		return err == nil && len(foundSubCategory.(*entities.SubCategory).GetMenuItemsIDs()) == 1
+	}, time.Second, 10*time.Millisecond)
+}
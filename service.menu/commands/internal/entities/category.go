@@ -1,8 +1,6 @@
 package entities
 
 import (
-	"encoding/json"
-
 	"github.com/Resta-Inc/resta/pkg/events"
 	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/Resta-Inc/resta/pkg/resources"
@@ -16,13 +14,16 @@ type Category struct {
 }
 type CategoryState struct {
 	Name             string
+	ParentMenuID     uuid.UUID
 	SubCategoriesIDs []uuid.UUID
 }
 
 // Business Logic
 func NewCategory(menuID uuid.UUID) *Category {
-	categoryID := utils.GenerateNewUUID()
+	return newCategory(utils.GenerateNewUUID(), menuID)
+}
 
+func newCategory(categoryID, menuID uuid.UUID) *Category {
 	event := events.CategoryCreated{
 		EventInfo:    eventutils.NewEventInfo(categoryID),
 		Name:         resources.DefaultCategoryName("en"),
@@ -39,10 +40,21 @@ func (category Category) GetName() string {
 	return category.State.Name
 }
 
+func (category Category) GetParentMenuID() uuid.UUID {
+	return category.State.ParentMenuID
+}
+
 func (category Category) GetSubCategoriesIDs() []uuid.UUID {
 	return category.State.SubCategoriesIDs
 }
 
+// Duplicate returns a new category with the ID, of the menu, with the name of the category
+func (category Category) Duplicate(id, menuID uuid.UUID) *Category {
+	duplicate := newCategory(id, menuID)
+	duplicate.ChangeName(category.State.Name)
+	return duplicate
+}
+
 func (category *Category) ChangeName(newName string) {
 	event := events.CategoryNameChanged{
 		EventInfo: eventutils.NewEventInfo(category.ID),
@@ -59,42 +71,96 @@ func (category *Category) AddSubCategory(categoryID uuid.UUID) {
 	eventutils.AddEvent(event, category)
 }
 
-// Events
+func (category *Category) RemoveSubCategory(subCategoryID uuid.UUID) {
+	event := events.SubCategoryRemovedFromCategory{
+		EventInfo:     eventutils.NewEventInfo(category.ID),
+		SubCategoryID: subCategoryID,
+	}
+	eventutils.AddEvent(event, category)
+}
+
+// ReorderSubCategories puts the subcategories of the category in the order of the IDs,
+// which must be a permutation of the current ones
+func (category *Category) ReorderSubCategories(subCategoriesIDs []uuid.UUID) error {
+	err := checkPermutation(category.State.SubCategoriesIDs, subCategoriesIDs)
+	if err != nil {
+		return err
+	}
+	event := events.SubCategoriesReordered{
+		EventInfo:        eventutils.NewEventInfo(category.ID),
+		SubCategoriesIDs: subCategoriesIDs,
+	}
+	eventutils.AddEvent(event, category)
+	return nil
+}
 
-func (category Category) DeserializeEvent(event eventutils.Event) eventutils.IEvent {
-	switch event.Name {
-	case "CategoryCreated":
-		var e events.CategoryCreated
-		json.Unmarshal(event.Data, &e)
-		return e
-	case "CategoryNameChanged":
-		var e events.CategoryNameChanged
-		json.Unmarshal(event.Data, &e)
-		return e
-	case "SubCategoryAddedToCategory":
-		var e events.SubCategoryAddedToCategory
-		json.Unmarshal(event.Data, &e)
-		return e
-	default:
-		return nil
+// MoveToMenu moves the category to another menu, the menus are changed in the background
+func (category *Category) MoveToMenu(menuID uuid.UUID) error {
+	if menuID == category.State.ParentMenuID {
+		return ErrSameParent
+	}
+	event := events.CategoryMoved{
+		EventInfo:       eventutils.NewEventInfo(category.ID),
+		OldParentMenuID: category.State.ParentMenuID,
+		NewParentMenuID: menuID,
 	}
+	eventutils.AddEvent(event, category)
+	return nil
 }
 
-func (category *Category) ApplyEvent(event eventutils.IEvent) {
-	eventType := utils.GetType(event)
-	switch eventType {
-	case "CategoryCreated":
-		applyCategoryCreated(category, event.(events.CategoryCreated))
-	case "CategoryNameChanged":
-		applyCategoryNameChanged(category, event.(events.CategoryNameChanged))
-	case "SubCategoryAddedToCategory":
-		applySubCategoryAddedToCategory(category, event.(events.SubCategoryAddedToCategory))
+func (category *Category) Delete() {
+	event := events.CategoryDeleted{
+		EventInfo:    eventutils.NewEventInfo(category.ID),
+		ParentMenuID: category.State.ParentMenuID,
 	}
+	eventutils.AddEvent(event, category)
+}
+
+// Snapshots
+
+const categorySnapshotVersion = 2
+
+func (category *Category) GetSnapshotState() interface{} {
+	return &category.State
+}
+
+func (category Category) GetSnapshotVersion() int {
+	return categorySnapshotVersion
+}
+
+// Events
+
+var categoryEvents = newCategoryEvents()
+
+func newCategoryEvents() *eventutils.EventRegistry[*Category] {
+	registry := eventutils.NewEventRegistry[*Category]()
+	registry.UseUpcasters(events.Upcasters)
+	eventutils.RegisterEvent(registry, applyCategoryCreated)
+	eventutils.RegisterEvent(registry, applyCategoryNameChanged)
+	eventutils.RegisterEvent(registry, applySubCategoryAddedToCategory)
+	eventutils.RegisterEvent(registry, applySubCategoryRemovedFromCategory)
+	eventutils.RegisterEvent(registry, applySubCategoriesReordered)
+	eventutils.RegisterEvent(registry, applyCategoryMoved)
+	eventutils.RegisterEvent(registry, applyCategoryDeleted)
+	return registry
+}
+
+func (category Category) SerializeEvent(event eventutils.IEvent) (eventutils.Event, error) {
+	return categoryEvents.Serialize(event)
+}
+
+func (category Category) DeserializeEvent(event eventutils.Event) (eventutils.IEvent, error) {
+	return categoryEvents.Deserialize(event)
+}
+
+func (category *Category) ApplyEvent(event eventutils.IEvent) error {
+	return categoryEvents.Apply(category, event)
 }
 
 func applyCategoryCreated(category *Category, event events.CategoryCreated) {
 	category.ID = event.EntityID
 	category.State.Name = event.Name
+	category.State.ParentMenuID = event.ParentMenuID
 }
 
 func applyCategoryNameChanged(category *Category, event events.CategoryNameChanged) {
@@ -104,3 +170,22 @@ func applyCategoryNameChanged(category *Category, event events.CategoryNameChang
 func applySubCategoryAddedToCategory(category *Category, event events.SubCategoryAddedToCategory) {
 	category.State.SubCategoriesIDs = append(category.State.SubCategoriesIDs, event.SubCategoryID)
 }
+
+func applySubCategoryRemovedFromCategory(category *Category, event events.SubCategoryRemovedFromCategory) {
+	i := utils.FindID(category.State.SubCategoriesIDs, event.SubCategoryID)
+	if i != -1 {
+		category.State.SubCategoriesIDs = utils.RemoveID(category.State.SubCategoriesIDs, i)
+	}
+}
+
+func applySubCategoriesReordered(category *Category, event events.SubCategoriesReordered) {
+	category.State.SubCategoriesIDs = append([]uuid.UUID{}, event.SubCategoriesIDs...)
+}
+
+func applyCategoryMoved(category *Category, event events.CategoryMoved) {
+	category.State.ParentMenuID = event.NewParentMenuID
+}
+
+func applyCategoryDeleted(category *Category, event events.CategoryDeleted) {
+	category.SetDeleted()
+}
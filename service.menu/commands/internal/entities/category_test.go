@@ -7,6 +7,7 @@ import (
 	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/Resta-Inc/resta/pkg/resources"
 	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -55,6 +56,84 @@ func Test_AddSubCategory(t *testing.T) {
 	require.IsType(t, events.SubCategoryAddedToCategory{}, latestEvent)
 }
 
+func Test_RemoveSubCategory(t *testing.T) {
+	// Arrange
+	subCategoryID := utils.GenerateNewUUID()
+	otherSubCategoryID := utils.GenerateNewUUID()
+	category := NewCategory(utils.GenerateNewUUID())
+	category.AddSubCategory(subCategoryID)
+	category.AddSubCategory(otherSubCategoryID)
+
+	// Act
+	category.RemoveSubCategory(subCategoryID)
+
+	// Assert
+	latestEvent := category.Events[len(category.Events)-1]
+	require.Equal(t, []uuid.UUID{otherSubCategoryID}, category.GetSubCategoriesIDs())
+	require.IsType(t, events.SubCategoryRemovedFromCategory{}, latestEvent)
+}
+
+func Test_ReorderSubCategories(t *testing.T) {
+	// Arrange
+	subCategoryID1, subCategoryID2 := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+	category := NewCategory(utils.GenerateNewUUID())
+	category.AddSubCategory(subCategoryID1)
+	category.AddSubCategory(subCategoryID2)
+
+	// Act
+	err := category.ReorderSubCategories([]uuid.UUID{subCategoryID2, subCategoryID1})
+
+	// Assert
+	require.NoError(t, err)
+	latestEvent := category.Events[len(category.Events)-1]
+	require.Equal(t, []uuid.UUID{subCategoryID2, subCategoryID1}, category.GetSubCategoriesIDs())
+	require.IsType(t, events.SubCategoriesReordered{}, latestEvent)
+}
+
+func TestDeleteCategory(t *testing.T) {
+	// Arrange
+	menuID := utils.GenerateNewUUID()
+	category := NewCategory(menuID)
+
+	// Act
+	category.Delete()
+
+	// Assert
+	latestEvent := category.Events[len(category.Events)-1]
+	require.True(t, category.IsDeleted)
+	require.IsType(t, events.CategoryDeleted{}, latestEvent)
+	require.Equal(t, menuID, latestEvent.(events.CategoryDeleted).ParentMenuID)
+}
+
+func TestMoveCategoryToMenu(t *testing.T) {
+	// Arrange
+	oldMenuID, newMenuID := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+	category := NewCategory(oldMenuID)
+
+	// Act
+	err := category.MoveToMenu(newMenuID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, newMenuID, category.GetParentMenuID())
+	latestEvent := category.Events[len(category.Events)-1].(events.CategoryMoved)
+	require.Equal(t, oldMenuID, latestEvent.OldParentMenuID)
+	require.Equal(t, newMenuID, latestEvent.NewParentMenuID)
+}
+
+func TestMoveCategoryToMenu_WhenMenuIsTheCurrentOne(t *testing.T) {
+	// Arrange
+	menuID := utils.GenerateNewUUID()
+	category := NewCategory(menuID)
+
+	// Act
+	err := category.MoveToMenu(menuID)
+
+	// Assert
+	require.ErrorIs(t, err, ErrSameParent)
+	require.Len(t, category.Events, 1)
+}
+
 func Test_DeserializeCategoryEvent(t *testing.T) {
 	// Arrange
 	events := []eventutils.IEvent{
@@ -67,15 +146,32 @@ func Test_DeserializeCategoryEvent(t *testing.T) {
 		events.SubCategoryAddedToCategory{
 			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
 		},
+		events.SubCategoryRemovedFromCategory{
+			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
+		},
+		events.SubCategoriesReordered{
+			EventInfo:        eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			SubCategoriesIDs: []uuid.UUID{utils.GenerateNewUUID()},
+		},
+		events.CategoryDeleted{
+			EventInfo:    eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			ParentMenuID: utils.GenerateNewUUID(),
+		},
+		events.CategoryMoved{
+			EventInfo:       eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			OldParentMenuID: utils.GenerateNewUUID(),
+			NewParentMenuID: utils.GenerateNewUUID(),
+		},
 	}
 
 	for _, event := range events {
 		serialized := eventutils.SerializedEvent(event)
 
 		// Act
-		deserialized := Category{}.DeserializeEvent(serialized)
+		deserialized, err := Category{}.DeserializeEvent(serialized)
 
 		// Assert
+		require.NoError(t, err)
 		require.Equal(t, event, deserialized)
 	}
 }
@@ -0,0 +1,73 @@
+package entities
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// historicStream is a stream as it was written by previous versions of the service,
+// with the state the entity must have once it is rebuilt from it
+type historicStream struct {
+	Events []struct {
+		EventType string          `json:"eventType"`
+		Data      json.RawMessage `json:"data"`
+		Metadata  json.RawMessage `json:"metadata"`
+	} `json:"events"`
+	State json.RawMessage `json:"state"`
+}
+
+func Test_RebuildFromHistoricStreams(t *testing.T) {
+	testCases := []struct {
+		fixture       string
+		entity        eventutils.ISnapshottable
+		expectedState interface{}
+	}{
+		{"menu_v1.json", &Menu{}, &MenuState{}},
+		{"category_v1.json", &Category{}, &CategoryState{}},
+		{"menuitem_v1.json", &MenuItem{}, &MenuItemState{}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.fixture, func(t *testing.T) {
+			// Arrange
+			stream := loadHistoricStream(t, testCase.fixture)
+			err := json.Unmarshal(stream.State, testCase.expectedState)
+			require.NoError(t, err)
+
+			storedEvents := []eventutils.Event{}
+			for i, storedEvent := range stream.Events {
+				storedEvents = append(storedEvents, eventutils.DeserializeRecordedEvent(&esdb.RecordedEvent{
+					EventID:      utils.GenerateNewUUID(),
+					EventType:    storedEvent.EventType,
+					EventNumber:  uint64(i),
+					Data:         storedEvent.Data,
+					UserMetadata: storedEvent.Metadata,
+				}))
+			}
+
+			// Act
+			err = eventutils.ReconstructFromEvents(testCase.entity, storedEvents)
+
+			// Assert
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedState, testCase.entity.GetSnapshotState())
+			require.Equal(t, uint64(len(storedEvents)-1), testCase.entity.GetRevision())
+		})
+	}
+}
+
+func loadHistoricStream(t *testing.T, fixture string) historicStream {
+	data, err := os.ReadFile(filepath.Join("testdata", fixture))
+	require.NoError(t, err)
+	var stream historicStream
+	err = json.Unmarshal(data, &stream)
+	require.NoError(t, err)
+	return stream
+}
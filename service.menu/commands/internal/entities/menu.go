@@ -1,7 +1,7 @@
 package entities
 
 import (
-	"encoding/json"
+	"errors"
 
 	"github.com/Resta-Inc/resta/pkg/events"
 	"github.com/Resta-Inc/resta/pkg/eventutils"
@@ -20,12 +20,18 @@ type MenuState struct {
 	Name          string
 	IsEnabled     bool
 	CategoriesIDs []uuid.UUID
+	// LatestVersion is the number of the last published version, 0 if the menu was never published
+	LatestVersion int
+	// PublishedVersion is the version guests see, the latest one unless an earlier one was restored
+	PublishedVersion int
 }
 
 // Business Logic
 func NewMenu() *Menu {
-	menuID := utils.GenerateNewUUID()
+	return newMenu(utils.GenerateNewUUID())
+}
 
+func newMenu(menuID uuid.UUID) *Menu {
 	event := events.MenuCreated{
 		EventInfo: eventutils.NewEventInfo(menuID),
 		Name:      resources.DefaultMenuName("en"),
@@ -49,6 +55,22 @@ func (menu Menu) GetCategoriesIDs() []uuid.UUID {
 	return menu.State.CategoriesIDs
 }
 
+func (menu Menu) GetLatestVersion() int {
+	return menu.State.LatestVersion
+}
+
+func (menu Menu) GetPublishedVersion() int {
+	return menu.State.PublishedVersion
+}
+
+// Duplicate returns a new menu with the ID and the name of the menu. Like a new menu it is disabled,
+// and its categories are added to it in the background as their duplicates are created
+func (menu Menu) Duplicate(id uuid.UUID) *Menu {
+	duplicate := newMenu(id)
+	duplicate.ChangeName(menu.State.Name)
+	return duplicate
+}
+
 func (menu *Menu) Enable() {
 	event := events.MenuEnabled{
 		EventInfo: eventutils.NewEventInfo(menu.ID),
@@ -79,48 +101,107 @@ func (menu *Menu) AddCategory(categoryID uuid.UUID) {
 	eventutils.AddEvent(event, menu)
 }
 
-// Events
+func (menu *Menu) RemoveCategory(categoryID uuid.UUID) {
+	event := events.CategoryRemovedFromMenu{
+		EventInfo:  eventutils.NewEventInfo(menu.ID),
+		CategoryID: categoryID,
+	}
+	eventutils.AddEvent(event, menu)
+}
 
-func (menu Menu) DeserializeEvent(event eventutils.Event) eventutils.IEvent {
-	switch event.Name {
-	case "MenuCreated":
-		var e events.MenuCreated
-		json.Unmarshal(event.Data, &e)
-		return e
-	case "MenuEnabled":
-		var e events.MenuEnabled
-		json.Unmarshal(event.Data, &e)
-		return e
-	case "MenuDisabled":
-		var e events.MenuDisabled
-		json.Unmarshal(event.Data, &e)
-		return e
-	case "MenuNameChanged":
-		var e events.MenuNameChanged
-		json.Unmarshal(event.Data, &e)
-		return e
-	case "CategoryAddedToMenu":
-		var e events.CategoryAddedToMenu
-		json.Unmarshal(event.Data, &e)
-		return e
+// ReorderCategories puts the categories of the menu in the order of the IDs,
+// which must be a permutation of the current ones
+func (menu *Menu) ReorderCategories(categoriesIDs []uuid.UUID) error {
+	err := checkPermutation(menu.State.CategoriesIDs, categoriesIDs)
+	if err != nil {
+		return err
+	}
+	event := events.CategoriesReordered{
+		EventInfo:     eventutils.NewEventInfo(menu.ID),
+		CategoriesIDs: categoriesIDs,
 	}
+	eventutils.AddEvent(event, menu)
 	return nil
 }
 
-func (menu *Menu) ApplyEvent(event eventutils.IEvent) {
-	eventType := utils.GetType(event)
-	switch eventType {
-	case "MenuCreated":
-		applyMenuCreated(menu, event.(events.MenuCreated))
-	case "MenuEnabled":
-		applyMenuEnabled(menu)
-	case "MenuDisabled":
-		applyMenuDisabled(menu)
-	case "MenuNameChanged":
-		applyMenuNameChanged(menu, event.(events.MenuNameChanged))
-	case "CategoryAddedToMenu":
-		applyCategoryAddedToMenu(menu, event.(events.CategoryAddedToMenu))
+// Publish makes the draft of the menu, with the categories read from its descendants, a new version
+// that guests see. It returns the number of the version, which is never given to another one.
+func (menu *Menu) Publish(categories []events.PublishedCategory) int {
+	event := events.MenuPublished{
+		EventInfo:  eventutils.NewEventInfo(menu.ID),
+		Version:    menu.State.LatestVersion + 1,
+		Name:       menu.State.Name,
+		Categories: categories,
 	}
+	eventutils.AddEvent(event, menu)
+	return event.Version
+}
+
+// RestoreVersion makes an earlier version the one guests see again, the draft is not changed
+func (menu *Menu) RestoreVersion(version int) error {
+	if version < 1 || version > menu.State.LatestVersion {
+		return ErrVersionNotFound
+	}
+	if version == menu.State.PublishedVersion {
+		return ErrVersionAlreadyPublished
+	}
+	event := events.MenuVersionRestored{
+		EventInfo: eventutils.NewEventInfo(menu.ID),
+		Version:   version,
+	}
+	eventutils.AddEvent(event, menu)
+	return nil
+}
+
+func (menu *Menu) Delete() {
+	event := events.MenuDeleted{
+		EventInfo: eventutils.NewEventInfo(menu.ID),
+	}
+	eventutils.AddEvent(event, menu)
+}
+
+// Snapshots
+
+const menuSnapshotVersion = 1
+
+func (menu *Menu) GetSnapshotState() interface{} {
+	return &menu.State
+}
+
+func (menu Menu) GetSnapshotVersion() int {
+	return menuSnapshotVersion
+}
+
+// Events
+
+var menuEvents = newMenuEvents()
+
+func newMenuEvents() *eventutils.EventRegistry[*Menu] {
+	registry := eventutils.NewEventRegistry[*Menu]()
+	registry.UseUpcasters(events.Upcasters)
+	eventutils.RegisterEvent(registry, applyMenuCreated)
+	eventutils.RegisterEvent(registry, applyMenuEnabled)
+	eventutils.RegisterEvent(registry, applyMenuDisabled)
+	eventutils.RegisterEvent(registry, applyMenuNameChanged)
+	eventutils.RegisterEvent(registry, applyCategoryAddedToMenu)
+	eventutils.RegisterEvent(registry, applyCategoryRemovedFromMenu)
+	eventutils.RegisterEvent(registry, applyCategoriesReordered)
+	eventutils.RegisterEvent(registry, applyMenuPublished)
+	eventutils.RegisterEvent(registry, applyMenuVersionRestored)
+	eventutils.RegisterEvent(registry, applyMenuDeleted)
+	return registry
+}
+
+func (menu Menu) SerializeEvent(event eventutils.IEvent) (eventutils.Event, error) {
+	return menuEvents.Serialize(event)
+}
+
+func (menu Menu) DeserializeEvent(event eventutils.Event) (eventutils.IEvent, error) {
+	return menuEvents.Deserialize(event)
+}
+
+func (menu *Menu) ApplyEvent(event eventutils.IEvent) error {
+	return menuEvents.Apply(menu, event)
 }
 
 func applyMenuCreated(menu *Menu, e events.MenuCreated) {
@@ -128,11 +209,11 @@ func applyMenuCreated(menu *Menu, e events.MenuCreated) {
 	menu.ID = e.EntityID
 }
 
-func applyMenuEnabled(menu *Menu) {
+func applyMenuEnabled(menu *Menu, event events.MenuEnabled) {
 	menu.State.IsEnabled = true
 }
 
-func applyMenuDisabled(menu *Menu) {
+func applyMenuDisabled(menu *Menu, event events.MenuDisabled) {
 	menu.State.IsEnabled = false
 }
 
@@ -143,3 +224,34 @@ func applyMenuNameChanged(menu *Menu, event events.MenuNameChanged) {
 func applyCategoryAddedToMenu(menu *Menu, event events.CategoryAddedToMenu) {
 	menu.State.CategoriesIDs = append(menu.State.CategoriesIDs, event.CategoryID)
 }
+
+func applyCategoryRemovedFromMenu(menu *Menu, event events.CategoryRemovedFromMenu) {
+	i := utils.FindID(menu.State.CategoriesIDs, event.CategoryID)
+	if i != -1 {
+		menu.State.CategoriesIDs = utils.RemoveID(menu.State.CategoriesIDs, i)
+	}
+}
+
+func applyCategoriesReordered(menu *Menu, event events.CategoriesReordered) {
+	menu.State.CategoriesIDs = append([]uuid.UUID{}, event.CategoriesIDs...)
+}
+
+func applyMenuPublished(menu *Menu, event events.MenuPublished) {
+	menu.State.LatestVersion = event.Version
+	menu.State.PublishedVersion = event.Version
+}
+
+func applyMenuVersionRestored(menu *Menu, event events.MenuVersionRestored) {
+	menu.State.PublishedVersion = event.Version
+}
+
+func applyMenuDeleted(menu *Menu, event events.MenuDeleted) {
+	menu.SetDeleted()
+}
+
+// Errors
+
+var (
+	ErrVersionNotFound         = errors.New("the menu has no such version")
+	ErrVersionAlreadyPublished = errors.New("the version is already the published one")
+)
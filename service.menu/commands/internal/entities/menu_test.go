@@ -1,12 +1,15 @@
 package entities
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/Resta-Inc/resta/pkg/events"
 	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/Resta-Inc/resta/pkg/resources"
 	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -80,6 +83,147 @@ func Test_AddCategory(t *testing.T) {
 	require.IsType(t, events.CategoryAddedToMenu{}, latestEvent)
 }
 
+func Test_RemoveCategory(t *testing.T) {
+	// Arrange
+	categoryID := utils.GenerateNewUUID()
+	otherCategoryID := utils.GenerateNewUUID()
+	menu := NewMenu()
+	menu.AddCategory(categoryID)
+	menu.AddCategory(otherCategoryID)
+
+	// Act
+	menu.RemoveCategory(categoryID)
+
+	// Assert
+	latestEvent := menu.Events[len(menu.Events)-1]
+	require.Equal(t, []uuid.UUID{otherCategoryID}, menu.GetCategoriesIDs())
+	require.IsType(t, events.CategoryRemovedFromMenu{}, latestEvent)
+}
+
+func Test_ReorderCategories(t *testing.T) {
+	// Arrange
+	categoryID1, categoryID2, categoryID3 := utils.GenerateNewUUID(), utils.GenerateNewUUID(), utils.GenerateNewUUID()
+	menu := NewMenu()
+	menu.AddCategory(categoryID1)
+	menu.AddCategory(categoryID2)
+	menu.AddCategory(categoryID3)
+
+	// Act
+	err := menu.ReorderCategories([]uuid.UUID{categoryID3, categoryID1, categoryID2})
+
+	// Assert
+	require.NoError(t, err)
+	latestEvent := menu.Events[len(menu.Events)-1]
+	require.Equal(t, []uuid.UUID{categoryID3, categoryID1, categoryID2}, menu.GetCategoriesIDs())
+	require.IsType(t, events.CategoriesReordered{}, latestEvent)
+}
+
+func Test_ReorderCategories_WhenNotAPermutation(t *testing.T) {
+	// Arrange
+	categoryID1, categoryID2 := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+	menu := NewMenu()
+	menu.AddCategory(categoryID1)
+	menu.AddCategory(categoryID2)
+
+	// Act
+	err := menu.ReorderCategories([]uuid.UUID{categoryID2, utils.GenerateNewUUID()})
+
+	// Assert
+	require.ErrorIs(t, err, ErrNotAPermutation)
+	require.Equal(t, []uuid.UUID{categoryID1, categoryID2}, menu.GetCategoriesIDs())
+	require.Len(t, menu.Events, 3)
+}
+
+func Test_DeleteMenu(t *testing.T) {
+	// Arrange
+	menu := NewMenu()
+
+	// Act
+	menu.Delete()
+
+	// Assert
+	latestEvent := menu.Events[len(menu.Events)-1]
+	require.True(t, menu.IsDeleted)
+	require.IsType(t, events.MenuDeleted{}, latestEvent)
+}
+
+func Test_DuplicateMenu(t *testing.T) {
+	// Arrange
+	menu := NewMenu()
+	menu.ChangeName("Summer")
+	menu.Enable()
+	menu.AddCategory(utils.GenerateNewUUID())
+	duplicateID := utils.GenerateNewUUID()
+
+	// Act
+	duplicate := menu.Duplicate(duplicateID)
+
+	// Assert
+	require.Equal(t, duplicateID, duplicate.ID)
+	require.Equal(t, "Summer", duplicate.GetName())
+	require.False(t, duplicate.IsEnabled())
+	require.Empty(t, duplicate.GetCategoriesIDs())
+}
+
+func Test_PublishMenu(t *testing.T) {
+	// Arrange
+	menu := NewMenu()
+	categories := []events.PublishedCategory{{ID: utils.GenerateNewUUID(), Name: "Starters"}}
+
+	// Act
+	firstVersion := menu.Publish(categories)
+	secondVersion := menu.Publish(categories)
+
+	// Assert
+	require.Equal(t, 1, firstVersion)
+	require.Equal(t, 2, secondVersion)
+	require.Equal(t, 2, menu.GetLatestVersion())
+	require.Equal(t, 2, menu.GetPublishedVersion())
+	latestEvent := menu.Events[len(menu.Events)-1].(events.MenuPublished)
+	require.Equal(t, categories, latestEvent.Categories)
+	require.Equal(t, menu.GetName(), latestEvent.Name)
+}
+
+func Test_RestoreMenuVersion(t *testing.T) {
+	// Arrange
+	menu := NewMenu()
+	menu.Publish(nil)
+	menu.Publish(nil)
+
+	// Act
+	err := menu.RestoreVersion(1)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, 1, menu.GetPublishedVersion())
+	require.Equal(t, 2, menu.GetLatestVersion())
+	require.IsType(t, events.MenuVersionRestored{}, menu.Events[len(menu.Events)-1])
+}
+
+func Test_RestoreMenuVersion_WhenVersionIsNotValid(t *testing.T) {
+	// Arrange
+	menu := NewMenu()
+	menu.Publish(nil)
+
+	testCases := []struct {
+		version     int
+		expectedErr error
+	}{
+		{0, ErrVersionNotFound},
+		{2, ErrVersionNotFound},
+		{1, ErrVersionAlreadyPublished},
+	}
+
+	for _, testCase := range testCases {
+		// Act
+		err := menu.RestoreVersion(testCase.version)
+
+		// Assert
+		require.ErrorIs(t, err, testCase.expectedErr)
+	}
+	require.Len(t, menu.Events, 2)
+}
+
 func Test_DeserializeMenuEvent(t *testing.T) {
 	// Arrange
 	events := []eventutils.IEvent{
@@ -98,15 +242,73 @@ func Test_DeserializeMenuEvent(t *testing.T) {
 		events.CategoryAddedToMenu{
 			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
 		},
+		events.CategoryRemovedFromMenu{
+			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
+		},
+		events.CategoriesReordered{
+			EventInfo:     eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			CategoriesIDs: []uuid.UUID{utils.GenerateNewUUID()},
+		},
+		events.MenuDeleted{
+			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
+		},
+		events.MenuPublished{
+			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			Version:   1,
+			Name:      "Lunch",
+			Categories: []events.PublishedCategory{{
+				ID:   utils.GenerateNewUUID(),
+				Name: "Starters",
+				SubCategories: []events.PublishedSubCategory{{
+					ID:   utils.GenerateNewUUID(),
+					Name: "Soups",
+					MenuItems: []events.PublishedMenuItem{{
+						ID:                       utils.GenerateNewUUID(),
+						Name:                     "Minestrone",
+						Price:                    events.Price{Amount: 750, Currency: "EUR"},
+						EstimatedPreparationTime: 10 * time.Minute,
+						Allergens:                []events.Allergen{events.AllergenCelery},
+						Variants:                 []events.PublishedVariant{},
+						ModifierGroups:           []events.PublishedModifierGroup{},
+					}},
+				}},
+			}},
+		},
+		events.MenuVersionRestored{
+			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			Version:   1,
+		},
 	}
 
 	for _, event := range events {
 		serialized := eventutils.SerializedEvent(event)
 
 		// Act
-		deserialized := NewMenu().DeserializeEvent(serialized)
+		deserialized, err := NewMenu().DeserializeEvent(serialized)
 
 		// Assert
+		require.NoError(t, err)
 		require.Equal(t, event, deserialized)
 	}
 }
+
+func Test_GetMenuFromSnapshot(t *testing.T) {
+	// Arrange
+	eventStore := eventutils.NewInMemoryEventStore()
+	repo := eventutils.NewEntityRepositoryWithSnapshots(eventStore, eventStore, 2)
+	menu := NewMenu()
+	menu.ChangeName("NewName")
+	menu.Enable()
+	menu.AddCategory(utils.GenerateNewUUID())
+	err := repo.SaveEntity(context.Background(), menu)
+	require.NoError(t, err)
+
+	// Act
+	foundMenu, err := repo.GetEntity(&Menu{}, menu.ID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, menu.ID, foundMenu.GetID())
+	require.Equal(t, menu.State, foundMenu.(*Menu).State)
+	require.Equal(t, uint64(3), foundMenu.GetRevision())
+}
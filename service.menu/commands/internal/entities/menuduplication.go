@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/Resta-Inc/resta/pkg/events"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
+)
+
+type MenuDuplicationStatus string
+
+const (
+	MenuDuplicationRunning   MenuDuplicationStatus = "running"
+	MenuDuplicationCompleted MenuDuplicationStatus = "completed"
+	MenuDuplicationFailed    MenuDuplicationStatus = "failed"
+)
+
+// Models
+
+// MenuDuplication tracks the copy of a menu into a new one, which spans the streams
+// of the menu and of all its descendants and so runs in the background
+type MenuDuplication struct {
+	eventutils.Entity
+	State MenuDuplicationState
+}
+
+type MenuDuplicationState struct {
+	SourceMenuID uuid.UUID
+	NewMenuID    uuid.UUID
+	Status       MenuDuplicationStatus
+	Error        string
+	StartedAt    time.Time
+	FinishedAt   time.Time
+}
+
+// Business Logic
+func NewMenuDuplication(sourceMenuID, newMenuID uuid.UUID) *MenuDuplication {
+	event := events.MenuDuplicationStarted{
+		EventInfo:    eventutils.NewEventInfo(utils.GenerateNewUUID()),
+		SourceMenuID: sourceMenuID,
+		NewMenuID:    newMenuID,
+	}
+
+	duplication := &MenuDuplication{}
+	duplication.SetNew()
+	eventutils.AddEvent(event, duplication)
+	return duplication
+}
+
+func (duplication MenuDuplication) GetSourceMenuID() uuid.UUID {
+	return duplication.State.SourceMenuID
+}
+
+func (duplication MenuDuplication) GetNewMenuID() uuid.UUID {
+	return duplication.State.NewMenuID
+}
+
+func (duplication MenuDuplication) GetStatus() MenuDuplicationStatus {
+	return duplication.State.Status
+}
+
+func (duplication *MenuDuplication) Complete() {
+	event := events.MenuDuplicationCompleted{
+		EventInfo: eventutils.NewEventInfo(duplication.ID),
+	}
+	eventutils.AddEvent(event, duplication)
+}
+
+func (duplication *MenuDuplication) Fail(err error) {
+	event := events.MenuDuplicationFailed{
+		EventInfo: eventutils.NewEventInfo(duplication.ID),
+		Error:     err.Error(),
+	}
+	eventutils.AddEvent(event, duplication)
+}
+
+// Events
+
+var menuDuplicationEvents = newMenuDuplicationEvents()
+
+func newMenuDuplicationEvents() *eventutils.EventRegistry[*MenuDuplication] {
+	registry := eventutils.NewEventRegistry[*MenuDuplication]()
+	registry.UseUpcasters(events.Upcasters)
+	eventutils.RegisterEvent(registry, applyMenuDuplicationStarted)
+	eventutils.RegisterEvent(registry, applyMenuDuplicationCompleted)
+	eventutils.RegisterEvent(registry, applyMenuDuplicationFailed)
+	return registry
+}
+
+func (duplication MenuDuplication) SerializeEvent(event eventutils.IEvent) (eventutils.Event, error) {
+	return menuDuplicationEvents.Serialize(event)
+}
+
+func (duplication MenuDuplication) DeserializeEvent(event eventutils.Event) (eventutils.IEvent, error) {
+	return menuDuplicationEvents.Deserialize(event)
+}
+
+func (duplication *MenuDuplication) ApplyEvent(event eventutils.IEvent) error {
+	return menuDuplicationEvents.Apply(duplication, event)
+}
+
+func applyMenuDuplicationStarted(duplication *MenuDuplication, event events.MenuDuplicationStarted) {
+	duplication.ID = event.EntityID
+	duplication.State.SourceMenuID = event.SourceMenuID
+	duplication.State.NewMenuID = event.NewMenuID
+	duplication.State.Status = MenuDuplicationRunning
+	duplication.State.StartedAt = event.CreatedAt
+}
+
+func applyMenuDuplicationCompleted(duplication *MenuDuplication, event events.MenuDuplicationCompleted) {
+	duplication.State.Status = MenuDuplicationCompleted
+	duplication.State.FinishedAt = event.CreatedAt
+}
+
+func applyMenuDuplicationFailed(duplication *MenuDuplication, event events.MenuDuplicationFailed) {
+	duplication.State.Status = MenuDuplicationFailed
+	duplication.State.Error = event.Error
+	duplication.State.FinishedAt = event.CreatedAt
+}
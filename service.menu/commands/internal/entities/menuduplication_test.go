@@ -0,0 +1,71 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Resta-Inc/resta/pkg/events"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMenuDuplication(t *testing.T) {
+	// Arrange
+	sourceMenuID, newMenuID := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+
+	// Act
+	duplication := NewMenuDuplication(sourceMenuID, newMenuID)
+
+	// Assert
+	require.Equal(t, sourceMenuID, duplication.GetSourceMenuID())
+	require.Equal(t, newMenuID, duplication.GetNewMenuID())
+	require.Equal(t, MenuDuplicationRunning, duplication.GetStatus())
+}
+
+func TestFailMenuDuplication(t *testing.T) {
+	// Arrange
+	duplication := NewMenuDuplication(utils.GenerateNewUUID(), utils.GenerateNewUUID())
+
+	// Act
+	duplication.Fail(errors.New("the event store is not reachable"))
+
+	// Assert
+	require.Equal(t, MenuDuplicationFailed, duplication.GetStatus())
+	require.Equal(t, "the event store is not reachable", duplication.State.Error)
+}
+
+func Test_DeserializeMenuDuplicationEvent(t *testing.T) {
+	// Arrange
+	// a time encoded in UTC is decoded in UTC, whatever the local time zone is
+	newEventInfo := func() eventutils.EventInfo {
+		eventInfo := eventutils.NewEventInfo(utils.GenerateNewUUID())
+		eventInfo.CreatedAt = eventInfo.CreatedAt.UTC()
+		return eventInfo
+	}
+	events := []eventutils.IEvent{
+		events.MenuDuplicationStarted{
+			EventInfo:    newEventInfo(),
+			SourceMenuID: utils.GenerateNewUUID(),
+			NewMenuID:    utils.GenerateNewUUID(),
+		},
+		events.MenuDuplicationCompleted{
+			EventInfo: newEventInfo(),
+		},
+		events.MenuDuplicationFailed{
+			EventInfo: newEventInfo(),
+			Error:     "error",
+		},
+	}
+
+	for _, event := range events {
+		serialized := eventutils.SerializedEvent(event)
+
+		// Act
+		deserialized, err := MenuDuplication{}.DeserializeEvent(serialized)
+
+		// Assert
+		require.NoError(t, err)
+		require.Equal(t, event, deserialized)
+	}
+}
@@ -1,14 +1,24 @@
 package entities
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Resta-Inc/resta/pkg/events"
 	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/Resta-Inc/resta/pkg/resources"
 	"github.com/Resta-Inc/resta/pkg/utils"
 	"github.com/gofrs/uuid"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	MinEstimatedPreparationTime = time.Minute
+	MaxEstimatedPreparationTime = 24 * time.Hour
+	MaxDescriptionLength        = 2000
 )
 
 type MenuItem struct {
@@ -17,15 +27,24 @@ type MenuItem struct {
 }
 type MenuItemState struct {
 	Name                     string
+	ParentSubCategoryID      uuid.UUID
 	EstimatedPreparationTime time.Duration
+	Price                    events.Price
+	Description              string
+	Allergens                []events.Allergen
+	DietaryTags              events.DietaryTags
+	Variants                 []Variant
+	ModifierGroups           []ModifierGroup
 }
 
 // Business Logic
 func NewMenuItem(subCategoryID uuid.UUID) *MenuItem {
-	categoryID := utils.GenerateNewUUID()
+	return newMenuItem(utils.GenerateNewUUID(), subCategoryID)
+}
 
+func newMenuItem(menuItemID, subCategoryID uuid.UUID) *MenuItem {
 	event := events.MenuItemCreated{
-		EventInfo:           eventutils.NewEventInfo(categoryID),
+		EventInfo:           eventutils.NewEventInfo(menuItemID),
 		Name:                resources.DefaultMenuItemName("en"),
 		ParentSubCategoryID: subCategoryID,
 	}
@@ -40,10 +59,86 @@ func (menuItem MenuItem) GetName() string {
 	return menuItem.State.Name
 }
 
+func (menuItem MenuItem) GetParentSubCategoryID() uuid.UUID {
+	return menuItem.State.ParentSubCategoryID
+}
+
 func (menuItem MenuItem) GetEstimatedPreparationtime() time.Duration {
 	return menuItem.State.EstimatedPreparationTime
 }
 
+func (menuItem MenuItem) GetPrice() events.Price {
+	return menuItem.State.Price
+}
+
+func (menuItem MenuItem) GetDescription() string {
+	return menuItem.State.Description
+}
+
+func (menuItem MenuItem) GetAllergens() []events.Allergen {
+	return menuItem.State.Allergens
+}
+
+func (menuItem MenuItem) GetDietaryTags() events.DietaryTags {
+	return menuItem.State.DietaryTags
+}
+
+// Duplicate returns a new menu item with the ID, of the subcategory, with the details, prices, variants and modifiers
+// of the menu item. The variants, modifier groups and modifiers of the duplicate have new IDs.
+func (menuItem MenuItem) Duplicate(id, subCategoryID uuid.UUID) (*MenuItem, error) {
+	duplicate := newMenuItem(id, subCategoryID)
+	duplicate.ChangeName(menuItem.State.Name)
+	if menuItem.State.EstimatedPreparationTime != 0 {
+		err := duplicate.ChangeEstimatedPreparationTime(menuItem.State.EstimatedPreparationTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if menuItem.State.Price.Currency != "" {
+		err := duplicate.ChangePrice(menuItem.State.Price.Amount, menuItem.State.Price.Currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if menuItem.State.Description != "" {
+		err := duplicate.ChangeDescription(menuItem.State.Description)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(menuItem.State.Allergens) > 0 {
+		err := duplicate.ChangeAllergens(menuItem.State.Allergens)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if menuItem.State.DietaryTags != (events.DietaryTags{}) {
+		err := duplicate.ChangeDietaryTags(menuItem.State.DietaryTags)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, variant := range menuItem.State.Variants {
+		_, err := duplicate.AddVariant(variant.Name, variant.Price.Amount, variant.Price.Currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, modifierGroup := range menuItem.State.ModifierGroups {
+		modifierGroupID, err := duplicate.AddModifierGroup(modifierGroup.Name, modifierGroup.MinSelections, modifierGroup.MaxSelections)
+		if err != nil {
+			return nil, err
+		}
+		for _, modifier := range modifierGroup.Modifiers {
+			_, err = duplicate.AddModifier(modifierGroupID, modifier.Name, modifier.PriceDelta.Amount, modifier.PriceDelta.Currency)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return duplicate, nil
+}
+
 func (menuItem *MenuItem) ChangeName(newName string) {
 	event := events.MenuItemNameChanged{
 		EventInfo: eventutils.NewEventInfo(menuItem.GetID()),
@@ -52,52 +147,177 @@ func (menuItem *MenuItem) ChangeName(newName string) {
 	eventutils.AddEvent(event, menuItem)
 }
 
-func (menuItem *MenuItem) ChangeEstimatedPreparationTime(newTime time.Duration) {
+// ChangeEstimatedPreparationTime sets how long the kitchen takes to prepare the menu item,
+// between MinEstimatedPreparationTime and MaxEstimatedPreparationTime
+func (menuItem *MenuItem) ChangeEstimatedPreparationTime(newTime time.Duration) error {
+	if newTime < MinEstimatedPreparationTime || newTime > MaxEstimatedPreparationTime {
+		return fmt.Errorf("%w: %s", ErrInvalidEstimatedPreparationTime, newTime)
+	}
 	event := events.MenuItemEstimatedPreparationTimeChanged{
 		EventInfo:   eventutils.NewEventInfo(menuItem.GetID()),
 		NewEstimate: newTime,
 	}
 
 	eventutils.AddEvent(event, menuItem)
+	return nil
 }
 
-// Events
+// ChangePrice sets the price of the menu item, the amount is in the minor unit of the currency
+// and the currency is an ISO 4217 code, the one of the variants and modifiers if the menu item has any
+func (menuItem *MenuItem) ChangePrice(amount int64, currencyCode string) error {
+	if amount < 0 {
+		return ErrNegativePrice
+	}
+	currency, err := parseCurrency(currencyCode)
+	if err != nil {
+		return err
+	}
+	err = menuItem.checkCurrency(currency, true)
+	if err != nil {
+		return err
+	}
+	event := events.MenuItemPriceChanged{
+		EventInfo: eventutils.NewEventInfo(menuItem.GetID()),
+		NewPrice: events.Price{
+			Amount:   amount,
+			Currency: currency,
+		},
+	}
+	eventutils.AddEvent(event, menuItem)
+	return nil
+}
 
-func (menuItem MenuItem) DeserializeEvent(event eventutils.Event) eventutils.IEvent {
-	switch event.Name {
-	case "MenuItemCreated":
-		var e events.MenuItemCreated
-		json.Unmarshal(event.Data, &e)
-		return e
-	case "MenuItemNameChanged":
-		var e events.MenuItemNameChanged
-		json.Unmarshal(event.Data, &e)
-		return e
-	case "MenuItemEstimatedPreparationTimeChanged":
-		var e events.MenuItemEstimatedPreparationTimeChanged
-		json.Unmarshal(event.Data, &e)
-		return e
-	default:
-		return nil
+// ChangeDescription sets the description shown to guests, an empty description clears it
+func (menuItem *MenuItem) ChangeDescription(newDescription string) error {
+	newDescription = strings.TrimSpace(newDescription)
+	if utf8.RuneCountInString(newDescription) > MaxDescriptionLength {
+		return ErrDescriptionTooLong
 	}
+	event := events.MenuItemDescriptionChanged{
+		EventInfo:      eventutils.NewEventInfo(menuItem.GetID()),
+		NewDescription: newDescription,
+	}
+	eventutils.AddEvent(event, menuItem)
+	return nil
 }
 
-func (menuItem *MenuItem) ApplyEvent(event eventutils.IEvent) {
-	eventType := utils.GetType(event)
-	switch eventType {
-	case "MenuItemCreated":
-		applyMenuItemCreated(menuItem, event.(events.MenuItemCreated))
-	case "MenuItemNameChanged":
-		applyMenuItemNameChanged(menuItem, event.(events.MenuItemNameChanged))
-	case "MenuItemEstimatedPreparationTimeChanged":
-		applyMenuItemEstimatedPreparationTimeChanged(menuItem, event.(events.MenuItemEstimatedPreparationTimeChanged))
+// ChangeAllergens replaces the allergens of the menu item, an empty list clears them
+func (menuItem *MenuItem) ChangeAllergens(newAllergens []events.Allergen) error {
+	allergens := []events.Allergen{}
+	for _, allergen := range newAllergens {
+		if !allergen.IsValid() {
+			return fmt.Errorf("%w: %s", ErrUnknownAllergen, allergen)
+		}
+		if !slices.Contains(allergens, allergen) {
+			allergens = append(allergens, allergen)
+		}
+	}
+	slices.Sort(allergens)
+	if menuItem.State.DietaryTags.GlutenFree && slices.Contains(allergens, events.AllergenGluten) {
+		return ErrGlutenFreeWithGluten
+	}
+	event := events.MenuItemAllergensChanged{
+		EventInfo:    eventutils.NewEventInfo(menuItem.GetID()),
+		NewAllergens: allergens,
+	}
+	eventutils.AddEvent(event, menuItem)
+	return nil
+}
 
+// ChangeDietaryTags replaces the dietary tags of the menu item, the zero value clears them
+func (menuItem *MenuItem) ChangeDietaryTags(newDietaryTags events.DietaryTags) error {
+	if newDietaryTags.SpicyLevel < 0 || newDietaryTags.SpicyLevel > events.MaxSpicyLevel {
+		return ErrInvalidSpicyLevel
 	}
+	if newDietaryTags.Vegan && !newDietaryTags.Vegetarian {
+		return ErrVeganNotVegetarian
+	}
+	if newDietaryTags.GlutenFree && slices.Contains(menuItem.State.Allergens, events.AllergenGluten) {
+		return ErrGlutenFreeWithGluten
+	}
+	event := events.MenuItemDietaryTagsChanged{
+		EventInfo:      eventutils.NewEventInfo(menuItem.GetID()),
+		NewDietaryTags: newDietaryTags,
+	}
+	eventutils.AddEvent(event, menuItem)
+	return nil
+}
+
+// MoveToSubCategory moves the menu item to another subcategory, the subcategories are changed in the background
+func (menuItem *MenuItem) MoveToSubCategory(subCategoryID uuid.UUID) error {
+	if subCategoryID == menuItem.State.ParentSubCategoryID {
+		return ErrSameParent
+	}
+	event := events.MenuItemMoved{
+		EventInfo:              eventutils.NewEventInfo(menuItem.GetID()),
+		OldParentSubCategoryID: menuItem.State.ParentSubCategoryID,
+		NewParentSubCategoryID: subCategoryID,
+	}
+	eventutils.AddEvent(event, menuItem)
+	return nil
+}
+
+func (menuItem *MenuItem) Delete() {
+	event := events.MenuItemDeleted{
+		EventInfo:           eventutils.NewEventInfo(menuItem.GetID()),
+		ParentSubCategoryID: menuItem.State.ParentSubCategoryID,
+	}
+	eventutils.AddEvent(event, menuItem)
+}
+
+// Snapshots
+
+const menuItemSnapshotVersion = 2
+
+func (menuItem *MenuItem) GetSnapshotState() interface{} {
+	return &menuItem.State
+}
+
+func (menuItem MenuItem) GetSnapshotVersion() int {
+	return menuItemSnapshotVersion
+}
+
+// Events
+
+var menuItemEvents = newMenuItemEvents()
+
+func newMenuItemEvents() *eventutils.EventRegistry[*MenuItem] {
+	registry := eventutils.NewEventRegistry[*MenuItem]()
+	registry.UseUpcasters(events.Upcasters)
+	eventutils.RegisterEvent(registry, applyMenuItemCreated)
+	eventutils.RegisterEvent(registry, applyMenuItemNameChanged)
+	eventutils.RegisterEvent(registry, applyMenuItemEstimatedPreparationTimeChanged)
+	eventutils.RegisterEvent(registry, applyMenuItemPriceChanged)
+	eventutils.RegisterEvent(registry, applyMenuItemDescriptionChanged)
+	eventutils.RegisterEvent(registry, applyMenuItemAllergensChanged)
+	eventutils.RegisterEvent(registry, applyMenuItemDietaryTagsChanged)
+	eventutils.RegisterEvent(registry, applyMenuItemVariantAdded)
+	eventutils.RegisterEvent(registry, applyMenuItemVariantRemoved)
+	eventutils.RegisterEvent(registry, applyMenuItemModifierGroupAdded)
+	eventutils.RegisterEvent(registry, applyMenuItemModifierGroupRemoved)
+	eventutils.RegisterEvent(registry, applyMenuItemModifierAdded)
+	eventutils.RegisterEvent(registry, applyMenuItemModifierRemoved)
+	eventutils.RegisterEvent(registry, applyMenuItemMoved)
+	eventutils.RegisterEvent(registry, applyMenuItemDeleted)
+	return registry
+}
+
+func (menuItem MenuItem) SerializeEvent(event eventutils.IEvent) (eventutils.Event, error) {
+	return menuItemEvents.Serialize(event)
+}
+
+func (menuItem MenuItem) DeserializeEvent(event eventutils.Event) (eventutils.IEvent, error) {
+	return menuItemEvents.Deserialize(event)
+}
+
+func (menuItem *MenuItem) ApplyEvent(event eventutils.IEvent) error {
+	return menuItemEvents.Apply(menuItem, event)
 }
 
 func applyMenuItemCreated(menuItem *MenuItem, event events.MenuItemCreated) {
 	menuItem.ID = event.EntityID
 	menuItem.State.Name = event.Name
+	menuItem.State.ParentSubCategoryID = event.ParentSubCategoryID
 }
 
 func applyMenuItemNameChanged(menuItem *MenuItem, event events.MenuItemNameChanged) {
@@ -108,3 +328,43 @@ func applyMenuItemNameChanged(menuItem *MenuItem, event events.MenuItemNameChang
 func applyMenuItemEstimatedPreparationTimeChanged(menuItem *MenuItem, event events.MenuItemEstimatedPreparationTimeChanged) {
 	menuItem.State.EstimatedPreparationTime = event.NewEstimate
 }
+
+func applyMenuItemPriceChanged(menuItem *MenuItem, event events.MenuItemPriceChanged) {
+	menuItem.State.Price = event.NewPrice
+}
+
+func applyMenuItemDescriptionChanged(menuItem *MenuItem, event events.MenuItemDescriptionChanged) {
+	menuItem.State.Description = event.NewDescription
+}
+
+func applyMenuItemAllergensChanged(menuItem *MenuItem, event events.MenuItemAllergensChanged) {
+	menuItem.State.Allergens = event.NewAllergens
+}
+
+func applyMenuItemDietaryTagsChanged(menuItem *MenuItem, event events.MenuItemDietaryTagsChanged) {
+	menuItem.State.DietaryTags = event.NewDietaryTags
+}
+
+func applyMenuItemMoved(menuItem *MenuItem, event events.MenuItemMoved) {
+	menuItem.State.ParentSubCategoryID = event.NewParentSubCategoryID
+}
+
+func applyMenuItemDeleted(menuItem *MenuItem, event events.MenuItemDeleted) {
+	menuItem.SetDeleted()
+}
+
+// Errors
+
+var (
+	ErrNegativePrice   = errors.New("the price cannot be negative")
+	ErrUnknownCurrency = errors.New("the currency is not an ISO 4217 code")
+
+	ErrInvalidEstimatedPreparationTime = fmt.Errorf("the estimated preparation time must be between %s and %s",
+		MinEstimatedPreparationTime, MaxEstimatedPreparationTime)
+
+	ErrDescriptionTooLong   = fmt.Errorf("the description cannot be longer than %d characters", MaxDescriptionLength)
+	ErrUnknownAllergen      = errors.New("the allergen is not one of the 14 allergens of the EU regulation")
+	ErrInvalidSpicyLevel    = fmt.Errorf("the spicy level must be between 0 and %d", events.MaxSpicyLevel)
+	ErrVeganNotVegetarian   = errors.New("a vegan menu item must be vegetarian too")
+	ErrGlutenFreeWithGluten = errors.New("a gluten free menu item cannot contain gluten")
+)
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -47,14 +48,258 @@ func TestChangeMenuItemEstimatedPreparationTime(t *testing.T) {
 	menuItem := NewMenuItem(menuItemID)
 
 	// Act
-	menuItem.ChangeEstimatedPreparationTime(10 * time.Minute)
+	err := menuItem.ChangeEstimatedPreparationTime(10 * time.Minute)
 
 	// Assert
 	latestEvent := menuItem.Events[len(menuItem.Events)-1]
+	require.NoError(t, err)
 	require.Equal(t, 10*time.Minute, menuItem.GetEstimatedPreparationtime())
 	require.IsType(t, events.MenuItemEstimatedPreparationTimeChanged{}, latestEvent)
 }
 
+func TestChangeMenuItemEstimatedPreparationTime_WhenTimeIsNotSensible(t *testing.T) {
+	testCases := []time.Duration{
+		0,
+		-10 * time.Minute,
+		30 * time.Second,
+		25 * time.Hour,
+	}
+	for _, newTime := range testCases {
+		t.Run(newTime.String(), func(t *testing.T) {
+			// Arrange
+			menuItem := NewMenuItem(utils.GenerateNewUUID())
+
+			// Act
+			err := menuItem.ChangeEstimatedPreparationTime(newTime)
+
+			// Assert
+			require.ErrorIs(t, err, ErrInvalidEstimatedPreparationTime)
+			require.Len(t, menuItem.Events, 1)
+			require.Zero(t, menuItem.GetEstimatedPreparationtime())
+		})
+	}
+}
+
+func TestChangeMenuItemPrice(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+
+	// Act
+	err := menuItem.ChangePrice(1250, "eur")
+
+	// Assert
+	latestEvent := menuItem.Events[len(menuItem.Events)-1]
+	require.NoError(t, err)
+	require.Equal(t, events.Price{Amount: 1250, Currency: "EUR"}, menuItem.GetPrice())
+	require.IsType(t, events.MenuItemPriceChanged{}, latestEvent)
+}
+
+func TestChangeMenuItemPrice_WhenPriceIsInvalid(t *testing.T) {
+	testCases := []struct {
+		name        string
+		amount      int64
+		currency    string
+		expectedErr error
+	}{
+		{"negative amount", -1, "EUR", ErrNegativePrice},
+		{"unknown currency", 1250, "ABC", ErrUnknownCurrency},
+		{"empty currency", 1250, "", ErrUnknownCurrency},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			menuItem := NewMenuItem(utils.GenerateNewUUID())
+
+			// Act
+			err := menuItem.ChangePrice(testCase.amount, testCase.currency)
+
+			// Assert
+			require.ErrorIs(t, err, testCase.expectedErr)
+			require.Len(t, menuItem.Events, 1)
+			require.Equal(t, events.Price{}, menuItem.GetPrice())
+		})
+	}
+}
+
+func TestChangeMenuItemDescription(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+
+	// Act
+	err := menuItem.ChangeDescription("  Tomato, mozzarella and basil  ")
+
+	// Assert
+	latestEvent := menuItem.Events[len(menuItem.Events)-1]
+	require.NoError(t, err)
+	require.Equal(t, "Tomato, mozzarella and basil", menuItem.GetDescription())
+	require.IsType(t, events.MenuItemDescriptionChanged{}, latestEvent)
+}
+
+func TestChangeMenuItemDescription_WhenTooLong(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+
+	// Act
+	err := menuItem.ChangeDescription(strings.Repeat("à", MaxDescriptionLength+1))
+
+	// Assert
+	require.ErrorIs(t, err, ErrDescriptionTooLong)
+	require.Len(t, menuItem.Events, 1)
+}
+
+func TestChangeMenuItemAllergens(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+
+	// Act
+	err := menuItem.ChangeAllergens([]events.Allergen{
+		events.AllergenNuts,
+		events.AllergenGluten,
+		events.AllergenNuts,
+	})
+
+	// Assert
+	latestEvent := menuItem.Events[len(menuItem.Events)-1]
+	require.NoError(t, err)
+	require.Equal(t, []events.Allergen{events.AllergenGluten, events.AllergenNuts}, menuItem.GetAllergens())
+	require.IsType(t, events.MenuItemAllergensChanged{}, latestEvent)
+}
+
+func TestChangeMenuItemAllergens_WhenAllergenIsUnknown(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+
+	// Act
+	err := menuItem.ChangeAllergens([]events.Allergen{events.AllergenGluten, "pineapple"})
+
+	// Assert
+	require.ErrorIs(t, err, ErrUnknownAllergen)
+	require.Len(t, menuItem.Events, 1)
+}
+
+func TestChangeMenuItemDietaryTags(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+	dietaryTags := events.DietaryTags{Vegan: true, Vegetarian: true, SpicyLevel: 2}
+
+	// Act
+	err := menuItem.ChangeDietaryTags(dietaryTags)
+
+	// Assert
+	latestEvent := menuItem.Events[len(menuItem.Events)-1]
+	require.NoError(t, err)
+	require.Equal(t, dietaryTags, menuItem.GetDietaryTags())
+	require.IsType(t, events.MenuItemDietaryTagsChanged{}, latestEvent)
+}
+
+func TestChangeMenuItemDietaryTags_WhenTagsAreInconsistent(t *testing.T) {
+	testCases := []struct {
+		name        string
+		allergens   []events.Allergen
+		dietaryTags events.DietaryTags
+		expectedErr error
+	}{
+		{"spicy level too high", nil, events.DietaryTags{SpicyLevel: events.MaxSpicyLevel + 1}, ErrInvalidSpicyLevel},
+		{"negative spicy level", nil, events.DietaryTags{SpicyLevel: -1}, ErrInvalidSpicyLevel},
+		{"vegan but not vegetarian", nil, events.DietaryTags{Vegan: true}, ErrVeganNotVegetarian},
+		{"gluten free with gluten", []events.Allergen{events.AllergenGluten}, events.DietaryTags{GlutenFree: true}, ErrGlutenFreeWithGluten},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			menuItem := NewMenuItem(utils.GenerateNewUUID())
+			require.NoError(t, menuItem.ChangeAllergens(testCase.allergens))
+
+			// Act
+			err := menuItem.ChangeDietaryTags(testCase.dietaryTags)
+
+			// Assert
+			require.ErrorIs(t, err, testCase.expectedErr)
+			require.Equal(t, events.DietaryTags{}, menuItem.GetDietaryTags())
+		})
+	}
+}
+
+func TestChangeMenuItemAllergens_WhenGlutenFree(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+	require.NoError(t, menuItem.ChangeDietaryTags(events.DietaryTags{GlutenFree: true}))
+
+	// Act
+	err := menuItem.ChangeAllergens([]events.Allergen{events.AllergenGluten})
+
+	// Assert
+	require.ErrorIs(t, err, ErrGlutenFreeWithGluten)
+	require.Empty(t, menuItem.GetAllergens())
+}
+
+func TestDeleteMenuItem(t *testing.T) {
+	// Arrange
+	subCategoryID := utils.GenerateNewUUID()
+	menuItem := NewMenuItem(subCategoryID)
+
+	// Act
+	menuItem.Delete()
+
+	// Assert
+	latestEvent := menuItem.Events[len(menuItem.Events)-1]
+	require.True(t, menuItem.IsDeleted)
+	require.IsType(t, events.MenuItemDeleted{}, latestEvent)
+	require.Equal(t, subCategoryID, latestEvent.(events.MenuItemDeleted).ParentSubCategoryID)
+}
+
+func TestMoveMenuItemToSubCategory(t *testing.T) {
+	// Arrange
+	oldSubCategoryID, newSubCategoryID := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+	menuItem := NewMenuItem(oldSubCategoryID)
+
+	// Act
+	err := menuItem.MoveToSubCategory(newSubCategoryID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, newSubCategoryID, menuItem.GetParentSubCategoryID())
+	latestEvent := menuItem.Events[len(menuItem.Events)-1].(events.MenuItemMoved)
+	require.Equal(t, oldSubCategoryID, latestEvent.OldParentSubCategoryID)
+	require.Equal(t, newSubCategoryID, latestEvent.NewParentSubCategoryID)
+}
+
+func TestDuplicateMenuItem(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+	menuItem.ChangeName("Pizza")
+	require.NoError(t, menuItem.ChangePrice(1250, "EUR"))
+	require.NoError(t, menuItem.ChangeEstimatedPreparationTime(15*time.Minute))
+	require.NoError(t, menuItem.ChangeAllergens([]events.Allergen{events.AllergenGluten}))
+	_, err := menuItem.AddVariant("Large", 1450, "EUR")
+	require.NoError(t, err)
+	modifierGroupID, err := menuItem.AddModifierGroup("Toppings", 0, 2)
+	require.NoError(t, err)
+	_, err = menuItem.AddModifier(modifierGroupID, "Olives", 100, "EUR")
+	require.NoError(t, err)
+	newSubCategoryID := utils.GenerateNewUUID()
+	duplicateID := utils.GenerateNewUUID()
+
+	// Act
+	duplicate, err := menuItem.Duplicate(duplicateID, newSubCategoryID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, duplicateID, duplicate.ID)
+	require.Equal(t, newSubCategoryID, duplicate.GetParentSubCategoryID())
+	require.Equal(t, "Pizza", duplicate.GetName())
+	require.Equal(t, menuItem.GetPrice(), duplicate.GetPrice())
+	require.Equal(t, 15*time.Minute, duplicate.GetEstimatedPreparationtime())
+	require.Equal(t, menuItem.GetAllergens(), duplicate.GetAllergens())
+	require.Len(t, duplicate.GetVariants(), 1)
+	require.NotEqual(t, menuItem.GetVariants()[0].ID, duplicate.GetVariants()[0].ID)
+	require.Equal(t, menuItem.GetVariants()[0].Price, duplicate.GetVariants()[0].Price)
+	require.Len(t, duplicate.GetModifierGroups(), 1)
+	require.NotEqual(t, modifierGroupID, duplicate.GetModifierGroups()[0].ID)
+	require.Equal(t, "Olives", duplicate.GetModifierGroups()[0].Modifiers[0].Name)
+	require.True(t, duplicate.IsNew())
+}
+
 func Test_DeserializeMenuItemEvent(t *testing.T) {
 	// Arrange
 	events := []eventutils.IEvent{
@@ -67,15 +312,74 @@ func Test_DeserializeMenuItemEvent(t *testing.T) {
 		events.MenuItemEstimatedPreparationTimeChanged{
 			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
 		},
+		events.MenuItemPriceChanged{
+			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			NewPrice:  events.Price{Amount: 1250, Currency: "EUR"},
+		},
+		events.MenuItemDescriptionChanged{
+			EventInfo:      eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			NewDescription: "TestDescription",
+		},
+		events.MenuItemAllergensChanged{
+			EventInfo:    eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			NewAllergens: []events.Allergen{events.AllergenGluten},
+		},
+		events.MenuItemDietaryTagsChanged{
+			EventInfo:      eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			NewDietaryTags: events.DietaryTags{Halal: true},
+		},
+		events.MenuItemVariantAdded{
+			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			VariantID: utils.GenerateNewUUID(),
+			Name:      "TestVariant",
+			Price:     events.Price{Amount: 1450, Currency: "EUR"},
+		},
+		events.MenuItemVariantRemoved{
+			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			VariantID: utils.GenerateNewUUID(),
+		},
+		events.MenuItemModifierGroupAdded{
+			EventInfo:       eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			ModifierGroupID: utils.GenerateNewUUID(),
+			Name:            "TestModifierGroup",
+			MinSelections:   1,
+			MaxSelections:   1,
+		},
+		events.MenuItemModifierGroupRemoved{
+			EventInfo:       eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			ModifierGroupID: utils.GenerateNewUUID(),
+		},
+		events.MenuItemModifierAdded{
+			EventInfo:       eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			ModifierGroupID: utils.GenerateNewUUID(),
+			ModifierID:      utils.GenerateNewUUID(),
+			Name:            "TestModifier",
+			PriceDelta:      events.Price{Amount: -100, Currency: "EUR"},
+		},
+		events.MenuItemModifierRemoved{
+			EventInfo:       eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			ModifierGroupID: utils.GenerateNewUUID(),
+			ModifierID:      utils.GenerateNewUUID(),
+		},
+		events.MenuItemDeleted{
+			EventInfo:           eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			ParentSubCategoryID: utils.GenerateNewUUID(),
+		},
+		events.MenuItemMoved{
+			EventInfo:              eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			OldParentSubCategoryID: utils.GenerateNewUUID(),
+			NewParentSubCategoryID: utils.GenerateNewUUID(),
+		},
 	}
 
 	for _, event := range events {
 		serialized := eventutils.SerializedEvent(event)
 
 		// Act
-		deserialized := MenuItem{}.DeserializeEvent(serialized)
+		deserialized, err := MenuItem{}.DeserializeEvent(serialized)
 
 		// Assert
+		require.NoError(t, err)
 		require.Equal(t, event, deserialized)
 	}
 }
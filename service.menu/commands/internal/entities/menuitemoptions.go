@@ -0,0 +1,300 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Resta-Inc/resta/pkg/events"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
+	"golang.org/x/text/currency"
+)
+
+// Variant is a version of the menu item with its own price, such as a size of a pizza
+type Variant struct {
+	ID    uuid.UUID
+	Name  string
+	Price events.Price
+}
+
+// ModifierGroup is a choice the guest makes when ordering the menu item, such as the toppings of a pizza,
+// picking at least MinSelections and at most MaxSelections of its modifiers
+type ModifierGroup struct {
+	ID            uuid.UUID
+	Name          string
+	MinSelections int
+	MaxSelections int
+	Modifiers     []Modifier
+}
+
+type Modifier struct {
+	ID         uuid.UUID
+	Name       string
+	PriceDelta events.Price
+}
+
+// Business Logic
+
+func (menuItem MenuItem) GetVariants() []Variant {
+	return menuItem.State.Variants
+}
+
+func (menuItem MenuItem) GetModifierGroups() []ModifierGroup {
+	return menuItem.State.ModifierGroups
+}
+
+// AddVariant adds a variant with its own price and returns its ID
+func (menuItem *MenuItem) AddVariant(name string, amount int64, currencyCode string) (uuid.UUID, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return uuid.Nil, ErrEmptyName
+	}
+	if amount < 0 {
+		return uuid.Nil, ErrNegativePrice
+	}
+	currency, err := parseCurrency(currencyCode)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	err = menuItem.checkCurrency(currency, false)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	event := events.MenuItemVariantAdded{
+		EventInfo: eventutils.NewEventInfo(menuItem.GetID()),
+		VariantID: utils.GenerateNewUUID(),
+		Name:      name,
+		Price: events.Price{
+			Amount:   amount,
+			Currency: currency,
+		},
+	}
+	eventutils.AddEvent(event, menuItem)
+	return event.VariantID, nil
+}
+
+func (menuItem *MenuItem) RemoveVariant(variantID uuid.UUID) error {
+	if menuItem.findVariant(variantID) == -1 {
+		return ErrVariantNotFound
+	}
+	event := events.MenuItemVariantRemoved{
+		EventInfo: eventutils.NewEventInfo(menuItem.GetID()),
+		VariantID: variantID,
+	}
+	eventutils.AddEvent(event, menuItem)
+	return nil
+}
+
+// AddModifierGroup adds a group the guest picks from minSelections to maxSelections modifiers of,
+// a group with a minSelections of 1 or more is required. It returns the ID of the group.
+func (menuItem *MenuItem) AddModifierGroup(name string, minSelections, maxSelections int) (uuid.UUID, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return uuid.Nil, ErrEmptyName
+	}
+	if minSelections < 0 || maxSelections < 1 || minSelections > maxSelections {
+		return uuid.Nil, fmt.Errorf("%w: from %d to %d", ErrInvalidSelections, minSelections, maxSelections)
+	}
+	event := events.MenuItemModifierGroupAdded{
+		EventInfo:       eventutils.NewEventInfo(menuItem.GetID()),
+		ModifierGroupID: utils.GenerateNewUUID(),
+		Name:            name,
+		MinSelections:   minSelections,
+		MaxSelections:   maxSelections,
+	}
+	eventutils.AddEvent(event, menuItem)
+	return event.ModifierGroupID, nil
+}
+
+// RemoveModifierGroup removes the group together with its modifiers
+func (menuItem *MenuItem) RemoveModifierGroup(modifierGroupID uuid.UUID) error {
+	if menuItem.findModifierGroup(modifierGroupID) == -1 {
+		return ErrModifierGroupNotFound
+	}
+	event := events.MenuItemModifierGroupRemoved{
+		EventInfo:       eventutils.NewEventInfo(menuItem.GetID()),
+		ModifierGroupID: modifierGroupID,
+	}
+	eventutils.AddEvent(event, menuItem)
+	return nil
+}
+
+// AddModifier adds a modifier to the group and returns its ID, the price delta is added to the price
+// of the menu item when the modifier is chosen and can be negative
+func (menuItem *MenuItem) AddModifier(modifierGroupID uuid.UUID, name string, priceDelta int64, currencyCode string) (uuid.UUID, error) {
+	if menuItem.findModifierGroup(modifierGroupID) == -1 {
+		return uuid.Nil, ErrModifierGroupNotFound
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return uuid.Nil, ErrEmptyName
+	}
+	currency, err := parseCurrency(currencyCode)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	err = menuItem.checkCurrency(currency, false)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	event := events.MenuItemModifierAdded{
+		EventInfo:       eventutils.NewEventInfo(menuItem.GetID()),
+		ModifierGroupID: modifierGroupID,
+		ModifierID:      utils.GenerateNewUUID(),
+		Name:            name,
+		PriceDelta: events.Price{
+			Amount:   priceDelta,
+			Currency: currency,
+		},
+	}
+	eventutils.AddEvent(event, menuItem)
+	return event.ModifierID, nil
+}
+
+func (menuItem *MenuItem) RemoveModifier(modifierGroupID, modifierID uuid.UUID) error {
+	groupIndex := menuItem.findModifierGroup(modifierGroupID)
+	if groupIndex == -1 {
+		return ErrModifierGroupNotFound
+	}
+	if menuItem.State.ModifierGroups[groupIndex].findModifier(modifierID) == -1 {
+		return ErrModifierNotFound
+	}
+	event := events.MenuItemModifierRemoved{
+		EventInfo:       eventutils.NewEventInfo(menuItem.GetID()),
+		ModifierGroupID: modifierGroupID,
+		ModifierID:      modifierID,
+	}
+	eventutils.AddEvent(event, menuItem)
+	return nil
+}
+
+// helpers
+
+func parseCurrency(currencyCode string) (string, error) {
+	unit, err := currency.ParseISO(currencyCode)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCurrency, currencyCode)
+	}
+	return unit.String(), nil
+}
+
+// checkCurrency checks that all the prices of the menu item, its variants and its modifiers are in
+// the same currency. With ignorePrice the price of the menu item, which is being replaced, is not checked.
+func (menuItem MenuItem) checkCurrency(currency string, ignorePrice bool) error {
+	currencies := []string{}
+	if !ignorePrice {
+		currencies = append(currencies, menuItem.State.Price.Currency)
+	}
+	for _, variant := range menuItem.State.Variants {
+		currencies = append(currencies, variant.Price.Currency)
+	}
+	for _, modifierGroup := range menuItem.State.ModifierGroups {
+		for _, modifier := range modifierGroup.Modifiers {
+			currencies = append(currencies, modifier.PriceDelta.Currency)
+		}
+	}
+	for _, otherCurrency := range currencies {
+		if otherCurrency != "" && otherCurrency != currency {
+			return fmt.Errorf("%w: %s instead of %s", ErrCurrencyMismatch, currency, otherCurrency)
+		}
+	}
+	return nil
+}
+
+func (menuItem MenuItem) findVariant(variantID uuid.UUID) int {
+	for i, variant := range menuItem.State.Variants {
+		if variant.ID == variantID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (menuItem MenuItem) findModifierGroup(modifierGroupID uuid.UUID) int {
+	for i, modifierGroup := range menuItem.State.ModifierGroups {
+		if modifierGroup.ID == modifierGroupID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (modifierGroup ModifierGroup) findModifier(modifierID uuid.UUID) int {
+	for i, modifier := range modifierGroup.Modifiers {
+		if modifier.ID == modifierID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Events
+
+func applyMenuItemVariantAdded(menuItem *MenuItem, event events.MenuItemVariantAdded) {
+	menuItem.State.Variants = append(menuItem.State.Variants, Variant{
+		ID:    event.VariantID,
+		Name:  event.Name,
+		Price: event.Price,
+	})
+}
+
+func applyMenuItemVariantRemoved(menuItem *MenuItem, event events.MenuItemVariantRemoved) {
+	i := menuItem.findVariant(event.VariantID)
+	if i != -1 {
+		menuItem.State.Variants = append(menuItem.State.Variants[:i], menuItem.State.Variants[i+1:]...)
+	}
+}
+
+func applyMenuItemModifierGroupAdded(menuItem *MenuItem, event events.MenuItemModifierGroupAdded) {
+	menuItem.State.ModifierGroups = append(menuItem.State.ModifierGroups, ModifierGroup{
+		ID:            event.ModifierGroupID,
+		Name:          event.Name,
+		MinSelections: event.MinSelections,
+		MaxSelections: event.MaxSelections,
+	})
+}
+
+func applyMenuItemModifierGroupRemoved(menuItem *MenuItem, event events.MenuItemModifierGroupRemoved) {
+	i := menuItem.findModifierGroup(event.ModifierGroupID)
+	if i != -1 {
+		menuItem.State.ModifierGroups = append(menuItem.State.ModifierGroups[:i], menuItem.State.ModifierGroups[i+1:]...)
+	}
+}
+
+func applyMenuItemModifierAdded(menuItem *MenuItem, event events.MenuItemModifierAdded) {
+	i := menuItem.findModifierGroup(event.ModifierGroupID)
+	if i == -1 {
+		return
+	}
+	modifierGroup := &menuItem.State.ModifierGroups[i]
+	modifierGroup.Modifiers = append(modifierGroup.Modifiers, Modifier{
+		ID:         event.ModifierID,
+		Name:       event.Name,
+		PriceDelta: event.PriceDelta,
+	})
+}
+
+func applyMenuItemModifierRemoved(menuItem *MenuItem, event events.MenuItemModifierRemoved) {
+	i := menuItem.findModifierGroup(event.ModifierGroupID)
+	if i == -1 {
+		return
+	}
+	modifierGroup := &menuItem.State.ModifierGroups[i]
+	j := modifierGroup.findModifier(event.ModifierID)
+	if j != -1 {
+		modifierGroup.Modifiers = append(modifierGroup.Modifiers[:j], modifierGroup.Modifiers[j+1:]...)
+	}
+}
+
+// Errors
+
+var (
+	ErrEmptyName             = errors.New("the name cannot be empty")
+	ErrInvalidSelections     = errors.New("a modifier group must allow at least one selection and no less than its minimum")
+	ErrCurrencyMismatch      = errors.New("the prices of a menu item must all be in the same currency")
+	ErrVariantNotFound       = errors.New("the menu item has no such variant")
+	ErrModifierGroupNotFound = errors.New("the menu item has no such modifier group")
+	ErrModifierNotFound      = errors.New("the modifier group has no such modifier")
+)
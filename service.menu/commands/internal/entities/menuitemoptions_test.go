@@ -0,0 +1,144 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/Resta-Inc/resta/pkg/events"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddMenuItemVariant(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+
+	// Act
+	variantID, err := menuItem.AddVariant("Large", 1450, "eur")
+
+	// Assert
+	latestEvent := menuItem.Events[len(menuItem.Events)-1]
+	require.NoError(t, err)
+	require.Equal(t, []Variant{{ID: variantID, Name: "Large", Price: events.Price{Amount: 1450, Currency: "EUR"}}}, menuItem.GetVariants())
+	require.IsType(t, events.MenuItemVariantAdded{}, latestEvent)
+}
+
+func TestAddMenuItemVariant_WhenCurrencyDiffersFromPrice(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+	require.NoError(t, menuItem.ChangePrice(1250, "EUR"))
+
+	// Act
+	_, err := menuItem.AddVariant("Large", 1450, "USD")
+
+	// Assert
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+	require.Empty(t, menuItem.GetVariants())
+}
+
+func TestChangeMenuItemPrice_WhenCurrencyDiffersFromVariants(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+	require.NoError(t, menuItem.ChangePrice(1250, "EUR"))
+	_, err := menuItem.AddVariant("Large", 1450, "EUR")
+	require.NoError(t, err)
+
+	// Act
+	err = menuItem.ChangePrice(1250, "USD")
+
+	// Assert
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+	require.Equal(t, "EUR", menuItem.GetPrice().Currency)
+}
+
+func TestRemoveMenuItemVariant(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+	smallID, _ := menuItem.AddVariant("Small", 950, "EUR")
+	largeID, _ := menuItem.AddVariant("Large", 1450, "EUR")
+
+	// Act
+	err := menuItem.RemoveVariant(smallID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, menuItem.GetVariants(), 1)
+	require.Equal(t, largeID, menuItem.GetVariants()[0].ID)
+	require.ErrorIs(t, menuItem.RemoveVariant(smallID), ErrVariantNotFound)
+}
+
+func TestAddMenuItemModifierGroup_WhenSelectionsAreInvalid(t *testing.T) {
+	testCases := []struct {
+		name          string
+		minSelections int
+		maxSelections int
+	}{
+		{"no selection allowed", 0, 0},
+		{"negative minimum", -1, 3},
+		{"minimum above maximum", 3, 2},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			menuItem := NewMenuItem(utils.GenerateNewUUID())
+
+			// Act
+			_, err := menuItem.AddModifierGroup("Toppings", testCase.minSelections, testCase.maxSelections)
+
+			// Assert
+			require.ErrorIs(t, err, ErrInvalidSelections)
+			require.Empty(t, menuItem.GetModifierGroups())
+		})
+	}
+}
+
+func TestAddMenuItemModifier(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+	modifierGroupID, err := menuItem.AddModifierGroup("Toppings", 0, 3)
+	require.NoError(t, err)
+
+	// Act
+	modifierID, err := menuItem.AddModifier(modifierGroupID, "Extra cheese", 150, "EUR")
+
+	// Assert
+	latestEvent := menuItem.Events[len(menuItem.Events)-1]
+	require.NoError(t, err)
+	require.IsType(t, events.MenuItemModifierAdded{}, latestEvent)
+	require.Equal(t, []ModifierGroup{
+		{
+			ID:            modifierGroupID,
+			Name:          "Toppings",
+			MinSelections: 0,
+			MaxSelections: 3,
+			Modifiers: []Modifier{
+				{ID: modifierID, Name: "Extra cheese", PriceDelta: events.Price{Amount: 150, Currency: "EUR"}},
+			},
+		},
+	}, menuItem.GetModifierGroups())
+}
+
+func TestAddMenuItemModifier_WhenGroupIsNotFound(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+
+	// Act
+	_, err := menuItem.AddModifier(utils.GenerateNewUUID(), "Extra cheese", 150, "EUR")
+
+	// Assert
+	require.ErrorIs(t, err, ErrModifierGroupNotFound)
+}
+
+func TestRemoveMenuItemModifierGroup(t *testing.T) {
+	// Arrange
+	menuItem := NewMenuItem(utils.GenerateNewUUID())
+	modifierGroupID, _ := menuItem.AddModifierGroup("Cooking temperature", 1, 1)
+	modifierID, _ := menuItem.AddModifier(modifierGroupID, "Rare", 0, "EUR")
+
+	// Act
+	err := menuItem.RemoveModifierGroup(modifierGroupID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Empty(t, menuItem.GetModifierGroups())
+	require.ErrorIs(t, menuItem.RemoveModifier(modifierGroupID, modifierID), ErrModifierGroupNotFound)
+}
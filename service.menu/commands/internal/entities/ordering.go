@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"errors"
+
+	"github.com/gofrs/uuid"
+)
+
+// checkPermutation checks that the new order has every current ID exactly once and no other ID
+func checkPermutation(currentIDs, newIDs []uuid.UUID) error {
+	if len(newIDs) != len(currentIDs) {
+		return ErrNotAPermutation
+	}
+	seen := make(map[uuid.UUID]bool, len(newIDs))
+	for _, id := range newIDs {
+		if seen[id] {
+			return ErrNotAPermutation
+		}
+		seen[id] = true
+	}
+	for _, id := range currentIDs {
+		if !seen[id] {
+			return ErrNotAPermutation
+		}
+	}
+	return nil
+}
+
+// Errors
+
+var (
+	ErrNotAPermutation = errors.New("the new order must have all the current children once and no others")
+	ErrSameParent      = errors.New("the new parent is the current one")
+)
@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPermutation(t *testing.T) {
+	id1, id2, otherID := utils.GenerateNewUUID(), utils.GenerateNewUUID(), utils.GenerateNewUUID()
+	currentIDs := []uuid.UUID{id1, id2}
+
+	testCases := []struct {
+		name   string
+		newIDs []uuid.UUID
+		valid  bool
+	}{
+		{"same order", []uuid.UUID{id1, id2}, true},
+		{"swapped", []uuid.UUID{id2, id1}, true},
+		{"missing id", []uuid.UUID{id2}, false},
+		{"duplicated id", []uuid.UUID{id2, id2}, false},
+		{"unknown id", []uuid.UUID{id1, otherID}, false},
+		{"extra id", []uuid.UUID{id1, id2, otherID}, false},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Act
+			err := checkPermutation(currentIDs, testCase.newIDs)
+
+			// Assert
+			if testCase.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrNotAPermutation)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/Resta-Inc/resta/pkg/events"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/gofrs/uuid"
+)
+
+// PendingLinksID identifies the only PendingLinks, so its stream works as a dead letter
+var PendingLinksID = uuid.Must(uuid.FromString("6f1d0c3e-2b7a-4d8e-9c5f-0a1b2c3d4e5f"))
+
+// Models
+
+// PendingLinks keeps the children that could not be added to their parent
+// after all the attempts, until they are linked by hand
+type PendingLinks struct {
+	eventutils.Entity
+	State PendingLinksState
+}
+
+type PendingLinksState struct {
+	Links []PendingLink
+}
+
+type PendingLink struct {
+	RequestEventID   uuid.UUID `json:"requestEventID"`
+	RequestEventType string    `json:"requestEventType"`
+	ChildID          uuid.UUID `json:"childID"`
+	ParentID         uuid.UUID `json:"parentID"`
+	PreviousParentID uuid.UUID `json:"previousParentID"`
+	Attempts         int       `json:"attempts"`
+	LastError        string    `json:"lastError"`
+	ParkedAt         time.Time `json:"parkedAt"`
+}
+
+// Business Logic
+func NewPendingLinks() *PendingLinks {
+	event := events.PendingLinksCreated{
+		EventInfo: eventutils.NewEventInfo(PendingLinksID),
+	}
+
+	pendingLinks := &PendingLinks{}
+	pendingLinks.SetNew()
+	eventutils.AddEvent(event, pendingLinks)
+	return pendingLinks
+}
+
+func (pendingLinks PendingLinks) GetLinks() []PendingLink {
+	return pendingLinks.State.Links
+}
+
+func (pendingLinks PendingLinks) FindLink(requestEventID uuid.UUID) (PendingLink, bool) {
+	for _, link := range pendingLinks.State.Links {
+		if link.RequestEventID == requestEventID {
+			return link, true
+		}
+	}
+	return PendingLink{}, false
+}
+
+func (pendingLinks *PendingLinks) Park(link PendingLink) {
+	event := events.LinkParked{
+		EventInfo:        eventutils.NewEventInfo(pendingLinks.ID),
+		RequestEventID:   link.RequestEventID,
+		RequestEventType: link.RequestEventType,
+		ChildID:          link.ChildID,
+		ParentID:         link.ParentID,
+		PreviousParentID: link.PreviousParentID,
+		Attempts:         link.Attempts,
+		LastError:        link.LastError,
+	}
+	eventutils.AddEvent(event, pendingLinks)
+}
+
+func (pendingLinks *PendingLinks) Resolve(requestEventID uuid.UUID) {
+	event := events.LinkResolved{
+		EventInfo:      eventutils.NewEventInfo(pendingLinks.ID),
+		RequestEventID: requestEventID,
+	}
+	eventutils.AddEvent(event, pendingLinks)
+}
+
+// Snapshots
+
+const pendingLinksSnapshotVersion = 1
+
+func (pendingLinks *PendingLinks) GetSnapshotState() interface{} {
+	return &pendingLinks.State
+}
+
+func (pendingLinks PendingLinks) GetSnapshotVersion() int {
+	return pendingLinksSnapshotVersion
+}
+
+// Events
+
+var pendingLinksEvents = newPendingLinksEvents()
+
+func newPendingLinksEvents() *eventutils.EventRegistry[*PendingLinks] {
+	registry := eventutils.NewEventRegistry[*PendingLinks]()
+	registry.UseUpcasters(events.Upcasters)
+	eventutils.RegisterEvent(registry, applyPendingLinksCreated)
+	eventutils.RegisterEvent(registry, applyLinkParked)
+	eventutils.RegisterEvent(registry, applyLinkResolved)
+	return registry
+}
+
+func (pendingLinks PendingLinks) SerializeEvent(event eventutils.IEvent) (eventutils.Event, error) {
+	return pendingLinksEvents.Serialize(event)
+}
+
+func (pendingLinks PendingLinks) DeserializeEvent(event eventutils.Event) (eventutils.IEvent, error) {
+	return pendingLinksEvents.Deserialize(event)
+}
+
+func (pendingLinks *PendingLinks) ApplyEvent(event eventutils.IEvent) error {
+	return pendingLinksEvents.Apply(pendingLinks, event)
+}
+
+func applyPendingLinksCreated(pendingLinks *PendingLinks, event events.PendingLinksCreated) {
+	pendingLinks.ID = event.EntityID
+}
+
+func applyLinkParked(pendingLinks *PendingLinks, event events.LinkParked) {
+	removeLink(pendingLinks, event.RequestEventID)
+	pendingLinks.State.Links = append(pendingLinks.State.Links, PendingLink{
+		RequestEventID:   event.RequestEventID,
+		RequestEventType: event.RequestEventType,
+		ChildID:          event.ChildID,
+		ParentID:         event.ParentID,
+		PreviousParentID: event.PreviousParentID,
+		Attempts:         event.Attempts,
+		LastError:        event.LastError,
+		ParkedAt:         event.CreatedAt,
+	})
+}
+
+func applyLinkResolved(pendingLinks *PendingLinks, event events.LinkResolved) {
+	removeLink(pendingLinks, event.RequestEventID)
+}
+
+func removeLink(pendingLinks *PendingLinks, requestEventID uuid.UUID) {
+	links := []PendingLink{}
+	for _, link := range pendingLinks.State.Links {
+		if link.RequestEventID != requestEventID {
+			links = append(links, link)
+		}
+	}
+	pendingLinks.State.Links = links
+}
@@ -1,8 +1,6 @@
 package entities
 
 import (
-	"encoding/json"
-
 	"github.com/Resta-Inc/resta/pkg/events"
 	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/Resta-Inc/resta/pkg/resources"
@@ -15,14 +13,17 @@ type SubCategory struct {
 	State SubCategoryState
 }
 type SubCategoryState struct {
-	Name         string
-	MenuItemsIDs []uuid.UUID
+	Name             string
+	ParentCategoryID uuid.UUID
+	MenuItemsIDs     []uuid.UUID
 }
 
 // Business Logic
 func NewSubCategory(categoryID uuid.UUID) *SubCategory {
-	subCategoryID := utils.GenerateNewUUID()
+	return newSubCategory(utils.GenerateNewUUID(), categoryID)
+}
 
+func newSubCategory(subCategoryID, categoryID uuid.UUID) *SubCategory {
 	event := events.SubCategoryCreated{
 		EventInfo:        eventutils.NewEventInfo(subCategoryID),
 		Name:             resources.DefaultSubCategoryName("en"),
@@ -39,10 +40,21 @@ func (subCategory SubCategory) GetName() string {
 	return subCategory.State.Name
 }
 
+func (subCategory SubCategory) GetParentCategoryID() uuid.UUID {
+	return subCategory.State.ParentCategoryID
+}
+
 func (subCategory SubCategory) GetMenuItemsIDs() []uuid.UUID {
 	return subCategory.State.MenuItemsIDs
 }
 
+// Duplicate returns a new subcategory with the ID, of the category, with the name of the subcategory
+func (subCategory SubCategory) Duplicate(id, categoryID uuid.UUID) *SubCategory {
+	duplicate := newSubCategory(id, categoryID)
+	duplicate.ChangeName(subCategory.State.Name)
+	return duplicate
+}
+
 func (subCategory *SubCategory) ChangeName(newName string) {
 	event := events.SubCategoryNameChanged{
 		EventInfo: eventutils.NewEventInfo(subCategory.ID),
@@ -59,42 +71,96 @@ func (subCategory *SubCategory) AddMenuItem(menuItemID uuid.UUID) {
 	eventutils.AddEvent(event, subCategory)
 }
 
-// Events
+func (subCategory *SubCategory) RemoveMenuItem(menuItemID uuid.UUID) {
+	event := events.MenuItemRemovedFromSubCategory{
+		EventInfo:  eventutils.NewEventInfo(subCategory.ID),
+		MenuItemID: menuItemID,
+	}
+	eventutils.AddEvent(event, subCategory)
+}
 
-func (subCategory SubCategory) DeserializeEvent(event eventutils.Event) eventutils.IEvent {
-	switch event.Name {
-	case "SubCategoryCreated":
-		var e events.SubCategoryCreated
-		json.Unmarshal(event.Data, &e)
-		return e
-	case "SubCategoryNameChanged":
-		var e events.SubCategoryNameChanged
-		json.Unmarshal(event.Data, &e)
-		return e
-	case "MenuItemAddedToSubCategory":
-		var e events.MenuItemAddedToSubCategory
-		json.Unmarshal(event.Data, &e)
-		return e
-	default:
-		return nil
+// ReorderMenuItems puts the menu items of the subcategory in the order of the IDs,
+// which must be a permutation of the current ones
+func (subCategory *SubCategory) ReorderMenuItems(menuItemsIDs []uuid.UUID) error {
+	err := checkPermutation(subCategory.State.MenuItemsIDs, menuItemsIDs)
+	if err != nil {
+		return err
 	}
+	event := events.MenuItemsReordered{
+		EventInfo:    eventutils.NewEventInfo(subCategory.ID),
+		MenuItemsIDs: menuItemsIDs,
+	}
+	eventutils.AddEvent(event, subCategory)
+	return nil
 }
 
-func (subCategory *SubCategory) ApplyEvent(event eventutils.IEvent) {
-	eventType := utils.GetType(event)
-	switch eventType {
-	case "SubCategoryCreated":
-		applySubCategoryCreated(subCategory, event.(events.SubCategoryCreated))
-	case "SubCategoryNameChanged":
-		applySubCategoryNameChanged(subCategory, event.(events.SubCategoryNameChanged))
-	case "MenuItemAddedToSubCategory":
-		applyMenuItemAddedToSubCategory(subCategory, event.(events.MenuItemAddedToSubCategory))
+// MoveToCategory moves the subcategory to another category, the categories are changed in the background
+func (subCategory *SubCategory) MoveToCategory(categoryID uuid.UUID) error {
+	if categoryID == subCategory.State.ParentCategoryID {
+		return ErrSameParent
+	}
+	event := events.SubCategoryMoved{
+		EventInfo:           eventutils.NewEventInfo(subCategory.ID),
+		OldParentCategoryID: subCategory.State.ParentCategoryID,
+		NewParentCategoryID: categoryID,
 	}
+	eventutils.AddEvent(event, subCategory)
+	return nil
+}
+
+func (subCategory *SubCategory) Delete() {
+	event := events.SubCategoryDeleted{
+		EventInfo:        eventutils.NewEventInfo(subCategory.ID),
+		ParentCategoryID: subCategory.State.ParentCategoryID,
+	}
+	eventutils.AddEvent(event, subCategory)
+}
+
+// Snapshots
+
+const subCategorySnapshotVersion = 2
+
+func (subCategory *SubCategory) GetSnapshotState() interface{} {
+	return &subCategory.State
+}
+
+func (subCategory SubCategory) GetSnapshotVersion() int {
+	return subCategorySnapshotVersion
+}
+
+// Events
+
+var subCategoryEvents = newSubCategoryEvents()
+
+func newSubCategoryEvents() *eventutils.EventRegistry[*SubCategory] {
+	registry := eventutils.NewEventRegistry[*SubCategory]()
+	registry.UseUpcasters(events.Upcasters)
+	eventutils.RegisterEvent(registry, applySubCategoryCreated)
+	eventutils.RegisterEvent(registry, applySubCategoryNameChanged)
+	eventutils.RegisterEvent(registry, applyMenuItemAddedToSubCategory)
+	eventutils.RegisterEvent(registry, applyMenuItemRemovedFromSubCategory)
+	eventutils.RegisterEvent(registry, applyMenuItemsReordered)
+	eventutils.RegisterEvent(registry, applySubCategoryMoved)
+	eventutils.RegisterEvent(registry, applySubCategoryDeleted)
+	return registry
+}
+
+func (subCategory SubCategory) SerializeEvent(event eventutils.IEvent) (eventutils.Event, error) {
+	return subCategoryEvents.Serialize(event)
+}
+
+func (subCategory SubCategory) DeserializeEvent(event eventutils.Event) (eventutils.IEvent, error) {
+	return subCategoryEvents.Deserialize(event)
+}
+
+func (subCategory *SubCategory) ApplyEvent(event eventutils.IEvent) error {
+	return subCategoryEvents.Apply(subCategory, event)
 }
 
 func applySubCategoryCreated(subCategory *SubCategory, event events.SubCategoryCreated) {
 	subCategory.ID = event.EntityID
 	subCategory.State.Name = event.Name
+	subCategory.State.ParentCategoryID = event.ParentCategoryID
 }
 
 func applySubCategoryNameChanged(subCategory *SubCategory, event events.SubCategoryNameChanged) {
@@ -104,3 +170,22 @@ func applySubCategoryNameChanged(subCategory *SubCategory, event events.SubCateg
 func applyMenuItemAddedToSubCategory(subCategory *SubCategory, event events.MenuItemAddedToSubCategory) {
 	subCategory.State.MenuItemsIDs = append(subCategory.State.MenuItemsIDs, event.MenuItemID)
 }
+
+func applyMenuItemRemovedFromSubCategory(subCategory *SubCategory, event events.MenuItemRemovedFromSubCategory) {
+	i := utils.FindID(subCategory.State.MenuItemsIDs, event.MenuItemID)
+	if i != -1 {
+		subCategory.State.MenuItemsIDs = utils.RemoveID(subCategory.State.MenuItemsIDs, i)
+	}
+}
+
+func applyMenuItemsReordered(subCategory *SubCategory, event events.MenuItemsReordered) {
+	subCategory.State.MenuItemsIDs = append([]uuid.UUID{}, event.MenuItemsIDs...)
+}
+
+func applySubCategoryMoved(subCategory *SubCategory, event events.SubCategoryMoved) {
+	subCategory.State.ParentCategoryID = event.NewParentCategoryID
+}
+
+func applySubCategoryDeleted(subCategory *SubCategory, event events.SubCategoryDeleted) {
+	subCategory.SetDeleted()
+}
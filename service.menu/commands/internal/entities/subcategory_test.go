@@ -7,6 +7,7 @@ import (
 	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/Resta-Inc/resta/pkg/resources"
 	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -55,6 +56,71 @@ func Test_AddMenuItem(t *testing.T) {
 	require.IsType(t, events.MenuItemAddedToSubCategory{}, latestEvent)
 }
 
+func Test_RemoveMenuItem(t *testing.T) {
+	// Arrange
+	menuItemID := utils.GenerateNewUUID()
+	otherMenuItemID := utils.GenerateNewUUID()
+	subCategory := NewSubCategory(utils.GenerateNewUUID())
+	subCategory.AddMenuItem(menuItemID)
+	subCategory.AddMenuItem(otherMenuItemID)
+
+	// Act
+	subCategory.RemoveMenuItem(menuItemID)
+
+	// Assert
+	latestEvent := subCategory.Events[len(subCategory.Events)-1]
+	require.Equal(t, []uuid.UUID{otherMenuItemID}, subCategory.GetMenuItemsIDs())
+	require.IsType(t, events.MenuItemRemovedFromSubCategory{}, latestEvent)
+}
+
+func Test_ReorderMenuItems(t *testing.T) {
+	// Arrange
+	menuItemID1, menuItemID2 := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+	subCategory := NewSubCategory(utils.GenerateNewUUID())
+	subCategory.AddMenuItem(menuItemID1)
+	subCategory.AddMenuItem(menuItemID2)
+
+	// Act
+	err := subCategory.ReorderMenuItems([]uuid.UUID{menuItemID2, menuItemID1})
+
+	// Assert
+	require.NoError(t, err)
+	latestEvent := subCategory.Events[len(subCategory.Events)-1]
+	require.Equal(t, []uuid.UUID{menuItemID2, menuItemID1}, subCategory.GetMenuItemsIDs())
+	require.IsType(t, events.MenuItemsReordered{}, latestEvent)
+}
+
+func TestDeleteSubCategory(t *testing.T) {
+	// Arrange
+	categoryID := utils.GenerateNewUUID()
+	subCategory := NewSubCategory(categoryID)
+
+	// Act
+	subCategory.Delete()
+
+	// Assert
+	latestEvent := subCategory.Events[len(subCategory.Events)-1]
+	require.True(t, subCategory.IsDeleted)
+	require.IsType(t, events.SubCategoryDeleted{}, latestEvent)
+	require.Equal(t, categoryID, latestEvent.(events.SubCategoryDeleted).ParentCategoryID)
+}
+
+func TestMoveSubCategoryToCategory(t *testing.T) {
+	// Arrange
+	oldCategoryID, newCategoryID := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+	subCategory := NewSubCategory(oldCategoryID)
+
+	// Act
+	err := subCategory.MoveToCategory(newCategoryID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, newCategoryID, subCategory.GetParentCategoryID())
+	latestEvent := subCategory.Events[len(subCategory.Events)-1].(events.SubCategoryMoved)
+	require.Equal(t, oldCategoryID, latestEvent.OldParentCategoryID)
+	require.Equal(t, newCategoryID, latestEvent.NewParentCategoryID)
+}
+
 func Test_DeserializeSubCategoryEvent(t *testing.T) {
 	// Arrange
 	events := []eventutils.IEvent{
@@ -67,15 +133,32 @@ func Test_DeserializeSubCategoryEvent(t *testing.T) {
 		events.MenuItemAddedToSubCategory{
 			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
 		},
+		events.MenuItemRemovedFromSubCategory{
+			EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
+		},
+		events.MenuItemsReordered{
+			EventInfo:    eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			MenuItemsIDs: []uuid.UUID{utils.GenerateNewUUID()},
+		},
+		events.SubCategoryDeleted{
+			EventInfo:        eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			ParentCategoryID: utils.GenerateNewUUID(),
+		},
+		events.SubCategoryMoved{
+			EventInfo:           eventutils.NewEventInfo(utils.GenerateNewUUID()),
+			OldParentCategoryID: utils.GenerateNewUUID(),
+			NewParentCategoryID: utils.GenerateNewUUID(),
+		},
 	}
 
 	for _, event := range events {
 		serialized := eventutils.SerializedEvent(event)
 
 		// Act
-		deserialized := SubCategory{}.DeserializeEvent(serialized)
+		deserialized, err := SubCategory{}.DeserializeEvent(serialized)
 
 		// Assert
+		require.NoError(t, err)
 		require.Equal(t, event, deserialized)
 	}
 }
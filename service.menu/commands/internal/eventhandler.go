@@ -1,55 +1,171 @@
 package internal
 
 import (
+	"context"
+
 	"github.com/EventStore/EventStore-Client-Go/esdb"
 	"github.com/Resta-Inc/resta/menu/commands/internal/entities"
 	"github.com/Resta-Inc/resta/pkg/events"
 	"github.com/Resta-Inc/resta/pkg/eventutils"
 )
 
+// a parent can be changed by several reactions at the same time,
+// so the reactions reload it and try again when their save conflicts
+const maxConcurrencyConflictRetries = 5
+
 type MenuEventHandler struct {
-	entityRepository eventutils.IEntityRepository
+	linkProcessManager *LinkProcessManager
 }
 
-func NewMenuEventHandler(repo eventutils.IEntityRepository) MenuEventHandler {
+func NewMenuEventHandler(linkProcessManager *LinkProcessManager) MenuEventHandler {
 	return MenuEventHandler{
-		entityRepository: repo,
+		linkProcessManager: linkProcessManager,
+	}
+}
+
+func (eventHandler MenuEventHandler) HandleCategoryCreated(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	event := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+	deserializedEvent, err := entities.Category{}.DeserializeEvent(event)
+	if err != nil {
+		return err
 	}
+	categoryCreatedEvent := deserializedEvent.(events.CategoryCreated)
+	ctx = eventutils.ContextWithMetadata(ctx, eventutils.CausedBy(event))
+	return eventHandler.linkProcessManager.Link(ctx, Link{
+		RequestEventID:   event.ID,
+		RequestEventType: event.Name,
+		ChildID:          categoryCreatedEvent.GetEntityID(),
+		ParentID:         categoryCreatedEvent.ParentMenuID,
+	})
 }
 
-func (eventHandler MenuEventHandler) HandleCategoryCreated(rawEvent *esdb.SubscriptionEvent) error {
+func (eventHandler MenuEventHandler) HandleSubCategoryCreated(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	event := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
-	categoryCreatedEvent := entities.Category{}.DeserializeEvent(event).(events.CategoryCreated)
-	menu, err := eventHandler.entityRepository.GetEntity(&entities.Menu{}, categoryCreatedEvent.ParentMenuID)
+	deserializedEvent, err := entities.SubCategory{}.DeserializeEvent(event)
 	if err != nil {
 		return err
 	}
-	menu.(*entities.Menu).AddCategory(categoryCreatedEvent.GetEntityID())
-	err = eventHandler.entityRepository.SaveEntity(menu)
-	return err
+	subCategoryCreatedEvent := deserializedEvent.(events.SubCategoryCreated)
+	ctx = eventutils.ContextWithMetadata(ctx, eventutils.CausedBy(event))
+	return eventHandler.linkProcessManager.Link(ctx, Link{
+		RequestEventID:   event.ID,
+		RequestEventType: event.Name,
+		ChildID:          subCategoryCreatedEvent.GetEntityID(),
+		ParentID:         subCategoryCreatedEvent.ParentCategoryID,
+	})
 }
 
-func (eventHandler MenuEventHandler) HandleSubCategoryCreated(rawEvent *esdb.SubscriptionEvent) error {
+func (eventHandler MenuEventHandler) HandleMenuItemCreated(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	event := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
-	subCategoryCreatedEvent := entities.SubCategory{}.DeserializeEvent(event).(events.SubCategoryCreated)
-	category, err := eventHandler.entityRepository.GetEntity(&entities.Category{}, subCategoryCreatedEvent.ParentCategoryID)
+	deserializedEvent, err := entities.MenuItem{}.DeserializeEvent(event)
 	if err != nil {
 		return err
 	}
-	category.(*entities.Category).AddSubCategory(subCategoryCreatedEvent.GetEntityID())
-	err = eventHandler.entityRepository.SaveEntity(category)
-	return err
+	menuItemCreatedEvent := deserializedEvent.(events.MenuItemCreated)
+	ctx = eventutils.ContextWithMetadata(ctx, eventutils.CausedBy(event))
+	return eventHandler.linkProcessManager.Link(ctx, Link{
+		RequestEventID:   event.ID,
+		RequestEventType: event.Name,
+		ChildID:          menuItemCreatedEvent.GetEntityID(),
+		ParentID:         menuItemCreatedEvent.ParentSubCategoryID,
+	})
 }
 
-func (eventHandler MenuEventHandler) HandleMenuItemCreated(rawEvent *esdb.SubscriptionEvent) error {
+func (eventHandler MenuEventHandler) HandleCategoryDeleted(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	event := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
-	menuItemCreatedEvent := entities.MenuItem{}.DeserializeEvent(event).(events.MenuItemCreated)
-	subCategory, err := eventHandler.entityRepository.GetEntity(&entities.SubCategory{}, menuItemCreatedEvent.ParentSubCategoryID)
+	deserializedEvent, err := entities.Category{}.DeserializeEvent(event)
 	if err != nil {
 		return err
 	}
+	categoryDeletedEvent := deserializedEvent.(events.CategoryDeleted)
+	ctx = eventutils.ContextWithMetadata(ctx, eventutils.CausedBy(event))
+	return eventHandler.linkProcessManager.Link(ctx, Link{
+		RequestEventID:   event.ID,
+		RequestEventType: event.Name,
+		ChildID:          categoryDeletedEvent.GetEntityID(),
+		ParentID:         categoryDeletedEvent.ParentMenuID,
+	})
+}
 
-	subCategory.(*entities.SubCategory).AddMenuItem(menuItemCreatedEvent.GetEntityID())
-	err = eventHandler.entityRepository.SaveEntity(subCategory)
-	return err
+func (eventHandler MenuEventHandler) HandleSubCategoryDeleted(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	event := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+	deserializedEvent, err := entities.SubCategory{}.DeserializeEvent(event)
+	if err != nil {
+		return err
+	}
+	subCategoryDeletedEvent := deserializedEvent.(events.SubCategoryDeleted)
+	ctx = eventutils.ContextWithMetadata(ctx, eventutils.CausedBy(event))
+	return eventHandler.linkProcessManager.Link(ctx, Link{
+		RequestEventID:   event.ID,
+		RequestEventType: event.Name,
+		ChildID:          subCategoryDeletedEvent.GetEntityID(),
+		ParentID:         subCategoryDeletedEvent.ParentCategoryID,
+	})
+}
+
+func (eventHandler MenuEventHandler) HandleMenuItemDeleted(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	event := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+	deserializedEvent, err := entities.MenuItem{}.DeserializeEvent(event)
+	if err != nil {
+		return err
+	}
+	menuItemDeletedEvent := deserializedEvent.(events.MenuItemDeleted)
+	ctx = eventutils.ContextWithMetadata(ctx, eventutils.CausedBy(event))
+	return eventHandler.linkProcessManager.Link(ctx, Link{
+		RequestEventID:   event.ID,
+		RequestEventType: event.Name,
+		ChildID:          menuItemDeletedEvent.GetEntityID(),
+		ParentID:         menuItemDeletedEvent.ParentSubCategoryID,
+	})
+}
+
+func (eventHandler MenuEventHandler) HandleCategoryMoved(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	event := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+	deserializedEvent, err := entities.Category{}.DeserializeEvent(event)
+	if err != nil {
+		return err
+	}
+	categoryMovedEvent := deserializedEvent.(events.CategoryMoved)
+	ctx = eventutils.ContextWithMetadata(ctx, eventutils.CausedBy(event))
+	return eventHandler.linkProcessManager.Link(ctx, Link{
+		RequestEventID:   event.ID,
+		RequestEventType: event.Name,
+		ChildID:          categoryMovedEvent.GetEntityID(),
+		ParentID:         categoryMovedEvent.NewParentMenuID,
+		PreviousParentID: categoryMovedEvent.OldParentMenuID,
+	})
+}
+
+func (eventHandler MenuEventHandler) HandleSubCategoryMoved(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	event := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+	deserializedEvent, err := entities.SubCategory{}.DeserializeEvent(event)
+	if err != nil {
+		return err
+	}
+	subCategoryMovedEvent := deserializedEvent.(events.SubCategoryMoved)
+	ctx = eventutils.ContextWithMetadata(ctx, eventutils.CausedBy(event))
+	return eventHandler.linkProcessManager.Link(ctx, Link{
+		RequestEventID:   event.ID,
+		RequestEventType: event.Name,
+		ChildID:          subCategoryMovedEvent.GetEntityID(),
+		ParentID:         subCategoryMovedEvent.NewParentCategoryID,
+		PreviousParentID: subCategoryMovedEvent.OldParentCategoryID,
+	})
+}
+
+func (eventHandler MenuEventHandler) HandleMenuItemMoved(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	event := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+	deserializedEvent, err := entities.MenuItem{}.DeserializeEvent(event)
+	if err != nil {
+		return err
+	}
+	menuItemMovedEvent := deserializedEvent.(events.MenuItemMoved)
+	ctx = eventutils.ContextWithMetadata(ctx, eventutils.CausedBy(event))
+	return eventHandler.linkProcessManager.Link(ctx, Link{
+		RequestEventID:   event.ID,
+		RequestEventType: event.Name,
+		ChildID:          menuItemMovedEvent.GetEntityID(),
+		ParentID:         menuItemMovedEvent.NewParentSubCategoryID,
+		PreviousParentID: menuItemMovedEvent.OldParentSubCategoryID,
+	})
 }
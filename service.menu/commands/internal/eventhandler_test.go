@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"testing"
 
 	"github.com/EventStore/EventStore-Client-Go/esdb"
@@ -9,13 +10,15 @@ import (
 	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/Resta-Inc/resta/pkg/utils"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/exp/slices"
 )
 
 func TestHandleCategoryCreatedMessage(t *testing.T) {
 	// Arrange
-	categoryID := utils.GenerateNewUUID()
 	menu := entities.NewMenu()
+	category := entities.NewCategory(menu.ID)
+	categoryID := category.ID
 
 	categoryCreatedEvent := events.CategoryCreated{
 		EventInfo:    eventutils.NewEventInfo(categoryID),
@@ -38,6 +41,10 @@ func TestHandleCategoryCreatedMessage(t *testing.T) {
 	}
 
 	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Category{}, categoryID).
+		Return(category, nil)
+
 	mockEntityRepository.
 		On("GetEntity", &entities.Menu{}, menu.ID).
 		Return(menu, nil)
@@ -50,19 +57,83 @@ func TestHandleCategoryCreatedMessage(t *testing.T) {
 		)).
 		Return(nil)
 
-	eventHandler := NewMenuEventHandler(mockEntityRepository)
+	eventHandler := NewMenuEventHandler(NewLinkProcessManager(mockEntityRepository, testLinkRetryPolicy))
+
+	// Act
+	eventHandler.HandleCategoryCreated(context.Background(), incomingMessage)
+
+	// Assert
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestHandleCategoryCreatedMessage_WhenMenuWasModifiedConcurrently(t *testing.T) {
+	// Arrange
+	menu := entities.NewMenu()
+	category := entities.NewCategory(menu.ID)
+	categoryID := category.ID
+	// after the conflict the menu is read again, without the category added by the failed attempt
+	reloadedMenu := &entities.Menu{}
+	err := eventutils.ReconstructFromEvents(reloadedMenu, []eventutils.Event{eventutils.SerializedEvent(menu.GetEvents()[0])})
+	require.NoError(t, err)
+
+	categoryCreatedEvent := events.CategoryCreated{
+		EventInfo:    eventutils.NewEventInfo(categoryID),
+		Name:         "TestCategoryName",
+		ParentMenuID: menu.ID,
+	}
+
+	serializedEvent := eventutils.SerializedEvent(categoryCreatedEvent)
+
+	incomingMessage := &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:   serializedEvent.ID,
+				EventType: serializedEvent.Name,
+				Data:      serializedEvent.Data,
+			},
+		},
+	}
+
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Category{}, categoryID).
+		Return(category, nil)
+
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, menu.ID).
+		Return(menu, nil).
+		Once()
+
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, menu.ID).
+		Return(reloadedMenu, nil).
+		Once()
+
+	mockEntityRepository.
+		On("SaveEntity", mock.AnythingOfType("*entities.Menu")).
+		Return(eventutils.ErrConcurrencyConflict).
+		Once()
+
+	mockEntityRepository.
+		On("SaveEntity", mock.AnythingOfType("*entities.Menu")).
+		Return(nil).
+		Once()
+
+	eventHandler := NewMenuEventHandler(NewLinkProcessManager(mockEntityRepository, testLinkRetryPolicy))
 
 	// Act
-	eventHandler.HandleCategoryCreated(incomingMessage)
+	err = eventHandler.HandleCategoryCreated(context.Background(), incomingMessage)
 
 	// Assert
+	require.NoError(t, err)
 	mockEntityRepository.AssertExpectations(t)
 }
 
 func TestHandleSubCategoryCreatedMessage(t *testing.T) {
 	// Arrange
-	subCategoryID := utils.GenerateNewUUID()
 	category := entities.NewCategory(utils.GenerateNewUUID())
+	subCategory := entities.NewSubCategory(category.ID)
+	subCategoryID := subCategory.ID
 
 	subCategoryCreatedEvent := events.SubCategoryCreated{
 		EventInfo:        eventutils.NewEventInfo(subCategoryID),
@@ -85,6 +156,10 @@ func TestHandleSubCategoryCreatedMessage(t *testing.T) {
 	}
 
 	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, subCategoryID).
+		Return(subCategory, nil)
+
 	mockEntityRepository.
 		On("GetEntity", &entities.Category{}, category.ID).
 		Return(category, nil)
@@ -97,10 +172,10 @@ func TestHandleSubCategoryCreatedMessage(t *testing.T) {
 		)).
 		Return(nil)
 
-	eventHandler := NewMenuEventHandler(mockEntityRepository)
+	eventHandler := NewMenuEventHandler(NewLinkProcessManager(mockEntityRepository, testLinkRetryPolicy))
 
 	// Act
-	eventHandler.HandleSubCategoryCreated(incomingMessage)
+	eventHandler.HandleSubCategoryCreated(context.Background(), incomingMessage)
 
 	// Assert
 	mockEntityRepository.AssertExpectations(t)
@@ -108,8 +183,9 @@ func TestHandleSubCategoryCreatedMessage(t *testing.T) {
 
 func TestHandleMenuItemCreatedMessage(t *testing.T) {
 	// Arrange
-	menuItemID := utils.GenerateNewUUID()
 	subCategory := entities.NewSubCategory(utils.GenerateNewUUID())
+	menuItem := entities.NewMenuItem(subCategory.ID)
+	menuItemID := menuItem.ID
 
 	menuItemCreatedEvent := events.MenuItemCreated{
 		EventInfo:           eventutils.NewEventInfo(menuItemID),
@@ -132,6 +208,10 @@ func TestHandleMenuItemCreatedMessage(t *testing.T) {
 	}
 
 	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.MenuItem{}, menuItemID).
+		Return(menuItem, nil)
+
 	mockEntityRepository.
 		On("GetEntity", &entities.SubCategory{}, subCategory.ID).
 		Return(subCategory, nil)
@@ -144,11 +224,141 @@ func TestHandleMenuItemCreatedMessage(t *testing.T) {
 		)).
 		Return(nil)
 
-	eventHandler := NewMenuEventHandler(mockEntityRepository)
+	eventHandler := NewMenuEventHandler(NewLinkProcessManager(mockEntityRepository, testLinkRetryPolicy))
+
+	// Act
+	eventHandler.HandleMenuItemCreated(context.Background(), incomingMessage)
+
+	// Assert
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestHandleCategoryDeletedMessage(t *testing.T) {
+	// Arrange
+	category := entities.NewCategory(utils.GenerateNewUUID())
+	menu := entities.NewMenu()
+	menu.AddCategory(category.ID)
+
+	categoryDeletedEvent := events.CategoryDeleted{
+		EventInfo:    eventutils.NewEventInfo(category.ID),
+		ParentMenuID: menu.ID,
+	}
+
+	serializedEvent := eventutils.SerializedEvent(categoryDeletedEvent)
+
+	incomingMessage := &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:   serializedEvent.ID,
+				EventType: serializedEvent.Name,
+				Data:      serializedEvent.Data,
+			},
+		},
+	}
+
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, menu.ID).
+		Return(menu, nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.MatchedBy(
+			func(menu *entities.Menu) bool {
+				return !slices.Contains(menu.GetCategoriesIDs(), category.ID)
+			},
+		)).
+		Return(nil)
+
+	eventHandler := NewMenuEventHandler(NewLinkProcessManager(mockEntityRepository, testLinkRetryPolicy))
+
+	// Act
+	err := eventHandler.HandleCategoryDeleted(context.Background(), incomingMessage)
+
+	// Assert
+	require.NoError(t, err)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestHandleCategoryDeletedMessage_WhenMenuWasDeleted(t *testing.T) {
+	// Arrange
+	categoryID := utils.GenerateNewUUID()
+	menuID := utils.GenerateNewUUID()
+
+	categoryDeletedEvent := events.CategoryDeleted{
+		EventInfo:    eventutils.NewEventInfo(categoryID),
+		ParentMenuID: menuID,
+	}
+
+	serializedEvent := eventutils.SerializedEvent(categoryDeletedEvent)
+
+	incomingMessage := &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:   serializedEvent.ID,
+				EventType: serializedEvent.Name,
+				Data:      serializedEvent.Data,
+			},
+		},
+	}
+
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, menuID).
+		Return(nil, eventutils.ErrEntityNotFound)
+
+	eventHandler := NewMenuEventHandler(NewLinkProcessManager(mockEntityRepository, testLinkRetryPolicy))
+
+	// Act
+	err := eventHandler.HandleCategoryDeleted(context.Background(), incomingMessage)
+
+	// Assert
+	require.NoError(t, err)
+	mockEntityRepository.AssertExpectations(t)
+	mockEntityRepository.AssertNotCalled(t, "SaveEntity", mock.Anything)
+}
+
+func TestHandleMenuItemDeletedMessage(t *testing.T) {
+	// Arrange
+	menuItem := entities.NewMenuItem(utils.GenerateNewUUID())
+	subCategory := entities.NewSubCategory(utils.GenerateNewUUID())
+	subCategory.AddMenuItem(menuItem.ID)
+
+	menuItemDeletedEvent := events.MenuItemDeleted{
+		EventInfo:           eventutils.NewEventInfo(menuItem.ID),
+		ParentSubCategoryID: subCategory.ID,
+	}
+
+	serializedEvent := eventutils.SerializedEvent(menuItemDeletedEvent)
+
+	incomingMessage := &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:   serializedEvent.ID,
+				EventType: serializedEvent.Name,
+				Data:      serializedEvent.Data,
+			},
+		},
+	}
+
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, subCategory.ID).
+		Return(subCategory, nil)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.MatchedBy(
+			func(subCategory *entities.SubCategory) bool {
+				return !slices.Contains(subCategory.GetMenuItemsIDs(), menuItem.ID)
+			},
+		)).
+		Return(nil)
+
+	eventHandler := NewMenuEventHandler(NewLinkProcessManager(mockEntityRepository, testLinkRetryPolicy))
 
 	// Act
-	eventHandler.HandleMenuItemCreated(incomingMessage)
+	err := eventHandler.HandleMenuItemDeleted(context.Background(), incomingMessage)
 
 	// Assert
+	require.NoError(t, err)
 	mockEntityRepository.AssertExpectations(t)
 }
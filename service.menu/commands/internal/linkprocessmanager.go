@@ -0,0 +1,390 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Resta-Inc/resta/menu/commands/internal/entities"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
+)
+
+// Link is a child to add to, remove from or move to its parent, as requested by an event of the child
+type Link struct {
+	RequestEventID   uuid.UUID `json:"requestEventID"`
+	RequestEventType string    `json:"requestEventType"`
+	ChildID          uuid.UUID `json:"childID"`
+	ParentID         uuid.UUID `json:"parentID"`
+	PreviousParentID uuid.UUID `json:"previousParentID"`
+}
+
+// LinkStatus is a link that is being retried
+type LinkStatus struct {
+	Link
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"lastError"`
+}
+
+var DefaultLinkRetryPolicy = eventutils.RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// LinkProcessManager links the children to their parents, the links that keep failing are parked in PendingLinks
+type LinkProcessManager struct {
+	entityRepository eventutils.IEntityRepository
+	retryPolicy      eventutils.RetryPolicy
+	linkers          map[string]func(ctx context.Context, link Link) error
+	mutex            sync.Mutex
+	inProgress       map[uuid.UUID]*LinkStatus
+}
+
+func NewLinkProcessManager(repo eventutils.IEntityRepository, retryPolicy eventutils.RetryPolicy) *LinkProcessManager {
+	processManager := &LinkProcessManager{
+		entityRepository: repo,
+		retryPolicy:      retryPolicy,
+		inProgress:       make(map[uuid.UUID]*LinkStatus),
+	}
+	processManager.linkers = map[string]func(ctx context.Context, link Link) error{
+		"CategoryCreated":    processManager.addCategoryToMenu,
+		"SubCategoryCreated": processManager.addSubCategoryToCategory,
+		"MenuItemCreated":    processManager.addMenuItemToSubCategory,
+		"CategoryDeleted":    processManager.removeCategoryFromMenu,
+		"SubCategoryDeleted": processManager.removeSubCategoryFromCategory,
+		"MenuItemDeleted":    processManager.removeMenuItemFromSubCategory,
+		"CategoryMoved":      processManager.moveCategory,
+		"SubCategoryMoved":   processManager.moveSubCategory,
+		"MenuItemMoved":      processManager.moveMenuItem,
+	}
+	return processManager
+}
+
+// Link retries the link as the policy says and parks it once the attempts are over, returning nil
+func (processManager *LinkProcessManager) Link(ctx context.Context, link Link) error {
+	linker, err := processManager.getLinker(link)
+	if err != nil {
+		return err
+	}
+	status := processManager.track(link)
+	defer processManager.untrack(link)
+
+	for attempt := 1; ; attempt++ {
+		err = linker(ctx, link)
+		if err == nil {
+			return nil
+		}
+		processManager.recordFailure(status, attempt, err)
+		if attempt >= processManager.retryPolicy.MaxAttempts {
+			return processManager.park(ctx, link, attempt, err)
+		}
+		err = processManager.retryPolicy.WaitContext(ctx, attempt)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// GetInProgressLinks returns the links that failed at least once and are still being retried
+func (processManager *LinkProcessManager) GetInProgressLinks() []LinkStatus {
+	processManager.mutex.Lock()
+	defer processManager.mutex.Unlock()
+
+	statuses := []LinkStatus{}
+	for _, status := range processManager.inProgress {
+		if status.Attempts > 0 {
+			statuses = append(statuses, *status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].RequestEventID.String() < statuses[j].RequestEventID.String()
+	})
+	return statuses
+}
+
+func (processManager *LinkProcessManager) GetParkedLinks() ([]entities.PendingLink, error) {
+	pendingLinks, err := processManager.getPendingLinks()
+	if err != nil {
+		return nil, err
+	}
+	return pendingLinks.GetLinks(), nil
+}
+
+// RetryParkedLink makes one more attempt of a parked link and, if it succeeds, removes it from the parked ones
+func (processManager *LinkProcessManager) RetryParkedLink(ctx context.Context, requestEventID uuid.UUID) error {
+	pendingLinks, err := processManager.getPendingLinks()
+	if err != nil {
+		return err
+	}
+	parkedLink, found := pendingLinks.FindLink(requestEventID)
+	if !found {
+		return ErrLinkNotParked
+	}
+	link := Link{
+		RequestEventID:   parkedLink.RequestEventID,
+		RequestEventType: parkedLink.RequestEventType,
+		ChildID:          parkedLink.ChildID,
+		ParentID:         parkedLink.ParentID,
+		PreviousParentID: parkedLink.PreviousParentID,
+	}
+	linker, err := processManager.getLinker(link)
+	if err != nil {
+		return err
+	}
+	err = linker(ctx, link)
+	if err != nil {
+		return err
+	}
+	return eventutils.RetryOnConcurrencyConflict(maxConcurrencyConflictRetries, func() error {
+		pendingLinks, err := processManager.getPendingLinks()
+		if err != nil {
+			return err
+		}
+		pendingLinks.Resolve(requestEventID)
+		return processManager.entityRepository.SaveEntity(ctx, pendingLinks)
+	})
+}
+
+func (processManager *LinkProcessManager) park(ctx context.Context, link Link, attempts int, lastErr error) error {
+	return eventutils.RetryOnConcurrencyConflict(maxConcurrencyConflictRetries, func() error {
+		pendingLinks, err := processManager.getPendingLinks()
+		if err != nil {
+			return err
+		}
+		pendingLinks.Park(entities.PendingLink{
+			RequestEventID:   link.RequestEventID,
+			RequestEventType: link.RequestEventType,
+			ChildID:          link.ChildID,
+			ParentID:         link.ParentID,
+			PreviousParentID: link.PreviousParentID,
+			Attempts:         attempts,
+			LastError:        lastErr.Error(),
+		})
+		return processManager.entityRepository.SaveEntity(ctx, pendingLinks)
+	})
+}
+
+func (processManager *LinkProcessManager) getPendingLinks() (*entities.PendingLinks, error) {
+	pendingLinks, err := processManager.entityRepository.GetEntity(&entities.PendingLinks{}, entities.PendingLinksID)
+	if errors.Is(err, eventutils.ErrEntityNotFound) {
+		return entities.NewPendingLinks(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pendingLinks.(*entities.PendingLinks), nil
+}
+
+func (processManager *LinkProcessManager) getLinker(link Link) (func(ctx context.Context, link Link) error, error) {
+	linker, found := processManager.linkers[link.RequestEventType]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", eventutils.ErrUnknownEventType, link.RequestEventType)
+	}
+	return linker, nil
+}
+
+func (processManager *LinkProcessManager) track(link Link) *LinkStatus {
+	processManager.mutex.Lock()
+	defer processManager.mutex.Unlock()
+
+	status := &LinkStatus{Link: link}
+	processManager.inProgress[link.RequestEventID] = status
+	return status
+}
+
+func (processManager *LinkProcessManager) recordFailure(status *LinkStatus, attempt int, err error) {
+	processManager.mutex.Lock()
+	defer processManager.mutex.Unlock()
+
+	status.Attempts = attempt
+	status.LastError = err.Error()
+}
+
+func (processManager *LinkProcessManager) untrack(link Link) {
+	processManager.mutex.Lock()
+	defer processManager.mutex.Unlock()
+
+	delete(processManager.inProgress, link.RequestEventID)
+}
+
+// addCategoryToMenu adds the category to its menu, unless it was deleted or moved since
+func (processManager *LinkProcessManager) addCategoryToMenu(ctx context.Context, link Link) error {
+	return eventutils.RetryOnConcurrencyConflict(maxConcurrencyConflictRetries, func() error {
+		isChild, err := processManager.isChildOfParent(&entities.Category{}, link)
+		if err != nil || !isChild {
+			return err
+		}
+		menu, err := processManager.entityRepository.GetEntity(&entities.Menu{}, link.ParentID)
+		if err != nil {
+			return err
+		}
+		if utils.FindID(menu.(*entities.Menu).GetCategoriesIDs(), link.ChildID) != -1 {
+			return nil
+		}
+		menu.(*entities.Menu).AddCategory(link.ChildID)
+		return processManager.entityRepository.SaveEntity(ctx, menu)
+	})
+}
+
+func (processManager *LinkProcessManager) addSubCategoryToCategory(ctx context.Context, link Link) error {
+	return eventutils.RetryOnConcurrencyConflict(maxConcurrencyConflictRetries, func() error {
+		isChild, err := processManager.isChildOfParent(&entities.SubCategory{}, link)
+		if err != nil || !isChild {
+			return err
+		}
+		category, err := processManager.entityRepository.GetEntity(&entities.Category{}, link.ParentID)
+		if err != nil {
+			return err
+		}
+		if utils.FindID(category.(*entities.Category).GetSubCategoriesIDs(), link.ChildID) != -1 {
+			return nil
+		}
+		category.(*entities.Category).AddSubCategory(link.ChildID)
+		return processManager.entityRepository.SaveEntity(ctx, category)
+	})
+}
+
+func (processManager *LinkProcessManager) addMenuItemToSubCategory(ctx context.Context, link Link) error {
+	return eventutils.RetryOnConcurrencyConflict(maxConcurrencyConflictRetries, func() error {
+		isChild, err := processManager.isChildOfParent(&entities.MenuItem{}, link)
+		if err != nil || !isChild {
+			return err
+		}
+		subCategory, err := processManager.entityRepository.GetEntity(&entities.SubCategory{}, link.ParentID)
+		if err != nil {
+			return err
+		}
+		if utils.FindID(subCategory.(*entities.SubCategory).GetMenuItemsIDs(), link.ChildID) != -1 {
+			return nil
+		}
+		subCategory.(*entities.SubCategory).AddMenuItem(link.ChildID)
+		return processManager.entityRepository.SaveEntity(ctx, subCategory)
+	})
+}
+
+// removeCategoryFromMenu removes the deleted category from its menu, unless the menu was deleted too
+func (processManager *LinkProcessManager) removeCategoryFromMenu(ctx context.Context, link Link) error {
+	return eventutils.RetryOnConcurrencyConflict(maxConcurrencyConflictRetries, func() error {
+		menu, err := processManager.entityRepository.GetEntity(&entities.Menu{}, link.ParentID)
+		if errors.Is(err, eventutils.ErrEntityNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if utils.FindID(menu.(*entities.Menu).GetCategoriesIDs(), link.ChildID) == -1 {
+			return nil
+		}
+		menu.(*entities.Menu).RemoveCategory(link.ChildID)
+		return processManager.entityRepository.SaveEntity(ctx, menu)
+	})
+}
+
+func (processManager *LinkProcessManager) removeSubCategoryFromCategory(ctx context.Context, link Link) error {
+	return eventutils.RetryOnConcurrencyConflict(maxConcurrencyConflictRetries, func() error {
+		category, err := processManager.entityRepository.GetEntity(&entities.Category{}, link.ParentID)
+		if errors.Is(err, eventutils.ErrEntityNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if utils.FindID(category.(*entities.Category).GetSubCategoriesIDs(), link.ChildID) == -1 {
+			return nil
+		}
+		category.(*entities.Category).RemoveSubCategory(link.ChildID)
+		return processManager.entityRepository.SaveEntity(ctx, category)
+	})
+}
+
+func (processManager *LinkProcessManager) removeMenuItemFromSubCategory(ctx context.Context, link Link) error {
+	return eventutils.RetryOnConcurrencyConflict(maxConcurrencyConflictRetries, func() error {
+		subCategory, err := processManager.entityRepository.GetEntity(&entities.SubCategory{}, link.ParentID)
+		if errors.Is(err, eventutils.ErrEntityNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if utils.FindID(subCategory.(*entities.SubCategory).GetMenuItemsIDs(), link.ChildID) == -1 {
+			return nil
+		}
+		subCategory.(*entities.SubCategory).RemoveMenuItem(link.ChildID)
+		return processManager.entityRepository.SaveEntity(ctx, subCategory)
+	})
+}
+
+// moveCategory removes the category from its previous menu before adding it, unless a later move or deletion superseded it
+func (processManager *LinkProcessManager) moveCategory(ctx context.Context, link Link) error {
+	isChild, err := processManager.isChildOfParent(&entities.Category{}, link)
+	if err != nil || !isChild {
+		return err
+	}
+	err = processManager.removeCategoryFromMenu(ctx, previousParentLink(link))
+	if err != nil {
+		return err
+	}
+	return processManager.addCategoryToMenu(ctx, link)
+}
+
+func (processManager *LinkProcessManager) moveSubCategory(ctx context.Context, link Link) error {
+	isChild, err := processManager.isChildOfParent(&entities.SubCategory{}, link)
+	if err != nil || !isChild {
+		return err
+	}
+	err = processManager.removeSubCategoryFromCategory(ctx, previousParentLink(link))
+	if err != nil {
+		return err
+	}
+	return processManager.addSubCategoryToCategory(ctx, link)
+}
+
+func (processManager *LinkProcessManager) moveMenuItem(ctx context.Context, link Link) error {
+	isChild, err := processManager.isChildOfParent(&entities.MenuItem{}, link)
+	if err != nil || !isChild {
+		return err
+	}
+	err = processManager.removeMenuItemFromSubCategory(ctx, previousParentLink(link))
+	if err != nil {
+		return err
+	}
+	return processManager.addMenuItemToSubCategory(ctx, link)
+}
+
+// isChildOfParent tells whether the child of the link still exists and has the parent of the link
+func (processManager *LinkProcessManager) isChildOfParent(child eventutils.IReconstructible, link Link) (bool, error) {
+	child, err := processManager.entityRepository.GetEntity(child, link.ChildID)
+	if errors.Is(err, eventutils.ErrEntityNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	switch child := child.(type) {
+	case *entities.Category:
+		return child.GetParentMenuID() == link.ParentID, nil
+	case *entities.SubCategory:
+		return child.GetParentCategoryID() == link.ParentID, nil
+	case *entities.MenuItem:
+		return child.GetParentSubCategoryID() == link.ParentID, nil
+	}
+	return false, fmt.Errorf("%T has no parent", child)
+}
+
+// previousParentLink returns the link of a move with the previous parent as parent
+func previousParentLink(link Link) Link {
+	link.ParentID = link.PreviousParentID
+	link.PreviousParentID = uuid.Nil
+	return link
+}
+
+// Errors
+
+var (
+	ErrLinkNotParked = errors.New("link is not parked")
+)
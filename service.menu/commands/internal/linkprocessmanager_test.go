@@ -0,0 +1,309 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Resta-Inc/resta/menu/commands/internal/entities"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLink_WhenAttemptsAreExhausted(t *testing.T) {
+	// Arrange
+	category := entities.NewCategory(utils.GenerateNewUUID())
+	link := Link{
+		RequestEventID:   utils.GenerateNewUUID(),
+		RequestEventType: "CategoryCreated",
+		ChildID:          category.ID,
+		ParentID:         category.GetParentMenuID(),
+	}
+
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Category{}, link.ChildID).
+		Return(category, nil)
+
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, link.ParentID).
+		Return(nil, errors.New("event store unavailable")).
+		Times(testLinkRetryPolicy.MaxAttempts)
+
+	mockEntityRepository.
+		On("GetEntity", &entities.PendingLinks{}, entities.PendingLinksID).
+		Return(nil, eventutils.ErrEntityNotFound)
+
+	mockEntityRepository.
+		On("SaveEntity", mock.MatchedBy(
+			func(pendingLinks *entities.PendingLinks) bool {
+				parkedLink, found := pendingLinks.FindLink(link.RequestEventID)
+				return found &&
+					parkedLink.ChildID == link.ChildID &&
+					parkedLink.ParentID == link.ParentID &&
+					parkedLink.Attempts == testLinkRetryPolicy.MaxAttempts &&
+					parkedLink.LastError == "event store unavailable"
+			},
+		)).
+		Return(nil)
+
+	processManager := NewLinkProcessManager(mockEntityRepository, testLinkRetryPolicy)
+
+	// Act
+	err := processManager.Link(context.Background(), link)
+
+	// Assert
+	require.NoError(t, err)
+	mockEntityRepository.AssertExpectations(t)
+	require.Empty(t, processManager.GetInProgressLinks())
+}
+
+func TestLink_WhenLinkCannotBeParked(t *testing.T) {
+	// Arrange
+	subCategory := entities.NewSubCategory(utils.GenerateNewUUID())
+	link := Link{
+		RequestEventID:   utils.GenerateNewUUID(),
+		RequestEventType: "SubCategoryCreated",
+		ChildID:          subCategory.ID,
+		ParentID:         subCategory.GetParentCategoryID(),
+	}
+	unavailable := errors.New("event store unavailable")
+
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.SubCategory{}, link.ChildID).
+		Return(subCategory, nil)
+
+	mockEntityRepository.
+		On("GetEntity", &entities.Category{}, link.ParentID).
+		Return(nil, unavailable)
+
+	mockEntityRepository.
+		On("GetEntity", &entities.PendingLinks{}, entities.PendingLinksID).
+		Return(nil, unavailable)
+
+	processManager := NewLinkProcessManager(mockEntityRepository, testLinkRetryPolicy)
+
+	// Act
+	err := processManager.Link(context.Background(), link)
+
+	// Assert
+	require.ErrorIs(t, err, unavailable)
+}
+
+func TestLink_WhenContextIsDone(t *testing.T) {
+	// Arrange
+	category := entities.NewCategory(utils.GenerateNewUUID())
+	link := Link{
+		RequestEventID:   utils.GenerateNewUUID(),
+		RequestEventType: "CategoryCreated",
+		ChildID:          category.ID,
+		ParentID:         category.GetParentMenuID(),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// SaveEntity is not expected, the link must not be parked while the service is stopping
+	mockEntityRepository := new(eventutils.MockEntityRepository)
+	mockEntityRepository.
+		On("GetEntity", &entities.Category{}, link.ChildID).
+		Return(category, nil).
+		Once()
+
+	mockEntityRepository.
+		On("GetEntity", &entities.Menu{}, link.ParentID).
+		Return(nil, errors.New("event store unavailable")).
+		Once()
+
+	processManager := NewLinkProcessManager(mockEntityRepository, testLinkRetryPolicy)
+
+	// Act
+	err := processManager.Link(ctx, link)
+
+	// Assert
+	require.ErrorIs(t, err, context.Canceled)
+	mockEntityRepository.AssertExpectations(t)
+}
+
+func TestLink_WhenChildIsAlreadyLinked(t *testing.T) {
+	menu := entities.NewMenu()
+	category := entities.NewCategory(menu.ID)
+	subCategory := entities.NewSubCategory(category.ID)
+	menuItem := entities.NewMenuItem(subCategory.ID)
+	testCases := []struct {
+		requestEventType string
+		parent           eventutils.IReconstructible
+		child            eventutils.IReconstructible
+		getChildrenIDs   func(repo eventutils.IEntityRepository) []uuid.UUID
+	}{
+		{"CategoryCreated", menu, category, func(repo eventutils.IEntityRepository) []uuid.UUID {
+			foundMenu, err := repo.GetEntity(&entities.Menu{}, menu.ID)
+			require.NoError(t, err)
+			return foundMenu.(*entities.Menu).GetCategoriesIDs()
+		}},
+		{"SubCategoryCreated", category, subCategory, func(repo eventutils.IEntityRepository) []uuid.UUID {
+			foundCategory, err := repo.GetEntity(&entities.Category{}, category.ID)
+			require.NoError(t, err)
+			return foundCategory.(*entities.Category).GetSubCategoriesIDs()
+		}},
+		{"MenuItemCreated", subCategory, menuItem, func(repo eventutils.IEntityRepository) []uuid.UUID {
+			foundSubCategory, err := repo.GetEntity(&entities.SubCategory{}, subCategory.ID)
+			require.NoError(t, err)
+			return foundSubCategory.(*entities.SubCategory).GetMenuItemsIDs()
+		}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.requestEventType, func(t *testing.T) {
+			// Arrange
+			entityRepository := eventutils.NewEntityRepository(eventutils.NewInMemoryEventStore())
+			processManager := NewLinkProcessManager(entityRepository, testLinkRetryPolicy)
+			err := entityRepository.SaveEntity(context.Background(), testCase.parent)
+			require.NoError(t, err)
+			err = entityRepository.SaveEntity(context.Background(), testCase.child)
+			require.NoError(t, err)
+			link := Link{
+				RequestEventID:   utils.GenerateNewUUID(),
+				RequestEventType: testCase.requestEventType,
+				ChildID:          testCase.child.GetID(),
+				ParentID:         testCase.parent.GetID(),
+			}
+			err = processManager.Link(context.Background(), link)
+			require.NoError(t, err)
+
+			// Act
+			// the creation event is delivered again
+			err = processManager.Link(context.Background(), link)
+
+			// Assert
+			require.NoError(t, err)
+			require.Equal(t, []uuid.UUID{link.ChildID}, testCase.getChildrenIDs(entityRepository))
+		})
+	}
+}
+
+func TestLink_WhenChildWasDeletedOrMoved(t *testing.T) {
+	testCases := []struct {
+		name        string
+		changeChild func(category *entities.Category)
+	}{
+		{"Deleted", func(category *entities.Category) {
+			category.Delete()
+		}},
+		{"Moved", func(category *entities.Category) {
+			require.NoError(t, category.MoveToMenu(utils.GenerateNewUUID()))
+		}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			entityRepository := eventutils.NewEntityRepository(eventutils.NewInMemoryEventStore())
+			processManager := NewLinkProcessManager(entityRepository, testLinkRetryPolicy)
+			menu := entities.NewMenu()
+			category := entities.NewCategory(menu.ID)
+			testCase.changeChild(category)
+			require.NoError(t, entityRepository.SaveEntity(context.Background(), menu))
+			require.NoError(t, entityRepository.SaveEntity(context.Background(), category))
+			link := Link{
+				RequestEventID:   utils.GenerateNewUUID(),
+				RequestEventType: "CategoryCreated",
+				ChildID:          category.ID,
+				ParentID:         menu.ID,
+			}
+
+			// Act
+			err := processManager.Link(context.Background(), link)
+
+			// Assert
+			require.NoError(t, err)
+			foundMenu, err := entityRepository.GetEntity(&entities.Menu{}, menu.ID)
+			require.NoError(t, err)
+			require.Empty(t, foundMenu.(*entities.Menu).GetCategoriesIDs())
+		})
+	}
+}
+
+func TestLink_WhenMoveWasSuperseded(t *testing.T) {
+	// Arrange
+	entityRepository := eventutils.NewEntityRepository(eventutils.NewInMemoryEventStore())
+	processManager := NewLinkProcessManager(entityRepository, testLinkRetryPolicy)
+	firstMenu, secondMenu, thirdMenu := entities.NewMenu(), entities.NewMenu(), entities.NewMenu()
+	category := entities.NewCategory(firstMenu.ID)
+	firstMenu.AddCategory(category.ID)
+	require.NoError(t, category.MoveToMenu(secondMenu.ID))
+	require.NoError(t, category.MoveToMenu(thirdMenu.ID))
+	for _, entity := range []eventutils.IReconstructible{firstMenu, secondMenu, thirdMenu, category} {
+		require.NoError(t, entityRepository.SaveEntity(context.Background(), entity))
+	}
+	link := Link{
+		RequestEventID:   utils.GenerateNewUUID(),
+		RequestEventType: "CategoryMoved",
+		ChildID:          category.ID,
+		ParentID:         secondMenu.ID,
+		PreviousParentID: firstMenu.ID,
+	}
+
+	// Act
+	err := processManager.Link(context.Background(), link)
+
+	// Assert
+	require.NoError(t, err)
+	foundFirstMenu, err := entityRepository.GetEntity(&entities.Menu{}, firstMenu.ID)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{category.ID}, foundFirstMenu.(*entities.Menu).GetCategoriesIDs())
+	foundSecondMenu, err := entityRepository.GetEntity(&entities.Menu{}, secondMenu.ID)
+	require.NoError(t, err)
+	require.Empty(t, foundSecondMenu.(*entities.Menu).GetCategoriesIDs())
+}
+
+func TestRetryParkedLink(t *testing.T) {
+	// Arrange
+	entityRepository := eventutils.NewEntityRepository(eventutils.NewInMemoryEventStore())
+	processManager := NewLinkProcessManager(entityRepository, testLinkRetryPolicy)
+	menu := entities.NewMenu()
+	category := entities.NewCategory(menu.ID)
+	err := entityRepository.SaveEntity(context.Background(), category)
+	require.NoError(t, err)
+	link := Link{
+		RequestEventID:   utils.GenerateNewUUID(),
+		RequestEventType: "CategoryCreated",
+		ChildID:          category.ID,
+		ParentID:         menu.ID,
+	}
+
+	// the menu does not exist yet, so the link is parked
+	err = processManager.Link(context.Background(), link)
+	require.NoError(t, err)
+	parkedLinks, err := processManager.GetParkedLinks()
+	require.NoError(t, err)
+	require.Len(t, parkedLinks, 1)
+
+	err = entityRepository.SaveEntity(context.Background(), menu)
+	require.NoError(t, err)
+
+	// Act
+	err = processManager.RetryParkedLink(context.Background(), link.RequestEventID)
+
+	// Assert
+	require.NoError(t, err)
+	foundMenu, err := entityRepository.GetEntity(&entities.Menu{}, menu.ID)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{link.ChildID}, foundMenu.(*entities.Menu).GetCategoriesIDs())
+	parkedLinks, err = processManager.GetParkedLinks()
+	require.NoError(t, err)
+	require.Empty(t, parkedLinks)
+}
+
+func TestRetryParkedLink_WhenLinkIsNotParked(t *testing.T) {
+	// Arrange
+	entityRepository := eventutils.NewEntityRepository(eventutils.NewInMemoryEventStore())
+	processManager := NewLinkProcessManager(entityRepository, testLinkRetryPolicy)
+
+	// Act
+	err := processManager.RetryParkedLink(context.Background(), utils.GenerateNewUUID())
+
+	// Assert
+	require.ErrorIs(t, err, ErrLinkNotParked)
+}
@@ -0,0 +1,207 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/menu/commands/internal/entities"
+	"github.com/Resta-Inc/resta/pkg/events"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
+)
+
+// MenuDuplicator copies a menu with its categories, subcategories, menu items and images into new aggregates.
+// The copy spans many streams, so it is tracked by a MenuDuplication and made by the handler of its MenuDuplicationStarted.
+// The duplicates are created in the order of the source menu, and added to their parents in the background
+// like any new category, subcategory or menu item, so they keep that order.
+type MenuDuplicator struct {
+	repository   eventutils.IEntityRepository
+	resourcePath string
+}
+
+func NewMenuDuplicator(repo eventutils.IEntityRepository, resourcePath string) MenuDuplicator {
+	return MenuDuplicator{
+		repository:   repo,
+		resourcePath: resourcePath,
+	}
+}
+
+// Start saves the MenuDuplication of the menu, the menu is copied when its MenuDuplicationStarted is handled
+func (duplicator MenuDuplicator) Start(ctx context.Context, sourceMenu *entities.Menu) (*entities.MenuDuplication, error) {
+	duplication := entities.NewMenuDuplication(sourceMenu.ID, utils.GenerateNewUUID())
+	err := duplicator.repository.SaveEntity(ctx, duplication)
+	if err != nil {
+		return nil, err
+	}
+	return duplication, nil
+}
+
+// HandleMenuDuplicationStarted copies the menu of a running duplication and completes it, or fails it with the
+// error of the copy. The duplicates have IDs derived from the ones of the new menu and of their source, so when
+// the copy is interrupted, for example by a restart, the event is delivered again and the copy resumes: the
+// duplicates saved by the previous attempt are kept.
+func (duplicator MenuDuplicator) HandleMenuDuplicationStarted(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	event := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+	deserializedEvent, err := entities.MenuDuplication{}.DeserializeEvent(event)
+	if err != nil {
+		return err
+	}
+	duplicationStartedEvent := deserializedEvent.(events.MenuDuplicationStarted)
+	duplication, err := duplicator.repository.GetEntity(&entities.MenuDuplication{}, duplicationStartedEvent.GetEntityID())
+	if err != nil {
+		return err
+	}
+	if duplication.(*entities.MenuDuplication).GetStatus() != entities.MenuDuplicationRunning {
+		return nil
+	}
+	ctx = eventutils.ContextWithMetadata(ctx, eventutils.CausedBy(event))
+	duplicationErr := duplicator.duplicateMenu(ctx, duplicationStartedEvent.SourceMenuID, duplicationStartedEvent.NewMenuID)
+	return eventutils.RetryOnConcurrencyConflict(maxConcurrencyConflictRetries, func() error {
+		duplication, err := duplicator.repository.GetEntity(&entities.MenuDuplication{}, duplicationStartedEvent.GetEntityID())
+		if err != nil {
+			return err
+		}
+		if duplication.(*entities.MenuDuplication).GetStatus() != entities.MenuDuplicationRunning {
+			return nil
+		}
+		if duplicationErr != nil {
+			duplication.(*entities.MenuDuplication).Fail(duplicationErr)
+		} else {
+			duplication.(*entities.MenuDuplication).Complete()
+		}
+		return duplicator.repository.SaveEntity(ctx, duplication)
+	})
+}
+
+func (duplicator MenuDuplicator) duplicateMenu(ctx context.Context, sourceMenuID, newMenuID uuid.UUID) error {
+	menu, err := duplicator.repository.GetEntity(&entities.Menu{}, sourceMenuID)
+	if err != nil {
+		return fmt.Errorf("could not read the menu %s: %w", sourceMenuID, err)
+	}
+	sourceMenu := menu.(*entities.Menu)
+	err = duplicator.saveDuplicate(ctx, sourceMenu.Duplicate(newMenuID))
+	if err != nil {
+		return err
+	}
+	for _, categoryID := range sourceMenu.GetCategoriesIDs() {
+		err = duplicator.duplicateCategory(ctx, categoryID, newMenuID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (duplicator MenuDuplicator) duplicateCategory(ctx context.Context, categoryID, newMenuID uuid.UUID) error {
+	category, err := duplicator.repository.GetEntity(&entities.Category{}, categoryID)
+	if errors.Is(err, eventutils.ErrEntityNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read the category %s: %w", categoryID, err)
+	}
+	sourceCategory := category.(*entities.Category)
+	newCategory := sourceCategory.Duplicate(duplicateID(newMenuID, categoryID), newMenuID)
+	err = duplicator.saveDuplicate(ctx, newCategory)
+	if err != nil {
+		return err
+	}
+	err = duplicator.copyImage("images/categories", categoryID, newCategory.ID)
+	if err != nil {
+		return err
+	}
+	for _, subCategoryID := range sourceCategory.GetSubCategoriesIDs() {
+		err = duplicator.duplicateSubCategory(ctx, subCategoryID, newMenuID, newCategory.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (duplicator MenuDuplicator) duplicateSubCategory(ctx context.Context, subCategoryID, newMenuID, newCategoryID uuid.UUID) error {
+	subCategory, err := duplicator.repository.GetEntity(&entities.SubCategory{}, subCategoryID)
+	if errors.Is(err, eventutils.ErrEntityNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read the subcategory %s: %w", subCategoryID, err)
+	}
+	sourceSubCategory := subCategory.(*entities.SubCategory)
+	newSubCategory := sourceSubCategory.Duplicate(duplicateID(newMenuID, subCategoryID), newCategoryID)
+	err = duplicator.saveDuplicate(ctx, newSubCategory)
+	if err != nil {
+		return err
+	}
+	err = duplicator.copyImage("images/subcategories", subCategoryID, newSubCategory.ID)
+	if err != nil {
+		return err
+	}
+	for _, menuItemID := range sourceSubCategory.GetMenuItemsIDs() {
+		err = duplicator.duplicateMenuItem(ctx, menuItemID, newMenuID, newSubCategory.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (duplicator MenuDuplicator) duplicateMenuItem(ctx context.Context, menuItemID, newMenuID, newSubCategoryID uuid.UUID) error {
+	menuItem, err := duplicator.repository.GetEntity(&entities.MenuItem{}, menuItemID)
+	if errors.Is(err, eventutils.ErrEntityNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read the menu item %s: %w", menuItemID, err)
+	}
+	newMenuItem, err := menuItem.(*entities.MenuItem).Duplicate(duplicateID(newMenuID, menuItemID), newSubCategoryID)
+	if err != nil {
+		return fmt.Errorf("could not copy the menu item %s: %w", menuItemID, err)
+	}
+	return duplicator.saveDuplicate(ctx, newMenuItem)
+}
+
+// saveDuplicate saves a new duplicate, unless an earlier attempt of the duplication already saved it
+func (duplicator MenuDuplicator) saveDuplicate(ctx context.Context, duplicate eventutils.IReconstructible) error {
+	err := duplicator.repository.SaveEntity(ctx, duplicate)
+	if errors.Is(err, eventutils.ErrConcurrencyConflict) {
+		return nil
+	}
+	return err
+}
+
+// duplicateID returns the ID of the duplicate of the source entity in the new menu, the same at every attempt
+func duplicateID(newMenuID, sourceID uuid.UUID) uuid.UUID {
+	return uuid.NewV5(newMenuID, sourceID.String())
+}
+
+// copyImage copies the image of the source entity, saved like saveFile does, to the new entity.
+// An entity without an image is skipped.
+func (duplicator MenuDuplicator) copyImage(directory string, sourceID, newID uuid.UUID) error {
+	path := filepath.Join(duplicator.resourcePath, directory)
+	source, err := os.Open(filepath.Join(path, fmt.Sprintf("%s.jpg", sourceID)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := os.Create(filepath.Join(path, fmt.Sprintf("%s.jpg", newID)))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(destination, source)
+	if err != nil {
+		destination.Close()
+		return err
+	}
+	return destination.Close()
+}
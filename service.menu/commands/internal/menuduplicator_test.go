@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Resta-Inc/resta/menu/commands/internal/entities"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleMenuDuplicationStarted_WhenAnEarlierAttemptWasInterrupted(t *testing.T) {
+	// Arrange
+	eventStore := eventutils.NewInMemoryEventStore()
+	entityRepository := eventutils.NewEntityRepository(eventStore)
+	duplicator := NewMenuDuplicator(entityRepository, t.TempDir())
+
+	menu := entities.NewMenu()
+	starters, mains := entities.NewCategory(menu.ID), entities.NewCategory(menu.ID)
+	menu.AddCategory(starters.ID)
+	menu.AddCategory(mains.ID)
+	for _, entity := range []eventutils.IReconstructible{menu, starters, mains} {
+		require.NoError(t, entityRepository.SaveEntity(context.Background(), entity))
+	}
+	duplication, err := duplicator.Start(context.Background(), menu)
+	require.NoError(t, err)
+
+	// the earlier attempt saved the new menu and the copy of the first category before being interrupted
+	newMenuID := duplication.GetNewMenuID()
+	require.NoError(t, entityRepository.SaveEntity(context.Background(), menu.Duplicate(newMenuID)))
+	require.NoError(t, entityRepository.SaveEntity(context.Background(), starters.Duplicate(duplicateID(newMenuID, starters.ID), newMenuID)))
+
+	incomingMessage := eventStore.SubscribeToAll("MenuDuplicationStarted").Recv()
+
+	// Act
+	err = duplicator.HandleMenuDuplicationStarted(context.Background(), incomingMessage)
+
+	// Assert
+	require.NoError(t, err)
+	foundDuplication, err := entityRepository.GetEntity(&entities.MenuDuplication{}, duplication.ID)
+	require.NoError(t, err)
+	require.Equal(t, entities.MenuDuplicationCompleted, foundDuplication.(*entities.MenuDuplication).GetStatus())
+	newMains, err := entityRepository.GetEntity(&entities.Category{}, duplicateID(newMenuID, mains.ID))
+	require.NoError(t, err)
+	require.Equal(t, newMenuID, newMains.(*entities.Category).GetParentMenuID())
+}
+
+func TestHandleMenuDuplicationStarted_WhenDuplicationIsCompleted(t *testing.T) {
+	// Arrange
+	eventStore := eventutils.NewInMemoryEventStore()
+	entityRepository := eventutils.NewEntityRepository(eventStore)
+	duplicator := NewMenuDuplicator(entityRepository, t.TempDir())
+
+	menu := entities.NewMenu()
+	require.NoError(t, entityRepository.SaveEntity(context.Background(), menu))
+	duplication, err := duplicator.Start(context.Background(), menu)
+	require.NoError(t, err)
+	incomingMessage := eventStore.SubscribeToAll("MenuDuplicationStarted").Recv()
+	require.NoError(t, duplicator.HandleMenuDuplicationStarted(context.Background(), incomingMessage))
+	foundDuplication, err := entityRepository.GetEntity(&entities.MenuDuplication{}, duplication.ID)
+	require.NoError(t, err)
+
+	// Act
+	err = duplicator.HandleMenuDuplicationStarted(context.Background(), incomingMessage)
+
+	// Assert
+	require.NoError(t, err)
+	duplicationAfterRedelivery, err := entityRepository.GetEntity(&entities.MenuDuplication{}, duplication.ID)
+	require.NoError(t, err)
+	require.Equal(t, foundDuplication.GetRevision(), duplicationAfterRedelivery.GetRevision())
+}
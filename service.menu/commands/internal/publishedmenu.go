@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Resta-Inc/resta/menu/commands/internal/entities"
+	"github.com/Resta-Inc/resta/pkg/events"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/gofrs/uuid"
+)
+
+// readPublishedCategories reads the categories of the menu with their subcategories and menu items,
+// in their order, as they are published. A child deleted but not removed from its parent yet is skipped.
+func readPublishedCategories(repo eventutils.IEntityRepository, menu *entities.Menu) ([]events.PublishedCategory, error) {
+	categories := []events.PublishedCategory{}
+	for _, categoryID := range menu.GetCategoriesIDs() {
+		entity, err := repo.GetEntity(&entities.Category{}, categoryID)
+		if errors.Is(err, eventutils.ErrEntityNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read the category %s: %w", categoryID, err)
+		}
+		category := entity.(*entities.Category)
+		subCategories, err := readPublishedSubCategories(repo, category.GetSubCategoriesIDs())
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, events.PublishedCategory{
+			ID:            category.ID,
+			Name:          category.GetName(),
+			SubCategories: subCategories,
+		})
+	}
+	return categories, nil
+}
+
+func readPublishedSubCategories(repo eventutils.IEntityRepository, subCategoriesIDs []uuid.UUID) ([]events.PublishedSubCategory, error) {
+	subCategories := []events.PublishedSubCategory{}
+	for _, subCategoryID := range subCategoriesIDs {
+		entity, err := repo.GetEntity(&entities.SubCategory{}, subCategoryID)
+		if errors.Is(err, eventutils.ErrEntityNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read the subcategory %s: %w", subCategoryID, err)
+		}
+		subCategory := entity.(*entities.SubCategory)
+		menuItems, err := readPublishedMenuItems(repo, subCategory.GetMenuItemsIDs())
+		if err != nil {
+			return nil, err
+		}
+		subCategories = append(subCategories, events.PublishedSubCategory{
+			ID:        subCategory.ID,
+			Name:      subCategory.GetName(),
+			MenuItems: menuItems,
+		})
+	}
+	return subCategories, nil
+}
+
+func readPublishedMenuItems(repo eventutils.IEntityRepository, menuItemsIDs []uuid.UUID) ([]events.PublishedMenuItem, error) {
+	menuItems := []events.PublishedMenuItem{}
+	for _, menuItemID := range menuItemsIDs {
+		entity, err := repo.GetEntity(&entities.MenuItem{}, menuItemID)
+		if errors.Is(err, eventutils.ErrEntityNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read the menu item %s: %w", menuItemID, err)
+		}
+		menuItems = append(menuItems, publishedMenuItem(entity.(*entities.MenuItem)))
+	}
+	return menuItems, nil
+}
+
+func publishedMenuItem(menuItem *entities.MenuItem) events.PublishedMenuItem {
+	variants := []events.PublishedVariant{}
+	for _, variant := range menuItem.GetVariants() {
+		variants = append(variants, events.PublishedVariant{
+			ID:    variant.ID,
+			Name:  variant.Name,
+			Price: variant.Price,
+		})
+	}
+	modifierGroups := []events.PublishedModifierGroup{}
+	for _, modifierGroup := range menuItem.GetModifierGroups() {
+		modifiers := []events.PublishedModifier{}
+		for _, modifier := range modifierGroup.Modifiers {
+			modifiers = append(modifiers, events.PublishedModifier{
+				ID:         modifier.ID,
+				Name:       modifier.Name,
+				PriceDelta: modifier.PriceDelta,
+			})
+		}
+		modifierGroups = append(modifierGroups, events.PublishedModifierGroup{
+			ID:            modifierGroup.ID,
+			Name:          modifierGroup.Name,
+			MinSelections: modifierGroup.MinSelections,
+			MaxSelections: modifierGroup.MaxSelections,
+			Modifiers:     modifiers,
+		})
+	}
+	return events.PublishedMenuItem{
+		ID:                       menuItem.ID,
+		Name:                     menuItem.GetName(),
+		Price:                    menuItem.GetPrice(),
+		EstimatedPreparationTime: menuItem.GetEstimatedPreparationtime(),
+		Description:              menuItem.GetDescription(),
+		Allergens:                menuItem.GetAllergens(),
+		DietaryTags:              menuItem.GetDietaryTags(),
+		Variants:                 variants,
+		ModifierGroups:           modifierGroups,
+	}
+}
@@ -4,12 +4,22 @@ import (
 	"os"
 	"testing"
 
+	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/Resta-Inc/resta/pkg/utils"
 	"github.com/benbjohnson/clock"
 )
 
+// the clock is mocked, so the tests must not wait between the attempts
+var testLinkRetryPolicy = eventutils.RetryPolicy{
+	MaxAttempts: 3,
+}
+
 func TestMain(m *testing.M) {
 	utils.Time = clock.NewMock()
 	code := m.Run()
 	os.Exit(code)
 }
+
+func newTestParkedEvents() eventutils.IParkedEvents {
+	return eventutils.NewInMemoryEventStore().SubscribeToAll()
+}
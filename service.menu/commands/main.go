@@ -1,6 +1,12 @@
 package main
 
 import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/EventStore/EventStore-Client-Go/esdb"
 	"github.com/Resta-Inc/resta/menu/commands/internal"
 	"github.com/Resta-Inc/resta/pkg/eventutils"
@@ -13,25 +19,90 @@ func main() {
 	config := internal.LoadConfig(".")
 	utils.Time = clock.New()
 
-	settings, _ := esdb.ParseConnectionString(config.EventStoreConnectionString)
-	db, _ := esdb.NewClient(settings)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	eventStore, err := eventutils.NewEventStore(db)
-	if err != nil {
-		panic(err)
+	var eventStore eventutils.IEventStore
+	var snapshotStore eventutils.ISnapshotStore
+	var eventHandler *eventutils.EventHandler
+	if config.UseInMemoryEventStore {
+		inMemoryEventStore := eventutils.NewInMemoryEventStore()
+		eventStore = inMemoryEventStore
+		snapshotStore = inMemoryEventStore
+		eventHandler = eventutils.NewEventHandlerFromSubscription(
+			inMemoryEventStore.SubscribeToAll(
+				"CategoryCreated", "SubCategoryCreated", "MenuItemCreated",
+				"CategoryDeleted", "SubCategoryDeleted", "MenuItemDeleted",
+				"CategoryMoved", "SubCategoryMoved", "MenuItemMoved",
+				"MenuDuplicationStarted",
+			),
+		)
+	} else {
+		settings, _ := esdb.ParseConnectionString(config.EventStoreConnectionString)
+		db, _ := esdb.NewClient(settings)
+
+		esdbEventStore, err := eventutils.NewEventStore(db)
+		if err != nil {
+			panic(err)
+		}
+		if config.EventStoreReadBatchSize > 0 {
+			esdbEventStore.ReadBatchSize = config.EventStoreReadBatchSize
+		}
+		eventStore = esdbEventStore
+		snapshotStore = esdbEventStore
+		eventHandler = eventutils.NewEventHandler(db, "menu.commands")
 	}
 
-	entityRepository := eventutils.NewEntityRepository(eventStore)
+	entityRepository := eventutils.NewEntityRepositoryWithSnapshots(eventStore, snapshotStore, config.SnapshotFrequency)
+
+	linkRetryPolicy := internal.DefaultLinkRetryPolicy
+	if config.LinkMaxAttempts > 0 {
+		linkRetryPolicy.MaxAttempts = config.LinkMaxAttempts
+	}
+	if config.LinkInitialBackoff > 0 {
+		linkRetryPolicy.InitialBackoff = config.LinkInitialBackoff
+	}
+	if config.LinkMaxBackoff > 0 {
+		linkRetryPolicy.MaxBackoff = config.LinkMaxBackoff
+	}
+	linkProcessManager := internal.NewLinkProcessManager(entityRepository, linkRetryPolicy)
 
-	eventHandler := eventutils.NewEventHandler(db, "menu.commands")
-	menuEventHandler := internal.NewMenuEventHandler(entityRepository)
+	menuEventHandler := internal.NewMenuEventHandler(linkProcessManager)
 	eventHandler.HandleEvent("CategoryCreated", menuEventHandler.HandleCategoryCreated)
 	eventHandler.HandleEvent("SubCategoryCreated", menuEventHandler.HandleSubCategoryCreated)
 	eventHandler.HandleEvent("MenuItemCreated", menuEventHandler.HandleMenuItemCreated)
-	eventHandler.Start()
+	eventHandler.HandleEvent("CategoryDeleted", menuEventHandler.HandleCategoryDeleted)
+	eventHandler.HandleEvent("SubCategoryDeleted", menuEventHandler.HandleSubCategoryDeleted)
+	eventHandler.HandleEvent("MenuItemDeleted", menuEventHandler.HandleMenuItemDeleted)
+	eventHandler.HandleEvent("CategoryMoved", menuEventHandler.HandleCategoryMoved)
+	eventHandler.HandleEvent("SubCategoryMoved", menuEventHandler.HandleSubCategoryMoved)
+	eventHandler.HandleEvent("MenuItemMoved", menuEventHandler.HandleMenuItemMoved)
+	menuDuplicator := internal.NewMenuDuplicator(entityRepository, config.ResourcePath)
+	eventHandler.HandleEvent("MenuDuplicationStarted", menuDuplicator.HandleMenuDuplicationStarted)
+	eventHandler.Start(ctx)
 
 	app := fiber.New()
 	internal.SetupApi(app, entityRepository, config.ResourcePath)
 
-	app.Listen(":10000")
+	adminApp := fiber.New()
+	internal.SetupAdminApi(adminApp, linkProcessManager, eventHandler)
+
+	go func() {
+		<-ctx.Done()
+		app.Shutdown()
+		adminApp.Shutdown()
+	}()
+
+	go func() {
+		err := adminApp.Listen(config.AdminListenAddress)
+		if err != nil {
+			log.Print(err)
+		}
+	}()
+
+	err := app.Listen(":10000")
+	if err != nil {
+		log.Print(err)
+	}
+	eventHandler.Stop()
 }
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"encoding/json"
+
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofrs/uuid"
+)
+
+// AdminApi is served on its own listener, see ADMIN_LISTEN_ADDRESS, so that it is not exposed with the public API
+type AdminApi struct {
+	parkedEvents eventutils.IParkedEvents
+}
+
+func SetupAdminApi(app *fiber.App, parkedEvents eventutils.IParkedEvents) {
+	api := AdminApi{
+		parkedEvents: parkedEvents,
+	}
+	api.setupRoutes(app)
+}
+
+func (api AdminApi) setupRoutes(app *fiber.App) {
+	app.Get("/admin/parked-events", api.GetParkedEvents)
+	app.Post("/admin/parked-events/replay", api.ReplayParkedEvents)
+}
+
+type ParkedEventView struct {
+	ID       uuid.UUID                `json:"id"`
+	Type     string                   `json:"type"`
+	Data     json.RawMessage          `json:"data"`
+	Metadata eventutils.EventMetadata `json:"metadata"`
+}
+
+func (api AdminApi) GetParkedEvents(c *fiber.Ctx) error {
+	parkedEvents, err := api.parkedEvents.GetParkedEvents()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to find the parked events, please try again later.")
+	}
+	views := []ParkedEventView{}
+	for _, event := range parkedEvents {
+		views = append(views, ParkedEventView{
+			ID:       event.ID,
+			Type:     event.Name,
+			Data:     event.Data,
+			Metadata: event.Metadata,
+		})
+	}
+	return c.JSON(views)
+}
+
+func (api AdminApi) ReplayParkedEvents(c *fiber.Ctx) error {
+	err := api.parkedEvents.ReplayParkedEvents()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to replay the parked events, please try again later.")
+	}
+	c.SendStatus(fiber.StatusAccepted)
+	return nil
+}
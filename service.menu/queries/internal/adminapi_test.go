@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetParkedEvents(t *testing.T) {
+	// Arrange
+	eventStore := eventutils.NewInMemoryEventStore()
+	subscription := eventStore.SubscribeToAll()
+	event := parkTestEvent(t, eventStore, subscription)
+
+	app := fiber.New()
+	SetupAdminApi(app, subscription)
+
+	request, err := http.NewRequest(http.MethodGet, "/admin/parked-events", nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	var parkedEvents []ParkedEventView
+	err = json.Unmarshal(body, &parkedEvents)
+	require.NoError(t, err)
+	require.Len(t, parkedEvents, 1)
+	require.Equal(t, event.ID, parkedEvents[0].ID)
+	require.Equal(t, event.Name, parkedEvents[0].Type)
+	require.JSONEq(t, string(event.Data), string(parkedEvents[0].Data))
+}
+
+func TestReplayParkedEvents(t *testing.T) {
+	// Arrange
+	eventStore := eventutils.NewInMemoryEventStore()
+	subscription := eventStore.SubscribeToAll()
+	event := parkTestEvent(t, eventStore, subscription)
+
+	app := fiber.New()
+	SetupAdminApi(app, subscription)
+
+	request, err := http.NewRequest(http.MethodPost, "/admin/parked-events/replay", nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusAccepted, resp.StatusCode)
+	parkedEvents, err := subscription.GetParkedEvents()
+	require.NoError(t, err)
+	require.Empty(t, parkedEvents)
+	replayedEvent := subscription.Recv()
+	require.Equal(t, event.ID.String(), replayedEvent.EventAppeared.Event.EventID.String())
+}
+
+func parkTestEvent(t *testing.T, eventStore *eventutils.InMemoryEventStore, subscription *eventutils.InMemorySubscription) eventutils.Event {
+	event := eventutils.Event{
+		ID:   utils.GenerateNewUUID(),
+		Name: "MenuCreated",
+		Data: []byte(`{"Name":"TestMenu"}`),
+	}
+	_, err := eventStore.SaveEventsToNewStream("Menu-"+utils.GenerateNewUUID().String(), []eventutils.Event{event})
+	require.NoError(t, err)
+	received := subscription.Recv()
+	err = subscription.Nack("test", esdb.Nack_Park, received.EventAppeared)
+	require.NoError(t, err)
+	return event
+}
@@ -4,12 +4,17 @@ import (
 	"database/sql"
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/Resta-Inc/resta/pkg/events"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofrs/uuid"
 )
 
+// NotFoundIDsHeader lists the IDs requested to a by-ids endpoint that were not found
+const NotFoundIDsHeader = "X-Not-Found-IDs"
+
 type Api struct {
 	menuRepository IMenuRepository
 	resourceHost   string
@@ -25,12 +30,17 @@ func SetupApi(app *fiber.App, repo IMenuRepository, resourcePath, resourceHost s
 
 func (api Api) setupRoutes(app *fiber.App, resourcePath string) {
 	app.Get("/menus/:id", api.GetMenu)
+	app.Get("/menus/:id/tree", api.GetMenuTree)
+	app.Get("/menus/:id/published", api.GetPublishedMenuTree)
+	app.Get("/menus/:id/versions", api.GetMenuVersions)
+	app.Get("/menus/:id/versions/:version", api.GetMenuVersion)
 	app.Get("/menus", api.GetAllMenus)
 
 	app.Get("/categories/by-ids", api.GetCategoriesByIDs)
 
 	app.Get("/subcategories/by-ids", api.GetSubCategoriesByIDs)
 
+	app.Get("/menuitems", api.GetMenuItems)
 	app.Get("/menuitems/by-ids", api.GetMenuItemsByIDs)
 
 	path := filepath.Join(resourcePath, "images")
@@ -42,7 +52,7 @@ func (api Api) GetMenu(c *fiber.Ctx) error {
 	if id == uuid.Nil {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid menu id")
 	}
-	menu, err := api.menuRepository.GetMenu(id)
+	menu, err := api.menuRepository.GetMenu(c.UserContext(), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return fiber.NewError(fiber.StatusNotFound, "Menu not found")
@@ -53,8 +63,82 @@ func (api Api) GetMenu(c *fiber.Ctx) error {
 	return c.JSON(menu)
 }
 
+// GetMenuTree returns the menu with its categories, subcategories and menu items,
+// with ?enabledOnly=true a disabled menu is not found
+func (api Api) GetMenuTree(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menu id")
+	}
+	enabledOnly, err := strconv.ParseBool(c.Query("enabledOnly", "false"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid enabledOnly, it must be true or false")
+	}
+	menuTree, err := api.menuRepository.GetMenuTree(c.UserContext(), id, enabledOnly)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusNotFound, "Menu not found")
+		} else {
+			return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to find the menu, please try again later.")
+		}
+	}
+	return c.JSON(populateMenuTreeImageURLs(menuTree, api))
+}
+
+// GetPublishedMenuTree returns the version of the menu guests see, a disabled menu
+// or a menu that has never been published is not found
+func (api Api) GetPublishedMenuTree(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menu id")
+	}
+	menuTree, err := api.menuRepository.GetPublishedMenuTree(c.UserContext(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusNotFound, "Published menu not found")
+		} else {
+			return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to find the menu, please try again later.")
+		}
+	}
+	return c.JSON(populateMenuTreeImageURLs(menuTree, api))
+}
+
+// GetMenuVersions returns the published versions of the menu, oldest first
+func (api Api) GetMenuVersions(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menu id")
+	}
+	versions, err := api.menuRepository.GetMenuVersions(c.UserContext(), id)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to find the menu versions, please try again later.")
+	}
+	return c.JSON(versions)
+}
+
+// GetMenuVersion returns a published version of the menu, whether guests see it or not
+func (api Api) GetMenuVersion(c *fiber.Ctx) error {
+	id := uuid.FromStringOrNil(c.Params("id"))
+	if id == uuid.Nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid menu id")
+	}
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil || version < 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid version, it must be a positive number")
+	}
+	menuTree, err := api.menuRepository.GetMenuVersion(c.UserContext(), id, version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusNotFound, "Menu version not found")
+		} else {
+			return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to find the menu, please try again later.")
+		}
+	}
+	return c.JSON(populateMenuTreeImageURLs(menuTree, api))
+}
+
 func (api Api) GetAllMenus(c *fiber.Ctx) error {
-	menus, err := api.menuRepository.GetAllMenus()
+	menus, err := api.menuRepository.GetAllMenus(c.UserContext())
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to find the menus, please try again later.")
 	}
@@ -72,13 +156,14 @@ func (api Api) GetCategoriesByIDs(c *fiber.Ctx) error {
 		}
 		uuids = append(uuids, parsedID)
 	}
-	categories, err := api.menuRepository.GetCategoriesByIDs(uuids)
+	categories, notFoundIDs, err := api.menuRepository.GetCategoriesByIDs(c.UserContext(), uuids)
 
 	categories = populateCategoryImageURL(categories, api)
 
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to find the categories, please try again later.")
 	}
+	setNotFoundIDs(c, notFoundIDs)
 	return c.JSON(categories)
 }
 
@@ -93,12 +178,13 @@ func (api Api) GetSubCategoriesByIDs(c *fiber.Ctx) error {
 		}
 		uuids = append(uuids, parsedID)
 	}
-	subcategories, err := api.menuRepository.GetSubCategoriesByIDs(uuids)
+	subcategories, notFoundIDs, err := api.menuRepository.GetSubCategoriesByIDs(c.UserContext(), uuids)
 	subcategories = populateSubCategoryImageURL(subcategories, api)
 
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to find the subcategories, please try again later.")
 	}
+	setNotFoundIDs(c, notFoundIDs)
 	return c.JSON(subcategories)
 }
 
@@ -113,7 +199,29 @@ func (api Api) GetMenuItemsByIDs(c *fiber.Ctx) error {
 		}
 		uuids = append(uuids, parsedID)
 	}
-	menuItems, err := api.menuRepository.GetMenuItemsByIDs(uuids)
+	menuItems, notFoundIDs, err := api.menuRepository.GetMenuItemsByIDs(c.UserContext(), uuids)
+
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to find the menuitems, please try again later.")
+	}
+	setNotFoundIDs(c, notFoundIDs)
+	return c.JSON(menuItems)
+}
+
+// GetMenuItems returns the menu items, without those that contain any of the allergens
+// listed by the excludeAllergens query parameter, such as ?excludeAllergens=gluten,nuts
+func (api Api) GetMenuItems(c *fiber.Ctx) error {
+	excludeAllergens := []string{}
+	if c.Query("excludeAllergens") != "" {
+		for _, v := range strings.Split(c.Query("excludeAllergens"), ",") {
+			if !events.Allergen(v).IsValid() {
+				fmtError := fmt.Sprintf("invalid allergen: %s", v)
+				return fiber.NewError(fiber.StatusBadRequest, fmtError)
+			}
+			excludeAllergens = append(excludeAllergens, v)
+		}
+	}
+	menuItems, err := api.menuRepository.GetMenuItems(c.UserContext(), excludeAllergens)
 
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Something went wrong when trying to find the menuitems, please try again later.")
@@ -123,6 +231,17 @@ func (api Api) GetMenuItemsByIDs(c *fiber.Ctx) error {
 
 // helpers
 
+func setNotFoundIDs(c *fiber.Ctx, notFoundIDs []uuid.UUID) {
+	if len(notFoundIDs) == 0 {
+		return
+	}
+	ids := []string{}
+	for _, id := range notFoundIDs {
+		ids = append(ids, id.String())
+	}
+	c.Set(NotFoundIDsHeader, strings.Join(ids, ","))
+}
+
 func populateSubCategoryImageURL(categories []SubCategoryView, api Api) (populatedSubCategories []SubCategoryView) {
 	for _, v := range categories {
 		v.ImageURL = fmt.Sprintf("%s/images/subcategories/%s.jpg", api.resourceHost, v.ID)
@@ -131,6 +250,16 @@ func populateSubCategoryImageURL(categories []SubCategoryView, api Api) (populat
 	return
 }
 
+func populateMenuTreeImageURLs(menuTree MenuTreeView, api Api) MenuTreeView {
+	for i, category := range menuTree.Categories {
+		menuTree.Categories[i].ImageURL = fmt.Sprintf("%s/images/categories/%s.jpg", api.resourceHost, category.ID)
+		for j, subCategory := range category.SubCategories {
+			category.SubCategories[j].ImageURL = fmt.Sprintf("%s/images/subcategories/%s.jpg", api.resourceHost, subCategory.ID)
+		}
+	}
+	return menuTree
+}
+
 func populateCategoryImageURL(categories []CategoryView, api Api) (populatedCategories []CategoryView) {
 	for _, v := range categories {
 		v.ImageURL = fmt.Sprintf("%s/images/categories/%s.jpg", api.resourceHost, v.ID)
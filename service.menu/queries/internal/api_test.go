@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -104,7 +105,7 @@ func TestGetCategoriesByIDsApi(t *testing.T) {
 			categories[0].ID,
 			categories[1].ID,
 		}).
-		Return(categories, nil)
+		Return(categories, []uuid.UUID{}, nil)
 
 	app := fiber.New()
 	SetupApi(app, mockMenuRepository, "", "")
@@ -148,7 +149,7 @@ func TestGetCategoriesByIDsApi_ShouldHaveImageURL(t *testing.T) {
 			categories[0].ID,
 			categories[1].ID,
 		}).
-		Return(categories, nil)
+		Return(categories, []uuid.UUID{}, nil)
 
 	app := fiber.New()
 
@@ -199,7 +200,7 @@ func TestGetSubCategoriesByIDsApi(t *testing.T) {
 			subcategories[0].ID,
 			subcategories[1].ID,
 		}).
-		Return(subcategories, nil)
+		Return(subcategories, []uuid.UUID{}, nil)
 
 	app := fiber.New()
 	SetupApi(app, mockMenuRepository, "", "")
@@ -243,7 +244,7 @@ func TestGetSubCategoriesByIDsApi_ShouldHaveImageURL(t *testing.T) {
 			subCategories[0].ID,
 			subCategories[1].ID,
 		}).
-		Return(subCategories, nil)
+		Return(subCategories, []uuid.UUID{}, nil)
 
 	app := fiber.New()
 
@@ -294,7 +295,7 @@ func TestGetMenuItemByIDs(t *testing.T) {
 			menuItems[0].ID,
 			menuItems[1].ID,
 		}).
-		Return(menuItems, nil)
+		Return(menuItems, []uuid.UUID{}, nil)
 
 	app := fiber.New()
 	SetupApi(app, mockMenuRepository, "", "")
@@ -318,3 +319,299 @@ func TestGetMenuItemByIDs(t *testing.T) {
 	require.Equal(t, menuItems[0].ID, menuItemResponse[0].ID)
 	require.Equal(t, menuItems[0].Name, menuItemResponse[0].Name)
 }
+
+func TestGetMenuItemsByIDsApi_WhenSomeAreNotFound(t *testing.T) {
+	// Arrange
+	menuItem := MenuItemView{
+		ID:   utils.GenerateNewUUID(),
+		Name: "TestName1",
+	}
+	missingIDs := []uuid.UUID{utils.GenerateNewUUID(), utils.GenerateNewUUID()}
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("GetMenuItemsByIDs", []uuid.UUID{
+			missingIDs[0],
+			menuItem.ID,
+			missingIDs[1],
+		}).
+		Return([]MenuItemView{menuItem}, missingIDs, nil)
+
+	app := fiber.New()
+	SetupApi(app, mockMenuRepository, "", "")
+
+	url := fmt.Sprintf("/menuitems/by-ids?id=%s,%s,%s", missingIDs[0], menuItem.ID, missingIDs[1])
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, fmt.Sprintf("%s,%s", missingIDs[0], missingIDs[1]), resp.Header.Get(NotFoundIDsHeader))
+
+	response, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var menuItemResponse []MenuItemView
+	err = json.Unmarshal(response, &menuItemResponse)
+	require.NoError(t, err)
+	require.Len(t, menuItemResponse, 1)
+	require.Equal(t, menuItem.ID, menuItemResponse[0].ID)
+}
+
+func TestGetMenuTreeApi(t *testing.T) {
+	// Arrange
+	menuTree := MenuTreeView{
+		ID:        utils.GenerateNewUUID(),
+		Name:      "TestMenu",
+		IsEnabled: true,
+		Categories: []CategoryTreeView{
+			{
+				ID:   utils.GenerateNewUUID(),
+				Name: "TestCategory",
+				SubCategories: []SubCategoryTreeView{
+					{
+						ID:        utils.GenerateNewUUID(),
+						Name:      "TestSubCategory",
+						MenuItems: []MenuItemView{{ID: utils.GenerateNewUUID(), Name: "TestMenuItem"}},
+					},
+				},
+			},
+		},
+	}
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("GetMenuTree", menuTree.ID, true).
+		Return(menuTree, nil)
+
+	app := fiber.New()
+	SetupApi(app, mockMenuRepository, "", "http://localhost:10001")
+
+	url := fmt.Sprintf("/menus/%s/tree?enabledOnly=true", menuTree.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	response, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var menuTreeResponse MenuTreeView
+	err = json.Unmarshal(response, &menuTreeResponse)
+	require.NoError(t, err)
+
+	category := menuTreeResponse.Categories[0]
+	subCategory := category.SubCategories[0]
+	require.Equal(t, menuTree.ID, menuTreeResponse.ID)
+	require.Equal(t, fmt.Sprintf("http://localhost:10001/images/categories/%s.jpg", category.ID), category.ImageURL)
+	require.Equal(t, fmt.Sprintf("http://localhost:10001/images/subcategories/%s.jpg", subCategory.ID), subCategory.ImageURL)
+	require.Equal(t, menuTree.Categories[0].SubCategories[0].MenuItems, subCategory.MenuItems)
+}
+
+func TestGetMenuTreeApi_WhenMenuIsNotFound(t *testing.T) {
+	// Arrange
+	menuID := utils.GenerateNewUUID()
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("GetMenuTree", menuID, false).
+		Return(MenuTreeView{}, sql.ErrNoRows)
+
+	app := fiber.New()
+	SetupApi(app, mockMenuRepository, "", "")
+
+	url := fmt.Sprintf("/menus/%s/tree", menuID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestGetMenuTreeApi_WhenEnabledOnlyIsInvalid(t *testing.T) {
+	// Arrange
+	app := fiber.New()
+	SetupApi(app, new(MockMenuRepository), "", "")
+
+	url := fmt.Sprintf("/menus/%s/tree?enabledOnly=maybe", utils.GenerateNewUUID())
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetPublishedMenuTreeApi(t *testing.T) {
+	// Arrange
+	menuTree := MenuTreeView{
+		ID:        utils.GenerateNewUUID(),
+		Name:      "PublishedMenu",
+		IsEnabled: true,
+		Categories: []CategoryTreeView{
+			{
+				ID:            utils.GenerateNewUUID(),
+				Name:          "PublishedCategory",
+				SubCategories: []SubCategoryTreeView{},
+			},
+		},
+	}
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("GetPublishedMenuTree", menuTree.ID).
+		Return(menuTree, nil)
+
+	app := fiber.New()
+	SetupApi(app, mockMenuRepository, "", "http://localhost:10001")
+
+	url := fmt.Sprintf("/menus/%s/published", menuTree.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	response, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var menuTreeResponse MenuTreeView
+	err = json.Unmarshal(response, &menuTreeResponse)
+	require.NoError(t, err)
+
+	category := menuTreeResponse.Categories[0]
+	require.Equal(t, "PublishedMenu", menuTreeResponse.Name)
+	require.Equal(t, fmt.Sprintf("http://localhost:10001/images/categories/%s.jpg", category.ID), category.ImageURL)
+}
+
+func TestGetPublishedMenuTreeApi_WhenMenuIsNotPublished(t *testing.T) {
+	// Arrange
+	menuID := utils.GenerateNewUUID()
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("GetPublishedMenuTree", menuID).
+		Return(MenuTreeView{}, sql.ErrNoRows)
+
+	app := fiber.New()
+	SetupApi(app, mockMenuRepository, "", "")
+
+	url := fmt.Sprintf("/menus/%s/published", menuID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestGetMenuVersionsApi(t *testing.T) {
+	// Arrange
+	menuID := utils.GenerateNewUUID()
+	versions := []MenuVersionView{
+		{Version: 1, IsPublished: false},
+		{Version: 2, IsPublished: true},
+	}
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("GetMenuVersions", menuID).
+		Return(versions, nil)
+
+	app := fiber.New()
+	SetupApi(app, mockMenuRepository, "", "")
+
+	url := fmt.Sprintf("/menus/%s/versions", menuID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	response, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var versionsResponse []MenuVersionView
+	err = json.Unmarshal(response, &versionsResponse)
+	require.NoError(t, err)
+	require.Equal(t, versions, versionsResponse)
+}
+
+func TestGetMenuVersionApi_WhenVersionIsInvalid(t *testing.T) {
+	// Arrange
+	app := fiber.New()
+	SetupApi(app, new(MockMenuRepository), "", "")
+
+	url := fmt.Sprintf("/menus/%s/versions/latest", utils.GenerateNewUUID())
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetMenuItemsApi_WhenAllergensAreExcluded(t *testing.T) {
+	// Arrange
+	menuItem := MenuItemView{
+		ID:        utils.GenerateNewUUID(),
+		Name:      "TestName1",
+		Allergens: []string{"milk"},
+	}
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("GetMenuItems", []string{"gluten", "nuts"}).
+		Return([]MenuItemView{menuItem}, nil)
+
+	app := fiber.New()
+	SetupApi(app, mockMenuRepository, "", "")
+
+	request, err := http.NewRequest(http.MethodGet, "/menuitems?excludeAllergens=gluten,nuts", nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	response, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var menuItemResponse []MenuItemView
+	err = json.Unmarshal(response, &menuItemResponse)
+	require.NoError(t, err)
+	require.Equal(t, []MenuItemView{menuItem}, menuItemResponse)
+	mockMenuRepository.AssertExpectations(t)
+}
+
+func TestGetMenuItemsApi_WhenAllergenIsUnknown(t *testing.T) {
+	// Arrange
+	app := fiber.New()
+	SetupApi(app, new(MockMenuRepository), "", "")
+
+	request, err := http.NewRequest(http.MethodGet, "/menuitems?excludeAllergens=gluten,pineapple", nil)
+	require.NoError(t, err)
+
+	// Act
+	resp, _ := app.Test(request)
+
+	// Assert
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
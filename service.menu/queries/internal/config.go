@@ -1,14 +1,24 @@
 package internal
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	EventStoreConnectionString string `mapstructure:"EVENT_STORE_CONNECTION_STRING"`
-	PostgresConnectionString   string `mapstructure:"POSTGRES_CONNECTION_STRING"`
-	ResourcePath               string `mapstructure:"RESOURCE_PATH"`
-	ResourceHost               string `mapstructure:"RESOURCE_HOST"`
+	EventStoreConnectionString string        `mapstructure:"EVENT_STORE_CONNECTION_STRING"`
+	UseInMemoryEventStore      bool          `mapstructure:"USE_IN_MEMORY_EVENT_STORE"`
+	PostgresConnectionString   string        `mapstructure:"POSTGRES_CONNECTION_STRING"`
+	PostgresMaxOpenConns       int           `mapstructure:"POSTGRES_MAX_OPEN_CONNS"`
+	PostgresMaxIdleConns       int           `mapstructure:"POSTGRES_MAX_IDLE_CONNS"`
+	PostgresConnMaxLifetime    time.Duration `mapstructure:"POSTGRES_CONN_MAX_LIFETIME"`
+	PostgresConnMaxIdleTime    time.Duration `mapstructure:"POSTGRES_CONN_MAX_IDLE_TIME"`
+	ResourcePath               string        `mapstructure:"RESOURCE_PATH"`
+	ResourceHost               string        `mapstructure:"RESOURCE_HOST"`
+	AdminListenAddress         string        `mapstructure:"ADMIN_LISTEN_ADDRESS"`
+	EventHandlerConcurrency    int           `mapstructure:"EVENT_HANDLER_CONCURRENCY"`
+	EventHandlerQueueSize      int           `mapstructure:"EVENT_HANDLER_QUEUE_SIZE"`
 }
 
 func LoadConfig(path string) (config Config) {
@@ -24,3 +34,12 @@ func LoadConfig(path string) (config Config) {
 	err = viper.Unmarshal(&config)
 	return
 }
+
+func (config Config) PostgresPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    config.PostgresMaxOpenConns,
+		MaxIdleConns:    config.PostgresMaxIdleConns,
+		ConnMaxLifetime: config.PostgresConnMaxLifetime,
+		ConnMaxIdleTime: config.PostgresConnMaxIdleTime,
+	}
+}
@@ -1,7 +1,9 @@
 package internal
 
 import (
+	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/EventStore/EventStore-Client-Go/esdb"
 	"github.com/Resta-Inc/resta/pkg/events"
@@ -18,123 +20,441 @@ func NewMenuEventHandler(repo IMenuRepository) MenuEventHandler {
 	}
 }
 
-func (menuEventHandler MenuEventHandler) HandleMenuCreated(rawEvent *esdb.SubscriptionEvent) error {
-	recordedEvent := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+func (menuEventHandler MenuEventHandler) HandleMenuCreated(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	var event events.MenuCreated
-	err := json.Unmarshal(recordedEvent.Data, &event)
+	err := deserializeEvent(rawEvent, &event)
 	if err != nil {
 		return err
 	}
-	err = menuEventHandler.menuRepository.CreateMenu(event.GetEntityID(), event.Name)
+	err = menuEventHandler.menuRepository.CreateMenu(ctx, event.GetEntityID(), event.Name)
 	return err
 }
 
-func (menuEventHandler MenuEventHandler) HandleMenuEnabled(rawEvent *esdb.SubscriptionEvent) error {
-	recordedEvent := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+func (menuEventHandler MenuEventHandler) HandleMenuEnabled(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	var event events.MenuEnabled
-	err := json.Unmarshal(recordedEvent.Data, &event)
+	err := deserializeEvent(rawEvent, &event)
 	if err != nil {
 		return err
 	}
-	err = menuEventHandler.menuRepository.EnableMenu(event.GetEntityID())
+	err = menuEventHandler.menuRepository.EnableMenu(ctx, event.GetEntityID())
 	return err
 }
 
-func (menuEventHandler MenuEventHandler) HandleMenuDisabled(rawEvent *esdb.SubscriptionEvent) error {
-	recordedEvent := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+func (menuEventHandler MenuEventHandler) HandleMenuDisabled(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	var event events.MenuDisabled
-	err := json.Unmarshal(recordedEvent.Data, &event)
+	err := deserializeEvent(rawEvent, &event)
 	if err != nil {
 		return err
 	}
-	err = menuEventHandler.menuRepository.DisableMenu(event.GetEntityID())
+	err = menuEventHandler.menuRepository.DisableMenu(ctx, event.GetEntityID())
 	return err
 }
 
-func (menuEventHandler MenuEventHandler) HandleMenuNameChanged(rawEvent *esdb.SubscriptionEvent) error {
-	recordedEvent := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+func (menuEventHandler MenuEventHandler) HandleMenuNameChanged(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	var event events.MenuNameChanged
-	err := json.Unmarshal(recordedEvent.Data, &event)
+	err := deserializeEvent(rawEvent, &event)
 	if err != nil {
 		return err
 	}
-	err = menuEventHandler.menuRepository.ChangeMenuName(event.GetEntityID(), event.NewName)
+	err = menuEventHandler.menuRepository.ChangeMenuName(ctx, event.GetEntityID(), event.NewName)
 	return err
 }
 
-func (menuEventHandler MenuEventHandler) HandleCategoryCreated(rawEvent *esdb.SubscriptionEvent) error {
-	recordedEvent := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+func (menuEventHandler MenuEventHandler) HandleCategoryCreated(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	var event events.CategoryCreated
-	err := json.Unmarshal(recordedEvent.Data, &event)
+	err := deserializeEvent(rawEvent, &event)
 	if err != nil {
 		return err
 	}
-	err = menuEventHandler.menuRepository.CreateCategory(event.GetEntityID(), event.Name)
+	err = menuEventHandler.menuRepository.CreateCategory(ctx, event.GetEntityID(), event.Name)
 	return err
 }
 
-func (menuEventHandler MenuEventHandler) HandleCategoryAddedToMenu(rawEvent *esdb.SubscriptionEvent) error {
-	recordedEvent := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+func (menuEventHandler MenuEventHandler) HandleCategoryAddedToMenu(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	var event events.CategoryAddedToMenu
-	err := json.Unmarshal(recordedEvent.Data, &event)
+	err := deserializeEvent(rawEvent, &event)
 	if err != nil {
 		return err
 	}
-	err = menuEventHandler.menuRepository.AddCategoryToMenu(event.GetEntityID(), event.CategoryID)
+	err = menuEventHandler.menuRepository.AddCategoryToMenu(ctx, event.GetEntityID(), event.CategoryID, rawEvent.EventAppeared.OriginalEvent().Position)
 	return err
 }
 
-func (menuEventHandler MenuEventHandler) HandleCategoryNameChanged(rawEvent *esdb.SubscriptionEvent) error {
-	recordedEvent := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+func (menuEventHandler MenuEventHandler) HandleCategoryNameChanged(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	var event events.CategoryNameChanged
-	err := json.Unmarshal(recordedEvent.Data, &event)
+	err := deserializeEvent(rawEvent, &event)
 	if err != nil {
 		return err
 	}
-	err = menuEventHandler.menuRepository.ChangeCategoryName(event.GetEntityID(), event.NewName)
+	err = menuEventHandler.menuRepository.ChangeCategoryName(ctx, event.GetEntityID(), event.NewName)
 	return err
 }
 
-func (menuEventHandler MenuEventHandler) HandleSubCategoryCreated(rawEvent *esdb.SubscriptionEvent) error {
-	recordedEvent := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+func (menuEventHandler MenuEventHandler) HandleSubCategoryCreated(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	var event events.SubCategoryCreated
-	err := json.Unmarshal(recordedEvent.Data, &event)
+	err := deserializeEvent(rawEvent, &event)
 	if err != nil {
 		return err
 	}
-	err = menuEventHandler.menuRepository.CreateSubCategory(event.GetEntityID(), event.Name)
+	err = menuEventHandler.menuRepository.CreateSubCategory(ctx, event.GetEntityID(), event.Name)
 	return err
 }
 
-func (menuEventHandler MenuEventHandler) HandleSubCategoryAddedToCategory(rawEvent *esdb.SubscriptionEvent) error {
-	recordedEvent := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+func (menuEventHandler MenuEventHandler) HandleSubCategoryAddedToCategory(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	var event events.SubCategoryAddedToCategory
-	err := json.Unmarshal(recordedEvent.Data, &event)
+	err := deserializeEvent(rawEvent, &event)
 	if err != nil {
 		return err
 	}
-	err = menuEventHandler.menuRepository.AddSubCategoryToCategory(event.GetEntityID(), event.SubCategoryID)
+	err = menuEventHandler.menuRepository.AddSubCategoryToCategory(ctx, event.GetEntityID(), event.SubCategoryID, rawEvent.EventAppeared.OriginalEvent().Position)
 	return err
 }
 
-func (menuEventHandler MenuEventHandler) HandleMenuItemCreated(rawEvent *esdb.SubscriptionEvent) error {
-	recordedEvent := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+func (menuEventHandler MenuEventHandler) HandleMenuItemCreated(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	var event events.MenuItemCreated
-	err := json.Unmarshal(recordedEvent.Data, &event)
+	err := deserializeEvent(rawEvent, &event)
 	if err != nil {
 		return err
 	}
-	err = menuEventHandler.menuRepository.CreateMenuItem(event.GetEntityID(), event.Name)
+	err = menuEventHandler.menuRepository.CreateMenuItem(ctx, event.GetEntityID(), event.Name)
 	return err
 }
 
-func (menuEventHandler MenuEventHandler) HandleMenuItemAddedToSubCategory(rawEvent *esdb.SubscriptionEvent) error {
-	recordedEvent := eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event)
+func (menuEventHandler MenuEventHandler) HandleMenuItemAddedToSubCategory(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
 	var event events.MenuItemAddedToSubCategory
-	err := json.Unmarshal(recordedEvent.Data, &event)
+	err := deserializeEvent(rawEvent, &event)
 	if err != nil {
 		return err
 	}
-	err = menuEventHandler.menuRepository.AddMenuItemToSubCategory(event.GetEntityID(), event.MenuItemID)
+	err = menuEventHandler.menuRepository.AddMenuItemToSubCategory(ctx, event.GetEntityID(), event.MenuItemID, rawEvent.EventAppeared.OriginalEvent().Position)
 	return err
 }
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemPriceChanged(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemPriceChanged
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.ChangeMenuItemPrice(ctx, event.GetEntityID(), event.NewPrice)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemEstimatedPreparationTimeChanged(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemEstimatedPreparationTimeChanged
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.ChangeMenuItemEstimatedPreparationTime(ctx, event.GetEntityID(), event.NewEstimate)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemDescriptionChanged(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemDescriptionChanged
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.ChangeMenuItemDescription(ctx, event.GetEntityID(), event.NewDescription)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemAllergensChanged(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemAllergensChanged
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.ChangeMenuItemAllergens(ctx, event.GetEntityID(), event.NewAllergens)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemDietaryTagsChanged(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemDietaryTagsChanged
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.ChangeMenuItemDietaryTags(ctx, event.GetEntityID(), event.NewDietaryTags)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemVariantAdded(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemVariantAdded
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.AddMenuItemVariant(ctx, event.GetEntityID(), event.VariantID, event.Name, event.Price)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemVariantRemoved(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemVariantRemoved
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.RemoveMenuItemVariant(ctx, event.VariantID)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemModifierGroupAdded(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemModifierGroupAdded
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.AddMenuItemModifierGroup(ctx, event.GetEntityID(), event.ModifierGroupID, event.Name, event.MinSelections, event.MaxSelections)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemModifierGroupRemoved(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemModifierGroupRemoved
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.RemoveMenuItemModifierGroup(ctx, event.ModifierGroupID)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemModifierAdded(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemModifierAdded
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.AddMenuItemModifier(ctx, event.ModifierGroupID, event.ModifierID, event.Name, event.PriceDelta)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemModifierRemoved(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemModifierRemoved
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.RemoveMenuItemModifier(ctx, event.ModifierID)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuDeleted(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuDeleted
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.MarkMenuAsDeleted(ctx, event.GetEntityID())
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleCategoryRemovedFromMenu(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.CategoryRemovedFromMenu
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.RemoveCategoryFromMenu(ctx, event.GetEntityID(), event.CategoryID, rawEvent.EventAppeared.OriginalEvent().Position)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleCategoryDeleted(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.CategoryDeleted
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.MarkCategoryAsDeleted(ctx, event.GetEntityID())
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleSubCategoryRemovedFromCategory(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.SubCategoryRemovedFromCategory
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.RemoveSubCategoryFromCategory(ctx, event.GetEntityID(), event.SubCategoryID, rawEvent.EventAppeared.OriginalEvent().Position)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleSubCategoryDeleted(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.SubCategoryDeleted
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.MarkSubCategoryAsDeleted(ctx, event.GetEntityID())
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemRemovedFromSubCategory(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemRemovedFromSubCategory
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.RemoveMenuItemFromSubCategory(ctx, event.GetEntityID(), event.MenuItemID, rawEvent.EventAppeared.OriginalEvent().Position)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemDeleted(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemDeleted
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.MarkMenuItemAsDeleted(ctx, event.GetEntityID())
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleCategoriesReordered(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.CategoriesReordered
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.ReorderCategories(ctx, event.GetEntityID(), event.CategoriesIDs)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleSubCategoriesReordered(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.SubCategoriesReordered
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.ReorderSubCategories(ctx, event.GetEntityID(), event.SubCategoriesIDs)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuItemsReordered(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuItemsReordered
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.ReorderMenuItems(ctx, event.GetEntityID(), event.MenuItemsIDs)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuPublished(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuPublished
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.PublishMenuVersion(ctx, event.GetEntityID(), event.Version, publishedMenuTree(event), event.CreatedAt)
+	return err
+}
+
+func (menuEventHandler MenuEventHandler) HandleMenuVersionRestored(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	var event events.MenuVersionRestored
+	err := deserializeEvent(rawEvent, &event)
+	if err != nil {
+		return err
+	}
+	err = menuEventHandler.menuRepository.RestoreMenuVersion(ctx, event.GetEntityID(), event.Version)
+	return err
+}
+
+// publishedMenuTree returns the menu carried by the event as the views the draft menu is read with.
+// The event has no creation time of the categories, subcategories and menu items, so theirs is left empty.
+func publishedMenuTree(event events.MenuPublished) MenuTreeView {
+	categories := []CategoryTreeView{}
+	for _, category := range event.Categories {
+		subCategories := []SubCategoryTreeView{}
+		for _, subCategory := range category.SubCategories {
+			menuItems := []MenuItemView{}
+			for _, menuItem := range subCategory.MenuItems {
+				menuItems = append(menuItems, publishedMenuItemView(menuItem))
+			}
+			subCategories = append(subCategories, SubCategoryTreeView{
+				ID:                             subCategory.ID,
+				Name:                           subCategory.Name,
+				MenuItems:                      menuItems,
+				MaxEstimatedPreparationSeconds: maxEstimatedPreparationSeconds(menuItems),
+			})
+		}
+		categories = append(categories, CategoryTreeView{
+			ID:            category.ID,
+			Name:          category.Name,
+			SubCategories: subCategories,
+		})
+	}
+	return MenuTreeView{
+		ID:         event.GetEntityID(),
+		Name:       event.Name,
+		Categories: categories,
+	}
+}
+
+// publishedMenuItemView leaves out the price and the estimated preparation time the menu item has not been given,
+// like the draft menu item does
+func publishedMenuItemView(menuItem events.PublishedMenuItem) MenuItemView {
+	menuItemView := MenuItemView{
+		ID:             menuItem.ID,
+		Name:           menuItem.Name,
+		Description:    menuItem.Description,
+		Allergens:      []string{},
+		Variants:       []VariantView{},
+		ModifierGroups: []ModifierGroupView{},
+		DietaryTags: DietaryTagsView{
+			Vegan:      menuItem.DietaryTags.Vegan,
+			Vegetarian: menuItem.DietaryTags.Vegetarian,
+			GlutenFree: menuItem.DietaryTags.GlutenFree,
+			Halal:      menuItem.DietaryTags.Halal,
+			SpicyLevel: menuItem.DietaryTags.SpicyLevel,
+		},
+	}
+	if menuItem.Price.Currency != "" {
+		menuItemView.Price = &PriceView{
+			Amount:   menuItem.Price.Amount,
+			Currency: menuItem.Price.Currency,
+		}
+	}
+	if menuItem.EstimatedPreparationTime > 0 {
+		seconds := int64(menuItem.EstimatedPreparationTime / time.Second)
+		menuItemView.EstimatedPreparationSeconds = &seconds
+	}
+	for _, allergen := range menuItem.Allergens {
+		menuItemView.Allergens = append(menuItemView.Allergens, string(allergen))
+	}
+	for _, variant := range menuItem.Variants {
+		menuItemView.Variants = append(menuItemView.Variants, VariantView{
+			ID:    variant.ID,
+			Name:  variant.Name,
+			Price: PriceView{Amount: variant.Price.Amount, Currency: variant.Price.Currency},
+		})
+	}
+	for _, modifierGroup := range menuItem.ModifierGroups {
+		modifiers := []ModifierView{}
+		for _, modifier := range modifierGroup.Modifiers {
+			modifiers = append(modifiers, ModifierView{
+				ID:         modifier.ID,
+				Name:       modifier.Name,
+				PriceDelta: PriceView{Amount: modifier.PriceDelta.Amount, Currency: modifier.PriceDelta.Currency},
+			})
+		}
+		menuItemView.ModifierGroups = append(menuItemView.ModifierGroups, ModifierGroupView{
+			ID:            modifierGroup.ID,
+			Name:          modifierGroup.Name,
+			MinSelections: modifierGroup.MinSelections,
+			MaxSelections: modifierGroup.MaxSelections,
+			Modifiers:     modifiers,
+		})
+	}
+	return menuItemView
+}
+
+// deserializeEvent decodes the data of the event into target, after upcasting it
+// to the current schema version so that the events written with an old one are read as well
+func deserializeEvent(rawEvent *esdb.SubscriptionEvent, target interface{}) error {
+	recordedEvent, err := events.Upcasters.Upcast(eventutils.DeserializeRecordedEvent(rawEvent.EventAppeared.Event))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(recordedEvent.Data, target)
+}
@@ -1,12 +1,17 @@
 package internal
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/EventStore/EventStore-Client-Go/esdb"
 	"github.com/Resta-Inc/resta/pkg/events"
 	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHandleMenuCreatedMessage(t *testing.T) {
@@ -40,7 +45,7 @@ func TestHandleMenuCreatedMessage(t *testing.T) {
 	eventHandler := NewMenuEventHandler(mockMenuRepository)
 
 	// Act
-	eventHandler.HandleMenuCreated(incomingMessage)
+	eventHandler.HandleMenuCreated(context.Background(), incomingMessage)
 
 	// Assert
 	mockMenuRepository.AssertExpectations(t)
@@ -76,7 +81,7 @@ func TestHandleMenuEnabledMessage(t *testing.T) {
 	eventHandler := NewMenuEventHandler(mockMenuRepository)
 
 	// Act
-	eventHandler.HandleMenuEnabled(incomingMessage)
+	eventHandler.HandleMenuEnabled(context.Background(), incomingMessage)
 
 	// Assert
 	mockMenuRepository.AssertExpectations(t)
@@ -112,7 +117,7 @@ func TestHandleMenuDisabledMessage(t *testing.T) {
 	eventHandler := NewMenuEventHandler(mockMenuRepository)
 
 	// Act
-	eventHandler.HandleMenuDisabled(incomingMessage)
+	eventHandler.HandleMenuDisabled(context.Background(), incomingMessage)
 
 	// Assert
 	mockMenuRepository.AssertExpectations(t)
@@ -149,7 +154,7 @@ func TestHandleMenuNameChangedMessage(t *testing.T) {
 	eventHandler := NewMenuEventHandler(mockMenuRepository)
 
 	// Act
-	eventHandler.HandleMenuNameChanged(incomingMessage)
+	eventHandler.HandleMenuNameChanged(context.Background(), incomingMessage)
 
 	// Assert
 	mockMenuRepository.AssertExpectations(t)
@@ -189,7 +194,7 @@ func TestHandleCategoryCreatedMessage(t *testing.T) {
 	eventHandler := NewMenuEventHandler(mockMenuRepository)
 
 	// Act
-	eventHandler.HandleCategoryCreated(incomingMessage)
+	eventHandler.HandleCategoryCreated(context.Background(), incomingMessage)
 
 	// Assert
 	mockMenuRepository.AssertExpectations(t)
@@ -204,6 +209,7 @@ func TestHandleCategoryAddedToMenuMessage(t *testing.T) {
 		CategoryID: categoryID,
 	}
 
+	position := esdb.Position{Commit: 42, Prepare: 41}
 	serializedEvent := eventutils.SerializedEvent(categoryAddedToMenuEvent)
 
 	incomingMessage := &esdb.SubscriptionEvent{
@@ -212,6 +218,7 @@ func TestHandleCategoryAddedToMenuMessage(t *testing.T) {
 				EventID:   serializedEvent.ID,
 				EventType: serializedEvent.Name,
 				Data:      serializedEvent.Data,
+				Position:  position,
 			},
 		},
 		SubscriptionDropped: &esdb.SubscriptionDropped{},
@@ -220,13 +227,13 @@ func TestHandleCategoryAddedToMenuMessage(t *testing.T) {
 
 	mockMenuRepository := new(MockMenuRepository)
 	mockMenuRepository.
-		On("AddCategoryToMenu", menuID, categoryID).
+		On("AddCategoryToMenu", menuID, categoryID, position).
 		Return(nil)
 
 	eventHandler := NewMenuEventHandler(mockMenuRepository)
 
 	// Act
-	eventHandler.HandleCategoryAddedToMenu(incomingMessage)
+	eventHandler.HandleCategoryAddedToMenu(context.Background(), incomingMessage)
 
 	// Assert
 	mockMenuRepository.AssertExpectations(t)
@@ -263,7 +270,7 @@ func TestHandleCategoryNameChangedMessage(t *testing.T) {
 	eventHandler := NewMenuEventHandler(mockMenuRepository)
 
 	// Act
-	eventHandler.HandleCategoryNameChanged(incomingMessage)
+	eventHandler.HandleCategoryNameChanged(context.Background(), incomingMessage)
 
 	// Assert
 	mockMenuRepository.AssertExpectations(t)
@@ -302,7 +309,7 @@ func TestHandleSubCategoryCreatedMessage(t *testing.T) {
 	eventHandler := NewMenuEventHandler(mockMenuRepository)
 
 	// Act
-	eventHandler.HandleSubCategoryCreated(incomingMessage)
+	eventHandler.HandleSubCategoryCreated(context.Background(), incomingMessage)
 
 	// Assert
 	mockMenuRepository.AssertExpectations(t)
@@ -317,6 +324,7 @@ func TestHandleSubCategoryAddetToCategory(t *testing.T) {
 		SubCategoryID: subCategoryID,
 	}
 
+	position := esdb.Position{Commit: 42, Prepare: 41}
 	serializedEvent := eventutils.SerializedEvent(subCategoryAddedToCategoryEvent)
 
 	incomingMessage := &esdb.SubscriptionEvent{
@@ -325,6 +333,7 @@ func TestHandleSubCategoryAddetToCategory(t *testing.T) {
 				EventID:   serializedEvent.ID,
 				EventType: serializedEvent.Name,
 				Data:      serializedEvent.Data,
+				Position:  position,
 			},
 		},
 		SubscriptionDropped: &esdb.SubscriptionDropped{},
@@ -335,13 +344,14 @@ func TestHandleSubCategoryAddetToCategory(t *testing.T) {
 	mockMenuRepository.
 		On("AddSubCategoryToCategory",
 			categoryID,
-			subCategoryID).
+			subCategoryID,
+			position).
 		Return(nil)
 
 	eventHandler := NewMenuEventHandler(mockMenuRepository)
 
 	// Act
-	eventHandler.HandleSubCategoryAddedToCategory(incomingMessage)
+	eventHandler.HandleSubCategoryAddedToCategory(context.Background(), incomingMessage)
 
 	// Assert
 	mockMenuRepository.AssertExpectations(t)
@@ -381,7 +391,7 @@ func TestHandleMenuItemCreatedMessage(t *testing.T) {
 	eventHandler := NewMenuEventHandler(mockMenuRepository)
 
 	// Act
-	eventHandler.HandleMenuItemCreated(incomingMessage)
+	eventHandler.HandleMenuItemCreated(context.Background(), incomingMessage)
 
 	// Assert
 	mockMenuRepository.AssertExpectations(t)
@@ -396,6 +406,7 @@ func TestHandleMenuItemAddetToCategory(t *testing.T) {
 		MenuItemID: menuItemID,
 	}
 
+	position := esdb.Position{Commit: 42, Prepare: 41}
 	serializedEvent := eventutils.SerializedEvent(subCategoryAddedToCategoryEvent)
 
 	incomingMessage := &esdb.SubscriptionEvent{
@@ -404,6 +415,7 @@ func TestHandleMenuItemAddetToCategory(t *testing.T) {
 				EventID:   serializedEvent.ID,
 				EventType: serializedEvent.Name,
 				Data:      serializedEvent.Data,
+				Position:  position,
 			},
 		},
 		SubscriptionDropped: &esdb.SubscriptionDropped{},
@@ -414,13 +426,385 @@ func TestHandleMenuItemAddetToCategory(t *testing.T) {
 	mockMenuRepository.
 		On("AddMenuItemToSubCategory",
 			subCategoryID,
-			menuItemID).
+			menuItemID,
+			position).
+		Return(nil)
+
+	eventHandler := NewMenuEventHandler(mockMenuRepository)
+
+	// Act
+	eventHandler.HandleMenuItemAddedToSubCategory(context.Background(), incomingMessage)
+
+	// Assert
+	mockMenuRepository.AssertExpectations(t)
+}
+
+func TestHandleMenuItemPriceChanged(t *testing.T) {
+	// Arrange
+	menuItemID := utils.GenerateNewUUID()
+	newPrice := events.Price{Amount: 1250, Currency: "EUR"}
+
+	menuItemPriceChangedEvent := events.MenuItemPriceChanged{
+		EventInfo: eventutils.NewEventInfo(menuItemID),
+		NewPrice:  newPrice,
+	}
+
+	serializedEvent := eventutils.SerializedEvent(menuItemPriceChangedEvent)
+
+	incomingMessage := &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:   serializedEvent.ID,
+				EventType: serializedEvent.Name,
+				Data:      serializedEvent.Data,
+			},
+		},
+		SubscriptionDropped: &esdb.SubscriptionDropped{},
+		CheckPointReached:   &esdb.Position{},
+	}
+
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("ChangeMenuItemPrice",
+			menuItemID,
+			newPrice).
+		Return(nil)
+
+	eventHandler := NewMenuEventHandler(mockMenuRepository)
+
+	// Act
+	eventHandler.HandleMenuItemPriceChanged(context.Background(), incomingMessage)
+
+	// Assert
+	mockMenuRepository.AssertExpectations(t)
+}
+
+func TestHandleMenuItemEstimatedPreparationTimeChanged(t *testing.T) {
+	// Arrange
+	menuItemID := utils.GenerateNewUUID()
+
+	menuItemEstimatedPreparationTimeChangedEvent := events.MenuItemEstimatedPreparationTimeChanged{
+		EventInfo:   eventutils.NewEventInfo(menuItemID),
+		NewEstimate: 15 * time.Minute,
+	}
+
+	serializedEvent := eventutils.SerializedEvent(menuItemEstimatedPreparationTimeChangedEvent)
+
+	incomingMessage := &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:   serializedEvent.ID,
+				EventType: serializedEvent.Name,
+				Data:      serializedEvent.Data,
+			},
+		},
+		SubscriptionDropped: &esdb.SubscriptionDropped{},
+		CheckPointReached:   &esdb.Position{},
+	}
+
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("ChangeMenuItemEstimatedPreparationTime",
+			menuItemID,
+			15*time.Minute).
+		Return(nil)
+
+	eventHandler := NewMenuEventHandler(mockMenuRepository)
+
+	// Act
+	eventHandler.HandleMenuItemEstimatedPreparationTimeChanged(context.Background(), incomingMessage)
+
+	// Assert
+	mockMenuRepository.AssertExpectations(t)
+}
+
+func TestHandleMenuItemEstimatedPreparationTimeChanged_WithSchemaVersion1(t *testing.T) {
+	// Arrange
+	menuItemID := utils.GenerateNewUUID()
+
+	incomingMessage := &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:      utils.GenerateNewUUID(),
+				EventType:    "MenuItemEstimatedPreparationTimeChanged",
+				Data:         []byte(`{"EntityID":"` + menuItemID.String() + `","NewEstimate":900000000000}`),
+				UserMetadata: []byte(`{"schemaVersion":1}`),
+			},
+		},
+	}
+
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("ChangeMenuItemEstimatedPreparationTime",
+			menuItemID,
+			15*time.Minute).
+		Return(nil)
+
+	eventHandler := NewMenuEventHandler(mockMenuRepository)
+
+	// Act
+	err := eventHandler.HandleMenuItemEstimatedPreparationTimeChanged(context.Background(), incomingMessage)
+
+	// Assert
+	require.NoError(t, err)
+	mockMenuRepository.AssertExpectations(t)
+}
+
+func TestHandleMenuItemAllergensChanged(t *testing.T) {
+	// Arrange
+	menuItemID := utils.GenerateNewUUID()
+	newAllergens := []events.Allergen{events.AllergenGluten, events.AllergenMilk}
+
+	menuItemAllergensChangedEvent := events.MenuItemAllergensChanged{
+		EventInfo:    eventutils.NewEventInfo(menuItemID),
+		NewAllergens: newAllergens,
+	}
+
+	serializedEvent := eventutils.SerializedEvent(menuItemAllergensChangedEvent)
+
+	incomingMessage := &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:   serializedEvent.ID,
+				EventType: serializedEvent.Name,
+				Data:      serializedEvent.Data,
+			},
+		},
+		SubscriptionDropped: &esdb.SubscriptionDropped{},
+		CheckPointReached:   &esdb.Position{},
+	}
+
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("ChangeMenuItemAllergens",
+			menuItemID,
+			newAllergens).
+		Return(nil)
+
+	eventHandler := NewMenuEventHandler(mockMenuRepository)
+
+	// Act
+	eventHandler.HandleMenuItemAllergensChanged(context.Background(), incomingMessage)
+
+	// Assert
+	mockMenuRepository.AssertExpectations(t)
+}
+
+func TestHandleMenuItemModifierAdded(t *testing.T) {
+	// Arrange
+	menuItemID, modifierGroupID, modifierID := utils.GenerateNewUUID(), utils.GenerateNewUUID(), utils.GenerateNewUUID()
+	priceDelta := events.Price{Amount: 150, Currency: "EUR"}
+
+	menuItemModifierAddedEvent := events.MenuItemModifierAdded{
+		EventInfo:       eventutils.NewEventInfo(menuItemID),
+		ModifierGroupID: modifierGroupID,
+		ModifierID:      modifierID,
+		Name:            "Extra cheese",
+		PriceDelta:      priceDelta,
+	}
+
+	serializedEvent := eventutils.SerializedEvent(menuItemModifierAddedEvent)
+
+	incomingMessage := &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:   serializedEvent.ID,
+				EventType: serializedEvent.Name,
+				Data:      serializedEvent.Data,
+			},
+		},
+		SubscriptionDropped: &esdb.SubscriptionDropped{},
+		CheckPointReached:   &esdb.Position{},
+	}
+
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("AddMenuItemModifier",
+			modifierGroupID,
+			modifierID,
+			"Extra cheese",
+			priceDelta).
+		Return(nil)
+
+	eventHandler := NewMenuEventHandler(mockMenuRepository)
+
+	// Act
+	eventHandler.HandleMenuItemModifierAdded(context.Background(), incomingMessage)
+
+	// Assert
+	mockMenuRepository.AssertExpectations(t)
+}
+
+func TestHandleCategoryDeleted(t *testing.T) {
+	// Arrange
+	categoryID := utils.GenerateNewUUID()
+
+	categoryDeletedEvent := events.CategoryDeleted{
+		EventInfo:    eventutils.NewEventInfo(categoryID),
+		ParentMenuID: utils.GenerateNewUUID(),
+	}
+
+	serializedEvent := eventutils.SerializedEvent(categoryDeletedEvent)
+
+	incomingMessage := &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:   serializedEvent.ID,
+				EventType: serializedEvent.Name,
+				Data:      serializedEvent.Data,
+			},
+		},
+		SubscriptionDropped: &esdb.SubscriptionDropped{},
+		CheckPointReached:   &esdb.Position{},
+	}
+
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("MarkCategoryAsDeleted", categoryID).
+		Return(nil)
+
+	eventHandler := NewMenuEventHandler(mockMenuRepository)
+
+	// Act
+	eventHandler.HandleCategoryDeleted(context.Background(), incomingMessage)
+
+	// Assert
+	mockMenuRepository.AssertExpectations(t)
+}
+
+func TestHandleCategoriesReordered(t *testing.T) {
+	// Arrange
+	menuID := utils.GenerateNewUUID()
+	categoriesIDs := []uuid.UUID{utils.GenerateNewUUID(), utils.GenerateNewUUID()}
+
+	categoriesReorderedEvent := events.CategoriesReordered{
+		EventInfo:     eventutils.NewEventInfo(menuID),
+		CategoriesIDs: categoriesIDs,
+	}
+
+	serializedEvent := eventutils.SerializedEvent(categoriesReorderedEvent)
+
+	incomingMessage := &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:   serializedEvent.ID,
+				EventType: serializedEvent.Name,
+				Data:      serializedEvent.Data,
+			},
+		},
+		SubscriptionDropped: &esdb.SubscriptionDropped{},
+		CheckPointReached:   &esdb.Position{},
+	}
+
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("ReorderCategories", menuID, categoriesIDs).
+		Return(nil)
+
+	eventHandler := NewMenuEventHandler(mockMenuRepository)
+
+	// Act
+	eventHandler.HandleCategoriesReordered(context.Background(), incomingMessage)
+
+	// Assert
+	mockMenuRepository.AssertExpectations(t)
+}
+
+func TestHandleMenuPublished(t *testing.T) {
+	// Arrange
+	menuID := utils.GenerateNewUUID()
+	categoryID := utils.GenerateNewUUID()
+	subCategoryID := utils.GenerateNewUUID()
+	menuItemID := utils.GenerateNewUUID()
+	variantID := utils.GenerateNewUUID()
+
+	menuPublishedEvent := events.MenuPublished{
+		EventInfo: eventutils.NewEventInfo(menuID),
+		Version:   3,
+		Name:      "TestMenu",
+		Categories: []events.PublishedCategory{
+			{
+				ID:   categoryID,
+				Name: "TestCategory",
+				SubCategories: []events.PublishedSubCategory{
+					{
+						ID:   subCategoryID,
+						Name: "TestSubCategory",
+						MenuItems: []events.PublishedMenuItem{
+							{
+								ID:                       menuItemID,
+								Name:                     "TestMenuItem",
+								Price:                    events.Price{Amount: 1250, Currency: "EUR"},
+								EstimatedPreparationTime: 15 * time.Minute,
+								Allergens:                []events.Allergen{events.AllergenGluten},
+								DietaryTags:              events.DietaryTags{Vegetarian: true},
+								Variants: []events.PublishedVariant{
+									{ID: variantID, Name: "Large", Price: events.Price{Amount: 1450, Currency: "EUR"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	serializedEvent := eventutils.SerializedEvent(menuPublishedEvent)
+
+	incomingMessage := &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:   serializedEvent.ID,
+				EventType: serializedEvent.Name,
+				Data:      serializedEvent.Data,
+			},
+		},
+		SubscriptionDropped: &esdb.SubscriptionDropped{},
+		CheckPointReached:   &esdb.Position{},
+	}
+
+	estimatedPreparationSeconds := int64(900)
+	expectedTree := MenuTreeView{
+		ID:   menuID,
+		Name: "TestMenu",
+		Categories: []CategoryTreeView{
+			{
+				ID:   categoryID,
+				Name: "TestCategory",
+				SubCategories: []SubCategoryTreeView{
+					{
+						ID:   subCategoryID,
+						Name: "TestSubCategory",
+						MenuItems: []MenuItemView{
+							{
+								ID:                          menuItemID,
+								Name:                        "TestMenuItem",
+								Price:                       &PriceView{Amount: 1250, Currency: "EUR"},
+								EstimatedPreparationSeconds: &estimatedPreparationSeconds,
+								Allergens:                   []string{"gluten"},
+								DietaryTags:                 DietaryTagsView{Vegetarian: true},
+								Variants: []VariantView{
+									{ID: variantID, Name: "Large", Price: PriceView{Amount: 1450, Currency: "EUR"}},
+								},
+								ModifierGroups: []ModifierGroupView{},
+							},
+						},
+						MaxEstimatedPreparationSeconds: &estimatedPreparationSeconds,
+					},
+				},
+			},
+		},
+	}
+
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("PublishMenuVersion", menuID, 3, expectedTree, mock.AnythingOfType("time.Time")).
 		Return(nil)
 
 	eventHandler := NewMenuEventHandler(mockMenuRepository)
 
 	// Act
-	eventHandler.HandleMenuItemAddedToSubCategory(incomingMessage)
+	eventHandler.HandleMenuPublished(context.Background(), incomingMessage)
 
 	// Assert
 	mockMenuRepository.AssertExpectations(t)
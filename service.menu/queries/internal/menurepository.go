@@ -1,621 +1,1287 @@
 package internal
 
 import (
+	"context"
 	"database/sql"
-	"log"
-	"strings"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/events"
 	"github.com/gofrs/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type IMenuRepository interface {
-	CreateMenu(menuID uuid.UUID, menuName string) error
-	GetMenu(menuID uuid.UUID) (MenuView, error)
-	GetAllMenus() ([]MenuView, error)
-	DeleteMenu(menuID uuid.UUID) error
-	EnableMenu(menuID uuid.UUID) error
-	DisableMenu(menuID uuid.UUID) error
-	ChangeMenuName(menuID uuid.UUID, newName string) error
-	CreateCategory(categoryID uuid.UUID, categoryName string) error
-	AddCategoryToMenu(menuID, categoryID uuid.UUID) error
-	GetCategoriesByIDs(categoriesIDs []uuid.UUID) ([]CategoryView, error)
-	ChangeCategoryName(categoryID uuid.UUID, newName string) error
-	CreateSubCategory(subCategoryID uuid.UUID, subCategoryName string) error
-	GetSubCategoriesByIDs(subCategoriesIDs []uuid.UUID) ([]SubCategoryView, error)
-	AddSubCategoryToCategory(categoryID, subCategoryID uuid.UUID) error
-	CreateMenuItem(menuItemID uuid.UUID, menuItemName string) error
-	AddMenuItemToSubCategory(subCategoryID, menuItemID uuid.UUID) error
-	GetMenuItemsByIDs(menuItemsIDs []uuid.UUID) ([]MenuItemView, error)
+	CreateMenu(ctx context.Context, menuID uuid.UUID, menuName string) error
+	GetMenu(ctx context.Context, menuID uuid.UUID) (MenuView, error)
+	GetAllMenus(ctx context.Context) ([]MenuView, error)
+	GetMenuTree(ctx context.Context, menuID uuid.UUID, enabledOnly bool) (MenuTreeView, error)
+	DeleteMenu(ctx context.Context, menuID uuid.UUID) error
+	MarkMenuAsDeleted(ctx context.Context, menuID uuid.UUID) error
+	EnableMenu(ctx context.Context, menuID uuid.UUID) error
+	DisableMenu(ctx context.Context, menuID uuid.UUID) error
+	ChangeMenuName(ctx context.Context, menuID uuid.UUID, newName string) error
+	PublishMenuVersion(ctx context.Context, menuID uuid.UUID, version int, tree MenuTreeView, publishedAt time.Time) error
+	RestoreMenuVersion(ctx context.Context, menuID uuid.UUID, version int) error
+	GetPublishedMenuTree(ctx context.Context, menuID uuid.UUID) (MenuTreeView, error)
+	GetMenuVersion(ctx context.Context, menuID uuid.UUID, version int) (MenuTreeView, error)
+	GetMenuVersions(ctx context.Context, menuID uuid.UUID) ([]MenuVersionView, error)
+	CreateCategory(ctx context.Context, categoryID uuid.UUID, categoryName string) error
+	AddCategoryToMenu(ctx context.Context, menuID, categoryID uuid.UUID, eventPosition esdb.Position) error
+	RemoveCategoryFromMenu(ctx context.Context, menuID, categoryID uuid.UUID, eventPosition esdb.Position) error
+	ReorderCategories(ctx context.Context, menuID uuid.UUID, categoriesIDs []uuid.UUID) error
+	MarkCategoryAsDeleted(ctx context.Context, categoryID uuid.UUID) error
+	GetCategoriesByIDs(ctx context.Context, categoriesIDs []uuid.UUID) ([]CategoryView, []uuid.UUID, error)
+	ChangeCategoryName(ctx context.Context, categoryID uuid.UUID, newName string) error
+	CreateSubCategory(ctx context.Context, subCategoryID uuid.UUID, subCategoryName string) error
+	GetSubCategoriesByIDs(ctx context.Context, subCategoriesIDs []uuid.UUID) ([]SubCategoryView, []uuid.UUID, error)
+	AddSubCategoryToCategory(ctx context.Context, categoryID, subCategoryID uuid.UUID, eventPosition esdb.Position) error
+	RemoveSubCategoryFromCategory(ctx context.Context, categoryID, subCategoryID uuid.UUID, eventPosition esdb.Position) error
+	ReorderSubCategories(ctx context.Context, categoryID uuid.UUID, subCategoriesIDs []uuid.UUID) error
+	MarkSubCategoryAsDeleted(ctx context.Context, subCategoryID uuid.UUID) error
+	CreateMenuItem(ctx context.Context, menuItemID uuid.UUID, menuItemName string) error
+	AddMenuItemToSubCategory(ctx context.Context, subCategoryID, menuItemID uuid.UUID, eventPosition esdb.Position) error
+	RemoveMenuItemFromSubCategory(ctx context.Context, subCategoryID, menuItemID uuid.UUID, eventPosition esdb.Position) error
+	ReorderMenuItems(ctx context.Context, subCategoryID uuid.UUID, menuItemsIDs []uuid.UUID) error
+	MarkMenuItemAsDeleted(ctx context.Context, menuItemID uuid.UUID) error
+	GetMenuItemsByIDs(ctx context.Context, menuItemsIDs []uuid.UUID) ([]MenuItemView, []uuid.UUID, error)
+	ChangeMenuItemPrice(ctx context.Context, menuItemID uuid.UUID, price events.Price) error
+	ChangeMenuItemEstimatedPreparationTime(ctx context.Context, menuItemID uuid.UUID, estimatedPreparationTime time.Duration) error
+	ChangeMenuItemDescription(ctx context.Context, menuItemID uuid.UUID, description string) error
+	ChangeMenuItemAllergens(ctx context.Context, menuItemID uuid.UUID, allergens []events.Allergen) error
+	ChangeMenuItemDietaryTags(ctx context.Context, menuItemID uuid.UUID, dietaryTags events.DietaryTags) error
+	GetMenuItems(ctx context.Context, excludeAllergens []string) ([]MenuItemView, error)
+	AddMenuItemVariant(ctx context.Context, menuItemID, variantID uuid.UUID, name string, price events.Price) error
+	RemoveMenuItemVariant(ctx context.Context, variantID uuid.UUID) error
+	AddMenuItemModifierGroup(ctx context.Context, menuItemID, modifierGroupID uuid.UUID, name string, minSelections, maxSelections int) error
+	RemoveMenuItemModifierGroup(ctx context.Context, modifierGroupID uuid.UUID) error
+	AddMenuItemModifier(ctx context.Context, modifierGroupID, modifierID uuid.UUID, name string, priceDelta events.Price) error
+	RemoveMenuItemModifier(ctx context.Context, modifierID uuid.UUID) error
+	RunInTransaction(ctx context.Context, fn func(repo IMenuRepository) error) error
+	GetCheckpoint(ctx context.Context, projectionName string) (esdb.Position, error)
+	SaveCheckpoint(ctx context.Context, projectionName string, position esdb.Position) error
+	IsEventProcessed(ctx context.Context, projectionName string, eventID uuid.UUID) (bool, error)
+	RecordProcessedEvent(ctx context.Context, projectionName string, eventID uuid.UUID) (bool, error)
+	ParkEvent(ctx context.Context, projectionName string, event *esdb.RecordedEvent) error
+	UnparkEvent(ctx context.Context, projectionName string, eventID uuid.UUID) error
+	GetParkedEvents(ctx context.Context, projectionName string) ([]*esdb.RecordedEvent, error)
 }
 
-type MenuRepository struct {
-	connectionString string
+// PoolConfig limits the connections the repository keeps open to Postgres,
+// a zero value leaves the default of database/sql
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
 
-func NewMenuRepository(connectionString string) MenuRepository {
-	return MenuRepository{
-		connectionString: connectionString,
-	}
+type MenuRepository struct {
+	db *sql.DB
+	// schema is the Postgres schema the tables are looked up in within a transaction,
+	// the default search path is used when it is empty
+	schema string
+	tx     *sql.Tx
 }
 
-func (repo MenuRepository) CreateMenu(menuID uuid.UUID, menuName string) error {
-	db, err := sql.Open("postgres", repo.connectionString)
+// NewMenuRepository returns a repository with its own pool of connections, which must be closed with Close
+func NewMenuRepository(connectionString string, poolConfig PoolConfig) (MenuRepository, error) {
+	db, err := sql.Open("postgres", connectionString)
 	if err != nil {
-		return err
+		return MenuRepository{}, err
 	}
-	defer db.Close()
-
-	query := `INSERT INTO menus ("id", "name") VALUES ($1, $2)`
-	_, err = db.Exec(query, menuID, menuName)
-	if err != nil {
-		return err
+	if poolConfig.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(poolConfig.MaxOpenConns)
+	}
+	if poolConfig.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(poolConfig.MaxIdleConns)
 	}
-	return nil
+	if poolConfig.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(poolConfig.ConnMaxLifetime)
+	}
+	if poolConfig.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(poolConfig.ConnMaxIdleTime)
+	}
+	return MenuRepository{
+		db: db,
+	}, nil
 }
 
-func (repo MenuRepository) GetMenu(menuID uuid.UUID) (MenuView, error) {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return MenuView{}, err
-	}
-	defer db.Close()
+// Close closes the connections of the repository and of every copy of it
+func (repo MenuRepository) Close() error {
+	return repo.db.Close()
+}
+
+func (repo MenuRepository) CreateMenu(ctx context.Context, menuID uuid.UUID, menuName string) error {
+	query := `INSERT INTO menus ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO NOTHING`
+	return repo.exec(ctx, query, menuID, menuName)
+}
 
+func (repo MenuRepository) GetMenu(ctx context.Context, menuID uuid.UUID) (MenuView, error) {
 	var menuView MenuView
 
 	query := `
-		SELECT m.*, array_agg(mc.category_id) AS ids
+		SELECT m.id, m.name, m.is_enabled, m.created_at,
+			COALESCE(array_agg(c.id ORDER BY mc.position) FILTER (WHERE c.id IS NOT NULL), '{}') AS ids
 		FROM menus m
 		LEFT JOIN menus_categories mc ON m.id = mc.menu_id
-		WHERE m.id=$1
+		LEFT JOIN categories c ON c.id = mc.category_id AND c.deleted_at IS NULL
+		WHERE m.id=$1 AND m.deleted_at IS NULL
 		GROUP BY m.id;
 	`
-	row := db.QueryRow(query, menuID)
-	var categoriesIDs []uint8
-	err = row.Scan(
+	row := repo.querier().QueryRowContext(ctx, query, menuID)
+	err := row.Scan(
 		&menuView.ID,
 		&menuView.Name,
 		&menuView.IsEnabled,
 		&menuView.CreatedAt,
-		&categoriesIDs,
+		pq.Array(&menuView.CategoriesIDs),
 	)
-	menuView.CategoriesIDs = convertUint8ToUUIDSlice(categoriesIDs)
 	if err != nil {
 		return MenuView{}, err
 	}
 	return menuView, nil
 }
 
-func (repo MenuRepository) GetAllMenus() ([]MenuView, error) {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return []MenuView{}, err
-	}
-	defer db.Close()
-
+func (repo MenuRepository) GetAllMenus(ctx context.Context) ([]MenuView, error) {
 	var menuViews []MenuView
 
 	query := `
-		SELECT m.*, array_agg(mc.category_id) AS ids
+		SELECT m.id, m.name, m.is_enabled, m.created_at,
+			COALESCE(array_agg(c.id ORDER BY mc.position) FILTER (WHERE c.id IS NOT NULL), '{}') AS ids
 		FROM menus m
 		LEFT JOIN menus_categories mc ON m.id = mc.menu_id
+		LEFT JOIN categories c ON c.id = mc.category_id AND c.deleted_at IS NULL
+		WHERE m.deleted_at IS NULL
 		GROUP BY m.id;
 	`
-	rows, err := db.Query(query)
+	rows, err := repo.querier().QueryContext(ctx, query)
+	if err != nil {
+		return []MenuView{}, err
+	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var menuView MenuView
-		var categoriesIDs []uint8
 		err = rows.Scan(
 			&menuView.ID,
 			&menuView.Name,
 			&menuView.IsEnabled,
 			&menuView.CreatedAt,
-			&categoriesIDs,
+			pq.Array(&menuView.CategoriesIDs),
 		)
-		menuView.CategoriesIDs = convertUint8ToUUIDSlice(categoriesIDs)
 		if err != nil {
-			log.Fatalf("Unable to scan the row. %v", err)
+			return []MenuView{}, err
 		}
 		menuViews = append(menuViews, menuView)
 	}
 
-	return menuViews, nil
+	return menuViews, rows.Err()
 }
 
-func (repo MenuRepository) DeleteMenu(menuID uuid.UUID) error {
-	db, err := sql.Open("postgres", repo.connectionString)
+// GetMenuTree returns the menu with all its descendants, read with one query for the menu, its categories
+// and subcategories and one for the menu items.
+// With enabledOnly a disabled menu is not found, sql.ErrNoRows is returned when the menu is not found.
+func (repo MenuRepository) GetMenuTree(ctx context.Context, menuID uuid.UUID, enabledOnly bool) (MenuTreeView, error) {
+	query := `
+		SELECT m.id, m.name, m.is_enabled, m.created_at,
+			c.id, c.name, c.created_at,
+			s.id, s.name, s.created_at,
+			i.id
+		FROM menus m
+		LEFT JOIN menus_categories mc ON m.id = mc.menu_id
+		LEFT JOIN categories c ON c.id = mc.category_id AND c.deleted_at IS NULL
+		LEFT JOIN category_subcategories cs ON c.id = cs.category_id
+		LEFT JOIN subcategories s ON s.id = cs.subcategory_id AND s.deleted_at IS NULL
+		LEFT JOIN subcategory_menuitems sm ON s.id = sm.subcategory_id
+		LEFT JOIN menuitems i ON i.id = sm.menuitem_id AND i.deleted_at IS NULL
+		WHERE m.id=$1 AND m.deleted_at IS NULL AND (NOT $2 OR m.is_enabled)
+		ORDER BY mc.position, c.id, cs.position, s.id, sm.position, i.id;
+	`
+	rows, err := repo.querier().QueryContext(ctx, query, menuID, enabledOnly)
 	if err != nil {
-		return err
+		return MenuTreeView{}, err
 	}
-	defer db.Close()
+	defer rows.Close()
 
-	query := `DELETE FROM menus WHERE id=$1`
-	_, err = db.Exec(query, menuID)
-	if err != nil {
-		return err
+	tree := menuTreeBuilder{}
+	for rows.Next() {
+		var row menuTreeRow
+		err = rows.Scan(
+			&tree.menu.ID,
+			&tree.menu.Name,
+			&tree.menu.IsEnabled,
+			&tree.menu.CreatedAt,
+			&row.categoryID,
+			&row.categoryName,
+			&row.categoryCreatedAt,
+			&row.subCategoryID,
+			&row.subCategoryName,
+			&row.subCategoryCreatedAt,
+			&row.menuItemID,
+		)
+		if err != nil {
+			return MenuTreeView{}, err
+		}
+		tree.add(row)
 	}
-	return nil
-}
-
-func (repo MenuRepository) EnableMenu(menuID uuid.UUID) error {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return err
+	if err = rows.Err(); err != nil {
+		return MenuTreeView{}, err
+	}
+	if tree.menu.ID.IsNil() {
+		return MenuTreeView{}, sql.ErrNoRows
 	}
-	defer db.Close()
 
-	query := `UPDATE menus SET is_enabled=TRUE WHERE id=$1`
-	_, err = db.Exec(query, menuID)
+	menuItems, err := repo.queryMenuItems(ctx, `WHERE i.id = ANY($1)`, pq.Array(tree.allMenuItemsIDs()))
 	if err != nil {
-		return err
+		return MenuTreeView{}, err
 	}
-	return nil
+	return tree.build(menuItems), nil
 }
 
-func (repo MenuRepository) DisableMenu(menuID uuid.UUID) error {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
+// DeleteMenu deletes the menu, its published versions and its links to its categories
+func (repo MenuRepository) DeleteMenu(ctx context.Context, menuID uuid.UUID) error {
+	return repo.execInTransaction(ctx, []string{
+		`DELETE FROM menus_categories WHERE menu_id=$1`,
+		`DELETE FROM menu_versions WHERE menu_id=$1`,
+		`DELETE FROM menus WHERE id=$1`,
+	}, menuID)
+}
 
-	query := `UPDATE menus SET is_enabled=FALSE WHERE id=$1`
-	_, err = db.Exec(query, menuID)
-	if err != nil {
-		return err
-	}
-	return nil
+// MarkMenuAsDeleted hides the menu and detaches its categories. The row is kept,
+// so that the links to the menu that are projected late are skipped instead of failing
+func (repo MenuRepository) MarkMenuAsDeleted(ctx context.Context, menuID uuid.UUID) error {
+	return repo.execInTransaction(ctx, []string{
+		`SELECT id FROM menus WHERE id=$1 FOR UPDATE`,
+		`DELETE FROM menus_categories WHERE menu_id=$1`,
+		`UPDATE menus SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL`,
+	}, menuID)
 }
 
-func (repo MenuRepository) ChangeMenuName(menuID uuid.UUID, newName string) error {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
+func (repo MenuRepository) EnableMenu(ctx context.Context, menuID uuid.UUID) error {
+	query := `UPDATE menus SET is_enabled=TRUE WHERE id=$1`
+	return repo.exec(ctx, query, menuID)
+}
 
+func (repo MenuRepository) DisableMenu(ctx context.Context, menuID uuid.UUID) error {
+	query := `UPDATE menus SET is_enabled=FALSE WHERE id=$1`
+	return repo.exec(ctx, query, menuID)
+}
+
+func (repo MenuRepository) ChangeMenuName(ctx context.Context, menuID uuid.UUID, newName string) error {
 	query := `UPDATE menus SET name=$2 WHERE id=$1`
-	_, err = db.Exec(query, menuID, newName)
-	if err != nil {
-		return err
-	}
-	return nil
+	return repo.exec(ctx, query, menuID, newName)
 }
 
-func (repo MenuRepository) CreateCategory(categoryID uuid.UUID, categoryName string) error {
-	db, err := sql.Open("postgres", repo.connectionString)
+// PublishMenuVersion saves the tree of a new version of the menu and makes it the one guests see
+func (repo MenuRepository) PublishMenuVersion(ctx context.Context, menuID uuid.UUID, version int, tree MenuTreeView, publishedAt time.Time) error {
+	serializedTree, err := json.Marshal(tree)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
+	return repo.inTransaction(ctx, func(txRepo MenuRepository) error {
+		query := `
+			INSERT INTO menu_versions ("menu_id", "version", "tree", "published_at")
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT ("menu_id", "version") DO NOTHING
+		`
+		err := txRepo.exec(ctx, query, menuID, version, serializedTree, publishedAt)
+		if err != nil {
+			return err
+		}
+		return txRepo.RestoreMenuVersion(ctx, menuID, version)
+	})
+}
 
-	query := `INSERT INTO categories ("id", "name") VALUES ($1, $2)`
-	_, err = db.Exec(query, categoryID, categoryName)
-	if err != nil {
-		return err
-	}
-	return nil
+// RestoreMenuVersion makes an already published version of the menu the one guests see
+func (repo MenuRepository) RestoreMenuVersion(ctx context.Context, menuID uuid.UUID, version int) error {
+	query := `UPDATE menus SET published_version=$2 WHERE id=$1`
+	return repo.exec(ctx, query, menuID, version)
+}
+
+// GetPublishedMenuTree returns the version of the menu guests see, sql.ErrNoRows is returned
+// when the menu is not found, is disabled or has never been published
+func (repo MenuRepository) GetPublishedMenuTree(ctx context.Context, menuID uuid.UUID) (MenuTreeView, error) {
+	query := `
+		SELECT v.tree, m.is_enabled, m.created_at
+		FROM menus m
+		JOIN menu_versions v ON v.menu_id = m.id AND v.version = m.published_version
+		WHERE m.id=$1 AND m.deleted_at IS NULL AND m.is_enabled
+	`
+	return repo.queryMenuVersionTree(ctx, query, menuID)
+}
+
+// GetMenuVersion returns a published version of the menu, sql.ErrNoRows is returned
+// when the menu or the version is not found
+func (repo MenuRepository) GetMenuVersion(ctx context.Context, menuID uuid.UUID, version int) (MenuTreeView, error) {
+	query := `
+		SELECT v.tree, m.is_enabled, m.created_at
+		FROM menus m
+		JOIN menu_versions v ON v.menu_id = m.id
+		WHERE m.id=$1 AND v.version=$2 AND m.deleted_at IS NULL
+	`
+	return repo.queryMenuVersionTree(ctx, query, menuID, version)
 }
 
-func (repo MenuRepository) DeleteCategory(categoryID uuid.UUID) error {
-	db, err := sql.Open("postgres", repo.connectionString)
+// GetMenuVersions returns the published versions of the menu, oldest first
+func (repo MenuRepository) GetMenuVersions(ctx context.Context, menuID uuid.UUID) ([]MenuVersionView, error) {
+	query := `
+		SELECT v.version, v.published_at, COALESCE(v.version = m.published_version, FALSE)
+		FROM menu_versions v
+		JOIN menus m ON m.id = v.menu_id
+		WHERE v.menu_id=$1 AND m.deleted_at IS NULL
+		ORDER BY v.version
+	`
+	rows, err := repo.querier().QueryContext(ctx, query, menuID)
 	if err != nil {
-		return err
+		return []MenuVersionView{}, err
 	}
-	defer db.Close()
+	defer rows.Close()
 
-	query := `DELETE FROM categories WHERE id=$1`
-	_, err = db.Exec(query, categoryID)
-	if err != nil {
-		return err
+	versions := []MenuVersionView{}
+	for rows.Next() {
+		var versionView MenuVersionView
+		err = rows.Scan(&versionView.Version, &versionView.PublishedAt, &versionView.IsPublished)
+		if err != nil {
+			return []MenuVersionView{}, err
+		}
+		versions = append(versions, versionView)
 	}
-	return nil
+	if err = rows.Err(); err != nil {
+		return []MenuVersionView{}, err
+	}
+	return versions, nil
 }
 
-func (repo MenuRepository) GetCategory(categoryID uuid.UUID) (CategoryView, error) {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return CategoryView{}, err
-	}
-	defer db.Close()
+// CreateCategory creates the category, or names the empty one created by a link event applied before it
+func (repo MenuRepository) CreateCategory(ctx context.Context, categoryID uuid.UUID, categoryName string) error {
+	query := `INSERT INTO categories ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET name=EXCLUDED.name`
+	return repo.exec(ctx, query, categoryID, categoryName)
+}
+
+// DeleteCategory deletes the category and its links to its menu and subcategories
+func (repo MenuRepository) DeleteCategory(ctx context.Context, categoryID uuid.UUID) error {
+	return repo.execInTransaction(ctx, []string{
+		`DELETE FROM menus_categories WHERE category_id=$1`,
+		`DELETE FROM category_subcategories WHERE category_id=$1`,
+		`DELETE FROM categories WHERE id=$1`,
+	}, categoryID)
+}
+
+// MarkCategoryAsDeleted hides the category, removes it from its menu and detaches its subcategories.
+// The category is locked first, so that a link event of the category applied meanwhile waits and is then skipped
+func (repo MenuRepository) MarkCategoryAsDeleted(ctx context.Context, categoryID uuid.UUID) error {
+	return repo.execInTransaction(ctx, []string{
+		`SELECT id FROM categories WHERE id=$1 FOR UPDATE`,
+		`DELETE FROM menus_categories WHERE category_id=$1`,
+		`DELETE FROM category_subcategories WHERE category_id=$1`,
+		`UPDATE categories SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL`,
+	}, categoryID)
+}
 
+func (repo MenuRepository) GetCategory(ctx context.Context, categoryID uuid.UUID) (CategoryView, error) {
 	var categoryView CategoryView
 
 	query := `
-		SELECT m.*, array_agg(mc.subcategory_id) AS ids
+		SELECT m.id, m.name, m.created_at,
+			COALESCE(array_agg(mc.subcategory_id ORDER BY mc.position) FILTER (WHERE mc.subcategory_id IS NOT NULL), '{}') AS ids
 		FROM categories m
 		LEFT JOIN category_subcategories mc ON m.id = mc.category_id
-		WHERE m.id=$1
+		WHERE m.id=$1 AND m.deleted_at IS NULL
 		GROUP BY m.id;
 	`
-	row := db.QueryRow(query, categoryID)
-	var subCategoriesIDs []uint8
-	err = row.Scan(
+	row := repo.querier().QueryRowContext(ctx, query, categoryID)
+	err := row.Scan(
 		&categoryView.ID,
 		&categoryView.Name,
 		&categoryView.CreatedAt,
-		&subCategoriesIDs,
+		pq.Array(&categoryView.SubCategoriesIDs),
 	)
-
-	categoryView.SubCategoriesIDs = convertUint8ToUUIDSlice(subCategoriesIDs)
-
 	if err != nil {
 		return CategoryView{}, err
 	}
 	return categoryView, nil
 }
 
-func (repo MenuRepository) AddCategoryToMenu(menuID, categoryID uuid.UUID) error {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	query := `INSERT INTO menus_categories ("menu_id", "category_id") VALUES ($1, $2)`
-	_, err = db.Exec(query, menuID, categoryID)
-	if err != nil {
-		return err
-	}
-	return nil
+// AddCategoryToMenu links the category to the menu after its other categories, unless one of them is deleted:
+// a deleted category is not linked again by a late CategoryAddedToMenu.
+// The category leaves the menu it was in, unless a later link event of the category was already applied.
+func (repo MenuRepository) AddCategoryToMenu(ctx context.Context, menuID, categoryID uuid.UUID, eventPosition esdb.Position) error {
+	return repo.applyLinkEvent(ctx, "categories", categoryID, eventPosition, menuID, []string{
+		`DELETE FROM menus_categories WHERE category_id=$2 AND menu_id<>$1`,
+		`INSERT INTO menus_categories ("menu_id", "category_id", "position")
+		SELECT $1::uuid, $2::uuid, (SELECT COALESCE(MAX(position) + 1, 0) FROM menus_categories WHERE menu_id=$1)
+		WHERE NOT EXISTS (SELECT 1 FROM menus WHERE id=$1 AND deleted_at IS NOT NULL)
+			AND NOT EXISTS (SELECT 1 FROM categories WHERE id=$2 AND deleted_at IS NOT NULL)
+		ON CONFLICT DO NOTHING`,
+	})
 }
 
-func (repo MenuRepository) RemoveCategoryFromMenu(menuID, categoryID uuid.UUID) error {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	query := `DELETE FROM menus_categories WHERE menu_id=$1 AND category_id=$2`
-	_, err = db.Exec(query, menuID, categoryID)
-	if err != nil {
-		return err
-	}
-	return nil
+// RemoveCategoryFromMenu unlinks the category from the menu, unless a later link event of the category was already applied
+func (repo MenuRepository) RemoveCategoryFromMenu(ctx context.Context, menuID, categoryID uuid.UUID, eventPosition esdb.Position) error {
+	return repo.applyLinkEvent(ctx, "categories", categoryID, eventPosition, menuID, []string{
+		`DELETE FROM menus_categories WHERE menu_id=$1 AND category_id=$2`,
+	})
 }
 
-func (repo MenuRepository) GetCategoriesByIDs(categoriesIDs []uuid.UUID) ([]CategoryView, error) {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return []CategoryView{}, err
-	}
-	defer db.Close()
-
-	idListString := makeStringList(categoriesIDs)
+// ReorderCategories sets the positions of the categories of the menu to their index in categoriesIDs
+func (repo MenuRepository) ReorderCategories(ctx context.Context, menuID uuid.UUID, categoriesIDs []uuid.UUID) error {
+	query := `
+		UPDATE menus_categories mc SET position = o.position - 1
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(category_id, position)
+		WHERE mc.menu_id=$1 AND mc.category_id = o.category_id`
+	return repo.exec(ctx, query, menuID, pq.Array(categoriesIDs))
+}
 
+// GetCategoriesByIDs returns the categories in the order of the requested IDs,
+// together with the requested IDs no category was found for
+func (repo MenuRepository) GetCategoriesByIDs(ctx context.Context, categoriesIDs []uuid.UUID) ([]CategoryView, []uuid.UUID, error) {
 	query := `
-		SELECT m.*, array_agg(mc.subcategory_id) AS ids
+		SELECT m.id, m.name, m.created_at,
+			COALESCE(array_agg(mc.subcategory_id ORDER BY mc.position) FILTER (WHERE mc.subcategory_id IS NOT NULL), '{}') AS ids
 		FROM categories m
 		LEFT JOIN category_subcategories mc ON m.id = mc.category_id
-		WHERE id IN(` + idListString + `)
+		WHERE m.id = ANY($1) AND m.deleted_at IS NULL
 		GROUP BY m.id;
 	`
 
-	rows, err := db.Query(query)
+	rows, err := repo.querier().QueryContext(ctx, query, pq.Array(categoriesIDs))
+	if err != nil {
+		return []CategoryView{}, nil, err
+	}
 	defer rows.Close()
 
 	categories := []CategoryView{}
 
 	for rows.Next() {
 		var categoryView CategoryView
-		var subCategoriesIDs []uint8
 		err = rows.Scan(
 			&categoryView.ID,
 			&categoryView.Name,
 			&categoryView.CreatedAt,
-			&subCategoriesIDs,
+			pq.Array(&categoryView.SubCategoriesIDs),
 		)
-
-		categoryView.SubCategoriesIDs = convertUint8ToUUIDSlice(subCategoriesIDs)
-
 		if err != nil {
-			return []CategoryView{}, err
+			return []CategoryView{}, nil, err
 		}
 		categories = append(categories, categoryView)
 	}
+	if err = rows.Err(); err != nil {
+		return []CategoryView{}, nil, err
+	}
 
-	return categories, nil
+	categories, notFoundIDs := orderByIDs(categoriesIDs, categories, func(category CategoryView) uuid.UUID {
+		return category.ID
+	})
+	return categories, notFoundIDs, nil
 }
 
-func (repo MenuRepository) ChangeCategoryName(categoryID uuid.UUID, newName string) error {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
+func (repo MenuRepository) ChangeCategoryName(ctx context.Context, categoryID uuid.UUID, newName string) error {
 	query := `UPDATE categories SET name=$2 WHERE id=$1`
-	_, err = db.Exec(query, categoryID, newName)
-	if err != nil {
-		return err
-	}
-	return nil
+	return repo.exec(ctx, query, categoryID, newName)
 }
 
-func (repo MenuRepository) CreateSubCategory(subCategoryID uuid.UUID, subCategoryName string) error {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	query := `INSERT INTO subcategories ("id", "name") VALUES ($1, $2)`
-	_, err = db.Exec(query, subCategoryID, subCategoryName)
-	if err != nil {
-		return err
-	}
-	return nil
+// CreateSubCategory creates the subcategory, or names the empty one created by a link event applied before it
+func (repo MenuRepository) CreateSubCategory(ctx context.Context, subCategoryID uuid.UUID, subCategoryName string) error {
+	query := `INSERT INTO subcategories ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET name=EXCLUDED.name`
+	return repo.exec(ctx, query, subCategoryID, subCategoryName)
 }
 
-func (repo MenuRepository) DeleteSubCategory(subCategoryID uuid.UUID) error {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	query := `DELETE FROM subcategories WHERE id=$1`
-	_, err = db.Exec(query, subCategoryID)
-	if err != nil {
-		return err
-	}
-	return nil
+// DeleteSubCategory deletes the subcategory and its links to its category and menu items
+func (repo MenuRepository) DeleteSubCategory(ctx context.Context, subCategoryID uuid.UUID) error {
+	return repo.execInTransaction(ctx, []string{
+		`DELETE FROM category_subcategories WHERE subcategory_id=$1`,
+		`DELETE FROM subcategory_menuitems WHERE subcategory_id=$1`,
+		`DELETE FROM subcategories WHERE id=$1`,
+	}, subCategoryID)
 }
 
-func (repo MenuRepository) GetSubCategory(subCategoryID uuid.UUID) (SubCategoryView, error) {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return SubCategoryView{}, err
-	}
-	defer db.Close()
-
-	var subCategoryView SubCategoryView
+// MarkSubCategoryAsDeleted hides the subcategory, removes it from its category and detaches its menu items
+func (repo MenuRepository) MarkSubCategoryAsDeleted(ctx context.Context, subCategoryID uuid.UUID) error {
+	return repo.execInTransaction(ctx, []string{
+		`SELECT id FROM subcategories WHERE id=$1 FOR UPDATE`,
+		`DELETE FROM category_subcategories WHERE subcategory_id=$1`,
+		`DELETE FROM subcategory_menuitems WHERE subcategory_id=$1`,
+		`UPDATE subcategories SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL`,
+	}, subCategoryID)
+}
 
+func (repo MenuRepository) GetSubCategory(ctx context.Context, subCategoryID uuid.UUID) (SubCategoryView, error) {
 	query := `
-		SELECT m.*, array_agg(mc.menuitem_id) AS ids
+		SELECT m.id, m.name, m.created_at,
+			COALESCE(array_agg(mc.menuitem_id ORDER BY mc.position) FILTER (WHERE mc.menuitem_id IS NOT NULL), '{}') AS ids,
+			MAX(i.estimated_preparation_seconds)
 		FROM subcategories m
 		LEFT JOIN subcategory_menuitems mc ON m.id = mc.subcategory_id
-		WHERE m.id=$1
+		LEFT JOIN menuitems i ON i.id = mc.menuitem_id
+		WHERE m.id=$1 AND m.deleted_at IS NULL
 		GROUP BY m.id;
 	`
-	row := db.QueryRow(query, subCategoryID)
-	var menuItemsIDs []uint8
-	err = row.Scan(
-		&subCategoryView.ID,
-		&subCategoryView.Name,
-		&subCategoryView.CreatedAt,
-		&menuItemsIDs,
-	)
-
-	subCategoryView.MenuItemsIDs = convertUint8ToUUIDSlice(menuItemsIDs)
-
+	row := repo.querier().QueryRowContext(ctx, query, subCategoryID)
+	subCategoryView, err := scanSubCategory(row)
 	if err != nil {
 		return SubCategoryView{}, err
 	}
 	return subCategoryView, nil
 }
 
-func (repo MenuRepository) GetSubCategoriesByIDs(subCategoriesIDs []uuid.UUID) ([]SubCategoryView, error) {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return []SubCategoryView{}, err
-	}
-	defer db.Close()
-
-	idListString := makeStringList(subCategoriesIDs)
-
+// GetSubCategoriesByIDs returns the subcategories in the order of the requested IDs,
+// together with the requested IDs no subcategory was found for
+func (repo MenuRepository) GetSubCategoriesByIDs(ctx context.Context, subCategoriesIDs []uuid.UUID) ([]SubCategoryView, []uuid.UUID, error) {
 	query := `
-		SELECT m.*, array_agg(mc.menuitem_id) AS ids
+		SELECT m.id, m.name, m.created_at,
+			COALESCE(array_agg(mc.menuitem_id ORDER BY mc.position) FILTER (WHERE mc.menuitem_id IS NOT NULL), '{}') AS ids,
+			MAX(i.estimated_preparation_seconds)
 		FROM subcategories m
 		LEFT JOIN subcategory_menuitems mc ON m.id = mc.subcategory_id
-		WHERE id IN(` + idListString + `)
+		LEFT JOIN menuitems i ON i.id = mc.menuitem_id
+		WHERE m.id = ANY($1) AND m.deleted_at IS NULL
 		GROUP BY m.id;
 	`
 
-	rows, err := db.Query(query)
+	rows, err := repo.querier().QueryContext(ctx, query, pq.Array(subCategoriesIDs))
+	if err != nil {
+		return []SubCategoryView{}, nil, err
+	}
 	defer rows.Close()
 
 	subCategories := []SubCategoryView{}
 
 	for rows.Next() {
-		var subCategoryView SubCategoryView
-		var menuItemsIDs []uint8
-		err = rows.Scan(
-			&subCategoryView.ID,
-			&subCategoryView.Name,
-			&subCategoryView.CreatedAt,
-			&menuItemsIDs,
-		)
-
-		subCategoryView.MenuItemsIDs = convertUint8ToUUIDSlice(menuItemsIDs)
-
+		subCategoryView, err := scanSubCategory(rows)
 		if err != nil {
-			return []SubCategoryView{}, err
+			return []SubCategoryView{}, nil, err
 		}
 		subCategories = append(subCategories, subCategoryView)
 	}
+	if err = rows.Err(); err != nil {
+		return []SubCategoryView{}, nil, err
+	}
+
+	subCategories, notFoundIDs := orderByIDs(subCategoriesIDs, subCategories, func(subCategory SubCategoryView) uuid.UUID {
+		return subCategory.ID
+	})
+	return subCategories, notFoundIDs, nil
+}
+
+// RemoveSubCategoryFromCategory unlinks the subcategory from the category,
+// unless a later link event of the subcategory was already applied
+func (repo MenuRepository) RemoveSubCategoryFromCategory(ctx context.Context, categoryID, subCategoryID uuid.UUID, eventPosition esdb.Position) error {
+	return repo.applyLinkEvent(ctx, "subcategories", subCategoryID, eventPosition, categoryID, []string{
+		`DELETE FROM category_subcategories WHERE category_id=$1 AND subcategory_id=$2`,
+	})
+}
+
+// AddSubCategoryToCategory links the subcategory to the category after its other subcategories,
+// unless one of them is deleted. The subcategory leaves the category it was in,
+// unless a later link event of the subcategory was already applied.
+func (repo MenuRepository) AddSubCategoryToCategory(ctx context.Context, categoryID, subCategoryID uuid.UUID, eventPosition esdb.Position) error {
+	return repo.applyLinkEvent(ctx, "subcategories", subCategoryID, eventPosition, categoryID, []string{
+		`DELETE FROM category_subcategories WHERE subcategory_id=$2 AND category_id<>$1`,
+		`INSERT INTO category_subcategories ("category_id", "subcategory_id", "position")
+		SELECT $1::uuid, $2::uuid, (SELECT COALESCE(MAX(position) + 1, 0) FROM category_subcategories WHERE category_id=$1)
+		WHERE NOT EXISTS (SELECT 1 FROM categories WHERE id=$1 AND deleted_at IS NOT NULL)
+			AND NOT EXISTS (SELECT 1 FROM subcategories WHERE id=$2 AND deleted_at IS NOT NULL)
+		ON CONFLICT DO NOTHING`,
+	})
+}
+
+// ReorderSubCategories sets the positions of the subcategories of the category to their index in subCategoriesIDs
+func (repo MenuRepository) ReorderSubCategories(ctx context.Context, categoryID uuid.UUID, subCategoriesIDs []uuid.UUID) error {
+	query := `
+		UPDATE category_subcategories cs SET position = o.position - 1
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(subcategory_id, position)
+		WHERE cs.category_id=$1 AND cs.subcategory_id = o.subcategory_id`
+	return repo.exec(ctx, query, categoryID, pq.Array(subCategoriesIDs))
+}
+
+// CreateMenuItem creates the menu item, or names the empty one created by a link event applied before it
+func (repo MenuRepository) CreateMenuItem(ctx context.Context, menuItemID uuid.UUID, menuItemName string) error {
+	query := `INSERT INTO menuitems ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET name=EXCLUDED.name`
+	return repo.exec(ctx, query, menuItemID, menuItemName)
+}
+
+// DeleteMenuItem deletes the menu item, its allergens, variants and modifiers and its link to its subcategory
+func (repo MenuRepository) DeleteMenuItem(ctx context.Context, menuItemID uuid.UUID) error {
+	return repo.execInTransaction(ctx, []string{
+		`DELETE FROM subcategory_menuitems WHERE menuitem_id=$1`,
+		`DELETE FROM menuitem_allergens WHERE menuitem_id=$1`,
+		`DELETE FROM menuitem_variants WHERE menuitem_id=$1`,
+		`DELETE FROM menuitem_modifiers WHERE modifier_group_id IN (SELECT id FROM menuitem_modifier_groups WHERE menuitem_id=$1)`,
+		`DELETE FROM menuitem_modifier_groups WHERE menuitem_id=$1`,
+		`DELETE FROM menuitems WHERE id=$1`,
+	}, menuItemID)
+}
 
-	return subCategories, nil
+// MarkMenuItemAsDeleted hides the menu item and removes it from its subcategory
+func (repo MenuRepository) MarkMenuItemAsDeleted(ctx context.Context, menuItemID uuid.UUID) error {
+	return repo.execInTransaction(ctx, []string{
+		`SELECT id FROM menuitems WHERE id=$1 FOR UPDATE`,
+		`DELETE FROM subcategory_menuitems WHERE menuitem_id=$1`,
+		`UPDATE menuitems SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL`,
+	}, menuItemID)
 }
 
-func (repo MenuRepository) RemoveSubCategoryFromCategory(categoryID, subCategoryID uuid.UUID) error {
-	db, err := sql.Open("postgres", repo.connectionString)
+func (repo MenuRepository) GetMenuItem(ctx context.Context, menuItemID uuid.UUID) (MenuItemView, error) {
+	query := `SELECT ` + menuItemColumns + ` FROM menuitems i WHERE i.id=$1 AND i.deleted_at IS NULL`
+	row := repo.querier().QueryRowContext(ctx, query, menuItemID)
+
+	menuItemView, err := scanMenuItem(row)
 	if err != nil {
-		return err
+		return MenuItemView{}, err
 	}
-	defer db.Close()
+	return menuItemView, nil
+}
 
-	query := `DELETE FROM category_subcategories WHERE category_id=$1 AND subcategory_id=$2`
-	_, err = db.Exec(query, categoryID, subCategoryID)
+// GetMenuItemsByIDs returns the menu items in the order of the requested IDs,
+// together with the requested IDs no menu item was found for
+func (repo MenuRepository) GetMenuItemsByIDs(ctx context.Context, menuItemsIDs []uuid.UUID) ([]MenuItemView, []uuid.UUID, error) {
+	menuItems, err := repo.queryMenuItems(ctx, `WHERE i.id = ANY($1) AND i.deleted_at IS NULL`, pq.Array(menuItemsIDs))
 	if err != nil {
-		return err
+		return []MenuItemView{}, nil, err
 	}
-	return nil
+
+	menuItems, notFoundIDs := orderByIDs(menuItemsIDs, menuItems, func(menuItem MenuItemView) uuid.UUID {
+		return menuItem.ID
+	})
+	return menuItems, notFoundIDs, nil
 }
 
-func (repo MenuRepository) AddSubCategoryToCategory(categoryID, subCategoryID uuid.UUID) error {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return err
+// GetMenuItems returns the menu items that are not deleted and contain none of the excluded allergens, oldest first
+func (repo MenuRepository) GetMenuItems(ctx context.Context, excludeAllergens []string) ([]MenuItemView, error) {
+	return repo.queryMenuItems(ctx, `
+		WHERE i.deleted_at IS NULL AND NOT EXISTS (
+			SELECT 1 FROM menuitem_allergens a WHERE a.menuitem_id = i.id AND a.allergen = ANY($1)
+		)
+		ORDER BY i.created_at, i.id`,
+		pq.Array(excludeAllergens),
+	)
+}
+
+func (repo MenuRepository) ChangeMenuItemPrice(ctx context.Context, menuItemID uuid.UUID, price events.Price) error {
+	query := `UPDATE menuitems SET price_amount=$2, price_currency=$3 WHERE id=$1`
+	return repo.exec(ctx, query, menuItemID, price.Amount, price.Currency)
+}
+
+// ChangeMenuItemEstimatedPreparationTime saves the time in whole seconds
+func (repo MenuRepository) ChangeMenuItemEstimatedPreparationTime(ctx context.Context, menuItemID uuid.UUID, estimatedPreparationTime time.Duration) error {
+	query := `UPDATE menuitems SET estimated_preparation_seconds=$2 WHERE id=$1`
+	return repo.exec(ctx, query, menuItemID, int64(estimatedPreparationTime/time.Second))
+}
+
+// ChangeMenuItemDescription saves the description, an empty one clears it
+func (repo MenuRepository) ChangeMenuItemDescription(ctx context.Context, menuItemID uuid.UUID, description string) error {
+	query := `UPDATE menuitems SET description=$2 WHERE id=$1`
+	return repo.exec(ctx, query, menuItemID, description)
+}
+
+// ChangeMenuItemAllergens replaces the allergens of the menu item
+func (repo MenuRepository) ChangeMenuItemAllergens(ctx context.Context, menuItemID uuid.UUID, allergens []events.Allergen) error {
+	allergenNames := make([]string, 0, len(allergens))
+	for _, allergen := range allergens {
+		allergenNames = append(allergenNames, string(allergen))
 	}
-	defer db.Close()
+	return repo.inTransaction(ctx, func(txRepo MenuRepository) error {
+		err := txRepo.exec(ctx, `DELETE FROM menuitem_allergens WHERE menuitem_id=$1`, menuItemID)
+		if err != nil {
+			return err
+		}
+		query := `
+			INSERT INTO menuitem_allergens ("menuitem_id", "allergen")
+			SELECT $1, unnest($2::varchar[])
+			ON CONFLICT DO NOTHING
+		`
+		return txRepo.exec(ctx, query, menuItemID, pq.Array(allergenNames))
+	})
+}
+
+func (repo MenuRepository) ChangeMenuItemDietaryTags(ctx context.Context, menuItemID uuid.UUID, dietaryTags events.DietaryTags) error {
+	query := `
+		UPDATE menuitems
+		SET is_vegan=$2, is_vegetarian=$3, is_gluten_free=$4, is_halal=$5, spicy_level=$6
+		WHERE id=$1
+	`
+	return repo.exec(ctx, query, menuItemID,
+		dietaryTags.Vegan,
+		dietaryTags.Vegetarian,
+		dietaryTags.GlutenFree,
+		dietaryTags.Halal,
+		dietaryTags.SpicyLevel,
+	)
+}
+
+func (repo MenuRepository) AddMenuItemVariant(ctx context.Context, menuItemID, variantID uuid.UUID, name string, price events.Price) error {
+	query := `
+		INSERT INTO menuitem_variants ("id", "menuitem_id", "name", "price_amount", "price_currency")
+		VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("id") DO NOTHING
+	`
+	return repo.exec(ctx, query, variantID, menuItemID, name, price.Amount, price.Currency)
+}
+
+func (repo MenuRepository) RemoveMenuItemVariant(ctx context.Context, variantID uuid.UUID) error {
+	query := `DELETE FROM menuitem_variants WHERE id=$1`
+	return repo.exec(ctx, query, variantID)
+}
+
+func (repo MenuRepository) AddMenuItemModifierGroup(ctx context.Context, menuItemID, modifierGroupID uuid.UUID, name string, minSelections, maxSelections int) error {
+	query := `
+		INSERT INTO menuitem_modifier_groups ("id", "menuitem_id", "name", "min_selections", "max_selections")
+		VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("id") DO NOTHING
+	`
+	return repo.exec(ctx, query, modifierGroupID, menuItemID, name, minSelections, maxSelections)
+}
+
+// RemoveMenuItemModifierGroup deletes the modifier group and its modifiers
+func (repo MenuRepository) RemoveMenuItemModifierGroup(ctx context.Context, modifierGroupID uuid.UUID) error {
+	return repo.execInTransaction(ctx, []string{
+		`DELETE FROM menuitem_modifiers WHERE modifier_group_id=$1`,
+		`DELETE FROM menuitem_modifier_groups WHERE id=$1`,
+	}, modifierGroupID)
+}
+
+func (repo MenuRepository) AddMenuItemModifier(ctx context.Context, modifierGroupID, modifierID uuid.UUID, name string, priceDelta events.Price) error {
+	query := `
+		INSERT INTO menuitem_modifiers ("id", "modifier_group_id", "name", "price_delta_amount", "price_delta_currency")
+		VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("id") DO NOTHING
+	`
+	return repo.exec(ctx, query, modifierID, modifierGroupID, name, priceDelta.Amount, priceDelta.Currency)
+}
+
+func (repo MenuRepository) RemoveMenuItemModifier(ctx context.Context, modifierID uuid.UUID) error {
+	query := `DELETE FROM menuitem_modifiers WHERE id=$1`
+	return repo.exec(ctx, query, modifierID)
+}
 
-	query := `INSERT INTO category_subcategories ("category_id", "subcategory_id") VALUES ($1, $2)`
-	_, err = db.Exec(query, categoryID, subCategoryID)
+// RemoveMenuItemFromSubCategory unlinks the menu item from the subcategory,
+// unless a later link event of the menu item was already applied
+func (repo MenuRepository) RemoveMenuItemFromSubCategory(ctx context.Context, subCategoryID, menuItemID uuid.UUID, eventPosition esdb.Position) error {
+	return repo.applyLinkEvent(ctx, "menuitems", menuItemID, eventPosition, subCategoryID, []string{
+		`DELETE FROM subcategory_menuitems WHERE subcategory_id=$1 AND menuitem_id=$2`,
+	})
+}
+
+// AddMenuItemToSubCategory links the menu item to the subcategory after its other menu items,
+// unless one of them is deleted. The menu item leaves the subcategory it was in,
+// unless a later link event of the menu item was already applied.
+func (repo MenuRepository) AddMenuItemToSubCategory(ctx context.Context, subCategoryID, menuItemID uuid.UUID, eventPosition esdb.Position) error {
+	return repo.applyLinkEvent(ctx, "menuitems", menuItemID, eventPosition, subCategoryID, []string{
+		`DELETE FROM subcategory_menuitems WHERE menuitem_id=$2 AND subcategory_id<>$1`,
+		`INSERT INTO subcategory_menuitems ("subcategory_id", "menuitem_id", "position")
+		SELECT $1::uuid, $2::uuid, (SELECT COALESCE(MAX(position) + 1, 0) FROM subcategory_menuitems WHERE subcategory_id=$1)
+		WHERE NOT EXISTS (SELECT 1 FROM subcategories WHERE id=$1 AND deleted_at IS NOT NULL)
+			AND NOT EXISTS (SELECT 1 FROM menuitems WHERE id=$2 AND deleted_at IS NOT NULL)
+		ON CONFLICT DO NOTHING`,
+	})
+}
+
+// ReorderMenuItems sets the positions of the menu items of the subcategory to their index in menuItemsIDs
+func (repo MenuRepository) ReorderMenuItems(ctx context.Context, subCategoryID uuid.UUID, menuItemsIDs []uuid.UUID) error {
+	query := `
+		UPDATE subcategory_menuitems sm SET position = o.position - 1
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(menuitem_id, position)
+		WHERE sm.subcategory_id=$1 AND sm.menuitem_id = o.menuitem_id`
+	return repo.exec(ctx, query, subCategoryID, pq.Array(menuItemsIDs))
+}
+
+// RunInTransaction runs fn with a repository whose changes are committed together when fn succeeds,
+// if the repository is already in a transaction fn runs in that one
+func (repo MenuRepository) RunInTransaction(ctx context.Context, fn func(repo IMenuRepository) error) error {
+	return repo.inTransaction(ctx, func(txRepo MenuRepository) error {
+		return fn(txRepo)
+	})
+}
+
+// InSchema returns a repository that works on the tables of the given schema in its transactions
+func (repo MenuRepository) InSchema(schema string) MenuRepository {
+	repo.schema = schema
+	return repo
+}
+
+// GetCheckpoint returns the position of $all until which the projection applied every event,
+// ErrCheckpointNotFound if the projection has not saved a checkpoint yet
+func (repo MenuRepository) GetCheckpoint(ctx context.Context, projectionName string) (esdb.Position, error) {
+	query := `SELECT commit_position, prepare_position FROM projection_checkpoints WHERE name=$1`
+	var commitPosition, preparePosition int64
+	err := repo.querier().QueryRowContext(ctx, query, projectionName).Scan(&commitPosition, &preparePosition)
+	if errors.Is(err, sql.ErrNoRows) {
+		return esdb.Position{}, ErrCheckpointNotFound
+	}
 	if err != nil {
-		return err
+		return esdb.Position{}, err
 	}
-	return nil
+	return esdb.Position{
+		Commit:  uint64(commitPosition),
+		Prepare: uint64(preparePosition),
+	}, nil
+}
+
+// SaveCheckpoint moves the checkpoint of the projection forward to the given position,
+// a position before the current checkpoint leaves it as it is
+func (repo MenuRepository) SaveCheckpoint(ctx context.Context, projectionName string, position esdb.Position) error {
+	query := `
+		INSERT INTO projection_checkpoints ("name", "commit_position", "prepare_position") VALUES ($1, $2, $3)
+		ON CONFLICT ("name") DO UPDATE
+		SET commit_position=EXCLUDED.commit_position, prepare_position=EXCLUDED.prepare_position, updated_at=now()
+		WHERE projection_checkpoints.commit_position < EXCLUDED.commit_position
+	`
+	return repo.exec(ctx, query, projectionName, int64(position.Commit), int64(position.Prepare))
 }
 
-func (repo MenuRepository) CreateMenuItem(menuItemID uuid.UUID, menuItemName string) error {
-	db, err := sql.Open("postgres", repo.connectionString)
+// IsEventProcessed tells whether the projection recorded the event as processed
+func (repo MenuRepository) IsEventProcessed(ctx context.Context, projectionName string, eventID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM processed_events WHERE projection=$1 AND event_id=$2)`
+	var processed bool
+	err := repo.querier().QueryRowContext(ctx, query, projectionName, eventID).Scan(&processed)
+	return processed, err
+}
+
+// RecordProcessedEvent records that the projection processed the event,
+// it returns false if the event had already been recorded
+func (repo MenuRepository) RecordProcessedEvent(ctx context.Context, projectionName string, eventID uuid.UUID) (bool, error) {
+	query := `INSERT INTO processed_events ("projection", "event_id") VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	result, err := repo.querier().ExecContext(ctx, query, projectionName, eventID)
 	if err != nil {
-		return err
+		return false, err
 	}
-	defer db.Close()
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
 
-	query := `INSERT INTO menuitems ("id", "name") VALUES ($1, $2)`
-	_, err = db.Exec(query, menuItemID, menuItemName)
+// ParkEvent keeps the event the projection parked, an event parked again is kept once
+func (repo MenuRepository) ParkEvent(ctx context.Context, projectionName string, event *esdb.RecordedEvent) error {
+	query := `
+		INSERT INTO parked_events ("projection", "event_id", "event_type", "content_type", "stream_id", "event_number",
+			"commit_position", "prepare_position", "created_date", "data", "user_metadata")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT DO NOTHING
+	`
+	return repo.exec(ctx, query, projectionName, event.EventID, event.EventType, event.ContentType, event.StreamID,
+		int64(event.EventNumber), int64(event.Position.Commit), int64(event.Position.Prepare), event.CreatedDate,
+		event.Data, event.UserMetadata)
+}
+
+// UnparkEvent removes the event the projection applied once replayed
+func (repo MenuRepository) UnparkEvent(ctx context.Context, projectionName string, eventID uuid.UUID) error {
+	query := `DELETE FROM parked_events WHERE projection=$1 AND event_id=$2`
+	return repo.exec(ctx, query, projectionName, eventID)
+}
+
+// GetParkedEvents returns the events the projection parked, in the order of $all
+func (repo MenuRepository) GetParkedEvents(ctx context.Context, projectionName string) ([]*esdb.RecordedEvent, error) {
+	query := `
+		SELECT event_id, event_type, content_type, stream_id, event_number, commit_position, prepare_position,
+			created_date, data, user_metadata
+		FROM parked_events
+		WHERE projection=$1
+		ORDER BY commit_position, prepare_position
+	`
+	rows, err := repo.querier().QueryContext(ctx, query, projectionName)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+
+	parkedEvents := []*esdb.RecordedEvent{}
+	for rows.Next() {
+		var event esdb.RecordedEvent
+		var eventNumber, commitPosition, preparePosition int64
+		err = rows.Scan(&event.EventID, &event.EventType, &event.ContentType, &event.StreamID, &eventNumber,
+			&commitPosition, &preparePosition, &event.CreatedDate, &event.Data, &event.UserMetadata)
+		if err != nil {
+			return nil, err
+		}
+		event.EventNumber = uint64(eventNumber)
+		event.CreatedDate = event.CreatedDate.UTC()
+		event.Position = esdb.Position{Commit: uint64(commitPosition), Prepare: uint64(preparePosition)}
+		parkedEvents = append(parkedEvents, &event)
+	}
+	return parkedEvents, rows.Err()
+}
+
+// helpers
+
+// querier is the part of *sql.DB and *sql.Tx the repository runs its statements with
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// querier returns the transaction of the repository, if any, or its pool of connections
+func (repo MenuRepository) querier() querier {
+	if repo.tx != nil {
+		return repo.tx
 	}
-	return nil
+	return repo.db
 }
 
-func (repo MenuRepository) DeleteMenuItem(menuItemID uuid.UUID) error {
-	db, err := sql.Open("postgres", repo.connectionString)
+func (repo MenuRepository) exec(ctx context.Context, query string, args ...interface{}) error {
+	_, err := repo.querier().ExecContext(ctx, query, args...)
+	return err
+}
+
+// execInTransaction runs the statements, all with the same arguments, in a single transaction
+func (repo MenuRepository) execInTransaction(ctx context.Context, queries []string, args ...interface{}) error {
+	return repo.inTransaction(ctx, func(txRepo MenuRepository) error {
+		for _, query := range queries {
+			err := txRepo.exec(ctx, query, args...)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// applyLinkEvent runs the statements of a link event of the child, with the parent and child IDs as arguments,
+// in a single transaction. The link events of a child are applied by the workers of their parents, so they can
+// be applied out of order, and before the creation of the child: the child is created empty if it does not
+// exist yet, and the statements are skipped if a link event of the child later in $all was already applied.
+func (repo MenuRepository) applyLinkEvent(ctx context.Context, childTable string, childID uuid.UUID, eventPosition esdb.Position, parentID uuid.UUID, statements []string) error {
+	return repo.inTransaction(ctx, func(txRepo MenuRepository) error {
+		query := fmt.Sprintf(`INSERT INTO %s ("id", "name") VALUES ($1, '') ON CONFLICT ("id") DO NOTHING`, childTable)
+		err := txRepo.exec(ctx, query, childID)
+		if err != nil {
+			return err
+		}
+		// the link events and the deletion of the child are applied one at a time
+		query = fmt.Sprintf(`SELECT id FROM %s WHERE id=$1 FOR UPDATE`, childTable)
+		err = txRepo.exec(ctx, query, childID)
+		if err != nil {
+			return err
+		}
+		query = fmt.Sprintf(`
+			UPDATE %s SET link_commit_position=$2, link_prepare_position=$3
+			WHERE id=$1 AND (link_commit_position, link_prepare_position) < ($2, $3)`, childTable)
+		result, err := txRepo.querier().ExecContext(ctx, query, childID, int64(eventPosition.Commit), int64(eventPosition.Prepare))
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+		for _, statement := range statements {
+			err = txRepo.exec(ctx, statement, parentID, childID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (repo MenuRepository) inTransaction(ctx context.Context, fn func(txRepo MenuRepository) error) error {
+	if repo.tx != nil {
+		return fn(repo)
+	}
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
-
-	query := `DELETE FROM menuitems WHERE id=$1`
-	_, err = db.Exec(query, menuItemID)
+	txRepo := repo
+	txRepo.tx = tx
+	if repo.schema != "" {
+		err = txRepo.exec(ctx, `SET LOCAL search_path TO `+pq.QuoteIdentifier(repo.schema))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	err = fn(txRepo)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
-	return nil
+	return tx.Commit()
 }
 
-func (repo MenuRepository) GetMenuItem(menuItemID uuid.UUID) (MenuItemView, error) {
-	db, err := sql.Open("postgres", repo.connectionString)
+// scanner is the part of *sql.Row and *sql.Rows a view is scanned with
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSubCategory scans the id, name, created_at, menu items IDs and max estimated preparation time
+// columns of a subcategory
+func scanSubCategory(row scanner) (SubCategoryView, error) {
+	var subCategoryView SubCategoryView
+	var maxEstimatedPreparationSeconds sql.NullInt64
+	err := row.Scan(
+		&subCategoryView.ID,
+		&subCategoryView.Name,
+		&subCategoryView.CreatedAt,
+		pq.Array(&subCategoryView.MenuItemsIDs),
+		&maxEstimatedPreparationSeconds,
+	)
 	if err != nil {
-		return MenuItemView{}, err
+		return SubCategoryView{}, err
 	}
-	defer db.Close()
+	subCategoryView.MaxEstimatedPreparationSeconds = nullInt64Pointer(maxEstimatedPreparationSeconds)
+	return subCategoryView, nil
+}
 
+// menuItemColumns are the columns scanMenuItem scans, of the menuitems table aliased as i.
+// The variants and the modifier groups are read as JSON arrays shaped like their views.
+const menuItemColumns = `
+	i.id, i.name, i.description, i.price_amount, i.price_currency, i.estimated_preparation_seconds,
+	COALESCE((SELECT array_agg(a.allergen ORDER BY a.allergen) FROM menuitem_allergens a WHERE a.menuitem_id = i.id), '{}'),
+	i.is_vegan, i.is_vegetarian, i.is_gluten_free, i.is_halal, i.spicy_level,
+	COALESCE((
+		SELECT json_agg(json_build_object(
+			'id', v.id, 'name', v.name,
+			'price', json_build_object('amount', v.price_amount, 'currency', v.price_currency)
+		) ORDER BY v.created_at, v.id)
+		FROM menuitem_variants v WHERE v.menuitem_id = i.id
+	), '[]'),
+	COALESCE((
+		SELECT json_agg(json_build_object(
+			'id', g.id, 'name', g.name, 'minSelections', g.min_selections, 'maxSelections', g.max_selections,
+			'modifiers', COALESCE((
+				SELECT json_agg(json_build_object(
+					'id', d.id, 'name', d.name,
+					'priceDelta', json_build_object('amount', d.price_delta_amount, 'currency', d.price_delta_currency)
+				) ORDER BY d.created_at, d.id)
+				FROM menuitem_modifiers d WHERE d.modifier_group_id = g.id
+			), '[]')
+		) ORDER BY g.created_at, g.id)
+		FROM menuitem_modifier_groups g WHERE g.menuitem_id = i.id
+	), '[]'),
+	i.created_at`
+
+func scanMenuItem(row scanner) (MenuItemView, error) {
 	var menuItemView MenuItemView
-
-	query := `SELECT * FROM menuitems WHERE id=$1`
-	row := db.QueryRow(query, menuItemID)
-
-	err = row.Scan(
+	var priceAmount, estimatedPreparationSeconds sql.NullInt64
+	var priceCurrency sql.NullString
+	var variants, modifierGroups []byte
+	err := row.Scan(
 		&menuItemView.ID,
 		&menuItemView.Name,
+		&menuItemView.Description,
+		&priceAmount,
+		&priceCurrency,
+		&estimatedPreparationSeconds,
+		pq.Array(&menuItemView.Allergens),
+		&menuItemView.DietaryTags.Vegan,
+		&menuItemView.DietaryTags.Vegetarian,
+		&menuItemView.DietaryTags.GlutenFree,
+		&menuItemView.DietaryTags.Halal,
+		&menuItemView.DietaryTags.SpicyLevel,
+		&variants,
+		&modifierGroups,
 		&menuItemView.CreatedAt,
 	)
-
 	if err != nil {
 		return MenuItemView{}, err
 	}
+	err = json.Unmarshal(variants, &menuItemView.Variants)
+	if err != nil {
+		return MenuItemView{}, err
+	}
+	err = json.Unmarshal(modifierGroups, &menuItemView.ModifierGroups)
+	if err != nil {
+		return MenuItemView{}, err
+	}
+	menuItemView.Price = newPriceView(priceAmount, priceCurrency)
+	menuItemView.EstimatedPreparationSeconds = nullInt64Pointer(estimatedPreparationSeconds)
 	return menuItemView, nil
 }
 
-func (repo MenuRepository) GetMenuItemsByIDs(menuItemsIDs []uuid.UUID) ([]MenuItemView, error) {
-	db, err := sql.Open("postgres", repo.connectionString)
+// queryMenuItems returns the menu items selected by the condition, which refers to the menuitems table as i
+func (repo MenuRepository) queryMenuItems(ctx context.Context, condition string, args ...interface{}) ([]MenuItemView, error) {
+	query := `SELECT ` + menuItemColumns + ` FROM menuitems i ` + condition
+	rows, err := repo.querier().QueryContext(ctx, query, args...)
 	if err != nil {
 		return []MenuItemView{}, err
 	}
-	defer db.Close()
-
-	idListString := makeStringList(menuItemsIDs)
-
-	query := `
-		SELECT *
-		FROM menuitems
-		WHERE id IN(` + idListString + `);
-	`
-
-	rows, err := db.Query(query)
 	defer rows.Close()
 
 	menuItems := []MenuItemView{}
-
 	for rows.Next() {
-		var menuItemView MenuItemView
-		err = rows.Scan(
-			&menuItemView.ID,
-			&menuItemView.Name,
-			&menuItemView.CreatedAt,
-		)
-
+		menuItemView, err := scanMenuItem(rows)
 		if err != nil {
 			return []MenuItemView{}, err
 		}
 		menuItems = append(menuItems, menuItemView)
 	}
-
+	if err = rows.Err(); err != nil {
+		return []MenuItemView{}, err
+	}
 	return menuItems, nil
 }
 
-func (repo MenuRepository) RemoveMenuItemFromSubCategory(subCategoryID, menuItemID uuid.UUID) error {
-	db, err := sql.Open("postgres", repo.connectionString)
+// queryMenuVersionTree reads the tree, is_enabled and created_at columns of the row selected by the query.
+// The tree is the menu as it was published, whether the menu is enabled is read from the menu.
+func (repo MenuRepository) queryMenuVersionTree(ctx context.Context, query string, args ...interface{}) (MenuTreeView, error) {
+	var serializedTree []byte
+	var isEnabled bool
+	var createdAt time.Time
+	err := repo.querier().QueryRowContext(ctx, query, args...).Scan(&serializedTree, &isEnabled, &createdAt)
 	if err != nil {
-		return err
+		return MenuTreeView{}, err
 	}
-	defer db.Close()
-
-	query := `DELETE FROM subcategory_menuitems WHERE subcategory_id=$1 AND menuitem_id=$2`
-	_, err = db.Exec(query, subCategoryID, menuItemID)
+	var tree MenuTreeView
+	err = json.Unmarshal(serializedTree, &tree)
 	if err != nil {
-		return err
+		return MenuTreeView{}, err
 	}
-	return nil
+	tree.IsEnabled = isEnabled
+	tree.CreatedAt = createdAt
+	return tree, nil
 }
 
-func (repo MenuRepository) AddMenuItemToSubCategory(subCategoryID, menuItemID uuid.UUID) error {
-	db, err := sql.Open("postgres", repo.connectionString)
-	if err != nil {
-		return err
+// newPriceView returns nil when the menu item has no price yet
+func newPriceView(amount sql.NullInt64, currency sql.NullString) *PriceView {
+	if !amount.Valid || !currency.Valid {
+		return nil
+	}
+	return &PriceView{
+		Amount:   amount.Int64,
+		Currency: currency.String,
 	}
-	defer db.Close()
+}
 
-	query := `INSERT INTO subcategory_menuitems ("subcategory_id", "menuitem_id") VALUES ($1, $2)`
-	_, err = db.Exec(query, subCategoryID, menuItemID)
-	if err != nil {
-		return err
+func nullInt64Pointer(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
 	}
-	return nil
+	return &value.Int64
 }
 
-// helpers
+// menuTreeRow is a row of the menu tree query, the columns of the children are null
+// when their parent has none
+type menuTreeRow struct {
+	categoryID           uuid.NullUUID
+	categoryName         sql.NullString
+	categoryCreatedAt    sql.NullTime
+	subCategoryID        uuid.NullUUID
+	subCategoryName      sql.NullString
+	subCategoryCreatedAt sql.NullTime
+	menuItemID           uuid.NullUUID
+}
 
-func convertUint8ToUUIDSlice(categoriesIDs []uint8) (res []uuid.UUID) {
-	categoriesIDsString := string(categoriesIDs)
-	if categoriesIDsString == "{NULL}" {
+// menuTreeBuilder nests the rows of the menu tree query, which come sorted by category and subcategory,
+// and then the menu items read by the IDs it collected
+type menuTreeBuilder struct {
+	menu          MenuTreeView
+	categories    []CategoryTreeView
+	subCategories map[uuid.UUID][]SubCategoryTreeView
+	menuItemsIDs  map[uuid.UUID][]uuid.UUID
+}
+
+func (builder *menuTreeBuilder) add(row menuTreeRow) {
+	if builder.subCategories == nil {
+		builder.subCategories = make(map[uuid.UUID][]SubCategoryTreeView)
+		builder.menuItemsIDs = make(map[uuid.UUID][]uuid.UUID)
+	}
+	if !row.categoryID.Valid {
 		return
 	}
-	categoriesString := strings.TrimPrefix(categoriesIDsString, "{")
-	categoriesString = strings.TrimSuffix(categoriesString, "}")
-	categories := strings.Split(string(categoriesString), ",")
-	for _, v := range categories {
-		id, _ := uuid.FromString(v)
-		res = append(res, id)
+	categoryID := row.categoryID.UUID
+	if len(builder.categories) == 0 || builder.categories[len(builder.categories)-1].ID != categoryID {
+		builder.categories = append(builder.categories, CategoryTreeView{
+			ID:        categoryID,
+			Name:      row.categoryName.String,
+			CreatedAt: row.categoryCreatedAt.Time,
+		})
 	}
-	return
+	if !row.subCategoryID.Valid {
+		return
+	}
+	subCategoryID := row.subCategoryID.UUID
+	subCategories := builder.subCategories[categoryID]
+	if len(subCategories) == 0 || subCategories[len(subCategories)-1].ID != subCategoryID {
+		builder.subCategories[categoryID] = append(subCategories, SubCategoryTreeView{
+			ID:        subCategoryID,
+			Name:      row.subCategoryName.String,
+			CreatedAt: row.subCategoryCreatedAt.Time,
+		})
+	}
+	if !row.menuItemID.Valid {
+		return
+	}
+	builder.menuItemsIDs[subCategoryID] = append(builder.menuItemsIDs[subCategoryID], row.menuItemID.UUID)
+}
+
+func (builder *menuTreeBuilder) allMenuItemsIDs() []uuid.UUID {
+	menuItemsIDs := []uuid.UUID{}
+	for _, subCategoryMenuItemsIDs := range builder.menuItemsIDs {
+		menuItemsIDs = append(menuItemsIDs, subCategoryMenuItemsIDs...)
+	}
+	return menuItemsIDs
+}
+
+// build nests the menu items in their subcategories, the IDs of menu items that are not among them are skipped
+func (builder *menuTreeBuilder) build(menuItems []MenuItemView) MenuTreeView {
+	menuItemsByID := make(map[uuid.UUID]MenuItemView, len(menuItems))
+	for _, menuItem := range menuItems {
+		menuItemsByID[menuItem.ID] = menuItem
+	}
+	menu := builder.menu
+	menu.Categories = []CategoryTreeView{}
+	for _, category := range builder.categories {
+		category.SubCategories = []SubCategoryTreeView{}
+		for _, subCategory := range builder.subCategories[category.ID] {
+			subCategory.MenuItems = []MenuItemView{}
+			for _, menuItemID := range builder.menuItemsIDs[subCategory.ID] {
+				if menuItem, found := menuItemsByID[menuItemID]; found {
+					subCategory.MenuItems = append(subCategory.MenuItems, menuItem)
+				}
+			}
+			subCategory.MaxEstimatedPreparationSeconds = maxEstimatedPreparationSeconds(subCategory.MenuItems)
+			category.SubCategories = append(category.SubCategories, subCategory)
+		}
+		menu.Categories = append(menu.Categories, category)
+	}
+	return menu
 }
 
-func makeStringList(categoriesIDs []uuid.UUID) string {
-	idListString := ""
-	for _, v := range categoriesIDs {
-		idListString = idListString + "'" + v.String() + "',"
+// maxEstimatedPreparationSeconds returns the longest estimated preparation time of the menu items,
+// nil when none of them has one
+func maxEstimatedPreparationSeconds(menuItems []MenuItemView) *int64 {
+	var max *int64
+	for _, menuItem := range menuItems {
+		if menuItem.EstimatedPreparationSeconds == nil {
+			continue
+		}
+		if max == nil || *menuItem.EstimatedPreparationSeconds > *max {
+			max = menuItem.EstimatedPreparationSeconds
+		}
 	}
-	if len(idListString) > 0 {
-		idListString = strings.TrimSuffix(idListString, ",")
+	return max
+}
+
+// orderByIDs returns the views in the order of the requested IDs, followed by the requested IDs
+// no view was found for
+func orderByIDs[T any](ids []uuid.UUID, views []T, getID func(view T) uuid.UUID) ([]T, []uuid.UUID) {
+	viewsByID := make(map[uuid.UUID]T, len(views))
+	for _, view := range views {
+		viewsByID[getID(view)] = view
 	}
-	return idListString
+	orderedViews := []T{}
+	notFoundIDs := []uuid.UUID{}
+	for _, id := range ids {
+		view, found := viewsByID[id]
+		if !found {
+			notFoundIDs = append(notFoundIDs, id)
+			continue
+		}
+		orderedViews = append(orderedViews, view)
+	}
+	return orderedViews, notFoundIDs
 }
+
+// Errors
+
+var (
+	ErrCheckpointNotFound    = errors.New("the projection has no checkpoint")
+	ErrEventAlreadyProcessed = errors.New("the projection already processed the event")
+)
@@ -1,10 +1,15 @@
 package internal
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"sort"
 	"testing"
+	"time"
 
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/events"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
 	"github.com/Resta-Inc/resta/pkg/utils"
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/require"
@@ -20,20 +25,28 @@ const (
 
 var pgConnectionString string = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbname)
 
+func newTestMenuRepository(t *testing.T) MenuRepository {
+	repo, err := NewMenuRepository(pgConnectionString, PoolConfig{})
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
 func TestCreateMenu(t *testing.T) {
 	// Arrange
+	ctx := context.Background()
 	menuID := utils.GenerateNewUUID()
 	menuName := "TestMenu"
 
-	viewRepository := NewMenuRepository(pgConnectionString)
-	defer viewRepository.DeleteMenu(menuID)
+	viewRepository := newTestMenuRepository(t)
+	defer viewRepository.DeleteMenu(ctx, menuID)
 
 	// Act
-	err := viewRepository.CreateMenu(menuID, menuName)
+	err := viewRepository.CreateMenu(ctx, menuID, menuName)
 
 	// Assert
 	require.NoError(t, err)
-	returnedMenu, err := viewRepository.GetMenu(menuID)
+	returnedMenu, err := viewRepository.GetMenu(ctx, menuID)
 	require.NoError(t, err)
 	require.Equal(t, menuID, returnedMenu.ID)
 	require.Equal(t, menuName, returnedMenu.Name)
@@ -41,18 +54,19 @@ func TestCreateMenu(t *testing.T) {
 
 func TestGetAllMenus(t *testing.T) {
 	// Arrange
+	ctx := context.Background()
 	menuID1, menuID2, menuID3 := utils.GenerateNewUUID(), utils.GenerateNewUUID(), utils.GenerateNewUUID()
 	menuName := "TestMenu"
-	viewRepository := NewMenuRepository(pgConnectionString)
-	defer viewRepository.DeleteMenu(menuID1)
-	defer viewRepository.DeleteMenu(menuID2)
-	defer viewRepository.DeleteMenu(menuID3)
-	_ = viewRepository.CreateMenu(menuID1, menuName)
-	_ = viewRepository.CreateMenu(menuID2, menuName)
-	_ = viewRepository.CreateMenu(menuID3, menuName)
+	viewRepository := newTestMenuRepository(t)
+	defer viewRepository.DeleteMenu(ctx, menuID1)
+	defer viewRepository.DeleteMenu(ctx, menuID2)
+	defer viewRepository.DeleteMenu(ctx, menuID3)
+	_ = viewRepository.CreateMenu(ctx, menuID1, menuName)
+	_ = viewRepository.CreateMenu(ctx, menuID2, menuName)
+	_ = viewRepository.CreateMenu(ctx, menuID3, menuName)
 
 	// Act
-	menus, err := viewRepository.GetAllMenus()
+	menus, err := viewRepository.GetAllMenus(ctx)
 
 	// Assert
 	require.NoError(t, err)
@@ -61,77 +75,81 @@ func TestGetAllMenus(t *testing.T) {
 
 func TestEnableMenu(t *testing.T) {
 	// Arrange
+	ctx := context.Background()
 	menuID := utils.GenerateNewUUID()
 	menuName := "TestMenu"
 
-	viewRepository := NewMenuRepository(pgConnectionString)
-	defer viewRepository.DeleteMenu(menuID)
-	err := viewRepository.CreateMenu(menuID, menuName)
+	viewRepository := newTestMenuRepository(t)
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	err := viewRepository.CreateMenu(ctx, menuID, menuName)
 
 	// Act
-	err = viewRepository.EnableMenu(menuID)
+	err = viewRepository.EnableMenu(ctx, menuID)
 
 	// Assert
 	require.NoError(t, err)
-	returnedMenu, err := viewRepository.GetMenu(menuID)
+	returnedMenu, err := viewRepository.GetMenu(ctx, menuID)
 	require.NoError(t, err)
 	require.True(t, returnedMenu.IsEnabled)
 }
 
 func TestDisableMenu(t *testing.T) {
 	// Arrange
+	ctx := context.Background()
 	menuID := utils.GenerateNewUUID()
 	menuName := "TestMenu"
 
-	viewRepository := NewMenuRepository(pgConnectionString)
-	defer viewRepository.DeleteMenu(menuID)
-	err := viewRepository.CreateMenu(menuID, menuName)
-	err = viewRepository.EnableMenu(menuID)
+	viewRepository := newTestMenuRepository(t)
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	err := viewRepository.CreateMenu(ctx, menuID, menuName)
+	err = viewRepository.EnableMenu(ctx, menuID)
 
 	// Act
-	err = viewRepository.DisableMenu(menuID)
+	err = viewRepository.DisableMenu(ctx, menuID)
 
 	// Assert
 	require.NoError(t, err)
-	returnedMenu, err := viewRepository.GetMenu(menuID)
+	returnedMenu, err := viewRepository.GetMenu(ctx, menuID)
 	require.NoError(t, err)
 	require.False(t, returnedMenu.IsEnabled)
 }
 
 func TestChangeMenuName(t *testing.T) {
 	// Arrange
+	ctx := context.Background()
 	menuID := utils.GenerateNewUUID()
 	menuName := "TestMenu"
 	newMenuName := "NewMenuName"
 
-	viewRepository := NewMenuRepository(pgConnectionString)
-	defer viewRepository.DeleteMenu(menuID)
-	err := viewRepository.CreateMenu(menuID, menuName)
+	viewRepository := newTestMenuRepository(t)
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	err := viewRepository.CreateMenu(ctx, menuID, menuName)
 
 	// Act
-	err = viewRepository.ChangeMenuName(menuID, newMenuName)
+	err = viewRepository.ChangeMenuName(ctx, menuID, newMenuName)
 
 	// Assert
 	require.NoError(t, err)
-	returnedMenu, err := viewRepository.GetMenu(menuID)
+	returnedMenu, err := viewRepository.GetMenu(ctx, menuID)
 	require.NoError(t, err)
 	require.Equal(t, newMenuName, returnedMenu.Name)
 }
 
 func TestCreateCategory(t *testing.T) {
 	// Arrange
+	ctx := context.Background()
 	categoryID, categoryName :=
 		utils.GenerateNewUUID(), "TestCategory"
 
-	viewRepository := NewMenuRepository(pgConnectionString)
-	defer viewRepository.DeleteCategory(categoryID)
+	viewRepository := newTestMenuRepository(t)
+	defer viewRepository.DeleteCategory(ctx, categoryID)
 
 	// Act
-	err := viewRepository.CreateCategory(categoryID, categoryName)
+	err := viewRepository.CreateCategory(ctx, categoryID, categoryName)
 
 	// Assert
 	require.NoError(t, err)
-	returnedCategory, err := viewRepository.GetCategory(categoryID)
+	returnedCategory, err := viewRepository.GetCategory(ctx, categoryID)
 	require.NoError(t, err)
 	require.Equal(t, categoryID, returnedCategory.ID)
 	require.Equal(t, categoryName, returnedCategory.Name)
@@ -139,25 +157,26 @@ func TestCreateCategory(t *testing.T) {
 
 func TestAddCategoryToMenu(t *testing.T) {
 	// Arrange
-	viewRepository := NewMenuRepository(pgConnectionString)
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
 	menuID, menuName, categoryID, categoryName :=
 		utils.GenerateNewUUID(),
 		"TestMenu",
 		utils.GenerateNewUUID(),
 		"TestCategory"
 
-	defer viewRepository.DeleteCategory(categoryID)
-	defer viewRepository.RemoveCategoryFromMenu(menuID, categoryID)
-	defer viewRepository.DeleteMenu(menuID)
-	viewRepository.CreateMenu(menuID, menuName)
-	viewRepository.CreateCategory(categoryID, categoryName)
+	defer viewRepository.DeleteCategory(ctx, categoryID)
+	defer viewRepository.RemoveCategoryFromMenu(ctx, menuID, categoryID, esdb.Position{Commit: 2, Prepare: 2})
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	viewRepository.CreateMenu(ctx, menuID, menuName)
+	viewRepository.CreateCategory(ctx, categoryID, categoryName)
 
 	// Act
-	err := viewRepository.AddCategoryToMenu(menuID, categoryID)
+	err := viewRepository.AddCategoryToMenu(ctx, menuID, categoryID, esdb.Position{Commit: 1, Prepare: 1})
 
 	// Assert
 	require.NoError(t, err)
-	returnedMenu, err := viewRepository.GetMenu(menuID)
+	returnedMenu, err := viewRepository.GetMenu(ctx, menuID)
 	require.NoError(t, err)
 	require.Len(t, returnedMenu.CategoriesIDs, 1)
 	require.Equal(t, returnedMenu.CategoriesIDs[0], categoryID)
@@ -165,7 +184,8 @@ func TestAddCategoryToMenu(t *testing.T) {
 
 func TestGetMenu_ShouldHaveCategoriesIDPopulated(t *testing.T) {
 	// Arrange
-	viewRepository := NewMenuRepository(pgConnectionString)
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
 	menuID, menuName, categoryID1, categoryID2, categoryName :=
 		utils.GenerateNewUUID(),
 		"TestMenu",
@@ -173,19 +193,19 @@ func TestGetMenu_ShouldHaveCategoriesIDPopulated(t *testing.T) {
 		utils.GenerateNewUUID(),
 		"TestCategory"
 
-	defer viewRepository.DeleteCategory(categoryID1)
-	defer viewRepository.DeleteCategory(categoryID2)
-	defer viewRepository.RemoveCategoryFromMenu(menuID, categoryID1)
-	defer viewRepository.RemoveCategoryFromMenu(menuID, categoryID2)
-	defer viewRepository.DeleteMenu(menuID)
-	viewRepository.CreateMenu(menuID, menuName)
-	viewRepository.CreateCategory(categoryID1, categoryName)
-	viewRepository.CreateCategory(categoryID2, categoryName)
-	viewRepository.AddCategoryToMenu(menuID, categoryID1)
-	viewRepository.AddCategoryToMenu(menuID, categoryID2)
+	defer viewRepository.DeleteCategory(ctx, categoryID1)
+	defer viewRepository.DeleteCategory(ctx, categoryID2)
+	defer viewRepository.RemoveCategoryFromMenu(ctx, menuID, categoryID1, esdb.Position{Commit: 2, Prepare: 2})
+	defer viewRepository.RemoveCategoryFromMenu(ctx, menuID, categoryID2, esdb.Position{Commit: 2, Prepare: 2})
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	viewRepository.CreateMenu(ctx, menuID, menuName)
+	viewRepository.CreateCategory(ctx, categoryID1, categoryName)
+	viewRepository.CreateCategory(ctx, categoryID2, categoryName)
+	viewRepository.AddCategoryToMenu(ctx, menuID, categoryID1, esdb.Position{Commit: 1, Prepare: 1})
+	viewRepository.AddCategoryToMenu(ctx, menuID, categoryID2, esdb.Position{Commit: 1, Prepare: 1})
 
 	// Act
-	returnedMenu, err := viewRepository.GetMenu(menuID)
+	returnedMenu, err := viewRepository.GetMenu(ctx, menuID)
 
 	// Assert
 	require.NoError(t, err)
@@ -196,14 +216,15 @@ func TestGetMenu_ShouldHaveCategoriesIDPopulated(t *testing.T) {
 
 func TestGetMenu_WhenNoCategory_ShouldHaveEmptyCategoryList(t *testing.T) {
 	// Arrange
-	viewRepository := NewMenuRepository(pgConnectionString)
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
 	menuID, menuName := utils.GenerateNewUUID(), "TestMenu"
 
-	defer viewRepository.DeleteMenu(menuID)
-	viewRepository.CreateMenu(menuID, menuName)
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	viewRepository.CreateMenu(ctx, menuID, menuName)
 
 	// Act
-	returnedMenu, err := viewRepository.GetMenu(menuID)
+	returnedMenu, err := viewRepository.GetMenu(ctx, menuID)
 
 	// Assert
 	require.NoError(t, err)
@@ -212,54 +233,73 @@ func TestGetMenu_WhenNoCategory_ShouldHaveEmptyCategoryList(t *testing.T) {
 
 func TestGetCategoriesByIDs(t *testing.T) {
 	// Arrange
-	viewRepository := NewMenuRepository(pgConnectionString)
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
 	categoryID1, categoryID2, categoryName :=
 		utils.GenerateNewUUID(),
 		utils.GenerateNewUUID(),
 		"TestCategory"
 
-	defer viewRepository.DeleteCategory(categoryID1)
-	defer viewRepository.DeleteCategory(categoryID2)
-	viewRepository.CreateCategory(categoryID1, categoryName)
-	viewRepository.CreateCategory(categoryID2, categoryName)
+	defer viewRepository.DeleteCategory(ctx, categoryID1)
+	defer viewRepository.DeleteCategory(ctx, categoryID2)
+	viewRepository.CreateCategory(ctx, categoryID1, categoryName)
+	viewRepository.CreateCategory(ctx, categoryID2, categoryName)
 
 	// Act
-	categories, err := viewRepository.GetCategoriesByIDs([]uuid.UUID{categoryID1, categoryID2})
-
-	sort.Slice(categories, func(i, j int) bool {
-		return categories[i].CreatedAt.Before(categories[j].CreatedAt)
-	})
+	categories, notFoundIDs, err := viewRepository.GetCategoriesByIDs(ctx, []uuid.UUID{categoryID2, categoryID1})
 
 	// Assert
 	require.NoError(t, err)
+	require.Empty(t, notFoundIDs)
 	require.Len(t, categories, 2)
-	require.Equal(t, categories[0].ID, categoryID1)
-	require.Equal(t, categories[1].ID, categoryID2)
+	require.Equal(t, categories[0].ID, categoryID2)
+	require.Equal(t, categories[1].ID, categoryID1)
+}
+
+func TestGetCategoriesByIDs_WhenSomeAreNotFound(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	categoryID, missingCategoryID := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteCategory(ctx, categoryID)
+	viewRepository.CreateCategory(ctx, categoryID, "TestCategory")
+
+	// Act
+	categories, notFoundIDs, err := viewRepository.GetCategoriesByIDs(ctx, []uuid.UUID{missingCategoryID, categoryID})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, categories, 1)
+	require.Equal(t, categoryID, categories[0].ID)
+	require.Empty(t, categories[0].SubCategoriesIDs)
+	require.Equal(t, []uuid.UUID{missingCategoryID}, notFoundIDs)
 }
 
 func TestGetCategoriesByIDs_ShouldHaveSubCategoriesIDPopulated(t *testing.T) {
 	// Arrange
-	viewRepository := NewMenuRepository(pgConnectionString)
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
 	categoryID, subCategoryID1, subCategoryID2, subCategoryName :=
 		utils.GenerateNewUUID(),
 		utils.GenerateNewUUID(),
 		utils.GenerateNewUUID(),
 		"TestSubCategory"
 
-	defer viewRepository.DeleteCategory(categoryID)
-	defer viewRepository.DeleteSubCategory(subCategoryID1)
-	defer viewRepository.DeleteSubCategory(subCategoryID2)
-	defer viewRepository.RemoveSubCategoryFromCategory(categoryID, subCategoryID1)
-	defer viewRepository.RemoveSubCategoryFromCategory(categoryID, subCategoryID2)
+	defer viewRepository.DeleteCategory(ctx, categoryID)
+	defer viewRepository.DeleteSubCategory(ctx, subCategoryID1)
+	defer viewRepository.DeleteSubCategory(ctx, subCategoryID2)
+	defer viewRepository.RemoveSubCategoryFromCategory(ctx, categoryID, subCategoryID1, esdb.Position{Commit: 2, Prepare: 2})
+	defer viewRepository.RemoveSubCategoryFromCategory(ctx, categoryID, subCategoryID2, esdb.Position{Commit: 2, Prepare: 2})
 
-	viewRepository.CreateCategory(categoryID, subCategoryName)
-	viewRepository.CreateSubCategory(subCategoryID1, subCategoryName)
-	viewRepository.CreateSubCategory(subCategoryID2, subCategoryName)
-	viewRepository.AddSubCategoryToCategory(categoryID, subCategoryID1)
-	viewRepository.AddSubCategoryToCategory(categoryID, subCategoryID2)
+	viewRepository.CreateCategory(ctx, categoryID, subCategoryName)
+	viewRepository.CreateSubCategory(ctx, subCategoryID1, subCategoryName)
+	viewRepository.CreateSubCategory(ctx, subCategoryID2, subCategoryName)
+	viewRepository.AddSubCategoryToCategory(ctx, categoryID, subCategoryID1, esdb.Position{Commit: 1, Prepare: 1})
+	viewRepository.AddSubCategoryToCategory(ctx, categoryID, subCategoryID2, esdb.Position{Commit: 1, Prepare: 1})
 
 	// Act
-	categories, err := viewRepository.GetCategoriesByIDs([]uuid.UUID{categoryID})
+	categories, _, err := viewRepository.GetCategoriesByIDs(ctx, []uuid.UUID{categoryID})
 
 	// Assert
 	require.NoError(t, err)
@@ -269,39 +309,41 @@ func TestGetCategoriesByIDs_ShouldHaveSubCategoriesIDPopulated(t *testing.T) {
 
 func TestChangeCategoryName(t *testing.T) {
 	// Arrange
+	ctx := context.Background()
 	newName := "NewName"
-	viewRepository := NewMenuRepository(pgConnectionString)
+	viewRepository := newTestMenuRepository(t)
 	categoryID, categoryName :=
 		utils.GenerateNewUUID(),
 		"TestCategory"
 
-	defer viewRepository.DeleteCategory(categoryID)
-	viewRepository.CreateCategory(categoryID, categoryName)
+	defer viewRepository.DeleteCategory(ctx, categoryID)
+	viewRepository.CreateCategory(ctx, categoryID, categoryName)
 
 	// Act
-	err := viewRepository.ChangeCategoryName(categoryID, newName)
+	err := viewRepository.ChangeCategoryName(ctx, categoryID, newName)
 
 	// Assert
 	require.NoError(t, err)
-	returnedCategory, err := viewRepository.GetCategory(categoryID)
+	returnedCategory, err := viewRepository.GetCategory(ctx, categoryID)
 	require.NoError(t, err)
 	require.Equal(t, newName, returnedCategory.Name)
 }
 
 func TestCreateSubCategory(t *testing.T) {
 	// Arrange
+	ctx := context.Background()
 	subCategoryID, subCategoryName :=
 		utils.GenerateNewUUID(), "TestSubCategory"
 
-	viewRepository := NewMenuRepository(pgConnectionString)
-	defer viewRepository.DeleteSubCategory(subCategoryID)
+	viewRepository := newTestMenuRepository(t)
+	defer viewRepository.DeleteSubCategory(ctx, subCategoryID)
 
 	// Act
-	err := viewRepository.CreateSubCategory(subCategoryID, subCategoryName)
+	err := viewRepository.CreateSubCategory(ctx, subCategoryID, subCategoryName)
 
 	// Assert
 	require.NoError(t, err)
-	returnedSubCategory, err := viewRepository.GetSubCategory(subCategoryID)
+	returnedSubCategory, err := viewRepository.GetSubCategory(ctx, subCategoryID)
 	require.NoError(t, err)
 	require.Equal(t, subCategoryID, returnedSubCategory.ID)
 	require.Equal(t, subCategoryName, returnedSubCategory.Name)
@@ -309,25 +351,26 @@ func TestCreateSubCategory(t *testing.T) {
 
 func TestAddSubCategoryToCategory(t *testing.T) {
 	// Arrange
-	viewRepository := NewMenuRepository(pgConnectionString)
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
 	categoryID, categoryName, subCategoryID, subCategoryName :=
 		utils.GenerateNewUUID(),
 		"TestCategory",
 		utils.GenerateNewUUID(),
 		"TestSubCategory"
 
-	defer viewRepository.DeleteSubCategory(subCategoryID)
-	defer viewRepository.RemoveSubCategoryFromCategory(categoryID, subCategoryID)
-	defer viewRepository.DeleteCategory(categoryID)
-	viewRepository.CreateCategory(categoryID, categoryName)
-	viewRepository.CreateSubCategory(subCategoryID, subCategoryName)
+	defer viewRepository.DeleteSubCategory(ctx, subCategoryID)
+	defer viewRepository.RemoveSubCategoryFromCategory(ctx, categoryID, subCategoryID, esdb.Position{Commit: 2, Prepare: 2})
+	defer viewRepository.DeleteCategory(ctx, categoryID)
+	viewRepository.CreateCategory(ctx, categoryID, categoryName)
+	viewRepository.CreateSubCategory(ctx, subCategoryID, subCategoryName)
 
 	// Act
-	err := viewRepository.AddSubCategoryToCategory(categoryID, subCategoryID)
+	err := viewRepository.AddSubCategoryToCategory(ctx, categoryID, subCategoryID, esdb.Position{Commit: 1, Prepare: 1})
 
 	// Assert
 	require.NoError(t, err)
-	returnedCategory, err := viewRepository.GetCategory(categoryID)
+	returnedCategory, err := viewRepository.GetCategory(ctx, categoryID)
 	require.NoError(t, err)
 	require.Len(t, returnedCategory.SubCategoriesIDs, 1)
 	require.Equal(t, returnedCategory.SubCategoriesIDs[0], subCategoryID)
@@ -335,44 +378,249 @@ func TestAddSubCategoryToCategory(t *testing.T) {
 
 func TestCreateMenuItem(t *testing.T) {
 	// Arrange
+	ctx := context.Background()
 	menuItemID, menuItemName :=
 		utils.GenerateNewUUID(), "TestMenuItem"
 
-	viewRepository := NewMenuRepository(pgConnectionString)
-	defer viewRepository.DeleteMenuItem(menuItemID)
+	viewRepository := newTestMenuRepository(t)
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID)
 
 	// Act
-	err := viewRepository.CreateMenuItem(menuItemID, menuItemName)
+	err := viewRepository.CreateMenuItem(ctx, menuItemID, menuItemName)
 
 	// Assert
 	require.NoError(t, err)
-	returnedMenuItem, err := viewRepository.GetMenuItem(menuItemID)
+	returnedMenuItem, err := viewRepository.GetMenuItem(ctx, menuItemID)
 	require.NoError(t, err)
 	require.Equal(t, menuItemID, returnedMenuItem.ID)
 	require.Equal(t, menuItemName, returnedMenuItem.Name)
 }
 
+func TestChangeMenuItemPrice(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	menuItemID := utils.GenerateNewUUID()
+	newPrice := events.Price{Amount: 1250, Currency: "EUR"}
+
+	viewRepository := newTestMenuRepository(t)
+	viewRepository.CreateMenuItem(ctx, menuItemID, "TestMenuItem")
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID)
+
+	// Act
+	err := viewRepository.ChangeMenuItemPrice(ctx, menuItemID, newPrice)
+
+	// Assert
+	require.NoError(t, err)
+	returnedMenuItem, err := viewRepository.GetMenuItem(ctx, menuItemID)
+	require.NoError(t, err)
+	require.Equal(t, &PriceView{Amount: 1250, Currency: "EUR"}, returnedMenuItem.Price)
+}
+
+func TestGetMenuItem_WhenMenuItemHasNoPrice(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	menuItemID := utils.GenerateNewUUID()
+
+	viewRepository := newTestMenuRepository(t)
+	viewRepository.CreateMenuItem(ctx, menuItemID, "TestMenuItem")
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID)
+
+	// Act
+	returnedMenuItem, err := viewRepository.GetMenuItem(ctx, menuItemID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Nil(t, returnedMenuItem.Price)
+}
+
+func TestChangeMenuItemEstimatedPreparationTime(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	menuItemID := utils.GenerateNewUUID()
+
+	viewRepository := newTestMenuRepository(t)
+	viewRepository.CreateMenuItem(ctx, menuItemID, "TestMenuItem")
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID)
+
+	// Act
+	err := viewRepository.ChangeMenuItemEstimatedPreparationTime(ctx, menuItemID, 15*time.Minute)
+
+	// Assert
+	require.NoError(t, err)
+	returnedMenuItem, err := viewRepository.GetMenuItem(ctx, menuItemID)
+	require.NoError(t, err)
+	require.Equal(t, int64(900), *returnedMenuItem.EstimatedPreparationSeconds)
+}
+
+func TestGetSubCategory_ShouldHaveMaxEstimatedPreparationSeconds(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	subCategoryID, menuItemID1, menuItemID2, menuItemID3 :=
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID()
+
+	viewRepository := newTestMenuRepository(t)
+	viewRepository.CreateSubCategory(ctx, subCategoryID, "TestSubCategory")
+	defer viewRepository.DeleteSubCategory(ctx, subCategoryID)
+	for _, menuItemID := range []uuid.UUID{menuItemID1, menuItemID2, menuItemID3} {
+		viewRepository.CreateMenuItem(ctx, menuItemID, "TestMenuItem")
+		defer viewRepository.DeleteMenuItem(ctx, menuItemID)
+		viewRepository.AddMenuItemToSubCategory(ctx, subCategoryID, menuItemID, esdb.Position{Commit: 1, Prepare: 1})
+	}
+	viewRepository.ChangeMenuItemEstimatedPreparationTime(ctx, menuItemID1, 10*time.Minute)
+	viewRepository.ChangeMenuItemEstimatedPreparationTime(ctx, menuItemID2, 25*time.Minute)
+
+	// Act
+	returnedSubCategory, err := viewRepository.GetSubCategory(ctx, subCategoryID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, int64(1500), *returnedSubCategory.MaxEstimatedPreparationSeconds)
+}
+
+func TestChangeMenuItemAllergens(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	menuItemID := utils.GenerateNewUUID()
+
+	viewRepository := newTestMenuRepository(t)
+	viewRepository.CreateMenuItem(ctx, menuItemID, "TestMenuItem")
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID)
+	viewRepository.ChangeMenuItemAllergens(ctx, menuItemID, []events.Allergen{events.AllergenMilk})
+
+	// Act
+	err := viewRepository.ChangeMenuItemAllergens(ctx, menuItemID, []events.Allergen{events.AllergenNuts, events.AllergenGluten})
+
+	// Assert
+	require.NoError(t, err)
+	returnedMenuItem, err := viewRepository.GetMenuItem(ctx, menuItemID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"gluten", "nuts"}, returnedMenuItem.Allergens)
+}
+
+func TestChangeMenuItemDescriptionAndDietaryTags(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	menuItemID := utils.GenerateNewUUID()
+
+	viewRepository := newTestMenuRepository(t)
+	viewRepository.CreateMenuItem(ctx, menuItemID, "TestMenuItem")
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID)
+
+	// Act
+	err1 := viewRepository.ChangeMenuItemDescription(ctx, menuItemID, "TestDescription")
+	err2 := viewRepository.ChangeMenuItemDietaryTags(ctx, menuItemID, events.DietaryTags{Vegetarian: true, SpicyLevel: 2})
+
+	// Assert
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	returnedMenuItem, err := viewRepository.GetMenuItem(ctx, menuItemID)
+	require.NoError(t, err)
+	require.Equal(t, "TestDescription", returnedMenuItem.Description)
+	require.Equal(t, DietaryTagsView{Vegetarian: true, SpicyLevel: 2}, returnedMenuItem.DietaryTags)
+	require.Empty(t, returnedMenuItem.Allergens)
+}
+
+func TestGetMenuItems_WhenAllergensAreExcluded(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	breadID, saladID, cakeID := utils.GenerateNewUUID(), utils.GenerateNewUUID(), utils.GenerateNewUUID()
+
+	viewRepository := newTestMenuRepository(t)
+	for _, menuItemID := range []uuid.UUID{breadID, saladID, cakeID} {
+		viewRepository.CreateMenuItem(ctx, menuItemID, "TestMenuItem")
+		defer viewRepository.DeleteMenuItem(ctx, menuItemID)
+	}
+	viewRepository.ChangeMenuItemAllergens(ctx, breadID, []events.Allergen{events.AllergenGluten})
+	viewRepository.ChangeMenuItemAllergens(ctx, cakeID, []events.Allergen{events.AllergenEggs, events.AllergenNuts})
+
+	// Act
+	menuItems, err := viewRepository.GetMenuItems(ctx, []string{"gluten", "nuts"})
+
+	// Assert
+	require.NoError(t, err)
+	returnedIDs := []uuid.UUID{}
+	for _, menuItem := range menuItems {
+		returnedIDs = append(returnedIDs, menuItem.ID)
+	}
+	require.Contains(t, returnedIDs, saladID)
+	require.NotContains(t, returnedIDs, breadID)
+	require.NotContains(t, returnedIDs, cakeID)
+}
+
+func TestGetMenuItem_ShouldHaveVariantsAndModifierGroups(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	menuItemID, smallID, largeID, toppingsID, cheeseID, temperatureID :=
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID()
+
+	viewRepository := newTestMenuRepository(t)
+	viewRepository.CreateMenuItem(ctx, menuItemID, "TestMenuItem")
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID)
+	viewRepository.AddMenuItemVariant(ctx, menuItemID, smallID, "Small", events.Price{Amount: 950, Currency: "EUR"})
+	viewRepository.AddMenuItemVariant(ctx, menuItemID, largeID, "Large", events.Price{Amount: 1450, Currency: "EUR"})
+	viewRepository.RemoveMenuItemVariant(ctx, smallID)
+	viewRepository.AddMenuItemModifierGroup(ctx, menuItemID, toppingsID, "Toppings", 0, 3)
+	viewRepository.AddMenuItemModifier(ctx, toppingsID, cheeseID, "Extra cheese", events.Price{Amount: 150, Currency: "EUR"})
+	viewRepository.AddMenuItemModifierGroup(ctx, menuItemID, temperatureID, "Cooking temperature", 1, 1)
+
+	// Act
+	returnedMenuItem, err := viewRepository.GetMenuItem(ctx, menuItemID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, []VariantView{
+		{ID: largeID, Name: "Large", Price: PriceView{Amount: 1450, Currency: "EUR"}},
+	}, returnedMenuItem.Variants)
+	require.Equal(t, []ModifierGroupView{
+		{
+			ID:            toppingsID,
+			Name:          "Toppings",
+			MinSelections: 0,
+			MaxSelections: 3,
+			Modifiers: []ModifierView{
+				{ID: cheeseID, Name: "Extra cheese", PriceDelta: PriceView{Amount: 150, Currency: "EUR"}},
+			},
+		},
+		{
+			ID:            temperatureID,
+			Name:          "Cooking temperature",
+			MinSelections: 1,
+			MaxSelections: 1,
+			Modifiers:     []ModifierView{},
+		},
+	}, returnedMenuItem.ModifierGroups)
+}
+
 func TestAddMenuItemToSubCategory(t *testing.T) {
 	// Arrange
-	viewRepository := NewMenuRepository(pgConnectionString)
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
 	subCategoryID, subCategoryName, menuItemID, menuItemName :=
 		utils.GenerateNewUUID(),
 		"TestCategory",
 		utils.GenerateNewUUID(),
 		"TestMenuItem"
 
-	defer viewRepository.DeleteMenuItem(menuItemID)
-	defer viewRepository.RemoveMenuItemFromSubCategory(subCategoryID, menuItemID)
-	defer viewRepository.DeleteCategory(subCategoryID)
-	viewRepository.CreateSubCategory(subCategoryID, subCategoryName)
-	viewRepository.CreateMenuItem(menuItemID, menuItemName)
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID)
+	defer viewRepository.RemoveMenuItemFromSubCategory(ctx, subCategoryID, menuItemID, esdb.Position{Commit: 2, Prepare: 2})
+	defer viewRepository.DeleteCategory(ctx, subCategoryID)
+	viewRepository.CreateSubCategory(ctx, subCategoryID, subCategoryName)
+	viewRepository.CreateMenuItem(ctx, menuItemID, menuItemName)
 
 	// Act
-	err := viewRepository.AddMenuItemToSubCategory(subCategoryID, menuItemID)
+	err := viewRepository.AddMenuItemToSubCategory(ctx, subCategoryID, menuItemID, esdb.Position{Commit: 1, Prepare: 1})
 
 	// Assert
 	require.NoError(t, err)
-	returnedSubCategory, err := viewRepository.GetSubCategory(subCategoryID)
+	returnedSubCategory, err := viewRepository.GetSubCategory(ctx, subCategoryID)
 	require.NoError(t, err)
 	require.Len(t, returnedSubCategory.MenuItemsIDs, 1)
 	require.Equal(t, returnedSubCategory.MenuItemsIDs[0], menuItemID)
@@ -380,27 +628,28 @@ func TestAddMenuItemToSubCategory(t *testing.T) {
 
 func TestGetSubCategoriesByIDs_ShouldHaveMenuItemsIDsPopulated(t *testing.T) {
 	// Arrange
-	viewRepository := NewMenuRepository(pgConnectionString)
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
 	subCategoryID, menuItem1, menuItem2, menuItemName :=
 		utils.GenerateNewUUID(),
 		utils.GenerateNewUUID(),
 		utils.GenerateNewUUID(),
 		"TestSubCategory"
 
-	defer viewRepository.DeleteCategory(subCategoryID)
-	defer viewRepository.DeleteSubCategory(menuItem1)
-	defer viewRepository.DeleteSubCategory(menuItem2)
-	defer viewRepository.RemoveSubCategoryFromCategory(subCategoryID, menuItem1)
-	defer viewRepository.RemoveSubCategoryFromCategory(subCategoryID, menuItem2)
+	defer viewRepository.DeleteCategory(ctx, subCategoryID)
+	defer viewRepository.DeleteSubCategory(ctx, menuItem1)
+	defer viewRepository.DeleteSubCategory(ctx, menuItem2)
+	defer viewRepository.RemoveSubCategoryFromCategory(ctx, subCategoryID, menuItem1, esdb.Position{Commit: 2, Prepare: 2})
+	defer viewRepository.RemoveSubCategoryFromCategory(ctx, subCategoryID, menuItem2, esdb.Position{Commit: 2, Prepare: 2})
 
-	viewRepository.CreateSubCategory(subCategoryID, menuItemName)
-	viewRepository.CreateMenuItem(menuItem1, menuItemName)
-	viewRepository.CreateMenuItem(menuItem2, menuItemName)
-	viewRepository.AddMenuItemToSubCategory(subCategoryID, menuItem1)
-	viewRepository.AddMenuItemToSubCategory(subCategoryID, menuItem2)
+	viewRepository.CreateSubCategory(ctx, subCategoryID, menuItemName)
+	viewRepository.CreateMenuItem(ctx, menuItem1, menuItemName)
+	viewRepository.CreateMenuItem(ctx, menuItem2, menuItemName)
+	viewRepository.AddMenuItemToSubCategory(ctx, subCategoryID, menuItem1, esdb.Position{Commit: 1, Prepare: 1})
+	viewRepository.AddMenuItemToSubCategory(ctx, subCategoryID, menuItem2, esdb.Position{Commit: 1, Prepare: 1})
 
 	// Act
-	categories, err := viewRepository.GetSubCategoriesByIDs([]uuid.UUID{subCategoryID})
+	categories, _, err := viewRepository.GetSubCategoriesByIDs(ctx, []uuid.UUID{subCategoryID})
 
 	// Assert
 	require.NoError(t, err)
@@ -410,23 +659,683 @@ func TestGetSubCategoriesByIDs_ShouldHaveMenuItemsIDsPopulated(t *testing.T) {
 
 func TestGetMenuItemsByIDs(t *testing.T) {
 	// Arrange
-	viewRepository := NewMenuRepository(pgConnectionString)
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
 	menuItemID1, menuItemID2, menuItemName :=
 		utils.GenerateNewUUID(),
 		utils.GenerateNewUUID(),
 		"TestName"
 
-	defer viewRepository.DeleteMenuItem(menuItemID1)
-	defer viewRepository.DeleteMenuItem(menuItemID2)
-	viewRepository.CreateMenuItem(menuItemID1, menuItemName)
-	viewRepository.CreateMenuItem(menuItemID2, menuItemName)
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID1)
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID2)
+	viewRepository.CreateMenuItem(ctx, menuItemID1, menuItemName)
+	viewRepository.CreateMenuItem(ctx, menuItemID2, menuItemName)
 
 	// Act
-	menuItems, err := viewRepository.GetMenuItemsByIDs([]uuid.UUID{menuItemID1, menuItemID2})
+	menuItems, _, err := viewRepository.GetMenuItemsByIDs(ctx, []uuid.UUID{menuItemID2, menuItemID1})
 
 	// Assert
 	require.NoError(t, err)
 	require.Len(t, menuItems, 2)
-	require.Equal(t, menuItems[0].ID, menuItemID1)
-	require.Equal(t, menuItems[1].ID, menuItemID2)
+	require.Equal(t, menuItems[0].ID, menuItemID2)
+	require.Equal(t, menuItems[1].ID, menuItemID1)
+}
+
+func TestSaveCheckpoint_OnlyMovesForward(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	projectionName := "TestProjection-" + utils.GenerateNewUUID().String()
+	defer viewRepository.exec(ctx, `DELETE FROM projection_checkpoints WHERE name=$1`, projectionName)
+	_ = viewRepository.SaveCheckpoint(ctx, projectionName, esdb.Position{Commit: 20, Prepare: 19})
+
+	// Act
+	err := viewRepository.SaveCheckpoint(ctx, projectionName, esdb.Position{Commit: 10, Prepare: 9})
+
+	// Assert
+	require.NoError(t, err)
+	checkpoint, err := viewRepository.GetCheckpoint(ctx, projectionName)
+	require.NoError(t, err)
+	require.Equal(t, esdb.Position{Commit: 20, Prepare: 19}, checkpoint)
+}
+
+func TestGetCheckpoint_WhenProjectionHasNoCheckpoint(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+
+	// Act
+	_, err := viewRepository.GetCheckpoint(ctx, "TestProjection-"+utils.GenerateNewUUID().String())
+
+	// Assert
+	require.ErrorIs(t, err, ErrCheckpointNotFound)
+}
+
+func TestRunInTransaction_WhenFnFails(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuID := utils.GenerateNewUUID()
+	projectionName := "TestProjection-" + utils.GenerateNewUUID().String()
+	defer viewRepository.DeleteMenu(ctx, menuID)
+
+	// Act
+	err := viewRepository.RunInTransaction(ctx, func(repo IMenuRepository) error {
+		_ = repo.CreateMenu(ctx, menuID, "TestMenu")
+		_ = repo.SaveCheckpoint(ctx, projectionName, esdb.Position{Commit: 1, Prepare: 1})
+		return ErrCheckpointNotFound
+	})
+
+	// Assert
+	require.ErrorIs(t, err, ErrCheckpointNotFound)
+	_, err = viewRepository.GetMenu(ctx, menuID)
+	require.Error(t, err)
+	_, err = viewRepository.GetCheckpoint(ctx, projectionName)
+	require.ErrorIs(t, err, ErrCheckpointNotFound)
+}
+
+func TestRecordProcessedEvent_WhenEventIsDeliveredTwice(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	projectionName := "TestProjection-" + utils.GenerateNewUUID().String()
+	eventID := utils.GenerateNewUUID()
+	defer viewRepository.exec(ctx, `DELETE FROM processed_events WHERE projection=$1`, projectionName)
+
+	// Act
+	firstDelivery, firstErr := viewRepository.RecordProcessedEvent(ctx, projectionName, eventID)
+	secondDelivery, secondErr := viewRepository.RecordProcessedEvent(ctx, projectionName, eventID)
+
+	// Assert
+	require.NoError(t, firstErr)
+	require.NoError(t, secondErr)
+	require.True(t, firstDelivery)
+	require.False(t, secondDelivery)
+}
+
+func TestIsEventProcessed(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	projectionName := "TestProjection-" + utils.GenerateNewUUID().String()
+	eventID := utils.GenerateNewUUID()
+	defer viewRepository.exec(ctx, `DELETE FROM processed_events WHERE projection=$1`, projectionName)
+
+	// Act
+	processedBefore, errBefore := viewRepository.IsEventProcessed(ctx, projectionName, eventID)
+	_, err := viewRepository.RecordProcessedEvent(ctx, projectionName, eventID)
+	require.NoError(t, err)
+	processedAfter, errAfter := viewRepository.IsEventProcessed(ctx, projectionName, eventID)
+
+	// Assert
+	require.NoError(t, errBefore)
+	require.NoError(t, errAfter)
+	require.False(t, processedBefore)
+	require.True(t, processedAfter)
+}
+
+func TestParkEvent(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	projectionName := "TestProjection-" + utils.GenerateNewUUID().String()
+	defer viewRepository.exec(ctx, `DELETE FROM parked_events WHERE projection=$1`, projectionName)
+	first := &esdb.RecordedEvent{
+		EventID:      utils.GenerateNewUUID(),
+		EventType:    "MenuCreated",
+		ContentType:  "application/json",
+		StreamID:     "Menu-" + utils.GenerateNewUUID().String(),
+		EventNumber:  3,
+		Position:     esdb.Position{Commit: 20, Prepare: 19},
+		CreatedDate:  time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		Data:         []byte(`{"Name":"TestName"}`),
+		UserMetadata: []byte(`{}`),
+	}
+	second := *first
+	second.EventID = utils.GenerateNewUUID()
+	second.Position = esdb.Position{Commit: 10, Prepare: 9}
+
+	// Act
+	require.NoError(t, viewRepository.ParkEvent(ctx, projectionName, first))
+	require.NoError(t, viewRepository.ParkEvent(ctx, projectionName, first))
+	require.NoError(t, viewRepository.ParkEvent(ctx, projectionName, &second))
+	parkedEvents, err := viewRepository.GetParkedEvents(ctx, projectionName)
+	require.NoError(t, err)
+	require.NoError(t, viewRepository.UnparkEvent(ctx, projectionName, second.EventID))
+	parkedAfterUnpark, err := viewRepository.GetParkedEvents(ctx, projectionName)
+	require.NoError(t, err)
+
+	// Assert
+	require.Equal(t, []*esdb.RecordedEvent{&second, first}, parkedEvents)
+	require.Equal(t, []*esdb.RecordedEvent{first}, parkedAfterUnpark)
+}
+
+func TestAddCategoryToMenu_WhenAppliedTwice(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuID, categoryID := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteCategory(ctx, categoryID)
+	defer viewRepository.RemoveCategoryFromMenu(ctx, menuID, categoryID, esdb.Position{Commit: 2, Prepare: 2})
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	_ = viewRepository.CreateMenu(ctx, menuID, "TestMenu")
+	_ = viewRepository.CreateCategory(ctx, categoryID, "TestCategory")
+
+	// Act
+	createErr := viewRepository.CreateMenu(ctx, menuID, "TestMenu")
+	firstErr := viewRepository.AddCategoryToMenu(ctx, menuID, categoryID, esdb.Position{Commit: 1, Prepare: 1})
+	secondErr := viewRepository.AddCategoryToMenu(ctx, menuID, categoryID, esdb.Position{Commit: 1, Prepare: 1})
+
+	// Assert
+	require.NoError(t, createErr)
+	require.NoError(t, firstErr)
+	require.NoError(t, secondErr)
+	returnedMenu, err := viewRepository.GetMenu(ctx, menuID)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{categoryID}, returnedMenu.CategoriesIDs)
+}
+
+func TestDeleteMenu_WhenMenuHasCategories(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuID, categoryID := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteCategory(ctx, categoryID)
+	_ = viewRepository.CreateMenu(ctx, menuID, "TestMenu")
+	_ = viewRepository.CreateCategory(ctx, categoryID, "TestCategory")
+	_ = viewRepository.AddCategoryToMenu(ctx, menuID, categoryID, esdb.Position{Commit: 1, Prepare: 1})
+
+	// Act
+	err := viewRepository.DeleteMenu(ctx, menuID)
+
+	// Assert
+	require.NoError(t, err)
+	_, err = viewRepository.GetMenu(ctx, menuID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = viewRepository.GetCategory(ctx, categoryID)
+	require.NoError(t, err)
+}
+
+func TestMarkCategoryAsDeleted(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuID, categoryID, subCategoryID := utils.GenerateNewUUID(), utils.GenerateNewUUID(), utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteSubCategory(ctx, subCategoryID)
+	defer viewRepository.DeleteCategory(ctx, categoryID)
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	_ = viewRepository.CreateMenu(ctx, menuID, "TestMenu")
+	_ = viewRepository.CreateCategory(ctx, categoryID, "TestCategory")
+	_ = viewRepository.CreateSubCategory(ctx, subCategoryID, "TestSubCategory")
+	_ = viewRepository.AddCategoryToMenu(ctx, menuID, categoryID, esdb.Position{Commit: 1, Prepare: 1})
+	_ = viewRepository.AddSubCategoryToCategory(ctx, categoryID, subCategoryID, esdb.Position{Commit: 1, Prepare: 1})
+
+	// Act
+	err := viewRepository.MarkCategoryAsDeleted(ctx, categoryID)
+
+	// Assert
+	require.NoError(t, err)
+	_, err = viewRepository.GetCategory(ctx, categoryID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	returnedMenu, err := viewRepository.GetMenu(ctx, menuID)
+	require.NoError(t, err)
+	require.Empty(t, returnedMenu.CategoriesIDs)
+	_, err = viewRepository.GetSubCategory(ctx, subCategoryID)
+	require.NoError(t, err)
+}
+
+func TestGetMenu_WhenLinkToDeletedCategoryIsLeft(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuID, categoryID := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteCategory(ctx, categoryID)
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	_ = viewRepository.CreateMenu(ctx, menuID, "TestMenu")
+	_ = viewRepository.CreateCategory(ctx, categoryID, "TestCategory")
+	_ = viewRepository.AddCategoryToMenu(ctx, menuID, categoryID, esdb.Position{Commit: 1, Prepare: 1})
+	// the category is hidden without removing its link to the menu
+	err := viewRepository.exec(ctx, `UPDATE categories SET deleted_at=now() WHERE id=$1`, categoryID)
+	require.NoError(t, err)
+
+	// Act
+	returnedMenu, menuErr := viewRepository.GetMenu(ctx, menuID)
+	menuTree, treeErr := viewRepository.GetMenuTree(ctx, menuID, false)
+
+	// Assert
+	require.NoError(t, menuErr)
+	require.NoError(t, treeErr)
+	require.Empty(t, returnedMenu.CategoriesIDs)
+	require.Empty(t, menuTree.Categories)
+}
+
+func TestAddCategoryToMenu_WhenCategoryIsDeleted(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuID, categoryID := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteCategory(ctx, categoryID)
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	_ = viewRepository.CreateMenu(ctx, menuID, "TestMenu")
+	_ = viewRepository.CreateCategory(ctx, categoryID, "TestCategory")
+	_ = viewRepository.MarkCategoryAsDeleted(ctx, categoryID)
+
+	// Act
+	err := viewRepository.AddCategoryToMenu(ctx, menuID, categoryID, esdb.Position{Commit: 1, Prepare: 1})
+
+	// Assert
+	require.NoError(t, err)
+	returnedMenu, err := viewRepository.GetMenu(ctx, menuID)
+	require.NoError(t, err)
+	require.Empty(t, returnedMenu.CategoriesIDs)
+}
+
+func TestLinkEvents_WhenAppliedOutOfOrderAcrossPartitions(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	firstMenuID, secondMenuID, categoryID, subCategoryID, menuItemID :=
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID)
+	defer viewRepository.DeleteSubCategory(ctx, subCategoryID)
+	defer viewRepository.DeleteCategory(ctx, categoryID)
+	defer viewRepository.DeleteMenu(ctx, secondMenuID)
+	defer viewRepository.DeleteMenu(ctx, firstMenuID)
+
+	// the events in the order of $all, the category is created in the first menu and moved to the second one
+	firstMenuCreated := newProjectionMessage(events.MenuCreated{EventInfo: eventutils.NewEventInfo(firstMenuID), Name: "FirstMenu"}, esdb.Position{Commit: 100, Prepare: 100})
+	secondMenuCreated := newProjectionMessage(events.MenuCreated{EventInfo: eventutils.NewEventInfo(secondMenuID), Name: "SecondMenu"}, esdb.Position{Commit: 200, Prepare: 200})
+	categoryCreated := newProjectionMessage(events.CategoryCreated{EventInfo: eventutils.NewEventInfo(categoryID), Name: "TestCategory"}, esdb.Position{Commit: 300, Prepare: 300})
+	categoryAddedToFirstMenu := newProjectionMessage(events.CategoryAddedToMenu{EventInfo: eventutils.NewEventInfo(firstMenuID), CategoryID: categoryID}, esdb.Position{Commit: 400, Prepare: 400})
+	subCategoryCreated := newProjectionMessage(events.SubCategoryCreated{EventInfo: eventutils.NewEventInfo(subCategoryID), Name: "TestSubCategory"}, esdb.Position{Commit: 500, Prepare: 500})
+	subCategoryAddedToCategory := newProjectionMessage(events.SubCategoryAddedToCategory{EventInfo: eventutils.NewEventInfo(categoryID), SubCategoryID: subCategoryID}, esdb.Position{Commit: 600, Prepare: 600})
+	menuItemCreated := newProjectionMessage(events.MenuItemCreated{EventInfo: eventutils.NewEventInfo(menuItemID), Name: "TestMenuItem"}, esdb.Position{Commit: 700, Prepare: 700})
+	menuItemAddedToSubCategory := newProjectionMessage(events.MenuItemAddedToSubCategory{EventInfo: eventutils.NewEventInfo(subCategoryID), MenuItemID: menuItemID}, esdb.Position{Commit: 800, Prepare: 800})
+	categoryRemovedFromFirstMenu := newProjectionMessage(events.CategoryRemovedFromMenu{EventInfo: eventutils.NewEventInfo(firstMenuID), CategoryID: categoryID}, esdb.Position{Commit: 900, Prepare: 900})
+	categoryAddedToSecondMenu := newProjectionMessage(events.CategoryAddedToMenu{EventInfo: eventutils.NewEventInfo(secondMenuID), CategoryID: categoryID}, esdb.Position{Commit: 1000, Prepare: 1000})
+
+	// the events of each entity keep their order, as with the workers partitioned by entity,
+	// but the links are applied before the creation of their children and the move before the first link
+	appliedEvents := []*esdb.SubscriptionEvent{
+		secondMenuCreated,
+		categoryAddedToSecondMenu,
+		firstMenuCreated,
+		categoryAddedToFirstMenu,
+		categoryRemovedFromFirstMenu,
+		subCategoryAddedToCategory,
+		menuItemAddedToSubCategory,
+		categoryCreated,
+		subCategoryCreated,
+		menuItemCreated,
+	}
+	projection := NewMenuProjection(viewRepository)
+
+	// Act
+	for _, rawEvent := range appliedEvents {
+		handle := projection.handlers[rawEvent.EventAppeared.Event.EventType]
+		err := handle(NewMenuEventHandler(viewRepository), ctx, rawEvent)
+		require.NoError(t, err)
+	}
+
+	// Assert
+	firstMenu, err := viewRepository.GetMenu(ctx, firstMenuID)
+	require.NoError(t, err)
+	require.Empty(t, firstMenu.CategoriesIDs)
+	secondMenu, err := viewRepository.GetMenu(ctx, secondMenuID)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{categoryID}, secondMenu.CategoriesIDs)
+	category, err := viewRepository.GetCategory(ctx, categoryID)
+	require.NoError(t, err)
+	require.Equal(t, "TestCategory", category.Name)
+	require.Equal(t, []uuid.UUID{subCategoryID}, category.SubCategoriesIDs)
+	subCategory, err := viewRepository.GetSubCategory(ctx, subCategoryID)
+	require.NoError(t, err)
+	require.Equal(t, "TestSubCategory", subCategory.Name)
+	require.Equal(t, []uuid.UUID{menuItemID}, subCategory.MenuItemsIDs)
+	menuItem, err := viewRepository.GetMenuItem(ctx, menuItemID)
+	require.NoError(t, err)
+	require.Equal(t, "TestMenuItem", menuItem.Name)
+}
+
+func TestReorderCategories(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuID, firstCategoryID, secondCategoryID := utils.GenerateNewUUID(), utils.GenerateNewUUID(), utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteCategory(ctx, secondCategoryID)
+	defer viewRepository.DeleteCategory(ctx, firstCategoryID)
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	_ = viewRepository.CreateMenu(ctx, menuID, "TestMenu")
+	_ = viewRepository.CreateCategory(ctx, firstCategoryID, "FirstCategory")
+	_ = viewRepository.CreateCategory(ctx, secondCategoryID, "SecondCategory")
+	_ = viewRepository.AddCategoryToMenu(ctx, menuID, firstCategoryID, esdb.Position{Commit: 1, Prepare: 1})
+	_ = viewRepository.AddCategoryToMenu(ctx, menuID, secondCategoryID, esdb.Position{Commit: 1, Prepare: 1})
+
+	// Act
+	err := viewRepository.ReorderCategories(ctx, menuID, []uuid.UUID{secondCategoryID, firstCategoryID})
+
+	// Assert
+	require.NoError(t, err)
+	returnedMenu, err := viewRepository.GetMenu(ctx, menuID)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{secondCategoryID, firstCategoryID}, returnedMenu.CategoriesIDs)
+}
+
+func TestPublishMenuVersion(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuID := utils.GenerateNewUUID()
+	publishedTree := MenuTreeView{
+		ID:         menuID,
+		Name:       "PublishedMenu",
+		Categories: []CategoryTreeView{{ID: utils.GenerateNewUUID(), Name: "PublishedCategory", SubCategories: []SubCategoryTreeView{}}},
+	}
+
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	_ = viewRepository.CreateMenu(ctx, menuID, "TestMenu")
+	_ = viewRepository.EnableMenu(ctx, menuID)
+
+	// Act
+	err := viewRepository.PublishMenuVersion(ctx, menuID, 1, publishedTree, time.Now())
+
+	// Assert
+	require.NoError(t, err)
+	_ = viewRepository.ChangeMenuName(ctx, menuID, "DraftMenu")
+	returnedTree, err := viewRepository.GetPublishedMenuTree(ctx, menuID)
+	require.NoError(t, err)
+	require.Equal(t, "PublishedMenu", returnedTree.Name)
+	require.Equal(t, publishedTree.Categories, returnedTree.Categories)
+	require.True(t, returnedTree.IsEnabled)
+}
+
+func TestRestoreMenuVersion(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuID := utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	_ = viewRepository.CreateMenu(ctx, menuID, "TestMenu")
+	_ = viewRepository.EnableMenu(ctx, menuID)
+	_ = viewRepository.PublishMenuVersion(ctx, menuID, 1, MenuTreeView{ID: menuID, Name: "FirstVersion"}, time.Now())
+	_ = viewRepository.PublishMenuVersion(ctx, menuID, 2, MenuTreeView{ID: menuID, Name: "SecondVersion"}, time.Now())
+
+	// Act
+	err := viewRepository.RestoreMenuVersion(ctx, menuID, 1)
+
+	// Assert
+	require.NoError(t, err)
+	returnedTree, err := viewRepository.GetPublishedMenuTree(ctx, menuID)
+	require.NoError(t, err)
+	require.Equal(t, "FirstVersion", returnedTree.Name)
+	versions, err := viewRepository.GetMenuVersions(ctx, menuID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	require.True(t, versions[0].IsPublished)
+	require.False(t, versions[1].IsPublished)
+	secondVersion, err := viewRepository.GetMenuVersion(ctx, menuID, 2)
+	require.NoError(t, err)
+	require.Equal(t, "SecondVersion", secondVersion.Name)
+}
+
+func TestGetPublishedMenuTree_WhenMenuIsNotPublished(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuID := utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	_ = viewRepository.CreateMenu(ctx, menuID, "TestMenu")
+	_ = viewRepository.EnableMenu(ctx, menuID)
+
+	// Act
+	_, err := viewRepository.GetPublishedMenuTree(ctx, menuID)
+
+	// Assert
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestGetMenuItems_WhenMenuItemIsDeleted(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuItemID := utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID)
+	_ = viewRepository.CreateMenuItem(ctx, menuItemID, "TestMenuItem")
+
+	// Act
+	err := viewRepository.MarkMenuItemAsDeleted(ctx, menuItemID)
+
+	// Assert
+	require.NoError(t, err)
+	_, err = viewRepository.GetMenuItem(ctx, menuItemID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	menuItems, err := viewRepository.GetMenuItems(ctx, nil)
+	require.NoError(t, err)
+	for _, menuItem := range menuItems {
+		require.NotEqual(t, menuItemID, menuItem.ID)
+	}
+}
+
+func TestOrderByIDs(t *testing.T) {
+	// Arrange
+	id1, id2, missingID := utils.GenerateNewUUID(), utils.GenerateNewUUID(), utils.GenerateNewUUID()
+	views := []MenuItemView{{ID: id1}, {ID: id2}}
+
+	// Act
+	orderedViews, notFoundIDs := orderByIDs([]uuid.UUID{id2, missingID, id1}, views, func(view MenuItemView) uuid.UUID {
+		return view.ID
+	})
+
+	// Assert
+	require.Equal(t, []MenuItemView{{ID: id2}, {ID: id1}}, orderedViews)
+	require.Equal(t, []uuid.UUID{missingID}, notFoundIDs)
+}
+
+func TestGetMenuTree(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuID, categoryID, emptyCategoryID, subCategoryID, menuItemID :=
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteMenuItem(ctx, menuItemID)
+	defer viewRepository.DeleteSubCategory(ctx, subCategoryID)
+	defer viewRepository.DeleteCategory(ctx, emptyCategoryID)
+	defer viewRepository.DeleteCategory(ctx, categoryID)
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	_ = viewRepository.CreateMenu(ctx, menuID, "TestMenu")
+	_ = viewRepository.CreateCategory(ctx, categoryID, "TestCategory")
+	_ = viewRepository.CreateCategory(ctx, emptyCategoryID, "EmptyCategory")
+	_ = viewRepository.CreateSubCategory(ctx, subCategoryID, "TestSubCategory")
+	_ = viewRepository.CreateMenuItem(ctx, menuItemID, "TestMenuItem")
+	_ = viewRepository.AddCategoryToMenu(ctx, menuID, categoryID, esdb.Position{Commit: 1, Prepare: 1})
+	_ = viewRepository.AddCategoryToMenu(ctx, menuID, emptyCategoryID, esdb.Position{Commit: 1, Prepare: 1})
+	_ = viewRepository.AddSubCategoryToCategory(ctx, categoryID, subCategoryID, esdb.Position{Commit: 1, Prepare: 1})
+	_ = viewRepository.AddMenuItemToSubCategory(ctx, subCategoryID, menuItemID, esdb.Position{Commit: 1, Prepare: 1})
+
+	// Act
+	menuTree, err := viewRepository.GetMenuTree(ctx, menuID, false)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, menuID, menuTree.ID)
+	require.Len(t, menuTree.Categories, 2)
+	require.Equal(t, categoryID, menuTree.Categories[0].ID)
+	require.Len(t, menuTree.Categories[0].SubCategories, 1)
+	require.Equal(t, subCategoryID, menuTree.Categories[0].SubCategories[0].ID)
+	require.Len(t, menuTree.Categories[0].SubCategories[0].MenuItems, 1)
+	require.Equal(t, menuItemID, menuTree.Categories[0].SubCategories[0].MenuItems[0].ID)
+	require.Equal(t, emptyCategoryID, menuTree.Categories[1].ID)
+	require.Empty(t, menuTree.Categories[1].SubCategories)
+}
+
+func TestGetMenuTree_WhenMenuIsDisabledAndEnabledOnly(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	menuID := utils.GenerateNewUUID()
+
+	defer viewRepository.DeleteMenu(ctx, menuID)
+	_ = viewRepository.CreateMenu(ctx, menuID, "TestMenu")
+
+	// Act
+	_, err := viewRepository.GetMenuTree(ctx, menuID, true)
+
+	// Assert
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+// fakeMenuItemRow is a row of menuItemColumns as the driver returns it
+type fakeMenuItemRow struct {
+	id             uuid.UUID
+	variants       string
+	modifierGroups string
+}
+
+func (row fakeMenuItemRow) Scan(dest ...interface{}) error {
+	values := []interface{}{
+		row.id.String(), "TestMenuItem", "", nil, nil, nil, []byte("{}"),
+		false, false, false, false, int64(0),
+		[]byte(row.variants), []byte(row.modifierGroups), time.Now(),
+	}
+	for i, value := range values {
+		err := convertAssign(dest[i], value)
+		if err != nil {
+			return fmt.Errorf("column %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// convertAssign assigns the value as database/sql does for the types scanMenuItem scans into
+func convertAssign(dest, value interface{}) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+	switch dest := dest.(type) {
+	case *uuid.UUID:
+		return dest.Scan(value)
+	case *string:
+		*dest = value.(string)
+	case *bool:
+		*dest = value.(bool)
+	case *int:
+		*dest = int(value.(int64))
+	case *[]byte:
+		*dest = value.([]byte)
+	case *time.Time:
+		*dest = value.(time.Time)
+	default:
+		return fmt.Errorf("unsupported destination %T", dest)
+	}
+	return nil
+}
+
+func TestScanMenuItem_ShouldDecodeVariantsAndModifierGroups(t *testing.T) {
+	// Arrange
+	variantID, modifierGroupID, modifierID := utils.GenerateNewUUID(), utils.GenerateNewUUID(), utils.GenerateNewUUID()
+	row := fakeMenuItemRow{
+		id: utils.GenerateNewUUID(),
+		variants: fmt.Sprintf(
+			`[{"id": "%s", "name": "Large", "price": {"amount": 1450, "currency": "EUR"}}]`, variantID),
+		modifierGroups: fmt.Sprintf(
+			`[{"id": "%s", "name": "Toppings", "minSelections": 0, "maxSelections": 3, "modifiers": `+
+				`[{"id": "%s", "name": "Extra cheese", "priceDelta": {"amount": 150, "currency": "EUR"}}]}]`,
+			modifierGroupID, modifierID),
+	}
+
+	// Act
+	menuItem, err := scanMenuItem(row)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, row.id, menuItem.ID)
+	require.Nil(t, menuItem.Price)
+	require.Equal(t, []string{}, menuItem.Allergens)
+	require.Equal(t, []VariantView{
+		{ID: variantID, Name: "Large", Price: PriceView{Amount: 1450, Currency: "EUR"}},
+	}, menuItem.Variants)
+	require.Equal(t, []ModifierGroupView{
+		{
+			ID:            modifierGroupID,
+			Name:          "Toppings",
+			MaxSelections: 3,
+			Modifiers: []ModifierView{
+				{ID: modifierID, Name: "Extra cheese", PriceDelta: PriceView{Amount: 150, Currency: "EUR"}},
+			},
+		},
+	}, menuItem.ModifierGroups)
+}
+
+func TestMenuTreeBuilder(t *testing.T) {
+	// Arrange
+	categoryID1, categoryID2, subCategoryID, menuItemID1, menuItemID2, deletedMenuItemID :=
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID(),
+		utils.GenerateNewUUID()
+	rows := []menuTreeRow{
+		{
+			categoryID:    uuid.NullUUID{UUID: categoryID1, Valid: true},
+			subCategoryID: uuid.NullUUID{UUID: subCategoryID, Valid: true},
+			menuItemID:    uuid.NullUUID{UUID: menuItemID1, Valid: true},
+		},
+		{
+			categoryID:    uuid.NullUUID{UUID: categoryID1, Valid: true},
+			subCategoryID: uuid.NullUUID{UUID: subCategoryID, Valid: true},
+			menuItemID:    uuid.NullUUID{UUID: deletedMenuItemID, Valid: true},
+		},
+		{
+			categoryID:    uuid.NullUUID{UUID: categoryID1, Valid: true},
+			subCategoryID: uuid.NullUUID{UUID: subCategoryID, Valid: true},
+			menuItemID:    uuid.NullUUID{UUID: menuItemID2, Valid: true},
+		},
+		{
+			categoryID: uuid.NullUUID{UUID: categoryID2, Valid: true},
+		},
+	}
+	estimatedPreparationSeconds := int64(600)
+	menuItems := []MenuItemView{
+		{ID: menuItemID2, Name: "TestMenuItem2"},
+		{ID: menuItemID1, Name: "TestMenuItem1", EstimatedPreparationSeconds: &estimatedPreparationSeconds},
+	}
+	builder := menuTreeBuilder{}
+
+	// Act
+	for _, row := range rows {
+		builder.add(row)
+	}
+	menuTree := builder.build(menuItems)
+
+	// Assert
+	require.ElementsMatch(t, []uuid.UUID{menuItemID1, deletedMenuItemID, menuItemID2}, builder.allMenuItemsIDs())
+	require.Len(t, menuTree.Categories, 2)
+	require.Len(t, menuTree.Categories[0].SubCategories, 1)
+	subCategory := menuTree.Categories[0].SubCategories[0]
+	require.Len(t, subCategory.MenuItems, 2)
+	require.Equal(t, menuItemID1, subCategory.MenuItems[0].ID)
+	require.Equal(t, "TestMenuItem1", subCategory.MenuItems[0].Name)
+	require.Equal(t, menuItemID2, subCategory.MenuItems[1].ID)
+	require.Equal(t, int64(600), *subCategory.MaxEstimatedPreparationSeconds)
+	require.Equal(t, []SubCategoryTreeView{}, menuTree.Categories[1].SubCategories)
 }
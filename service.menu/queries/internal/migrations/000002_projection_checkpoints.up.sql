@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS projection_checkpoints (
+   name VARCHAR (100) PRIMARY KEY,
+   commit_position BIGINT NOT NULL,
+   prepare_position BIGINT NOT NULL,
+   updated_at TIMESTAMP DEFAULT now()
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS processed_events (
+   projection VARCHAR (100) NOT NULL,
+   event_id uuid NOT NULL,
+   processed_at TIMESTAMP DEFAULT now(),
+   PRIMARY KEY(projection, event_id)
+);
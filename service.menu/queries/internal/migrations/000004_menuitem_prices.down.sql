@@ -0,0 +1,3 @@
+ALTER TABLE menuitems
+   DROP COLUMN IF EXISTS price_amount,
+   DROP COLUMN IF EXISTS price_currency;
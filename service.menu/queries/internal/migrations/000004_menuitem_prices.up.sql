@@ -0,0 +1,3 @@
+ALTER TABLE menuitems
+   ADD COLUMN IF NOT EXISTS price_amount BIGINT,
+   ADD COLUMN IF NOT EXISTS price_currency CHAR (3);
@@ -0,0 +1,2 @@
+ALTER TABLE menuitems
+   DROP COLUMN IF EXISTS estimated_preparation_seconds;
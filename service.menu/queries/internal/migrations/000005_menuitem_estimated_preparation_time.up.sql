@@ -0,0 +1,2 @@
+ALTER TABLE menuitems
+   ADD COLUMN IF NOT EXISTS estimated_preparation_seconds INTEGER;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS menuitem_allergens;
+
+ALTER TABLE menuitems
+   DROP COLUMN IF EXISTS description,
+   DROP COLUMN IF EXISTS is_vegan,
+   DROP COLUMN IF EXISTS is_vegetarian,
+   DROP COLUMN IF EXISTS is_gluten_free,
+   DROP COLUMN IF EXISTS is_halal,
+   DROP COLUMN IF EXISTS spicy_level;
@@ -0,0 +1,16 @@
+ALTER TABLE menuitems
+   ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '',
+   ADD COLUMN IF NOT EXISTS is_vegan BOOLEAN NOT NULL DEFAULT FALSE,
+   ADD COLUMN IF NOT EXISTS is_vegetarian BOOLEAN NOT NULL DEFAULT FALSE,
+   ADD COLUMN IF NOT EXISTS is_gluten_free BOOLEAN NOT NULL DEFAULT FALSE,
+   ADD COLUMN IF NOT EXISTS is_halal BOOLEAN NOT NULL DEFAULT FALSE,
+   ADD COLUMN IF NOT EXISTS spicy_level SMALLINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS menuitem_allergens (
+   menuitem_id uuid NOT NULL,
+   allergen VARCHAR (20) NOT NULL,
+   PRIMARY KEY(menuitem_id, allergen),
+   FOREIGN KEY(menuitem_id) REFERENCES menuitems(id)
+);
+
+CREATE INDEX IF NOT EXISTS menuitem_allergens_allergen_idx ON menuitem_allergens (allergen);
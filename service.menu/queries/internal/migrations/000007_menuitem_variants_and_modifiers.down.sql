@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS menuitem_modifiers;
+DROP TABLE IF EXISTS menuitem_modifier_groups;
+DROP TABLE IF EXISTS menuitem_variants;
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS menuitem_variants (
+   id uuid PRIMARY KEY,
+   menuitem_id uuid NOT NULL,
+   name VARCHAR (50) NOT NULL,
+   price_amount BIGINT NOT NULL,
+   price_currency CHAR (3) NOT NULL,
+   created_at TIMESTAMP DEFAULT now(),
+   FOREIGN KEY(menuitem_id) REFERENCES menuitems(id)
+);
+
+CREATE INDEX IF NOT EXISTS menuitem_variants_menuitem_id_idx ON menuitem_variants (menuitem_id);
+
+CREATE TABLE IF NOT EXISTS menuitem_modifier_groups (
+   id uuid PRIMARY KEY,
+   menuitem_id uuid NOT NULL,
+   name VARCHAR (50) NOT NULL,
+   min_selections INTEGER NOT NULL,
+   max_selections INTEGER NOT NULL,
+   created_at TIMESTAMP DEFAULT now(),
+   FOREIGN KEY(menuitem_id) REFERENCES menuitems(id)
+);
+
+CREATE INDEX IF NOT EXISTS menuitem_modifier_groups_menuitem_id_idx ON menuitem_modifier_groups (menuitem_id);
+
+CREATE TABLE IF NOT EXISTS menuitem_modifiers (
+   id uuid PRIMARY KEY,
+   modifier_group_id uuid NOT NULL,
+   name VARCHAR (50) NOT NULL,
+   price_delta_amount BIGINT NOT NULL,
+   price_delta_currency CHAR (3) NOT NULL,
+   created_at TIMESTAMP DEFAULT now(),
+   FOREIGN KEY(modifier_group_id) REFERENCES menuitem_modifier_groups(id)
+);
+
+CREATE INDEX IF NOT EXISTS menuitem_modifiers_modifier_group_id_idx ON menuitem_modifiers (modifier_group_id);
@@ -0,0 +1,11 @@
+ALTER TABLE menuitems
+   DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE subcategories
+   DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE categories
+   DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE menus
+   DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,11 @@
+ALTER TABLE menus
+   ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+ALTER TABLE categories
+   ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+ALTER TABLE subcategories
+   ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+ALTER TABLE menuitems
+   ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
@@ -0,0 +1,8 @@
+ALTER TABLE subcategory_menuitems
+   DROP COLUMN IF EXISTS position;
+
+ALTER TABLE category_subcategories
+   DROP COLUMN IF EXISTS position;
+
+ALTER TABLE menus_categories
+   DROP COLUMN IF EXISTS position;
@@ -0,0 +1,30 @@
+ALTER TABLE menus_categories
+   ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE category_subcategories
+   ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE subcategory_menuitems
+   ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+
+-- the existing links keep the order they were shown in, by creation of the child
+UPDATE menus_categories mc SET position = p.position
+FROM (
+   SELECT l.category_id, row_number() OVER (PARTITION BY l.menu_id ORDER BY c.created_at, c.id) - 1 AS position
+   FROM menus_categories l JOIN categories c ON c.id = l.category_id
+) p
+WHERE mc.category_id = p.category_id;
+
+UPDATE category_subcategories cs SET position = p.position
+FROM (
+   SELECT l.subcategory_id, row_number() OVER (PARTITION BY l.category_id ORDER BY s.created_at, s.id) - 1 AS position
+   FROM category_subcategories l JOIN subcategories s ON s.id = l.subcategory_id
+) p
+WHERE cs.subcategory_id = p.subcategory_id;
+
+UPDATE subcategory_menuitems sm SET position = p.position
+FROM (
+   SELECT l.menuitem_id, row_number() OVER (PARTITION BY l.subcategory_id ORDER BY i.created_at, i.id) - 1 AS position
+   FROM subcategory_menuitems l JOIN menuitems i ON i.id = l.menuitem_id
+) p
+WHERE sm.menuitem_id = p.menuitem_id;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS menu_versions (
+   menu_id uuid NOT NULL,
+   version INTEGER NOT NULL,
+   tree JSONB NOT NULL,
+   published_at TIMESTAMP NOT NULL,
+   PRIMARY KEY(menu_id, version)
+);
+
+-- the version guests see, null while the menu has never been published
+ALTER TABLE menus
+   ADD COLUMN IF NOT EXISTS published_version INTEGER;
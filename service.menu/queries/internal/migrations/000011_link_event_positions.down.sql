@@ -0,0 +1,11 @@
+ALTER TABLE menuitems
+   DROP COLUMN IF EXISTS link_prepare_position,
+   DROP COLUMN IF EXISTS link_commit_position;
+
+ALTER TABLE subcategories
+   DROP COLUMN IF EXISTS link_prepare_position,
+   DROP COLUMN IF EXISTS link_commit_position;
+
+ALTER TABLE categories
+   DROP COLUMN IF EXISTS link_prepare_position,
+   DROP COLUMN IF EXISTS link_commit_position;
@@ -0,0 +1,13 @@
+-- the position in $all of the last link event applied to each child,
+-- the link events of a child applied out of order are skipped
+ALTER TABLE categories
+   ADD COLUMN IF NOT EXISTS link_commit_position BIGINT NOT NULL DEFAULT -1,
+   ADD COLUMN IF NOT EXISTS link_prepare_position BIGINT NOT NULL DEFAULT -1;
+
+ALTER TABLE subcategories
+   ADD COLUMN IF NOT EXISTS link_commit_position BIGINT NOT NULL DEFAULT -1,
+   ADD COLUMN IF NOT EXISTS link_prepare_position BIGINT NOT NULL DEFAULT -1;
+
+ALTER TABLE menuitems
+   ADD COLUMN IF NOT EXISTS link_commit_position BIGINT NOT NULL DEFAULT -1,
+   ADD COLUMN IF NOT EXISTS link_prepare_position BIGINT NOT NULL DEFAULT -1;
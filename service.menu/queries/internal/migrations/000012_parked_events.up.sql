@@ -0,0 +1,16 @@
+-- the events a projection parked, kept until they are replayed and applied
+CREATE TABLE IF NOT EXISTS parked_events (
+   projection VARCHAR (100) NOT NULL,
+   event_id uuid NOT NULL,
+   event_type VARCHAR (255) NOT NULL,
+   content_type VARCHAR (255) NOT NULL,
+   stream_id VARCHAR (255) NOT NULL,
+   event_number BIGINT NOT NULL,
+   commit_position BIGINT NOT NULL,
+   prepare_position BIGINT NOT NULL,
+   created_date TIMESTAMPTZ NOT NULL,
+   data BYTEA NOT NULL,
+   user_metadata BYTEA NOT NULL,
+   parked_at TIMESTAMP DEFAULT now(),
+   PRIMARY KEY(projection, event_id)
+);
@@ -1,6 +1,11 @@
 package internal
 
 import (
+	"context"
+	"time"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/events"
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/mock"
 )
@@ -9,98 +14,288 @@ type MockMenuRepository struct {
 	mock.Mock
 }
 
-func (m MockMenuRepository) CreateMenu(menuID uuid.UUID, menuName string) error {
+func (m MockMenuRepository) CreateMenu(ctx context.Context, menuID uuid.UUID, menuName string) error {
 	args := m.Called(menuID, menuName)
 	return args.Error(0)
 }
 
-func (m MockMenuRepository) GetMenu(menuID uuid.UUID) (MenuView, error) {
+func (m MockMenuRepository) GetMenu(ctx context.Context, menuID uuid.UUID) (MenuView, error) {
 	args := m.Called(menuID)
 	menuView, _ := args.Get(0).(MenuView)
 	return menuView, args.Error(1)
 }
 
-func (m MockMenuRepository) GetAllMenus() ([]MenuView, error) {
+func (m MockMenuRepository) GetAllMenus(ctx context.Context) ([]MenuView, error) {
 	args := m.Called()
 	menuViews, _ := args.Get(0).([]MenuView)
 	return menuViews, args.Error(1)
 }
 
-func (m MockMenuRepository) DeleteMenu(menuID uuid.UUID) error {
+func (m MockMenuRepository) GetMenuTree(ctx context.Context, menuID uuid.UUID, enabledOnly bool) (MenuTreeView, error) {
+	args := m.Called(menuID, enabledOnly)
+	menuTreeView, _ := args.Get(0).(MenuTreeView)
+	return menuTreeView, args.Error(1)
+}
+
+func (m MockMenuRepository) DeleteMenu(ctx context.Context, menuID uuid.UUID) error {
+	args := m.Called(menuID)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) MarkMenuAsDeleted(ctx context.Context, menuID uuid.UUID) error {
 	args := m.Called(menuID)
 	return args.Error(0)
 }
 
-func (m MockMenuRepository) EnableMenu(menuID uuid.UUID) error {
+func (m MockMenuRepository) EnableMenu(ctx context.Context, menuID uuid.UUID) error {
 	args := m.Called(menuID)
 	return args.Error(0)
 }
 
-func (m MockMenuRepository) DisableMenu(menuID uuid.UUID) error {
+func (m MockMenuRepository) DisableMenu(ctx context.Context, menuID uuid.UUID) error {
 	args := m.Called(menuID)
 	return args.Error(0)
 }
 
-func (m MockMenuRepository) ChangeMenuName(menuID uuid.UUID, newName string) error {
+func (m MockMenuRepository) ChangeMenuName(ctx context.Context, menuID uuid.UUID, newName string) error {
 	args := m.Called(menuID, newName)
 	return args.Error(0)
 }
 
-func (m MockMenuRepository) CreateCategory(categoryID uuid.UUID, categoryName string) error {
+func (m MockMenuRepository) PublishMenuVersion(ctx context.Context, menuID uuid.UUID, version int, tree MenuTreeView, publishedAt time.Time) error {
+	args := m.Called(menuID, version, tree, publishedAt)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) RestoreMenuVersion(ctx context.Context, menuID uuid.UUID, version int) error {
+	args := m.Called(menuID, version)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) GetPublishedMenuTree(ctx context.Context, menuID uuid.UUID) (MenuTreeView, error) {
+	args := m.Called(menuID)
+	menuTreeView, _ := args.Get(0).(MenuTreeView)
+	return menuTreeView, args.Error(1)
+}
+
+func (m MockMenuRepository) GetMenuVersion(ctx context.Context, menuID uuid.UUID, version int) (MenuTreeView, error) {
+	args := m.Called(menuID, version)
+	menuTreeView, _ := args.Get(0).(MenuTreeView)
+	return menuTreeView, args.Error(1)
+}
+
+func (m MockMenuRepository) GetMenuVersions(ctx context.Context, menuID uuid.UUID) ([]MenuVersionView, error) {
+	args := m.Called(menuID)
+	versions, _ := args.Get(0).([]MenuVersionView)
+	return versions, args.Error(1)
+}
+
+func (m MockMenuRepository) CreateCategory(ctx context.Context, categoryID uuid.UUID, categoryName string) error {
 	args := m.Called(categoryID, categoryName)
 	return args.Error(0)
 }
 
-func (m MockMenuRepository) GetCategory(categoryID uuid.UUID) (CategoryView, error) {
+func (m MockMenuRepository) GetCategory(ctx context.Context, categoryID uuid.UUID) (CategoryView, error) {
 	args := m.Called(categoryID)
 	categoryView, _ := args.Get(0).(CategoryView)
 	return categoryView, args.Error(1)
 }
 
-func (m MockMenuRepository) AddCategoryToMenu(menuID, categoryID uuid.UUID) error {
-	args := m.Called(menuID, categoryID)
+func (m MockMenuRepository) AddCategoryToMenu(ctx context.Context, menuID, categoryID uuid.UUID, eventPosition esdb.Position) error {
+	args := m.Called(menuID, categoryID, eventPosition)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) RemoveCategoryFromMenu(ctx context.Context, menuID, categoryID uuid.UUID, eventPosition esdb.Position) error {
+	args := m.Called(menuID, categoryID, eventPosition)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) ReorderCategories(ctx context.Context, menuID uuid.UUID, categoriesIDs []uuid.UUID) error {
+	args := m.Called(menuID, categoriesIDs)
 	return args.Error(0)
 }
 
-func (m MockMenuRepository) GetCategoriesByIDs(categoriesIDs []uuid.UUID) ([]CategoryView, error) {
+func (m MockMenuRepository) MarkCategoryAsDeleted(ctx context.Context, categoryID uuid.UUID) error {
+	args := m.Called(categoryID)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) GetCategoriesByIDs(ctx context.Context, categoriesIDs []uuid.UUID) ([]CategoryView, []uuid.UUID, error) {
 	args := m.Called(categoriesIDs)
 	categoriesViews, _ := args.Get(0).([]CategoryView)
-	return categoriesViews, args.Error(1)
+	notFoundIDs, _ := args.Get(1).([]uuid.UUID)
+	return categoriesViews, notFoundIDs, args.Error(2)
 }
 
-func (m MockMenuRepository) ChangeCategoryName(categoryID uuid.UUID, newName string) error {
+func (m MockMenuRepository) ChangeCategoryName(ctx context.Context, categoryID uuid.UUID, newName string) error {
 	args := m.Called(categoryID, newName)
 	return args.Error(0)
 }
 
-func (m MockMenuRepository) CreateSubCategory(subCategoryID uuid.UUID, subCategoryName string) error {
+func (m MockMenuRepository) CreateSubCategory(ctx context.Context, subCategoryID uuid.UUID, subCategoryName string) error {
 	args := m.Called(subCategoryID, subCategoryName)
 	return args.Error(0)
 }
 
-func (m MockMenuRepository) GetSubCategoriesByIDs(subCategoriesIDs []uuid.UUID) ([]SubCategoryView, error) {
+func (m MockMenuRepository) GetSubCategoriesByIDs(ctx context.Context, subCategoriesIDs []uuid.UUID) ([]SubCategoryView, []uuid.UUID, error) {
 	args := m.Called(subCategoriesIDs)
 	subCategoriesViews, _ := args.Get(0).([]SubCategoryView)
-	return subCategoriesViews, args.Error(1)
+	notFoundIDs, _ := args.Get(1).([]uuid.UUID)
+	return subCategoriesViews, notFoundIDs, args.Error(2)
+}
+
+func (m MockMenuRepository) AddSubCategoryToCategory(ctx context.Context, categoryID, subCategoryID uuid.UUID, eventPosition esdb.Position) error {
+	args := m.Called(categoryID, subCategoryID, eventPosition)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) RemoveSubCategoryFromCategory(ctx context.Context, categoryID, subCategoryID uuid.UUID, eventPosition esdb.Position) error {
+	args := m.Called(categoryID, subCategoryID, eventPosition)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) ReorderSubCategories(ctx context.Context, categoryID uuid.UUID, subCategoriesIDs []uuid.UUID) error {
+	args := m.Called(categoryID, subCategoriesIDs)
+	return args.Error(0)
 }
 
-func (m MockMenuRepository) AddSubCategoryToCategory(categoryID, subCategoryID uuid.UUID) error {
-	args := m.Called(categoryID, subCategoryID)
+func (m MockMenuRepository) MarkSubCategoryAsDeleted(ctx context.Context, subCategoryID uuid.UUID) error {
+	args := m.Called(subCategoryID)
 	return args.Error(0)
 }
 
-func (m MockMenuRepository) CreateMenuItem(menuItemID uuid.UUID, menuItemName string) error {
+func (m MockMenuRepository) CreateMenuItem(ctx context.Context, menuItemID uuid.UUID, menuItemName string) error {
 	args := m.Called(menuItemID, menuItemName)
 	return args.Error(0)
 }
 
-func (m MockMenuRepository) AddMenuItemToSubCategory(subCategoryID, menuItemID uuid.UUID) error {
-	args := m.Called(subCategoryID, menuItemID)
+func (m MockMenuRepository) AddMenuItemToSubCategory(ctx context.Context, subCategoryID, menuItemID uuid.UUID, eventPosition esdb.Position) error {
+	args := m.Called(subCategoryID, menuItemID, eventPosition)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) RemoveMenuItemFromSubCategory(ctx context.Context, subCategoryID, menuItemID uuid.UUID, eventPosition esdb.Position) error {
+	args := m.Called(subCategoryID, menuItemID, eventPosition)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) ReorderMenuItems(ctx context.Context, subCategoryID uuid.UUID, menuItemsIDs []uuid.UUID) error {
+	args := m.Called(subCategoryID, menuItemsIDs)
 	return args.Error(0)
 }
 
-func (m MockMenuRepository) GetMenuItemsByIDs(menuItemsIDs []uuid.UUID) ([]MenuItemView, error) {
+func (m MockMenuRepository) MarkMenuItemAsDeleted(ctx context.Context, menuItemID uuid.UUID) error {
+	args := m.Called(menuItemID)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) GetMenuItemsByIDs(ctx context.Context, menuItemsIDs []uuid.UUID) ([]MenuItemView, []uuid.UUID, error) {
 	args := m.Called(menuItemsIDs)
 	menuItemsViews, _ := args.Get(0).([]MenuItemView)
+	notFoundIDs, _ := args.Get(1).([]uuid.UUID)
+	return menuItemsViews, notFoundIDs, args.Error(2)
+}
+
+func (m MockMenuRepository) ChangeMenuItemPrice(ctx context.Context, menuItemID uuid.UUID, price events.Price) error {
+	args := m.Called(menuItemID, price)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) ChangeMenuItemEstimatedPreparationTime(ctx context.Context, menuItemID uuid.UUID, estimatedPreparationTime time.Duration) error {
+	args := m.Called(menuItemID, estimatedPreparationTime)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) ChangeMenuItemDescription(ctx context.Context, menuItemID uuid.UUID, description string) error {
+	args := m.Called(menuItemID, description)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) ChangeMenuItemAllergens(ctx context.Context, menuItemID uuid.UUID, allergens []events.Allergen) error {
+	args := m.Called(menuItemID, allergens)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) ChangeMenuItemDietaryTags(ctx context.Context, menuItemID uuid.UUID, dietaryTags events.DietaryTags) error {
+	args := m.Called(menuItemID, dietaryTags)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) GetMenuItems(ctx context.Context, excludeAllergens []string) ([]MenuItemView, error) {
+	args := m.Called(excludeAllergens)
+	menuItemsViews, _ := args.Get(0).([]MenuItemView)
 	return menuItemsViews, args.Error(1)
 }
+
+func (m MockMenuRepository) AddMenuItemVariant(ctx context.Context, menuItemID, variantID uuid.UUID, name string, price events.Price) error {
+	args := m.Called(menuItemID, variantID, name, price)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) RemoveMenuItemVariant(ctx context.Context, variantID uuid.UUID) error {
+	args := m.Called(variantID)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) AddMenuItemModifierGroup(ctx context.Context, menuItemID, modifierGroupID uuid.UUID, name string, minSelections, maxSelections int) error {
+	args := m.Called(menuItemID, modifierGroupID, name, minSelections, maxSelections)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) RemoveMenuItemModifierGroup(ctx context.Context, modifierGroupID uuid.UUID) error {
+	args := m.Called(modifierGroupID)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) AddMenuItemModifier(ctx context.Context, modifierGroupID, modifierID uuid.UUID, name string, priceDelta events.Price) error {
+	args := m.Called(modifierGroupID, modifierID, name, priceDelta)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) RemoveMenuItemModifier(ctx context.Context, modifierID uuid.UUID) error {
+	args := m.Called(modifierID)
+	return args.Error(0)
+}
+
+// RunInTransaction runs fn with the mock itself, the calls made by fn are expected on the mock
+func (m MockMenuRepository) RunInTransaction(ctx context.Context, fn func(repo IMenuRepository) error) error {
+	return fn(&m)
+}
+
+func (m MockMenuRepository) GetCheckpoint(ctx context.Context, projectionName string) (esdb.Position, error) {
+	args := m.Called(projectionName)
+	position, _ := args.Get(0).(esdb.Position)
+	return position, args.Error(1)
+}
+
+func (m MockMenuRepository) SaveCheckpoint(ctx context.Context, projectionName string, position esdb.Position) error {
+	args := m.Called(projectionName, position)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) IsEventProcessed(ctx context.Context, projectionName string, eventID uuid.UUID) (bool, error) {
+	args := m.Called(projectionName, eventID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m MockMenuRepository) RecordProcessedEvent(ctx context.Context, projectionName string, eventID uuid.UUID) (bool, error) {
+	args := m.Called(projectionName, eventID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m MockMenuRepository) ParkEvent(ctx context.Context, projectionName string, event *esdb.RecordedEvent) error {
+	args := m.Called(projectionName, event)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) UnparkEvent(ctx context.Context, projectionName string, eventID uuid.UUID) error {
+	args := m.Called(projectionName, eventID)
+	return args.Error(0)
+}
+
+func (m MockMenuRepository) GetParkedEvents(ctx context.Context, projectionName string) ([]*esdb.RecordedEvent, error) {
+	args := m.Called(projectionName)
+	parkedEvents, _ := args.Get(0).([]*esdb.RecordedEvent)
+	return parkedEvents, args.Error(1)
+}
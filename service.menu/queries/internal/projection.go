@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/gofrs/uuid"
+)
+
+const MenuProjectionName = "menu"
+
+// Projection applies the events of $all to the views, each in a transaction that records its ID and saves the checkpoint
+type Projection struct {
+	name           string
+	menuRepository IMenuRepository
+	checkpoints    eventutils.ICheckpoints
+	handlers       map[string]func(MenuEventHandler, context.Context, *esdb.SubscriptionEvent) error
+}
+
+func NewMenuProjection(repo IMenuRepository) *Projection {
+	return &Projection{
+		name:           MenuProjectionName,
+		menuRepository: repo,
+		handlers: map[string]func(MenuEventHandler, context.Context, *esdb.SubscriptionEvent) error{
+			"MenuCreated":                             MenuEventHandler.HandleMenuCreated,
+			"MenuEnabled":                             MenuEventHandler.HandleMenuEnabled,
+			"MenuDisabled":                            MenuEventHandler.HandleMenuDisabled,
+			"MenuNameChanged":                         MenuEventHandler.HandleMenuNameChanged,
+			"CategoryCreated":                         MenuEventHandler.HandleCategoryCreated,
+			"CategoryAddedToMenu":                     MenuEventHandler.HandleCategoryAddedToMenu,
+			"CategoryNameChanged":                     MenuEventHandler.HandleCategoryNameChanged,
+			"SubCategoryCreated":                      MenuEventHandler.HandleSubCategoryCreated,
+			"SubCategoryAddedToCategory":              MenuEventHandler.HandleSubCategoryAddedToCategory,
+			"MenuItemCreated":                         MenuEventHandler.HandleMenuItemCreated,
+			"MenuItemAddedToSubCategory":              MenuEventHandler.HandleMenuItemAddedToSubCategory,
+			"MenuItemPriceChanged":                    MenuEventHandler.HandleMenuItemPriceChanged,
+			"MenuItemEstimatedPreparationTimeChanged": MenuEventHandler.HandleMenuItemEstimatedPreparationTimeChanged,
+			"MenuItemDescriptionChanged":              MenuEventHandler.HandleMenuItemDescriptionChanged,
+			"MenuItemAllergensChanged":                MenuEventHandler.HandleMenuItemAllergensChanged,
+			"MenuItemDietaryTagsChanged":              MenuEventHandler.HandleMenuItemDietaryTagsChanged,
+			"MenuItemVariantAdded":                    MenuEventHandler.HandleMenuItemVariantAdded,
+			"MenuItemVariantRemoved":                  MenuEventHandler.HandleMenuItemVariantRemoved,
+			"MenuItemModifierGroupAdded":              MenuEventHandler.HandleMenuItemModifierGroupAdded,
+			"MenuItemModifierGroupRemoved":            MenuEventHandler.HandleMenuItemModifierGroupRemoved,
+			"MenuItemModifierAdded":                   MenuEventHandler.HandleMenuItemModifierAdded,
+			"MenuItemModifierRemoved":                 MenuEventHandler.HandleMenuItemModifierRemoved,
+			"MenuDeleted":                             MenuEventHandler.HandleMenuDeleted,
+			"CategoryRemovedFromMenu":                 MenuEventHandler.HandleCategoryRemovedFromMenu,
+			"CategoryDeleted":                         MenuEventHandler.HandleCategoryDeleted,
+			"SubCategoryRemovedFromCategory":          MenuEventHandler.HandleSubCategoryRemovedFromCategory,
+			"SubCategoryDeleted":                      MenuEventHandler.HandleSubCategoryDeleted,
+			"MenuItemRemovedFromSubCategory":          MenuEventHandler.HandleMenuItemRemovedFromSubCategory,
+			"MenuItemDeleted":                         MenuEventHandler.HandleMenuItemDeleted,
+			"CategoriesReordered":                     MenuEventHandler.HandleCategoriesReordered,
+			"SubCategoriesReordered":                  MenuEventHandler.HandleSubCategoriesReordered,
+			"MenuItemsReordered":                      MenuEventHandler.HandleMenuItemsReordered,
+			"MenuPublished":                           MenuEventHandler.HandleMenuPublished,
+			"MenuVersionRestored":                     MenuEventHandler.HandleMenuVersionRestored,
+		},
+	}
+}
+
+// WithCheckpoints saves the checkpoint of the subscription, SaveCheckpoint must be called once it is stopped
+func (projection *Projection) WithCheckpoints(checkpoints eventutils.ICheckpoints) *Projection {
+	projection.checkpoints = checkpoints
+	return projection
+}
+
+// EventTypes returns the types of the events the projection applies
+func (projection *Projection) EventTypes() []string {
+	eventTypes := []string{}
+	for eventType := range projection.handlers {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Strings(eventTypes)
+	return eventTypes
+}
+
+// LoadCheckpoint returns the checkpoint of the projection, found is false when no event was applied yet
+func (projection *Projection) LoadCheckpoint(ctx context.Context) (esdb.Position, bool, error) {
+	checkpoint, err := projection.menuRepository.GetCheckpoint(ctx, projection.name)
+	if errors.Is(err, ErrCheckpointNotFound) {
+		return esdb.Position{}, false, nil
+	}
+	if err != nil {
+		return esdb.Position{}, false, err
+	}
+	return checkpoint, true, nil
+}
+
+// ParkEvent keeps the event the subscription of the projection parked
+func (projection *Projection) ParkEvent(ctx context.Context, event *esdb.RecordedEvent) error {
+	return projection.menuRepository.ParkEvent(ctx, projection.name, event)
+}
+
+// UnparkEvent removes the parked event once the subscription of the projection replayed it
+func (projection *Projection) UnparkEvent(ctx context.Context, eventID uuid.UUID) error {
+	return projection.menuRepository.UnparkEvent(ctx, projection.name, eventID)
+}
+
+// LoadParkedEvents returns the events the subscription of the projection parked
+func (projection *Projection) LoadParkedEvents(ctx context.Context) ([]*esdb.RecordedEvent, error) {
+	return projection.menuRepository.GetParkedEvents(ctx, projection.name)
+}
+
+// SaveCheckpoint saves the checkpoint of the subscription given to WithCheckpoints, if it has one
+func (projection *Projection) SaveCheckpoint(ctx context.Context) error {
+	if projection.checkpoints == nil {
+		return nil
+	}
+	checkpoint, found := projection.checkpoints.Checkpoint()
+	if !found {
+		return nil
+	}
+	return projection.menuRepository.SaveCheckpoint(ctx, projection.name, checkpoint)
+}
+
+// Register makes the event handler pass the events of the projection to it
+func (projection *Projection) Register(eventHandler *eventutils.EventHandler) {
+	for eventName := range projection.handlers {
+		eventHandler.HandleEvent(eventName, projection.Apply)
+	}
+}
+
+// Apply applies the event to the views and moves the checkpoint forward in a new transaction
+func (projection *Projection) Apply(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	return projection.menuRepository.RunInTransaction(ctx, func(repo IMenuRepository) error {
+		return projection.applyWith(ctx, repo, rawEvent)
+	})
+}
+
+// applyWith applies the event with a repository that is already in a transaction
+func (projection *Projection) applyWith(ctx context.Context, repo IMenuRepository, rawEvent *esdb.SubscriptionEvent) error {
+	recordedEvent := rawEvent.EventAppeared.OriginalEvent()
+	handle, found := projection.handlers[recordedEvent.EventType]
+	if !found {
+		return fmt.Errorf("%w: %s", eventutils.ErrUnknownEventType, recordedEvent.EventType)
+	}
+	processed, err := repo.IsEventProcessed(ctx, projection.name, recordedEvent.EventID)
+	if err != nil || processed {
+		return err
+	}
+	err = handle(NewMenuEventHandler(repo), ctx, rawEvent)
+	if err != nil {
+		return err
+	}
+	// a delivery running concurrently recorded the event first, failing rolls back this one
+	firstDelivery, err := repo.RecordProcessedEvent(ctx, projection.name, recordedEvent.EventID)
+	if err != nil {
+		return err
+	}
+	if !firstDelivery {
+		return fmt.Errorf("%w: %s", ErrEventAlreadyProcessed, recordedEvent.EventID)
+	}
+	checkpoint, found := recordedEvent.Position, true
+	if projection.checkpoints != nil {
+		checkpoint, found = projection.checkpoints.Checkpoint()
+	}
+	if !found {
+		return nil
+	}
+	return repo.SaveCheckpoint(ctx, projection.name, checkpoint)
+}
@@ -0,0 +1,312 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/events"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectionApply(t *testing.T) {
+	// Arrange
+	menuID := utils.GenerateNewUUID()
+	menuCreatedEvent := events.MenuCreated{
+		EventInfo: eventutils.NewEventInfo(menuID),
+		Name:      "TestMenuName",
+	}
+	position := esdb.Position{Commit: 42, Prepare: 41}
+	incomingMessage := newProjectionMessage(menuCreatedEvent, position)
+
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("IsEventProcessed", MenuProjectionName, incomingMessage.EventAppeared.Event.EventID).
+		Return(false, nil)
+	mockMenuRepository.
+		On("RecordProcessedEvent", MenuProjectionName, incomingMessage.EventAppeared.Event.EventID).
+		Return(true, nil)
+	mockMenuRepository.
+		On("CreateMenu", menuID, menuCreatedEvent.Name).
+		Return(nil).
+		Once()
+	mockMenuRepository.
+		On("SaveCheckpoint", MenuProjectionName, position).
+		Return(nil).
+		Once()
+
+	projection := NewMenuProjection(mockMenuRepository)
+
+	// Act
+	err := projection.Apply(context.Background(), incomingMessage)
+
+	// Assert
+	require.NoError(t, err)
+	mockMenuRepository.AssertExpectations(t)
+}
+
+// fakeCheckpoints is the checkpoint of a subscription
+type fakeCheckpoints struct {
+	position esdb.Position
+	found    bool
+}
+
+func (checkpoints fakeCheckpoints) Checkpoint() (esdb.Position, bool) {
+	return checkpoints.position, checkpoints.found
+}
+
+func TestProjectionApply_WithCheckpoints(t *testing.T) {
+	testCases := []struct {
+		name        string
+		checkpoints fakeCheckpoints
+	}{
+		{"WhenSubscriptionHasCheckpoint", fakeCheckpoints{position: esdb.Position{Commit: 30, Prepare: 29}, found: true}},
+		{"WhenSubscriptionHasNoCheckpoint", fakeCheckpoints{}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			menuID := utils.GenerateNewUUID()
+			menuCreatedEvent := events.MenuCreated{
+				EventInfo: eventutils.NewEventInfo(menuID),
+				Name:      "TestMenuName",
+			}
+			incomingMessage := newProjectionMessage(menuCreatedEvent, esdb.Position{Commit: 42, Prepare: 41})
+
+			// the checkpoint of the event itself is not expected, the mock fails the test if it is saved
+			mockMenuRepository := new(MockMenuRepository)
+			mockMenuRepository.
+				On("IsEventProcessed", MenuProjectionName, incomingMessage.EventAppeared.Event.EventID).
+				Return(false, nil)
+			mockMenuRepository.
+				On("RecordProcessedEvent", MenuProjectionName, incomingMessage.EventAppeared.Event.EventID).
+				Return(true, nil)
+			mockMenuRepository.
+				On("CreateMenu", menuID, menuCreatedEvent.Name).
+				Return(nil)
+			if testCase.checkpoints.found {
+				mockMenuRepository.
+					On("SaveCheckpoint", MenuProjectionName, testCase.checkpoints.position).
+					Return(nil).
+					Once()
+			}
+
+			projection := NewMenuProjection(mockMenuRepository).WithCheckpoints(testCase.checkpoints)
+
+			// Act
+			err := projection.Apply(context.Background(), incomingMessage)
+
+			// Assert
+			require.NoError(t, err)
+			mockMenuRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProjectionLoadCheckpoint_WhenProjectionHasNoCheckpoint(t *testing.T) {
+	// Arrange
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("GetCheckpoint", MenuProjectionName).
+		Return(esdb.Position{}, ErrCheckpointNotFound)
+
+	projection := NewMenuProjection(mockMenuRepository)
+
+	// Act
+	_, found, err := projection.LoadCheckpoint(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestProjectionEventTypes(t *testing.T) {
+	// Arrange
+	projection := NewMenuProjection(new(MockMenuRepository))
+
+	// Act
+	eventTypes := projection.EventTypes()
+
+	// Assert
+	require.Len(t, eventTypes, len(projection.handlers))
+	require.Contains(t, eventTypes, "MenuCreated")
+	require.IsIncreasing(t, eventTypes)
+}
+
+func TestProjectionApply_WhenHandlerFails(t *testing.T) {
+	// Arrange
+	menuID := utils.GenerateNewUUID()
+	menuCreatedEvent := events.MenuCreated{
+		EventInfo: eventutils.NewEventInfo(menuID),
+		Name:      "TestMenuName",
+	}
+	incomingMessage := newProjectionMessage(menuCreatedEvent, esdb.Position{Commit: 42, Prepare: 41})
+	handlerErr := errors.New("test error")
+
+	// RecordProcessedEvent and SaveCheckpoint are not expected, the mock fails the test if they are called
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("IsEventProcessed", MenuProjectionName, incomingMessage.EventAppeared.Event.EventID).
+		Return(false, nil)
+	mockMenuRepository.
+		On("CreateMenu", menuID, menuCreatedEvent.Name).
+		Return(handlerErr)
+
+	projection := NewMenuProjection(mockMenuRepository)
+
+	// Act
+	err := projection.Apply(context.Background(), incomingMessage)
+
+	// Assert
+	require.ErrorIs(t, err, handlerErr)
+}
+
+func TestProjectionApply_WhenEventIsRecordedConcurrently(t *testing.T) {
+	// Arrange
+	menuID := utils.GenerateNewUUID()
+	menuCreatedEvent := events.MenuCreated{
+		EventInfo: eventutils.NewEventInfo(menuID),
+		Name:      "TestMenuName",
+	}
+	incomingMessage := newProjectionMessage(menuCreatedEvent, esdb.Position{Commit: 42, Prepare: 41})
+
+	// SaveCheckpoint is not expected, the mock fails the test if it is called
+	mockMenuRepository := new(MockMenuRepository)
+	mockMenuRepository.
+		On("IsEventProcessed", MenuProjectionName, incomingMessage.EventAppeared.Event.EventID).
+		Return(false, nil)
+	mockMenuRepository.
+		On("CreateMenu", menuID, menuCreatedEvent.Name).
+		Return(nil)
+	mockMenuRepository.
+		On("RecordProcessedEvent", MenuProjectionName, incomingMessage.EventAppeared.Event.EventID).
+		Return(false, nil)
+
+	projection := NewMenuProjection(mockMenuRepository)
+
+	// Act
+	err := projection.Apply(context.Background(), incomingMessage)
+
+	// Assert
+	require.ErrorIs(t, err, ErrEventAlreadyProcessed)
+}
+
+func TestProjectionApply_WhenEventIsUnknown(t *testing.T) {
+	// Arrange
+	incomingMessage := newProjectionMessage(events.PendingLinksCreated{
+		EventInfo: eventutils.NewEventInfo(utils.GenerateNewUUID()),
+	}, esdb.Position{})
+
+	projection := NewMenuProjection(new(MockMenuRepository))
+
+	// Act
+	err := projection.Apply(context.Background(), incomingMessage)
+
+	// Assert
+	require.ErrorIs(t, err, eventutils.ErrUnknownEventType)
+}
+
+func TestProjectionApply_EveryEventTwice(t *testing.T) {
+	entityID, childID := utils.GenerateNewUUID(), utils.GenerateNewUUID()
+	position := esdb.Position{Commit: 42, Prepare: 41}
+	testCases := []struct {
+		event          eventutils.IEvent
+		expectedMethod string
+		expectedArgs   []interface{}
+	}{
+		{events.MenuCreated{EventInfo: eventutils.NewEventInfo(entityID), Name: "TestName"}, "CreateMenu", []interface{}{entityID, "TestName"}},
+		{events.MenuEnabled{EventInfo: eventutils.NewEventInfo(entityID)}, "EnableMenu", []interface{}{entityID}},
+		{events.MenuDisabled{EventInfo: eventutils.NewEventInfo(entityID)}, "DisableMenu", []interface{}{entityID}},
+		{events.MenuNameChanged{EventInfo: eventutils.NewEventInfo(entityID), NewName: "NewName"}, "ChangeMenuName", []interface{}{entityID, "NewName"}},
+		{events.CategoryCreated{EventInfo: eventutils.NewEventInfo(entityID), Name: "TestName"}, "CreateCategory", []interface{}{entityID, "TestName"}},
+		{events.CategoryAddedToMenu{EventInfo: eventutils.NewEventInfo(entityID), CategoryID: childID}, "AddCategoryToMenu", []interface{}{entityID, childID, position}},
+		{events.CategoryNameChanged{EventInfo: eventutils.NewEventInfo(entityID), NewName: "NewName"}, "ChangeCategoryName", []interface{}{entityID, "NewName"}},
+		{events.SubCategoryCreated{EventInfo: eventutils.NewEventInfo(entityID), Name: "TestName"}, "CreateSubCategory", []interface{}{entityID, "TestName"}},
+		{events.SubCategoryAddedToCategory{EventInfo: eventutils.NewEventInfo(entityID), SubCategoryID: childID}, "AddSubCategoryToCategory", []interface{}{entityID, childID, position}},
+		{events.MenuItemCreated{EventInfo: eventutils.NewEventInfo(entityID), Name: "TestName"}, "CreateMenuItem", []interface{}{entityID, "TestName"}},
+		{events.MenuItemAddedToSubCategory{EventInfo: eventutils.NewEventInfo(entityID), MenuItemID: childID}, "AddMenuItemToSubCategory", []interface{}{entityID, childID, position}},
+		{events.MenuItemPriceChanged{EventInfo: eventutils.NewEventInfo(entityID), NewPrice: events.Price{Amount: 1250, Currency: "EUR"}}, "ChangeMenuItemPrice", []interface{}{entityID, events.Price{Amount: 1250, Currency: "EUR"}}},
+		{events.MenuItemEstimatedPreparationTimeChanged{EventInfo: eventutils.NewEventInfo(entityID), NewEstimate: 15 * time.Minute}, "ChangeMenuItemEstimatedPreparationTime", []interface{}{entityID, 15 * time.Minute}},
+		{events.MenuItemDescriptionChanged{EventInfo: eventutils.NewEventInfo(entityID), NewDescription: "TestDescription"}, "ChangeMenuItemDescription", []interface{}{entityID, "TestDescription"}},
+		{events.MenuItemAllergensChanged{EventInfo: eventutils.NewEventInfo(entityID), NewAllergens: []events.Allergen{events.AllergenGluten}}, "ChangeMenuItemAllergens", []interface{}{entityID, []events.Allergen{events.AllergenGluten}}},
+		{events.MenuItemDietaryTagsChanged{EventInfo: eventutils.NewEventInfo(entityID), NewDietaryTags: events.DietaryTags{Halal: true}}, "ChangeMenuItemDietaryTags", []interface{}{entityID, events.DietaryTags{Halal: true}}},
+		{events.MenuItemVariantAdded{EventInfo: eventutils.NewEventInfo(entityID), VariantID: childID, Name: "TestName", Price: events.Price{Amount: 1450, Currency: "EUR"}}, "AddMenuItemVariant", []interface{}{entityID, childID, "TestName", events.Price{Amount: 1450, Currency: "EUR"}}},
+		{events.MenuItemVariantRemoved{EventInfo: eventutils.NewEventInfo(entityID), VariantID: childID}, "RemoveMenuItemVariant", []interface{}{childID}},
+		{events.MenuItemModifierGroupAdded{EventInfo: eventutils.NewEventInfo(entityID), ModifierGroupID: childID, Name: "TestName", MinSelections: 1, MaxSelections: 2}, "AddMenuItemModifierGroup", []interface{}{entityID, childID, "TestName", 1, 2}},
+		{events.MenuItemModifierGroupRemoved{EventInfo: eventutils.NewEventInfo(entityID), ModifierGroupID: childID}, "RemoveMenuItemModifierGroup", []interface{}{childID}},
+		{events.MenuItemModifierAdded{EventInfo: eventutils.NewEventInfo(entityID), ModifierGroupID: childID, ModifierID: entityID, Name: "TestName", PriceDelta: events.Price{Amount: -100, Currency: "EUR"}}, "AddMenuItemModifier", []interface{}{childID, entityID, "TestName", events.Price{Amount: -100, Currency: "EUR"}}},
+		{events.MenuItemModifierRemoved{EventInfo: eventutils.NewEventInfo(entityID), ModifierGroupID: childID, ModifierID: entityID}, "RemoveMenuItemModifier", []interface{}{entityID}},
+		{events.MenuDeleted{EventInfo: eventutils.NewEventInfo(entityID)}, "MarkMenuAsDeleted", []interface{}{entityID}},
+		{events.CategoryRemovedFromMenu{EventInfo: eventutils.NewEventInfo(entityID), CategoryID: childID}, "RemoveCategoryFromMenu", []interface{}{entityID, childID, position}},
+		{events.CategoryDeleted{EventInfo: eventutils.NewEventInfo(entityID), ParentMenuID: childID}, "MarkCategoryAsDeleted", []interface{}{entityID}},
+		{events.SubCategoryRemovedFromCategory{EventInfo: eventutils.NewEventInfo(entityID), SubCategoryID: childID}, "RemoveSubCategoryFromCategory", []interface{}{entityID, childID, position}},
+		{events.SubCategoryDeleted{EventInfo: eventutils.NewEventInfo(entityID), ParentCategoryID: childID}, "MarkSubCategoryAsDeleted", []interface{}{entityID}},
+		{events.MenuItemRemovedFromSubCategory{EventInfo: eventutils.NewEventInfo(entityID), MenuItemID: childID}, "RemoveMenuItemFromSubCategory", []interface{}{entityID, childID, position}},
+		{events.MenuItemDeleted{EventInfo: eventutils.NewEventInfo(entityID), ParentSubCategoryID: childID}, "MarkMenuItemAsDeleted", []interface{}{entityID}},
+		{events.CategoriesReordered{EventInfo: eventutils.NewEventInfo(entityID), CategoriesIDs: []uuid.UUID{childID}}, "ReorderCategories", []interface{}{entityID, []uuid.UUID{childID}}},
+		{events.SubCategoriesReordered{EventInfo: eventutils.NewEventInfo(entityID), SubCategoriesIDs: []uuid.UUID{childID}}, "ReorderSubCategories", []interface{}{entityID, []uuid.UUID{childID}}},
+		{events.MenuItemsReordered{EventInfo: eventutils.NewEventInfo(entityID), MenuItemsIDs: []uuid.UUID{childID}}, "ReorderMenuItems", []interface{}{entityID, []uuid.UUID{childID}}},
+		{events.MenuPublished{EventInfo: eventutils.NewEventInfo(entityID), Version: 2, Name: "TestName"}, "PublishMenuVersion", []interface{}{entityID, 2, MenuTreeView{ID: entityID, Name: "TestName", Categories: []CategoryTreeView{}}, mock.AnythingOfType("time.Time")}},
+		{events.MenuVersionRestored{EventInfo: eventutils.NewEventInfo(entityID), Version: 1}, "RestoreMenuVersion", []interface{}{entityID, 1}},
+	}
+	for _, testCase := range testCases {
+		t.Run(utils.GetType(testCase.event), func(t *testing.T) {
+			// Arrange
+			incomingMessage := newProjectionMessage(testCase.event, position)
+			eventID := incomingMessage.EventAppeared.Event.EventID
+
+			mockMenuRepository := new(MockMenuRepository)
+			mockMenuRepository.
+				On("IsEventProcessed", MenuProjectionName, eventID).
+				Return(false, nil).
+				Once()
+			mockMenuRepository.
+				On("IsEventProcessed", MenuProjectionName, eventID).
+				Return(true, nil).
+				Once()
+			mockMenuRepository.
+				On("RecordProcessedEvent", MenuProjectionName, eventID).
+				Return(true, nil).
+				Once()
+			mockMenuRepository.
+				On(testCase.expectedMethod, testCase.expectedArgs...).
+				Return(nil).
+				Once()
+			mockMenuRepository.
+				On("SaveCheckpoint", MenuProjectionName, position).
+				Return(nil).
+				Once()
+
+			projection := NewMenuProjection(mockMenuRepository)
+
+			// Act
+			firstErr := projection.Apply(context.Background(), incomingMessage)
+			secondErr := projection.Apply(context.Background(), incomingMessage)
+
+			// Assert
+			require.NoError(t, firstErr)
+			require.NoError(t, secondErr)
+			mockMenuRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func newProjectionMessage(event eventutils.IEvent, position esdb.Position) *esdb.SubscriptionEvent {
+	serializedEvent := eventutils.SerializedEvent(event)
+	return &esdb.SubscriptionEvent{
+		EventAppeared: &esdb.ResolvedEvent{
+			Event: &esdb.RecordedEvent{
+				EventID:   serializedEvent.ID,
+				EventType: serializedEvent.Name,
+				Data:      serializedEvent.Data,
+				Position:  position,
+			},
+		},
+	}
+}
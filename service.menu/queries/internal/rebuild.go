@@ -0,0 +1,240 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/lib/pq"
+)
+
+const (
+	shadowSchema  = "rebuild"
+	retiredSchema = "retired"
+)
+
+// projectionTables are the tables written by the menu projection,
+// a table added to the projection must be added here to be rebuilt
+var projectionTables = []string{
+	"menus",
+	"categories",
+	"menus_categories",
+	"subcategories",
+	"category_subcategories",
+	"menuitems",
+	"subcategory_menuitems",
+	"menuitem_allergens",
+	"menuitem_variants",
+	"menuitem_modifier_groups",
+	"menuitem_modifiers",
+	"menu_versions",
+	"projection_checkpoints",
+	"processed_events",
+	"parked_events",
+}
+
+// Rebuilder rebuilds the views of the menu projection from the whole history of $all
+type Rebuilder struct {
+	menuRepository MenuRepository
+	eventReader    eventutils.IAllEventsReader
+	concurrency    int
+	queueSize      int
+}
+
+func NewRebuilder(repo MenuRepository, eventReader eventutils.IAllEventsReader, concurrency, queueSize int) Rebuilder {
+	return Rebuilder{
+		menuRepository: repo,
+		eventReader:    eventReader,
+		concurrency:    concurrency,
+		queueSize:      queueSize,
+	}
+}
+
+// Rebuild empties the views and applies every event again from the start of $all.
+// The service must be stopped meanwhile, or the events it applies would be mixed with the replayed ones.
+func (rebuilder Rebuilder) Rebuild(ctx context.Context) error {
+	err := rebuilder.menuRepository.exec(ctx, `TRUNCATE `+quoteTables(projectionTables))
+	if err != nil {
+		return err
+	}
+	return rebuilder.replay(ctx, rebuilder.menuRepository)
+}
+
+// RebuildInShadowTables rebuilds the views in copies of their tables while the service keeps serving
+// and updating the current ones. When the copies have caught up they replace the current tables in a
+// single transaction, during which the service waits before saving its next checkpoint.
+func (rebuilder Rebuilder) RebuildInShadowTables(ctx context.Context) error {
+	err := rebuilder.createShadowTables(ctx)
+	if err != nil {
+		return err
+	}
+	shadowRepository := rebuilder.menuRepository.InSchema(shadowSchema)
+	err = rebuilder.replay(ctx, shadowRepository)
+	if err != nil {
+		// the shadow tables are dropped even when the rebuild was cancelled
+		rebuilder.menuRepository.exec(context.Background(), `DROP SCHEMA IF EXISTS `+shadowSchema+` CASCADE`)
+		return err
+	}
+	return rebuilder.swapShadowTables(ctx, shadowRepository)
+}
+
+func (rebuilder Rebuilder) replay(ctx context.Context, repo MenuRepository) error {
+	subscription := eventutils.NewReplaySubscription(rebuilder.eventReader, esdb.Position{})
+	eventHandler := eventutils.NewEventHandlerFromSubscription(subscription).
+		WithConcurrency(rebuilder.concurrency, rebuilder.queueSize).
+		HandleUnknownEvents(eventutils.RetryPolicy{MaxAttempts: 1}, ignoreEvent)
+	projection := NewMenuProjection(repo).WithCheckpoints(subscription)
+	projection.Register(eventHandler)
+
+	eventHandler.Start(ctx)
+	eventHandler.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if subscription.Err() != nil {
+		return subscription.Err()
+	}
+	parkedEvents, err := eventHandler.GetParkedEvents()
+	if err != nil {
+		return err
+	}
+	for _, parkedEvent := range parkedEvents {
+		log.Printf("could not apply %s %s", parkedEvent.Name, parkedEvent.ID)
+	}
+	if len(parkedEvents) > 0 {
+		return fmt.Errorf("%w: %d events could not be applied", ErrRebuildIncomplete, len(parkedEvents))
+	}
+	return projection.SaveCheckpoint(ctx)
+}
+
+// createShadowTables creates empty copies of the tables of the projection, with their foreign keys
+// pointing to the other copies
+func (rebuilder Rebuilder) createShadowTables(ctx context.Context) error {
+	return rebuilder.menuRepository.RunInTransaction(ctx, func(txRepo IMenuRepository) error {
+		repo := txRepo.(MenuRepository)
+		foreignKeys, err := repo.getForeignKeys(ctx, projectionTables)
+		if err != nil {
+			return err
+		}
+		statements := []string{
+			`DROP SCHEMA IF EXISTS ` + shadowSchema + ` CASCADE`,
+			`CREATE SCHEMA ` + shadowSchema,
+		}
+		for _, table := range projectionTables {
+			statements = append(statements, fmt.Sprintf(`CREATE TABLE %s.%s (LIKE public.%s INCLUDING ALL)`,
+				shadowSchema, pq.QuoteIdentifier(table), pq.QuoteIdentifier(table)))
+		}
+		// the definitions of the foreign keys name the referenced tables without their schema,
+		// so that they point to the copies when they are created with the shadow schema as search path
+		statements = append(statements, `SET LOCAL search_path TO `+shadowSchema)
+		statements = append(statements, foreignKeys...)
+		for _, statement := range statements {
+			err = repo.exec(ctx, statement)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// swapShadowTables applies to the copies the events written since the end of the replay,
+// then moves them in place of the current tables
+func (rebuilder Rebuilder) swapShadowTables(ctx context.Context, shadowRepository MenuRepository) error {
+	return shadowRepository.RunInTransaction(ctx, func(txRepo IMenuRepository) error {
+		repo := txRepo.(MenuRepository)
+		err := repo.exec(ctx, `LOCK TABLE public.projection_checkpoints IN EXCLUSIVE MODE`)
+		if err != nil {
+			return err
+		}
+		err = rebuilder.catchUp(ctx, repo)
+		if err != nil {
+			return err
+		}
+		statements := []string{`CREATE SCHEMA ` + retiredSchema}
+		for _, table := range projectionTables {
+			statements = append(statements,
+				fmt.Sprintf(`ALTER TABLE public.%s SET SCHEMA %s`, pq.QuoteIdentifier(table), retiredSchema),
+				fmt.Sprintf(`ALTER TABLE %s.%s SET SCHEMA public`, shadowSchema, pq.QuoteIdentifier(table)),
+			)
+		}
+		statements = append(statements,
+			`DROP SCHEMA `+retiredSchema+` CASCADE`,
+			`DROP SCHEMA `+shadowSchema,
+		)
+		for _, statement := range statements {
+			err = repo.exec(ctx, statement)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (rebuilder Rebuilder) catchUp(ctx context.Context, repo MenuRepository) error {
+	projection := NewMenuProjection(repo)
+	checkpoint, err := repo.GetCheckpoint(ctx, MenuProjectionName)
+	found := err == nil
+	if err != nil && !errors.Is(err, ErrCheckpointNotFound) {
+		return err
+	}
+	return rebuilder.eventReader.ReadAllEvents(checkpoint, func(event *esdb.RecordedEvent) error {
+		if found && event.Position == checkpoint {
+			return nil
+		}
+		if _, handled := projection.handlers[event.EventType]; !handled {
+			return nil
+		}
+		return projection.applyWith(ctx, repo, &esdb.SubscriptionEvent{
+			EventAppeared: &esdb.ResolvedEvent{Event: event},
+		})
+	})
+}
+
+// getForeignKeys returns the statements that add the foreign keys of the tables
+func (repo MenuRepository) getForeignKeys(ctx context.Context, tables []string) ([]string, error) {
+	query := `
+		SELECT c.conrelid::regclass::text, c.conname, pg_get_constraintdef(c.oid)
+		FROM pg_constraint c
+		WHERE c.contype = 'f' AND c.conrelid = ANY($1::regclass[])
+	`
+	rows, err := repo.tx.QueryContext(ctx, query, pq.Array(tables))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statements := []string{}
+	for rows.Next() {
+		var table, name, definition string
+		err = rows.Scan(&table, &name, &definition)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, fmt.Sprintf(`ALTER TABLE %s ADD CONSTRAINT %s %s`, table, pq.QuoteIdentifier(name), definition))
+	}
+	return statements, rows.Err()
+}
+
+func quoteTables(tables []string) string {
+	quoted := []string{}
+	for _, table := range tables {
+		quoted = append(quoted, pq.QuoteIdentifier(table))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func ignoreEvent(ctx context.Context, rawEvent *esdb.SubscriptionEvent) error {
+	return nil
+}
+
+// Errors
+
+var (
+	ErrRebuildIncomplete = errors.New("the rebuild did not apply every event")
+)
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/Resta-Inc/resta/pkg/events"
+	"github.com/Resta-Inc/resta/pkg/eventutils"
+	"github.com/Resta-Inc/resta/pkg/utils"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebuild(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	eventStore := eventutils.NewInMemoryEventStore()
+	menuID := saveTestMenuEvents(t, eventStore, "RebuiltMenu")
+	staleMenuID := utils.GenerateNewUUID()
+	_ = viewRepository.CreateMenu(ctx, staleMenuID, "StaleMenu")
+
+	rebuilder := NewRebuilder(viewRepository, eventStore, 2, 10)
+
+	// Act
+	err := rebuilder.Rebuild(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	menu, err := viewRepository.GetMenu(ctx, menuID)
+	require.NoError(t, err)
+	require.Equal(t, "RebuiltMenu", menu.Name)
+	require.True(t, menu.IsEnabled)
+	_, err = viewRepository.GetMenu(ctx, staleMenuID)
+	require.Error(t, err)
+	_, err = viewRepository.GetCheckpoint(ctx, MenuProjectionName)
+	require.NoError(t, err)
+}
+
+func TestRebuildInShadowTables(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	viewRepository := newTestMenuRepository(t)
+	eventStore := eventutils.NewInMemoryEventStore()
+	menuID := saveTestMenuEvents(t, eventStore, "RebuiltMenu")
+	staleMenuID := utils.GenerateNewUUID()
+	_ = viewRepository.CreateMenu(ctx, staleMenuID, "StaleMenu")
+
+	rebuilder := NewRebuilder(viewRepository, eventStore, 2, 10)
+
+	// Act
+	err := rebuilder.RebuildInShadowTables(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	menu, err := viewRepository.GetMenu(ctx, menuID)
+	require.NoError(t, err)
+	require.Equal(t, "RebuiltMenu", menu.Name)
+	_, err = viewRepository.GetMenu(ctx, staleMenuID)
+	require.Error(t, err)
+	categoryID := utils.GenerateNewUUID()
+	_ = viewRepository.CreateCategory(ctx, categoryID, "TestCategory")
+	err = viewRepository.AddCategoryToMenu(ctx, utils.GenerateNewUUID(), categoryID, esdb.Position{Commit: 1, Prepare: 1})
+	require.Error(t, err, "the foreign keys must have been copied to the rebuilt tables")
+}
+
+func saveTestMenuEvents(t *testing.T, eventStore *eventutils.InMemoryEventStore, menuName string) uuid.UUID {
+	menuID := utils.GenerateNewUUID()
+	menuEvents := []eventutils.IEvent{
+		events.MenuCreated{
+			EventInfo: eventutils.NewEventInfo(menuID),
+			Name:      menuName,
+		},
+		events.MenuEnabled{
+			EventInfo: eventutils.NewEventInfo(menuID),
+		},
+	}
+	serializedEvents := []eventutils.Event{}
+	for _, event := range menuEvents {
+		serializedEvents = append(serializedEvents, eventutils.SerializedEvent(event))
+	}
+	_, err := eventStore.SaveEventsToNewStream("Menu-"+menuID.String(), serializedEvents)
+	require.NoError(t, err)
+	return menuID
+}
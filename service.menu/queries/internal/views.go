@@ -27,11 +27,90 @@ type SubCategoryView struct {
 	Name         string      `json:"name"`
 	ImageURL     string      `json:"imageURL"`
 	MenuItemsIDs []uuid.UUID `json:"menuItemsIDs"`
-	CreatedAt    time.Time   `json:"createdAt"`
+	// MaxEstimatedPreparationSeconds is the longest estimated preparation time of the menu items,
+	// nil when none of them has one
+	MaxEstimatedPreparationSeconds *int64    `json:"maxEstimatedPreparationSeconds"`
+	CreatedAt                      time.Time `json:"createdAt"`
 }
 
 type MenuItemView struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID                          uuid.UUID           `json:"id"`
+	Name                        string              `json:"name"`
+	Description                 string              `json:"description"`
+	Price                       *PriceView          `json:"price"`
+	EstimatedPreparationSeconds *int64              `json:"estimatedPreparationSeconds"`
+	Allergens                   []string            `json:"allergens"`
+	DietaryTags                 DietaryTagsView     `json:"dietaryTags"`
+	Variants                    []VariantView       `json:"variants"`
+	ModifierGroups              []ModifierGroupView `json:"modifierGroups"`
+	CreatedAt                   time.Time           `json:"createdAt"`
+}
+
+// PriceView is an amount in the minor unit of its ISO 4217 currency, 1250 EUR is 12.50 €
+type PriceView struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type VariantView struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Price PriceView `json:"price"`
+}
+
+// ModifierGroupView is a choice of at least MinSelections and at most MaxSelections of its modifiers
+type ModifierGroupView struct {
+	ID            uuid.UUID      `json:"id"`
+	Name          string         `json:"name"`
+	MinSelections int            `json:"minSelections"`
+	MaxSelections int            `json:"maxSelections"`
+	Modifiers     []ModifierView `json:"modifiers"`
+}
+
+// ModifierView has the amount added to the price of the menu item when the modifier is chosen
+type ModifierView struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	PriceDelta PriceView `json:"priceDelta"`
+}
+
+type DietaryTagsView struct {
+	Vegan      bool `json:"vegan"`
+	Vegetarian bool `json:"vegetarian"`
+	GlutenFree bool `json:"glutenFree"`
+	Halal      bool `json:"halal"`
+	SpicyLevel int  `json:"spicyLevel"`
+}
+
+// MenuTreeView is a menu with its categories, subcategories and menu items nested in it
+type MenuTreeView struct {
+	ID         uuid.UUID          `json:"id"`
+	Name       string             `json:"name"`
+	IsEnabled  bool               `json:"isEnabled"`
+	Categories []CategoryTreeView `json:"categories"`
+	CreatedAt  time.Time          `json:"createdAt"`
+}
+
+type CategoryTreeView struct {
+	ID            uuid.UUID             `json:"id"`
+	Name          string                `json:"name"`
+	ImageURL      string                `json:"imageURL"`
+	SubCategories []SubCategoryTreeView `json:"subCategories"`
+	CreatedAt     time.Time             `json:"createdAt"`
+}
+
+type SubCategoryTreeView struct {
+	ID                             uuid.UUID      `json:"id"`
+	Name                           string         `json:"name"`
+	ImageURL                       string         `json:"imageURL"`
+	MenuItems                      []MenuItemView `json:"menuItems"`
+	MaxEstimatedPreparationSeconds *int64         `json:"maxEstimatedPreparationSeconds"`
+	CreatedAt                      time.Time      `json:"createdAt"`
+}
+
+// MenuVersionView is a published version of a menu, IsPublished tells whether it is the one guests see
+type MenuVersionView struct {
+	Version     int       `json:"version"`
+	PublishedAt time.Time `json:"publishedAt"`
+	IsPublished bool      `json:"isPublished"`
 }
@@ -1,6 +1,13 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/EventStore/EventStore-Client-Go/esdb"
 	"github.com/Resta-Inc/resta/menu/queries/internal"
 	"github.com/Resta-Inc/resta/pkg/eventutils"
@@ -12,26 +19,108 @@ import (
 func main() {
 	config := internal.LoadConfig(".")
 	utils.Time = clock.New()
-	settings, _ := esdb.ParseConnectionString(config.EventStoreConnectionString)
-	db, _ := esdb.NewClient(settings)
-	eventHandler := eventutils.NewEventHandler(db, "menu.queries")
-	menuRepository := internal.NewMenuRepository(config.PostgresConnectionString)
-	menuEventHandler := internal.NewMenuEventHandler(menuRepository)
-	eventHandler.HandleEvent("MenuCreated", menuEventHandler.HandleMenuCreated)
-	eventHandler.HandleEvent("MenuEnabled", menuEventHandler.HandleMenuEnabled)
-	eventHandler.HandleEvent("MenuDisabled", menuEventHandler.HandleMenuDisabled)
-	eventHandler.HandleEvent("MenuNameChanged", menuEventHandler.HandleMenuNameChanged)
-	eventHandler.HandleEvent("CategoryCreated", menuEventHandler.HandleCategoryCreated)
-	eventHandler.HandleEvent("CategoryAddedToMenu", menuEventHandler.HandleCategoryAddedToMenu)
-	eventHandler.HandleEvent("CategoryNameChanged", menuEventHandler.HandleCategoryNameChanged)
-	eventHandler.HandleEvent("SubCategoryCreated", menuEventHandler.HandleSubCategoryCreated)
-	eventHandler.HandleEvent("SubCategoryAddedToCategory", menuEventHandler.HandleSubCategoryAddedToCategory)
-	eventHandler.HandleEvent("MenuItemCreated", menuEventHandler.HandleMenuItemCreated)
-	eventHandler.HandleEvent("MenuItemAddedToSubCategory", menuEventHandler.HandleMenuItemAddedToSubCategory)
-	eventHandler.Start()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == "rebuild" {
+		rebuild(ctx, config, os.Args[2:])
+		return
+	}
+
+	menuRepository, err := internal.NewMenuRepository(config.PostgresConnectionString, config.PostgresPoolConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer menuRepository.Close()
+
+	projection := internal.NewMenuProjection(menuRepository)
+	var eventHandler *eventutils.EventHandler
+	if config.UseInMemoryEventStore {
+		eventHandler = eventutils.NewEventHandlerFromSubscription(
+			eventutils.NewInMemoryEventStore().SubscribeToAll(projection.EventTypes()...),
+		)
+	} else {
+		settings, _ := esdb.ParseConnectionString(config.EventStoreConnectionString)
+		db, _ := esdb.NewClient(settings)
+
+		// the projection resumes after the checkpoint it saved in Postgres with its views
+		subscription := eventutils.NewCatchUpSubscription(db, projection.EventTypes(), projection.LoadCheckpoint, projection)
+		eventHandler = eventutils.NewCatchUpEventHandler(subscription)
+		projection.WithCheckpoints(subscription)
+	}
+	eventHandler.WithConcurrency(config.EventHandlerConcurrency, config.EventHandlerQueueSize)
+	projection.Register(eventHandler)
+	eventHandler.Start(ctx)
 
 	app := fiber.New()
 	internal.SetupApi(app, menuRepository, config.ResourcePath, config.ResourceHost)
 
-	app.Listen(":10001")
+	adminApp := fiber.New()
+	internal.SetupAdminApi(adminApp, eventHandler)
+
+	go func() {
+		<-ctx.Done()
+		app.Shutdown()
+		adminApp.Shutdown()
+	}()
+
+	go func() {
+		err := adminApp.Listen(config.AdminListenAddress)
+		if err != nil {
+			log.Print(err)
+		}
+	}()
+
+	err = app.Listen(":10001")
+	if err != nil {
+		log.Print(err)
+	}
+	eventHandler.Stop()
+	err = projection.SaveCheckpoint(context.Background())
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// rebuild replays the whole history of $all into the views of the menu projection
+func rebuild(ctx context.Context, config internal.Config, args []string) {
+	flags := flag.NewFlagSet("rebuild", flag.ExitOnError)
+	shadow := flags.Bool("shadow", false, "rebuild in shadow tables and swap them in when done, the service can keep running meanwhile")
+	flags.Parse(args)
+
+	settings, err := esdb.ParseConnectionString(config.EventStoreConnectionString)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := esdb.NewClient(settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+	eventStore, err := eventutils.NewEventStore(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	menuRepository, err := internal.NewMenuRepository(config.PostgresConnectionString, config.PostgresPoolConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer menuRepository.Close()
+
+	rebuilder := internal.NewRebuilder(
+		menuRepository,
+		eventStore,
+		config.EventHandlerConcurrency,
+		config.EventHandlerQueueSize,
+	)
+	if *shadow {
+		err = rebuilder.RebuildInShadowTables(ctx)
+	} else {
+		err = rebuilder.Rebuild(ctx)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Print("the views were rebuilt")
 }